@@ -16,14 +16,19 @@ import (
 
 	"github.com/andranikuz/smart-goal-calendar/config"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/auth"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/google"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/microsoft"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/migrations"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/notify"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/postgres"
 	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/caldav"
 	httpHandlers "github.com/andranikuz/smart-goal-calendar/internal/ports/http/handlers"
 	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
 	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/routes"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/worker"
 )
 
 func main() {
@@ -81,27 +86,57 @@ func main() {
 	
 	zlog.Info().Msg("Database migrations completed successfully")
 
+	// Initialize encryption of OAuth tokens at rest
+	encryptionKeys, err := cfg.EncryptionKeys()
+	if err != nil {
+		zlog.Fatal().Err(err).Msg("Failed to load encryption keys")
+	}
+	tokenCipher, err := auth.NewTokenCipher(encryptionKeys, cfg.Encryption.ActiveKeyID)
+	if err != nil {
+		zlog.Fatal().Err(err).Msg("Failed to initialize token encryption")
+	}
+
 	// Initialize repositories
 	userRepo := postgres.NewUserRepository(db.Pool)
+	userCredentialRepo := postgres.NewUserCredentialRepository(db.Pool)
+	sessionRepo := postgres.NewSessionRepository(db.Pool)
 	goalRepo := postgres.NewGoalRepository(db.Pool)
 	taskRepo := postgres.NewTaskRepository(db.Pool)
 	milestoneRepo := postgres.NewMilestoneRepository(db.Pool)
 	eventRepo := postgres.NewEventRepository(db.Pool)
+	eventExceptionRepo := postgres.NewEventExceptionRepository(db.Pool)
+	calendarRepo := postgres.NewCalendarRepository(db.Pool)
+	calendarFeedRepo := postgres.NewCalendarFeedRepository(db.Pool)
 	moodRepo := postgres.NewMoodRepository(db.Pool)
-	googleIntegrationRepo := postgres.NewGoogleIntegrationRepository(db.Pool)
+	googleIntegrationRepo := postgres.NewGoogleIntegrationRepository(db.Pool, tokenCipher)
 	googleCalendarSyncRepo := postgres.NewGoogleCalendarSyncRepository(db.Pool)
+	googleEventLinkRepo := postgres.NewGoogleEventLinkRepository(db.Pool)
+	googleSyncConflictRepo := postgres.NewGoogleSyncConflictRepository(db.Pool)
+	syncRunRepo := postgres.NewSyncRunRepository(db.Pool)
+	googleOutboxRepo := postgres.NewGoogleOutboxRepository(db.Pool)
+	oauthStateRepo := postgres.NewOAuthStateRepository(db.Pool)
+	notificationRepo := postgres.NewNotificationRepository(db.Pool)
 
 	// Initialize services
 	userService := services.NewUserService()
 	goalService := services.NewGoalService()
 	eventService := services.NewEventService()
 	moodService := services.NewMoodService()
+	passwordHasher := auth.NewPasswordHasher(auth.Argon2Params{
+		Memory:      cfg.Password.Memory,
+		Iterations:  cfg.Password.Iterations,
+		Parallelism: cfg.Password.Parallelism,
+	})
 
 	// Initialize application handlers
-	userHandler := appHandlers.NewUserHandler(userRepo)
+	userHandler := appHandlers.NewUserHandler(userRepo, userCredentialRepo, passwordHasher)
+	sessionHandler := appHandlers.NewSessionHandler(sessionRepo, cfg.JWT.RefreshExpiry)
 	goalHandler := appHandlers.NewGoalHandler(goalRepo, taskRepo, milestoneRepo, goalService)
-	eventHandler := appHandlers.NewEventHandler(eventRepo, goalRepo, eventService)
+	eventHandler := appHandlers.NewEventHandler(eventRepo, eventExceptionRepo, calendarRepo, goalRepo, eventService)
+	calendarHandler := appHandlers.NewCalendarHandler(calendarRepo)
 	moodHandler := appHandlers.NewMoodHandler(moodRepo, moodService)
+	calendarFeedHandler := appHandlers.NewCalendarFeedHandler(calendarFeedRepo, goalRepo, milestoneRepo, eventHandler)
+	notificationHandler := appHandlers.NewNotificationHandler(notificationRepo)
 
 	// Initialize JWT service
 	jwtService := auth.NewJWTService(
@@ -112,34 +147,72 @@ func main() {
 	)
 
 	// Initialize Google services
-	oauth2Service := google.NewOAuth2Service(
-		cfg.Google.ClientID,
-		cfg.Google.ClientSecret,
-		cfg.Google.RedirectURL,
-	)
+	oauth2Service := google.NewOAuth2Service(google.Config{
+		ClientID:     cfg.Google.ClientID,
+		ClientSecret: cfg.Google.ClientSecret,
+		RedirectURL:  cfg.Google.RedirectURL,
+		APIURL:       cfg.Google.APIURL,
+		AuthURL:      cfg.Google.AuthURL,
+		TokenURL:     cfg.Google.TokenURL,
+	})
 	calendarService := google.NewCalendarService(oauth2Service)
+	googleAuthFlow := google.NewAuthFlow(oauth2Service, oauthStateRepo, cfg.Google.StateTTL)
+
+	// Calendar providers the syncs run against; Outlook needs a Graph app
+	calendarProviders := []services.CalendarProvider{calendarService}
+	if cfg.Microsoft.ClientID != "" {
+		calendarProviders = append(calendarProviders, microsoft.NewCalendarService(microsoft.Config{
+			ClientID:     cfg.Microsoft.ClientID,
+			ClientSecret: cfg.Microsoft.ClientSecret,
+			Tenant:       cfg.Microsoft.Tenant,
+			GraphURL:     cfg.Microsoft.GraphURL,
+		}))
+	}
+
+	integrationTokens := calendarsync.NewTokenSource(calendarProviders, googleIntegrationRepo)
+	calendarSyncer := calendarsync.NewSyncer(
+		calendarProviders,
+		integrationTokens,
+		googleCalendarSyncRepo,
+		eventRepo,
+		eventExceptionRepo,
+		googleEventLinkRepo,
+		googleSyncConflictRepo,
+		syncRunRepo,
+	)
 
 	// Initialize HTTP handlers
-	userHTTPHandler := httpHandlers.NewUserHTTPHandler(userHandler, userService, jwtService)
+	userHTTPHandler := httpHandlers.NewUserHTTPHandler(userHandler, sessionHandler, userService, jwtService)
 	goalHTTPHandler := httpHandlers.NewGoalHTTPHandler(goalHandler)
 	eventHTTPHandler := httpHandlers.NewEventHTTPHandler(eventHandler)
 	moodHTTPHandler := httpHandlers.NewMoodHTTPHandler(moodHandler)
+	calendarHTTPHandler := httpHandlers.NewCalendarHTTPHandler(calendarHandler)
+	calendarFeedHTTPHandler := httpHandlers.NewCalendarFeedHTTPHandler(calendarFeedHandler)
+	notificationHTTPHandler := httpHandlers.NewNotificationHTTPHandler(notificationHandler)
 	googleAuthHandler := httpHandlers.NewGoogleAuthHandler(
 		oauth2Service,
+		googleAuthFlow,
 		calendarService,
+		calendarSyncer,
 		googleIntegrationRepo,
 		googleCalendarSyncRepo,
+		calendarRepo,
 	)
 	googleCalendarSyncHandler := httpHandlers.NewGoogleCalendarSyncHandler(
-		oauth2Service,
-		calendarService,
+		calendarSyncer,
 		googleIntegrationRepo,
 		googleCalendarSyncRepo,
-		eventRepo,
+		googleSyncConflictRepo,
+		syncRunRepo,
+		calendarRepo,
 	)
+	googleNotificationHandler := httpHandlers.NewGoogleNotificationHandler(googleCalendarSyncRepo)
+
+	// Initialize CalDAV server
+	caldavServer := caldav.NewServer(userHandler, eventHandler, eventRepo, eventExceptionRepo, calendarRepo, eventService)
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(jwtService)
+	authMiddleware := middleware.NewAuthMiddleware(jwtService, sessionHandler)
 
 	// Setup HTTP router
 	router := gin.Default()
@@ -178,18 +251,36 @@ func main() {
 
 		// Setup mood routes
 		routes.SetupMoodRoutes(v1, moodHTTPHandler, authMiddleware)
+		
+		// Setup calendar routes
+		routes.SetupCalendarRoutes(v1, calendarHTTPHandler, authMiddleware)
+
+		// Setup calendar feed routes
+		routes.SetupCalendarFeedRoutes(v1, calendarFeedHTTPHandler, authMiddleware)
+
+		// Setup notification routes
+		routes.SetupNotificationRoutes(v1, notificationHTTPHandler, authMiddleware)
 
 		// Setup Google authentication routes
 		routes.SetupGoogleAuthRoutes(v1, googleAuthHandler, authMiddleware)
 
 		// Setup Google calendar sync routes
 		routes.SetupGoogleCalendarSyncRoutes(v1, googleCalendarSyncHandler, authMiddleware)
+
+		// Setup Google push notification webhook
+		routes.SetupGoogleNotificationRoutes(v1, googleNotificationHandler)
 	}
 
+	// CalDAV is served next to gin: its WebDAV methods and OPTIONS requests
+	// must not go through the REST middleware
+	mux := http.NewServeMux()
+	caldavServer.Register(mux)
+	mux.Handle("/", router)
+
 	// Setup HTTP server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:      router,
+		Handler:      mux,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
@@ -207,6 +298,81 @@ func main() {
 		}
 	}()
 
+	// Start background calendar syncs and pushes, and event reminders
+	var syncScheduler *worker.SyncScheduler
+	var outboxDispatcher *worker.OutboxDispatcher
+	var channelRenewer *worker.ChannelRenewer
+	var tokenRefresher *worker.TokenRefresher
+	var reminderScheduler *worker.ReminderScheduler
+	var notificationDispatcher *worker.NotificationDispatcher
+	if cfg.Worker.Enabled {
+		syncScheduler = worker.NewSyncScheduler(googleCalendarSyncRepo, calendarSyncer, worker.SyncSchedulerConfig{
+			PollInterval:  cfg.Worker.PollInterval,
+			BatchSize:     cfg.Worker.BatchSize,
+			Concurrency:   cfg.Worker.Concurrency,
+			LeaseDuration: cfg.Worker.LeaseDuration,
+		})
+		syncScheduler.Start()
+
+		outboxDispatcher = worker.NewOutboxDispatcher(googleOutboxRepo, calendarSyncer, worker.OutboxDispatcherConfig{
+			PollInterval:  cfg.Worker.OutboxPollInterval,
+			BatchSize:     cfg.Worker.BatchSize,
+			Concurrency:   cfg.Worker.Concurrency,
+			LeaseDuration: cfg.Worker.LeaseDuration,
+			MaxAttempts:   cfg.Worker.OutboxMaxAttempts,
+		})
+		outboxDispatcher.Start()
+
+		if cfg.Google.WebhookURL != "" {
+			channelRenewer = worker.NewChannelRenewer(googleCalendarSyncRepo, calendarSyncer, worker.ChannelRenewerConfig{
+				PollInterval: cfg.Worker.ChannelPollInterval,
+				WebhookURL:   cfg.Google.WebhookURL,
+				TTL:          cfg.Worker.ChannelTTL,
+				RenewBefore:  cfg.Worker.ChannelRenewBefore,
+			})
+			channelRenewer.Start()
+		}
+
+		tokenRefresher = worker.NewTokenRefresher(googleIntegrationRepo, integrationTokens, worker.TokenRefresherConfig{
+			PollInterval:  cfg.Worker.TokenPollInterval,
+			RefreshBefore: cfg.Worker.TokenRefreshBefore,
+		})
+		tokenRefresher.Start()
+
+		// Email reminders need an SMTP server; without one they fail
+		notifiers := []services.Notifier{
+			notify.NewInAppNotifier(),
+			notify.NewWebhookNotifier(&http.Client{Timeout: cfg.Notifications.WebhookTimeout}, cfg.Notifications.WebhookSecret),
+		}
+		if cfg.Notifications.SMTP.Host != "" {
+			notifiers = append(notifiers, notify.NewEmailNotifier(notify.SMTPConfig{
+				Host:     cfg.Notifications.SMTP.Host,
+				Port:     cfg.Notifications.SMTP.Port,
+				Username: cfg.Notifications.SMTP.Username,
+				Password: cfg.Notifications.SMTP.Password,
+				From:     cfg.Notifications.SMTP.From,
+			}))
+		}
+
+		reminderScheduler = worker.NewReminderScheduler(
+			notify.NewScheduler(userRepo, calendarRepo, eventRepo, eventExceptionRepo, notificationRepo),
+			worker.ReminderSchedulerConfig{
+				PollInterval: cfg.Worker.ReminderPollInterval,
+				Lookback:     cfg.Worker.ReminderLookback,
+			},
+		)
+		reminderScheduler.Start()
+
+		notificationDispatcher = worker.NewNotificationDispatcher(notificationRepo, notify.NewSender(userRepo, notifiers), worker.NotificationDispatcherConfig{
+			PollInterval:  cfg.Worker.NotificationPollInterval,
+			BatchSize:     cfg.Worker.BatchSize,
+			Concurrency:   cfg.Worker.Concurrency,
+			LeaseDuration: cfg.Worker.LeaseDuration,
+			MaxAttempts:   cfg.Worker.NotificationMaxAttempts,
+		})
+		notificationDispatcher.Start()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -222,6 +388,37 @@ func main() {
 		zlog.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
 
+	if syncScheduler != nil {
+		if err := syncScheduler.Shutdown(ctx); err != nil {
+			zlog.Error().Err(err).Msg("Calendar sync scheduler forced to shutdown")
+		}
+	}
+	if outboxDispatcher != nil {
+		if err := outboxDispatcher.Shutdown(ctx); err != nil {
+			zlog.Error().Err(err).Msg("Google outbox dispatcher forced to shutdown")
+		}
+	}
+	if channelRenewer != nil {
+		if err := channelRenewer.Shutdown(ctx); err != nil {
+			zlog.Error().Err(err).Msg("Google watch channel renewer forced to shutdown")
+		}
+	}
+	if tokenRefresher != nil {
+		if err := tokenRefresher.Shutdown(ctx); err != nil {
+			zlog.Error().Err(err).Msg("Integration token refresher forced to shutdown")
+		}
+	}
+	if reminderScheduler != nil {
+		if err := reminderScheduler.Shutdown(ctx); err != nil {
+			zlog.Error().Err(err).Msg("Reminder scheduler forced to shutdown")
+		}
+	}
+	if notificationDispatcher != nil {
+		if err := notificationDispatcher.Shutdown(ctx); err != nil {
+			zlog.Error().Err(err).Msg("Notification dispatcher forced to shutdown")
+		}
+	}
+
 	zlog.Info().Msg("Server exited")
 }
 
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/andranikuz/smart-goal-calendar/config"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/auth"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/migrations"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/postgres"
 )
@@ -16,7 +17,7 @@ import (
 func main() {
 	var (
 		configPath = flag.String("config", "", "path to config file")
-		action     = flag.String("action", "migrate", "action to perform: migrate, status, or create")
+		action     = flag.String("action", "migrate", "action to perform: migrate, status, create, or reencrypt-tokens")
 		name       = flag.String("name", "", "name for new migration (only for create action)")
 	)
 	flag.Parse()
@@ -89,8 +90,16 @@ func main() {
 		}
 		fmt.Printf("Migration created successfully: %s\n", *name)
 
+	case "reencrypt-tokens":
+		fmt.Println("Re-encrypting OAuth tokens with the active key...")
+		count, err := reencryptTokens(cfg, db)
+		if err != nil {
+			log.Fatalf("Failed to re-encrypt tokens: %v", err)
+		}
+		fmt.Printf("Re-encrypted tokens of %d integrations\n", count)
+
 	default:
-		log.Fatalf("Unknown action: %s. Use migrate, status, or create", *action)
+		log.Fatalf("Unknown action: %s. Use migrate, status, create, or reencrypt-tokens", *action)
 	}
 }
 
@@ -127,3 +136,19 @@ func createMigration(name string) error {
 
 	return nil
 }
+
+// reencryptTokens seals OAuth tokens stored unencrypted or with a retired
+// key with the active key
+func reencryptTokens(cfg *config.Config, db *postgres.Database) (int, error) {
+	keys, err := cfg.EncryptionKeys()
+	if err != nil {
+		return 0, err
+	}
+	cipher, err := auth.NewTokenCipher(keys, cfg.Encryption.ActiveKeyID)
+	if err != nil {
+		return 0, err
+	}
+
+	repo := postgres.NewGoogleIntegrationRepository(db.Pool, cipher)
+	return repo.ReencryptTokens(context.Background())
+}
@@ -1,19 +1,33 @@
 package config
 
 import (
+	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Google   GoogleConfig   `mapstructure:"google"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
+	// Environment is "local" on development machines, which allows the
+	// development encryption key of local.yaml
+	Environment string `mapstructure:"environment"`
+
+	Server        ServerConfig        `mapstructure:"server"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	JWT           JWTConfig           `mapstructure:"jwt"`
+	Password      PasswordConfig      `mapstructure:"password"`
+	Encryption    EncryptionConfig    `mapstructure:"encryption"`
+	Google        GoogleConfig        `mapstructure:"google"`
+	Microsoft     MicrosoftConfig     `mapstructure:"microsoft"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	Worker        WorkerConfig        `mapstructure:"worker"`
+	Logging       LoggingConfig       `mapstructure:"logging"`
 }
 
 type ServerConfig struct {
@@ -56,10 +70,195 @@ type JWTConfig struct {
 	Issuer         string        `mapstructure:"issuer"`
 }
 
+// PasswordConfig holds argon2id parameters. Changing them makes existing
+// hashes get upgraded on the next successful login.
+type PasswordConfig struct {
+	Memory      uint32 `mapstructure:"memory"` // KiB
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+}
+
+// EncryptionConfig holds the keys that encrypt OAuth tokens at rest. They
+// are read from ENCRYPTION_KEYS, or the file named by ENCRYPTION_KEYS_FILE,
+// as comma separated id:base64 pairs; the first key is active unless
+// ENCRYPTION_ACTIVE_KEY_ID names another. To rotate, add a new key, make it
+// active and run the reencrypt-tokens migrate action; the old key can be
+// removed afterwards.
+type EncryptionConfig struct {
+	ActiveKeyID string          `mapstructure:"active_key_id"`
+	Keys        []EncryptionKey `mapstructure:"keys"`
+}
+
+type EncryptionKey struct {
+	ID  string `mapstructure:"id"`
+	Key string `mapstructure:"key"` // Base64 of 32 random bytes
+}
+
+// devEncryptionKey is the public key of local.yaml
+const devEncryptionKey = "3yoHENGyl7iX8x+Q+8tOE2hWUhR4PqMpio33cKKhoGc="
+
+// EncryptionKeys returns the encryption keys by ID. It fails without keys
+// and when the development key is used outside the local environment.
+func (c *Config) EncryptionKeys() (map[string][]byte, error) {
+	keys, err := c.Encryption.DecodedKeys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption keys configured, set ENCRYPTION_KEYS or ENCRYPTION_KEYS_FILE")
+	}
+	if c.Environment != "local" {
+		dev, _ := base64.StdEncoding.DecodeString(devEncryptionKey)
+		for id, key := range keys {
+			if bytes.Equal(key, dev) {
+				return nil, fmt.Errorf("encryption key %q is the development key, which is only allowed in the local environment", id)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// DecodedKeys returns the keys by ID
+func (c EncryptionConfig) DecodedKeys() (map[string][]byte, error) {
+	keys := make(map[string][]byte, len(c.Keys))
+	for _, key := range c.Keys {
+		decoded, err := base64.StdEncoding.DecodeString(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", key.ID, err)
+		}
+		if _, ok := keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate encryption key %q", key.ID)
+		}
+		keys[key.ID] = decoded
+	}
+	return keys, nil
+}
+
+// loadKeysFromEnv replaces the keys with the ones of ENCRYPTION_KEYS or
+// ENCRYPTION_KEYS_FILE when either is set
+func (c *EncryptionConfig) loadKeysFromEnv() error {
+	value, file := os.Getenv("ENCRYPTION_KEYS"), os.Getenv("ENCRYPTION_KEYS_FILE")
+	if value != "" && file != "" {
+		return errors.New("set either ENCRYPTION_KEYS or ENCRYPTION_KEYS_FILE, not both")
+	}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read encryption keys file: %w", err)
+		}
+		value = string(content)
+	}
+
+	if value != "" {
+		keys, err := parseEncryptionKeys(value)
+		if err != nil {
+			return err
+		}
+		c.Keys, c.ActiveKeyID = keys, keys[0].ID
+	}
+	if id := os.Getenv("ENCRYPTION_ACTIVE_KEY_ID"); id != "" {
+		c.ActiveKeyID = id
+	}
+	return nil
+}
+
+// parseEncryptionKeys parses id:base64 pairs separated by commas or newlines
+func parseEncryptionKeys(value string) ([]EncryptionKey, error) {
+	var keys []EncryptionKey
+	for _, pair := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, key, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || key == "" {
+			return nil, fmt.Errorf("invalid encryption key %q, want id:base64", pair)
+		}
+		keys = append(keys, EncryptionKey{ID: id, Key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption keys in ENCRYPTION_KEYS or its file")
+	}
+	return keys, nil
+}
+
 type GoogleConfig struct {
 	ClientID     string `mapstructure:"client_id"`
 	ClientSecret string `mapstructure:"client_secret"`
 	RedirectURL  string `mapstructure:"redirect_url"`
+
+	// Overrides of Google's endpoints, e.g. to run against a local stand-in
+	APIURL   string `mapstructure:"api_url"`   // Root of Google's APIs
+	AuthURL  string `mapstructure:"auth_url"`  // Consent page
+	TokenURL string `mapstructure:"token_url"` // Token endpoint
+
+	// StateTTL is how long a started connect flow can be completed
+	StateTTL time.Duration `mapstructure:"state_ttl"`
+
+	// WebhookURL is the public address of the push notification endpoint,
+	// /api/v1/google/notifications. Watch channels are disabled without it
+	// and syncs only run on their interval.
+	WebhookURL string `mapstructure:"webhook_url"`
+}
+
+// MicrosoftConfig configures syncing Outlook calendars through Microsoft
+// Graph. The provider is disabled without a client ID.
+type MicrosoftConfig struct {
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	Tenant       string `mapstructure:"tenant"`    // Azure AD tenant, e.g. "common"
+	GraphURL     string `mapstructure:"graph_url"` // Root of the Graph API
+}
+
+// NotificationsConfig configures the delivery of event reminders. Email
+// reminders can't be delivered without an SMTP host.
+type NotificationsConfig struct {
+	SMTP SMTPConfig `mapstructure:"smtp"`
+
+	// WebhookSecret signs the reminders posted to users' webhooks; they are
+	// unsigned when it is empty
+	WebhookSecret  string        `mapstructure:"webhook_secret"`
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
+}
+
+// SMTPConfig is the mail server reminders are sent through. Leave the
+// username empty for servers without authentication, such as a local
+// Mailpit sink.
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// WorkerConfig controls the background scheduler of calendar syncs.
+// Every replica may run it; due syncs are claimed with row locks.
+type WorkerConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	PollInterval  time.Duration `mapstructure:"poll_interval"`
+	BatchSize     int           `mapstructure:"batch_size"`
+	Concurrency   int           `mapstructure:"concurrency"`
+	LeaseDuration time.Duration `mapstructure:"lease_duration"` // How long a claimed sync stays locked
+
+	// Pushes of local changes to the provider
+	OutboxPollInterval time.Duration `mapstructure:"outbox_poll_interval"`
+	OutboxMaxAttempts  int           `mapstructure:"outbox_max_attempts"`
+
+	// Google watch channels, see GoogleConfig.WebhookURL
+	ChannelPollInterval time.Duration `mapstructure:"channel_poll_interval"`
+	ChannelTTL          time.Duration `mapstructure:"channel_ttl"`
+	ChannelRenewBefore  time.Duration `mapstructure:"channel_renew_before"`
+
+	// Proactive refreshes of integration access tokens
+	TokenPollInterval  time.Duration `mapstructure:"token_poll_interval"`
+	TokenRefreshBefore time.Duration `mapstructure:"token_refresh_before"`
+
+	// Event reminders, see NotificationsConfig
+	ReminderPollInterval     time.Duration `mapstructure:"reminder_poll_interval"`
+	ReminderLookback         time.Duration `mapstructure:"reminder_lookback"` // How late a missed reminder is still sent
+	NotificationPollInterval time.Duration `mapstructure:"notification_poll_interval"`
+	NotificationMaxAttempts  int           `mapstructure:"notification_max_attempts"`
 }
 
 type LoggingConfig struct {
@@ -98,11 +297,16 @@ func Load(configPath string) (*Config, error) {
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	if err := config.Encryption.loadKeysFromEnv(); err != nil {
+		return nil, err
+	}
 	
 	return &config, nil
 }
 
 func setDefaults() {
+	viper.SetDefault("environment", "production")
+	
 	// Server defaults
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.port", 8080)
@@ -139,10 +343,53 @@ func setDefaults() {
 	viper.SetDefault("jwt.refresh_expiry", 24*time.Hour*7)
 	viper.SetDefault("jwt.issuer", "smart-goal-calendar")
 	
+	// Password hashing defaults
+	viper.SetDefault("password.memory", 64*1024)
+	viper.SetDefault("password.iterations", 3)
+	viper.SetDefault("password.parallelism", 2)
+	
 	// Google defaults
 	viper.SetDefault("google.client_id", "")
 	viper.SetDefault("google.client_secret", "")
 	viper.SetDefault("google.redirect_url", "http://localhost:8080/auth/google/callback")
+	viper.SetDefault("google.api_url", "")
+	viper.SetDefault("google.auth_url", "")
+	viper.SetDefault("google.token_url", "")
+	viper.SetDefault("google.state_ttl", 10*time.Minute)
+	viper.SetDefault("google.webhook_url", "")
+
+	// Microsoft defaults
+	viper.SetDefault("microsoft.client_id", "")
+	viper.SetDefault("microsoft.client_secret", "")
+	viper.SetDefault("microsoft.tenant", "common")
+	viper.SetDefault("microsoft.graph_url", "https://graph.microsoft.com/v1.0")
+
+	// Notification defaults
+	viper.SetDefault("notifications.smtp.host", "")
+	viper.SetDefault("notifications.smtp.port", 587)
+	viper.SetDefault("notifications.smtp.username", "")
+	viper.SetDefault("notifications.smtp.password", "")
+	viper.SetDefault("notifications.smtp.from", "Smart Goal Calendar <reminders@localhost>")
+	viper.SetDefault("notifications.webhook_secret", "")
+	viper.SetDefault("notifications.webhook_timeout", 10*time.Second)
+	
+	// Worker defaults
+	viper.SetDefault("worker.enabled", true)
+	viper.SetDefault("worker.poll_interval", 30*time.Second)
+	viper.SetDefault("worker.batch_size", 10)
+	viper.SetDefault("worker.concurrency", 4)
+	viper.SetDefault("worker.lease_duration", 5*time.Minute)
+	viper.SetDefault("worker.outbox_poll_interval", 5*time.Second)
+	viper.SetDefault("worker.outbox_max_attempts", 10)
+	viper.SetDefault("worker.channel_poll_interval", 15*time.Minute)
+	viper.SetDefault("worker.channel_ttl", 7*24*time.Hour)
+	viper.SetDefault("worker.channel_renew_before", 24*time.Hour)
+	viper.SetDefault("worker.token_poll_interval", 5*time.Minute)
+	viper.SetDefault("worker.token_refresh_before", 15*time.Minute)
+	viper.SetDefault("worker.reminder_poll_interval", time.Minute)
+	viper.SetDefault("worker.reminder_lookback", 15*time.Minute)
+	viper.SetDefault("worker.notification_poll_interval", 5*time.Second)
+	viper.SetDefault("worker.notification_max_attempts", 5)
 	
 	// Logging defaults
 	viper.SetDefault("logging.level", "info")
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKeysFromEnv(t *testing.T) {
+	const key = "q83vEjRWeJCrze8SNFZ4kKvN7xI0VniQq83vEjRWeJA="
+
+	file := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(file, []byte("old:"+key+"\nnew:"+key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantIDs    []string
+		wantActive string
+		wantErr    bool
+	}{
+		{
+			name:       "keys from the variable",
+			env:        map[string]string{"ENCRYPTION_KEYS": "new:" + key + ", old:" + key},
+			wantIDs:    []string{"new", "old"},
+			wantActive: "new",
+		},
+		{
+			name:       "active key named",
+			env:        map[string]string{"ENCRYPTION_KEYS": "new:" + key + ",old:" + key, "ENCRYPTION_ACTIVE_KEY_ID": "old"},
+			wantIDs:    []string{"new", "old"},
+			wantActive: "old",
+		},
+		{
+			name:       "keys from a file",
+			env:        map[string]string{"ENCRYPTION_KEYS_FILE": file},
+			wantIDs:    []string{"old", "new"},
+			wantActive: "old",
+		},
+		{
+			name:       "nothing set",
+			wantActive: "config",
+			wantIDs:    []string{"config"},
+		},
+		{name: "both set", env: map[string]string{"ENCRYPTION_KEYS": "a:" + key, "ENCRYPTION_KEYS_FILE": file}, wantErr: true},
+		{name: "missing file", env: map[string]string{"ENCRYPTION_KEYS_FILE": file + ".missing"}, wantErr: true},
+		{name: "pair without an ID", env: map[string]string{"ENCRYPTION_KEYS": key}, wantErr: true},
+		{name: "no pairs", env: map[string]string{"ENCRYPTION_KEYS": " , "}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"ENCRYPTION_KEYS", "ENCRYPTION_KEYS_FILE", "ENCRYPTION_ACTIVE_KEY_ID"} {
+				t.Setenv(name, tt.env[name])
+			}
+			c := EncryptionConfig{ActiveKeyID: "config", Keys: []EncryptionKey{{ID: "config", Key: key}}}
+
+			err := c.loadKeysFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("loadKeysFromEnv() = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadKeysFromEnv() = %v", err)
+			}
+			if c.ActiveKeyID != tt.wantActive {
+				t.Errorf("active key = %q, want %q", c.ActiveKeyID, tt.wantActive)
+			}
+			if len(c.Keys) != len(tt.wantIDs) {
+				t.Fatalf("keys = %+v, want IDs %v", c.Keys, tt.wantIDs)
+			}
+			for i, id := range tt.wantIDs {
+				if c.Keys[i].ID != id || c.Keys[i].Key != key {
+					t.Errorf("key %d = %+v, want %s:%s", i, c.Keys[i], id, key)
+				}
+			}
+		})
+	}
+}
+
+func TestEncryptionKeys(t *testing.T) {
+	const key = "q83vEjRWeJCrze8SNFZ4kKvN7xI0VniQq83vEjRWeJA="
+
+	tests := []struct {
+		name        string
+		environment string
+		keys        []EncryptionKey
+		wantErr     bool
+	}{
+		{name: "production key", environment: "production", keys: []EncryptionKey{{ID: "prod-1", Key: key}}},
+		{name: "development key in local", environment: "local", keys: []EncryptionKey{{ID: "dev-1", Key: devEncryptionKey}}},
+		{name: "development key in production", environment: "production", keys: []EncryptionKey{{ID: "prod-1", Key: key}, {ID: "dev-1", Key: devEncryptionKey}}, wantErr: true},
+		{name: "development key under another ID", environment: "staging", keys: []EncryptionKey{{ID: "staging-1", Key: devEncryptionKey}}, wantErr: true},
+		{name: "no keys", environment: "local", wantErr: true},
+		{name: "invalid base64", environment: "production", keys: []EncryptionKey{{ID: "prod-1", Key: "not base64!"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{Environment: tt.environment, Encryption: EncryptionConfig{Keys: tt.keys}}
+			keys, err := c.EncryptionKeys()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("EncryptionKeys() = %v, want an error", keys)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncryptionKeys() = %v", err)
+			}
+			if len(keys) != len(tt.keys) {
+				t.Errorf("EncryptionKeys() returned %d keys, want %d", len(keys), len(tt.keys))
+			}
+		})
+	}
+}
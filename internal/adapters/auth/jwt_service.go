@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
 )
 
@@ -16,10 +17,11 @@ type JWTService struct {
 }
 
 type JWTClaims struct {
-	UserID entities.UserID `json:"user_id"`
-	Email  string          `json:"email"`
-	Name   string          `json:"name"`
-	Type   string          `json:"type"` // "access" or "refresh"
+	UserID    entities.UserID    `json:"user_id"`
+	Email     string             `json:"email"`
+	Name      string             `json:"name"`
+	Type      string             `json:"type"` // "access" or "refresh"
+	SessionID entities.SessionID `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -39,17 +41,20 @@ func NewJWTService(secretKey string, accessExpiry, refreshExpiry time.Duration,
 	}
 }
 
-// GenerateTokenPair generates both access and refresh tokens
-func (s *JWTService) GenerateTokenPair(user *entities.User) (*TokenPair, error) {
+// GenerateTokenPair generates both access and refresh tokens for a session.
+// refreshTokenID becomes the jti of the refresh token so it can be tracked.
+func (s *JWTService) GenerateTokenPair(user *entities.User, sessionID entities.SessionID, refreshTokenID string) (*TokenPair, error) {
 	now := time.Now()
 	
 	// Generate access token
 	accessClaims := &JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Name:   user.Name,
-		Type:   "access",
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Type:      "access",
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			Issuer:    s.issuer,
 			Subject:   string(user.ID),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -66,11 +71,13 @@ func (s *JWTService) GenerateTokenPair(user *entities.User) (*TokenPair, error)
 	
 	// Generate refresh token
 	refreshClaims := &JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Name:   user.Name,
-		Type:   "refresh",
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Type:      "refresh",
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshTokenID,
 			Issuer:    s.issuer,
 			Subject:   string(user.ID),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -145,19 +152,12 @@ func (s *JWTService) ValidateRefreshToken(tokenString string) (*JWTClaims, error
 		return nil, fmt.Errorf("not a refresh token")
 	}
 	
-	return claims, nil
-}
-
-// RefreshTokenPair generates new token pair using refresh token
-func (s *JWTService) RefreshTokenPair(refreshTokenString string, user *entities.User) (*TokenPair, error) {
-	// Validate refresh token
-	_, err := s.ValidateRefreshToken(refreshTokenString)
-	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token: %w", err)
+	// Refresh tokens are tracked by session and jti
+	if claims.SessionID == "" || claims.ID == "" {
+		return nil, fmt.Errorf("refresh token is not bound to a session")
 	}
 	
-	// Generate new token pair
-	return s.GenerateTokenPair(user)
+	return claims, nil
 }
 
 // ExtractTokenFromHeader extracts token from Authorization header
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// tokenCipherPrefix marks encrypted values. Values without it were stored
+// before encryption was enabled and are read as plaintext.
+const tokenCipherPrefix = "enc:v1:"
+
+// dataKeyLength is the size of the per-value AES-256 data keys
+const dataKeyLength = 32
+
+// TokenCipher encrypts tokens with envelope encryption: every value is
+// sealed with a fresh AES-256-GCM data key, which is in turn sealed with a
+// key encryption key. Values are encoded as
+// enc:v1:<key id>:<sealed data key>:<sealed value>, so keys can be rotated:
+// new values use the active key while the others still decrypt old ones.
+type TokenCipher struct {
+	keys        map[string]cipher.AEAD
+	activeKeyID string
+}
+
+// NewTokenCipher creates a cipher from key encryption keys by ID. Keys are
+// 32 bytes; activeKeyID selects the one new values are sealed with.
+func NewTokenCipher(keys map[string][]byte, activeKeyID string) (*TokenCipher, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not configured", activeKeyID)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid encryption key ID %q", id)
+		}
+		if len(key) != dataKeyLength {
+			return nil, fmt.Errorf("encryption key %q must be %d bytes, got %d", id, dataKeyLength, len(key))
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+		aeads[id] = aead
+	}
+
+	return &TokenCipher{keys: aeads, activeKeyID: activeKeyID}, nil
+}
+
+// Encrypt seals plaintext with a new data key under the active key
+func (c *TokenCipher) Encrypt(plaintext, associatedData string) (string, error) {
+	dataKey := make([]byte, dataKeyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	// The data key is bound to its key ID, the value to its owner
+	sealedKey, err := seal(c.keys[c.activeKeyID], dataKey, []byte(c.activeKeyID))
+	if err != nil {
+		return "", err
+	}
+	sealedValue, err := seal(dataAEAD, []byte(plaintext), []byte(associatedData))
+	if err != nil {
+		return "", err
+	}
+
+	return tokenCipherPrefix + c.activeKeyID + ":" +
+		base64.RawURLEncoding.EncodeToString(sealedKey) + ":" +
+		base64.RawURLEncoding.EncodeToString(sealedValue), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values stored before encryption
+// was enabled are returned as they are and need re-encrypting.
+func (c *TokenCipher) Decrypt(ciphertext, associatedData string) (string, bool, error) {
+	encoded, ok := strings.CutPrefix(ciphertext, tokenCipherPrefix)
+	if !ok {
+		return ciphertext, true, nil
+	}
+
+	parts := strings.Split(encoded, ":")
+	if len(parts) != 3 {
+		return "", false, fmt.Errorf("invalid encrypted token format")
+	}
+	keyID := parts[0]
+
+	keyAEAD, ok := c.keys[keyID]
+	if !ok {
+		return "", false, fmt.Errorf("unknown encryption key %q", keyID)
+	}
+
+	sealedKey, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", false, fmt.Errorf("invalid encrypted data key: %w", err)
+	}
+	sealedValue, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", false, fmt.Errorf("invalid encrypted token: %w", err)
+	}
+
+	dataKey, err := open(keyAEAD, sealedKey, []byte(keyID))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", false, err
+	}
+
+	plaintext, err := open(dataAEAD, sealedValue, []byte(associatedData))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt token: %w", err)
+	}
+
+	return string(plaintext), keyID != c.activeKeyID, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce, which prefixes the result
+func seal(aead cipher.AEAD, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(aead cipher.AEAD, sealed, associatedData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, associatedData)
+}
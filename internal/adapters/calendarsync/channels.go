@@ -0,0 +1,113 @@
+package calendarsync
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+// secretBytes is the entropy of watch channel tokens. Google limits channel
+// tokens to 256 characters.
+const secretBytes = 32
+
+// RenewChannel brings the watch channel of a sync in line with
+// GoogleCalendarSync.NeedsWatch. A watched sync gets a new channel on which
+// the provider notifies address, replacing its current one; an unwatched
+// sync has its channel stopped. Syncs of providers that aren't a
+// services.ChangeWatcher are never watched. Concurrent renewals of a sync are
+// safe: the loser stops the channel it opened.
+func (s *Syncer) RenewChannel(ctx context.Context, sync *entities.GoogleCalendarSync, address string, ttl time.Duration) error {
+	integration, err := s.integration(ctx, sync.GoogleIntegrationID)
+	if err != nil {
+		return err
+	}
+
+	watcher, watchable := integration.provider.(services.ChangeWatcher)
+
+	var channel *entities.WatchChannel
+	if watchable && sync.NeedsWatch() {
+		token, err := generateSecret()
+		if err != nil {
+			return err
+		}
+
+		channel, err = watcher.WatchEvents(ctx, integration.AccessToken,
+			sync.CalendarID, uuid.New().String(), address, token, ttl)
+		if err != nil {
+			return err
+		}
+		channel.TokenHash = hashSecret(token)
+	}
+
+	var oldChannelID *string
+	if sync.Channel != nil {
+		oldChannelID = &sync.Channel.ID
+	}
+
+	swapped, err := s.syncRepo.SwapChannel(ctx, sync.ID, oldChannelID, channel)
+	if err != nil || !swapped {
+		// The new channel isn't stored, so nothing would ever stop it
+		if channel != nil {
+			_ = s.stopChannel(context.WithoutCancel(ctx), integration, channel)
+		}
+		return err
+	}
+
+	oldChannel := sync.Channel
+	sync.Channel = channel
+	if oldChannel == nil {
+		return nil
+	}
+	return s.stopChannel(ctx, integration, oldChannel)
+}
+
+// StopChannel stops the watch channel of a sync that is being deleted.
+// Channels that already expired are ignored.
+func (s *Syncer) StopChannel(ctx context.Context, sync *entities.GoogleCalendarSync) error {
+	if sync.Channel == nil {
+		return nil
+	}
+
+	integration, err := s.integration(ctx, sync.GoogleIntegrationID)
+	if err != nil {
+		return err
+	}
+	return s.stopChannel(ctx, integration, sync.Channel)
+}
+
+func (s *Syncer) stopChannel(ctx context.Context, integration *connection, channel *entities.WatchChannel) error {
+	watcher, ok := integration.provider.(services.ChangeWatcher)
+	if !ok {
+		return nil
+	}
+	return watcher.StopWatch(ctx, integration.AccessToken, channel)
+}
+
+// VerifyChannelToken reports whether a notification's token belongs to channel
+func VerifyChannelToken(channel *entities.WatchChannel, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(token)), []byte(channel.TokenHash)) == 1
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret returns the stored form of a secret
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package calendarsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+var (
+	// ErrConflictResolved is returned when a conflict was already resolved
+	ErrConflictResolved = errors.New("sync conflict already resolved")
+
+	// ErrMergedVersionRequired is returned when a conflict is merged without a version
+	ErrMergedVersionRequired = errors.New("merged version is required")
+)
+
+// ResolveConflict settles a queued conflict with the version the user
+// picked: the remote one is applied locally, the local one is pushed to the
+// provider and a merged one is applied to both.
+func (s *Syncer) ResolveConflict(ctx context.Context, sync *entities.GoogleCalendarSync, conflict *entities.GoogleSyncConflict, choice entities.ConflictChoice, merged *entities.EventVersion) error {
+	if !conflict.IsOpen() {
+		return ErrConflictResolved
+	}
+	if choice == entities.ConflictChoiceMerged && merged == nil {
+		return ErrMergedVersionRequired
+	}
+
+	local, err := s.eventRepo.GetByID(ctx, conflict.EventID)
+	if err != nil {
+		return fmt.Errorf("failed to get event: %w", err)
+	}
+
+	switch {
+	case local == nil:
+		// The event was deleted meanwhile; nothing is left to resolve
+	case choice == entities.ConflictChoiceRemote:
+		if err := s.applyRemote(ctx, sync, local, conflict.GoogleEventID, conflict.RemoteVersion); err != nil {
+			return err
+		}
+	default:
+		if choice == entities.ConflictChoiceMerged {
+			merged.ApplyTo(local)
+			local.UpdatedAt = time.Now()
+			if err := s.eventRepo.Update(ctx, local); err != nil {
+				return fmt.Errorf("failed to update event: %w", err)
+			}
+		}
+
+		integration, err := s.integration(ctx, sync.GoogleIntegrationID)
+		if err != nil {
+			return err
+		}
+		if err := s.pushEvent(ctx, sync, integration, local, conflict.GoogleEventID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.conflictRepo.Resolve(ctx, conflict.ID, choice, time.Now()); err != nil {
+		return fmt.Errorf("failed to resolve conflict: %w", err)
+	}
+	return nil
+}
+
+// applyRemote overwrites a local event with its remote version
+func (s *Syncer) applyRemote(ctx context.Context, sync *entities.GoogleCalendarSync, local *entities.Event, remoteEventID string, remote entities.EventVersion) error {
+	remote.ApplyTo(local)
+	local.UpdatedAt = time.Now()
+
+	if err := s.eventRepo.Update(ctx, local); err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	if err := s.excludeOccurrences(ctx, local, remote.ExcludedDates); err != nil {
+		return err
+	}
+	return s.saveLink(ctx, sync, local, remoteEventID, remote.UpdatedAt)
+}
+
+// pushEvent overwrites a remote event with its local version
+func (s *Syncer) pushEvent(ctx context.Context, sync *entities.GoogleCalendarSync, integration *connection, local *entities.Event, remoteEventID string) error {
+	exceptions, err := s.exceptions(ctx, local)
+	if err != nil {
+		return err
+	}
+	remoteEvent, err := integration.provider.UpdateEvent(ctx, integration.AccessToken, sync.CalendarID, remoteEventID, local, exceptions)
+	if err != nil {
+		return err
+	}
+	return s.saveLink(ctx, sync, local, remoteEventID, remoteEvent.UpdatedAt)
+}
+
+// queueConflict stores both versions for the user to pick from, or
+// refreshes them when the event already has an open conflict
+func (s *Syncer) queueConflict(ctx context.Context, sync *entities.GoogleCalendarSync, local *entities.Event, remoteEventID string, remote entities.EventVersion) error {
+	conflict := &entities.GoogleSyncConflict{
+		ID:             uuid.New().String(),
+		CalendarSyncID: sync.ID,
+		UserID:         sync.UserID,
+		EventID:        local.ID,
+		GoogleEventID:  remoteEventID,
+		LocalVersion:   entities.NewEventVersion(local),
+		RemoteVersion:  remote,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := s.conflictRepo.Upsert(ctx, conflict); err != nil {
+		return fmt.Errorf("failed to queue conflict: %w", err)
+	}
+	return nil
+}
+
+// saveLink records the versions both sides of an event are synced at
+func (s *Syncer) saveLink(ctx context.Context, sync *entities.GoogleCalendarSync, local *entities.Event, remoteEventID string, remoteUpdatedAt time.Time) error {
+	link := &entities.GoogleEventLink{
+		ID:              uuid.New().String(),
+		CalendarSyncID:  sync.ID,
+		EventID:         local.ID,
+		GoogleEventID:   remoteEventID,
+		LocalUpdatedAt:  local.UpdatedAt,
+		RemoteUpdatedAt: remoteUpdatedAt,
+		SyncedAt:        time.Now(),
+	}
+
+	if err := s.linkRepo.Upsert(ctx, link); err != nil {
+		return fmt.Errorf("failed to link event: %w", err)
+	}
+	return nil
+}
+
+// remoteVersion captures the synced fields of a remote event
+func remoteVersion(remoteEvent *services.RemoteEvent) entities.EventVersion {
+	timezone := remoteEvent.TimeZone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	status := remoteEvent.Status
+	if status == "" {
+		status = entities.EventStatusConfirmed
+	}
+
+	return entities.EventVersion{
+		Title:         remoteEvent.Summary,
+		Description:   remoteEvent.Description,
+		Location:      remoteEvent.Location,
+		StartTime:     remoteEvent.StartTime,
+		EndTime:       remoteEvent.EndTime,
+		Timezone:      timezone,
+		AllDay:        remoteEvent.AllDay,
+		Recurrence:    remoteEvent.Recurrence,
+		ExcludedDates: remoteEvent.ExcludedDates,
+		Attendees:     remoteEvent.Attendees,
+		Status:        status,
+		Color:         remoteEvent.Color,
+		Transparent:   remoteEvent.Transparent,
+		UpdatedAt:     remoteEvent.UpdatedAt,
+	}
+}
@@ -0,0 +1,59 @@
+package calendarsync
+
+import (
+	"context"
+	"errors"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+// Push applies a queued local change to its remote event. Changes that are
+// already remote, e.g. ones the sync pushed meanwhile, are skipped.
+func (s *Syncer) Push(ctx context.Context, entry *entities.GoogleOutboxEntry) error {
+	sync, err := s.syncRepo.GetByID(ctx, entry.CalendarSyncID)
+	if err != nil {
+		return err
+	}
+	if sync == nil || sync.SyncDirection == entities.SyncDirectionFromGoogle {
+		return nil
+	}
+
+	if entry.Operation == entities.GoogleOutboxDelete {
+		integration, err := s.integration(ctx, sync.GoogleIntegrationID)
+		if err != nil {
+			return err
+		}
+		err = integration.provider.DeleteEvent(ctx, integration.AccessToken, sync.CalendarID, entry.GoogleEventID)
+		if errors.Is(err, services.ErrRemoteEventNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	event, err := s.eventRepo.GetByID(ctx, entry.EventID)
+	if err != nil || event == nil {
+		// A deleted event has a delete entry queued after this one
+		return err
+	}
+
+	link, err := s.linkRepo.GetByEventID(ctx, sync.ID, event.ID)
+	if err != nil {
+		return err
+	}
+	if link == nil || !event.UpdatedAt.After(link.LocalUpdatedAt) {
+		return nil
+	}
+
+	// Events with an open conflict wait for the user
+	conflict, err := s.conflictRepo.GetOpenByEventID(ctx, sync.ID, event.ID)
+	if err != nil || conflict != nil {
+		return err
+	}
+
+	integration, err := s.integration(ctx, sync.GoogleIntegrationID)
+	if err != nil {
+		return err
+	}
+	return s.pushEvent(ctx, sync, integration, event, link.GoogleEventID)
+}
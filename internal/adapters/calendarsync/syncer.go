@@ -0,0 +1,726 @@
+package calendarsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+const (
+	// retryBaseDelay is the delay after the first failed run; it doubles
+	// with every further failure up to retryMaxDelay
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = 6 * time.Hour
+
+	// keptSyncRuns is how many runs of each calendar sync are kept
+	keptSyncRuns = 200
+)
+
+var (
+	// ErrIntegrationNotFound is returned when the integration of a sync is gone
+	ErrIntegrationNotFound = errors.New("integration not found")
+
+	// ErrProviderNotConfigured is returned for integrations of a provider
+	// the Syncer has no CalendarProvider for
+	ErrProviderNotConfigured = errors.New("calendar provider not configured")
+
+	// ErrTokenRefreshFailed is returned when the provider rejects the
+	// refresh token of an integration, e.g. because access was revoked
+	ErrTokenRefreshFailed = errors.New("failed to refresh token")
+
+	// ErrEventsFailed is returned when some events of a run failed to sync;
+	// the others were synced and the run's event errors tell which failed
+	ErrEventsFailed = errors.New("events failed to sync")
+)
+
+// Syncer runs calendar syncs between remote calendars and local events. It
+// works against services.CalendarProvider, picking the provider of each
+// sync's integration. It is shared by manual syncs and the background
+// scheduler.
+type Syncer struct {
+	providers     map[entities.IntegrationProvider]services.CalendarProvider
+	tokens        *TokenSource
+	syncRepo      repositories.GoogleCalendarSyncRepository
+	eventRepo     repositories.EventRepository
+	exceptionRepo repositories.EventExceptionRepository
+	linkRepo      repositories.GoogleEventLinkRepository
+	conflictRepo  repositories.GoogleSyncConflictRepository
+	runRepo       repositories.SyncRunRepository
+}
+
+// connection is an integration along with the provider it connects to
+type connection struct {
+	*entities.GoogleIntegration
+	provider services.CalendarProvider
+}
+
+// SyncResult is the outcome of a single sync run
+type SyncResult struct {
+	SyncedCount int // Events created, updated or deleted on either side
+	SyncedAt    time.Time
+	Run         *entities.SyncRun
+	Err         error // Set when the run failed; it is also stored on the sync
+}
+
+// outcome is what syncing a single event did
+type outcome int
+
+const (
+	outcomeNone    outcome = iota // Nothing to sync; not counted
+	outcomeSkipped                // Unchanged, or waiting for a conflict to be resolved
+	outcomeCreated
+	outcomeUpdated
+	outcomeDeleted
+)
+
+func NewSyncer(
+	providers []services.CalendarProvider,
+	tokens *TokenSource,
+	syncRepo repositories.GoogleCalendarSyncRepository,
+	eventRepo repositories.EventRepository,
+	exceptionRepo repositories.EventExceptionRepository,
+	linkRepo repositories.GoogleEventLinkRepository,
+	conflictRepo repositories.GoogleSyncConflictRepository,
+	runRepo repositories.SyncRunRepository,
+) *Syncer {
+	byName := make(map[entities.IntegrationProvider]services.CalendarProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &Syncer{
+		providers:     byName,
+		tokens:        tokens,
+		syncRepo:      syncRepo,
+		eventRepo:     eventRepo,
+		exceptionRepo: exceptionRepo,
+		linkRepo:      linkRepo,
+		conflictRepo:  conflictRepo,
+		runRepo:       runRepo,
+	}
+}
+
+// Run syncs a calendar the caller has claimed as workerID, records the
+// outcome and the run and releases the claim with the next run scheduled:
+// one interval later after a success, after a jittered exponential backoff
+// after a failure. The returned error is only set when the outcome couldn't
+// be stored.
+func (s *Syncer) Run(ctx context.Context, sync *entities.GoogleCalendarSync, workerID string, trigger entities.SyncTrigger) (*SyncResult, error) {
+	run := &entities.SyncRun{
+		ID:             uuid.New().String(),
+		CalendarSyncID: sync.ID,
+		UserID:         sync.UserID,
+		Trigger:        trigger,
+		StartedAt:      time.Now(),
+		EventErrors:    []entities.SyncEventError{},
+	}
+	syncErr := s.sync(ctx, sync, run)
+
+	// The outcome is stored even when the run was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	now := time.Now()
+	run.FinishedAt = now
+	if syncErr != nil {
+		run.Error = syncErr.Error()
+	}
+	result := &SyncResult{
+		SyncedCount: run.Created + run.Updated + run.Deleted,
+		SyncedAt:    now,
+		Run:         run,
+		Err:         syncErr,
+	}
+
+	status := entities.SyncStatusActive
+	errorMsg := ""
+	failureCount := 0
+	nextSyncAt := now.Add(sync.Interval())
+	if syncErr != nil {
+		status = entities.SyncStatusError
+		errorMsg = syncErr.Error()
+		failureCount = sync.FailureCount + 1
+		nextSyncAt = now.Add(RetryDelay(failureCount))
+	}
+
+	if err := s.syncRepo.UpdateSyncStatus(ctx, sync.ID, status, &now, errorMsg); err != nil {
+		return result, fmt.Errorf("failed to update sync status: %w", err)
+	}
+	if err := s.syncRepo.Release(ctx, sync.ID, workerID, nextSyncAt, failureCount); err != nil {
+		return result, fmt.Errorf("failed to release calendar sync: %w", err)
+	}
+
+	if err := s.runRepo.Create(ctx, run); err != nil {
+		return result, fmt.Errorf("failed to record sync run: %w", err)
+	}
+	if err := s.runRepo.Prune(ctx, sync.ID, keptSyncRuns); err != nil {
+		return result, fmt.Errorf("failed to prune sync runs: %w", err)
+	}
+
+	return result, nil
+}
+
+// RetryDelay returns the delay before retrying a sync that failed
+// failureCount times in a row. Half of it is random so that syncs failing
+// together, e.g. during a provider outage, don't retry together.
+func RetryDelay(failureCount int) time.Duration {
+	delay := retryMaxDelay
+	if failureCount < 20 {
+		delay = min(retryBaseDelay<<(failureCount-1), retryMaxDelay)
+	}
+	return delay/2 + rand.N(delay/2)
+}
+
+// sync runs the sync in its configured direction, counting what it did in
+// run. Events that fail are recorded on run and don't stop the others.
+func (s *Syncer) sync(ctx context.Context, sync *entities.GoogleCalendarSync, run *entities.SyncRun) error {
+	integration, err := s.integration(ctx, sync.GoogleIntegrationID)
+	if err != nil {
+		return err
+	}
+
+	switch sync.SyncDirection {
+	case entities.SyncDirectionFromGoogle:
+		err = s.syncFromRemote(ctx, sync, integration, run)
+	case entities.SyncDirectionToGoogle:
+		err = s.syncToRemote(ctx, sync, integration, run)
+	case entities.SyncDirectionBidirectional:
+		// First sync from the provider, then to it
+		if err = s.syncFromRemote(ctx, sync, integration, run); err == nil {
+			err = s.syncToRemote(ctx, sync, integration, run)
+		}
+	default:
+		return fmt.Errorf("unknown sync direction: %s", sync.SyncDirection)
+	}
+	if err != nil {
+		return err
+	}
+
+	if run.Failed > 0 {
+		return fmt.Errorf("%d %w, the first with: %s", run.Failed, ErrEventsFailed, run.EventErrors[0].Error)
+	}
+	return nil
+}
+
+// count adds the outcome of an event to the counts of run
+func count(run *entities.SyncRun, result outcome) {
+	switch result {
+	case outcomeSkipped:
+		run.Skipped++
+	case outcomeCreated:
+		run.Created++
+	case outcomeUpdated:
+		run.Updated++
+	case outcomeDeleted:
+		run.Deleted++
+	}
+}
+
+// integration returns an integration with a valid access token along with
+// its provider
+func (s *Syncer) integration(ctx context.Context, id entities.GoogleIntegrationID) (*connection, error) {
+	integration, err := s.tokens.Integration(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := s.providers[integration.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotConfigured, integration.Provider)
+	}
+	return &connection{GoogleIntegration: integration, provider: provider}, nil
+}
+
+// ListCalendars lists the calendars of an integration's account
+func (s *Syncer) ListCalendars(ctx context.Context, id entities.GoogleIntegrationID) ([]*services.RemoteCalendar, error) {
+	integration, err := s.integration(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return integration.provider.ListCalendars(ctx, integration.AccessToken)
+}
+
+// syncFromRemote mirrors the changes made in the remote calendar since the
+// last sync into local events. The delta token is only advanced once all
+// changes are stored, so changes are listed again until none fails.
+func (s *Syncer) syncFromRemote(ctx context.Context, sync *entities.GoogleCalendarSync, integration *connection, run *entities.SyncRun) error {
+	// The window only applies to full listings; incremental ones return
+	// every change to the events of the first listing and later ones
+	timeMin := time.Now()
+	if sync.Settings.SyncPastEvents {
+		timeMin = timeMin.AddDate(0, -1, 0) // 1 month ago
+	}
+
+	listedAt := time.Now()
+	changes, err := integration.provider.ListEventChanges(ctx, integration.AccessToken, sync.CalendarID, sync.SyncToken, timeMin)
+	if err != nil {
+		return err
+	}
+
+	failed := run.Failed
+	for _, remoteEvent := range changes.Events {
+		var result outcome
+		switch {
+		case remoteEvent.Cancelled && remoteEvent.RecurringEventID != "":
+			result, err = s.cancelInstance(ctx, sync, remoteEvent)
+		case remoteEvent.Cancelled:
+			result, err = s.deleteEvent(ctx, sync, remoteEvent)
+		case remoteEvent.RecurringEventID != "":
+			result, err = s.overrideInstance(ctx, sync, remoteEvent)
+		default:
+			result, err = s.saveEvent(ctx, sync, integration, remoteEvent)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			run.AddEventError(entities.SyncEventError{
+				RemoteEventID: remoteEvent.ID,
+				Title:         remoteEvent.Summary,
+				Side:          entities.SyncSideLocal,
+				Error:         err.Error(),
+			})
+			continue
+		}
+		count(run, result)
+	}
+
+	if changes.FullSync {
+		if err := s.deleteUnlisted(ctx, sync, changes, timeMin, listedAt, run); err != nil {
+			return err
+		}
+	}
+
+	if run.Failed > failed {
+		return nil
+	}
+	if changes.DeltaToken != "" && changes.DeltaToken != sync.SyncToken {
+		if err := s.syncRepo.UpdateSyncToken(ctx, sync.ID, changes.DeltaToken); err != nil {
+			return fmt.Errorf("failed to update sync token: %w", err)
+		}
+		sync.SyncToken = changes.DeltaToken
+	}
+
+	return nil
+}
+
+// saveEvent creates or updates the local copy of a remote event. When a
+// bidirectional sync finds the event changed on both sides, the conflict
+// resolution of the sync decides.
+func (s *Syncer) saveEvent(ctx context.Context, sync *entities.GoogleCalendarSync, integration *connection, remoteEvent *services.RemoteEvent) (outcome, error) {
+	link, err := s.linkRepo.GetByGoogleEventID(ctx, sync.ID, remoteEvent.ID)
+	if err != nil {
+		return outcomeNone, err
+	}
+
+	var local *entities.Event
+	if link != nil {
+		if local, err = s.eventRepo.GetByID(ctx, link.EventID); err != nil {
+			return outcomeNone, fmt.Errorf("failed to get event: %w", err)
+		}
+	}
+	if local == nil {
+		if local, err = s.eventByExternalID(ctx, sync, remoteEvent.ID); err != nil {
+			return outcomeNone, err
+		}
+	}
+
+	remote := remoteVersion(remoteEvent)
+	if local == nil {
+		return outcomeCreated, s.createEvent(ctx, sync, integration, remoteEvent.ID, remote)
+	}
+
+	// Unchanged remotely, e.g. the echo of a change pushed by the last sync
+	if link != nil && !remoteEvent.UpdatedAt.After(link.RemoteUpdatedAt) {
+		return outcomeSkipped, nil
+	}
+
+	localChanged := link != nil && local.UpdatedAt.After(link.LocalUpdatedAt)
+	if localChanged && sync.SyncDirection == entities.SyncDirectionBidirectional {
+		switch sync.Settings.ConflictResolution {
+		case entities.ConflictResolutionLocalWins:
+			return outcomeUpdated, s.pushEvent(ctx, sync, integration, local, remoteEvent.ID)
+		case entities.ConflictResolutionManual:
+			return outcomeSkipped, s.queueConflict(ctx, sync, local, remoteEvent.ID, remote)
+		}
+	}
+
+	return outcomeUpdated, s.applyRemote(ctx, sync, local, remoteEvent.ID, remote)
+}
+
+// createEvent creates the local copy of a new remote event
+func (s *Syncer) createEvent(ctx context.Context, sync *entities.GoogleCalendarSync, integration *connection, remoteEventID string, remote entities.EventVersion) error {
+	// Truncated to the stored precision, as the link compares against it
+	now := time.Now().Truncate(time.Microsecond)
+	event := &entities.Event{
+		ID:             entities.EventID(uuid.New().String()),
+		UserID:         sync.UserID,
+		CalendarID:     sync.LocalCalendarID,
+		Status:         entities.EventStatusConfirmed,
+		ExternalID:     remoteEventID,
+		ExternalSource: string(integration.Provider),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	remote.ApplyTo(event)
+
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+	if err := s.excludeOccurrences(ctx, event, remote.ExcludedDates); err != nil {
+		return err
+	}
+	return s.saveLink(ctx, sync, event, remoteEventID, remote.UpdatedAt)
+}
+
+// deleteEvent deletes the local copy of an event deleted remotely
+func (s *Syncer) deleteEvent(ctx context.Context, sync *entities.GoogleCalendarSync, remoteEvent *services.RemoteEvent) (outcome, error) {
+	existingEvent, err := s.localEvent(ctx, sync, remoteEvent.ID)
+	if err != nil {
+		return outcomeNone, err
+	}
+	if existingEvent == nil {
+		return outcomeSkipped, nil
+	}
+
+	if err := s.eventRepo.DeleteSynced(ctx, existingEvent.ID); err != nil {
+		return outcomeNone, fmt.Errorf("failed to delete event: %w", err)
+	}
+	return outcomeDeleted, nil
+}
+
+// deleteUnlisted deletes the local copies of the events a full listing
+// should have returned but didn't, which were deleted remotely while the
+// sync had no valid delta token. Links stored since the listing started,
+// e.g. by a concurrent push, are left alone.
+func (s *Syncer) deleteUnlisted(ctx context.Context, sync *entities.GoogleCalendarSync, changes *services.RemoteEventChanges, timeMin, listedAt time.Time, run *entities.SyncRun) error {
+	listed := make(map[string]bool, len(changes.Events))
+	for _, remoteEvent := range changes.Events {
+		listed[remoteEvent.ID] = true
+	}
+
+	links, err := s.linkRepo.GetBySyncID(ctx, sync.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if listed[link.GoogleEventID] || link.SyncedAt.After(listedAt) {
+			continue
+		}
+
+		event, err := s.eventRepo.GetByID(ctx, link.EventID)
+		if err != nil {
+			return fmt.Errorf("failed to get event: %w", err)
+		}
+		if event == nil || event.CalendarID != sync.LocalCalendarID || !withinListing(event, timeMin, changes.WindowEnd) {
+			continue
+		}
+
+		if err := s.eventRepo.DeleteSynced(ctx, event.ID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			run.AddEventError(entities.SyncEventError{
+				RemoteEventID: link.GoogleEventID,
+				Title:         event.Title,
+				Side:          entities.SyncSideLocal,
+				Error:         fmt.Sprintf("failed to delete event: %v", err),
+			})
+			continue
+		}
+		count(run, outcomeDeleted)
+	}
+
+	return nil
+}
+
+// withinListing reports whether a full listing of the events ending after
+// timeMin and starting before windowEnd, unless it is zero, includes the
+// event; recurring series are listed when one of their occurrences is
+func withinListing(event *entities.Event, timeMin, windowEnd time.Time) bool {
+	if !event.IsRecurring() {
+		return event.EndTime.After(timeMin) && (windowEnd.IsZero() || event.StartTime.Before(windowEnd))
+	}
+
+	next := event.OccurrencesAfter(timeMin.Add(-event.EndTime.Sub(event.StartTime)), 1)
+	return len(next) > 0 && (windowEnd.IsZero() || next[0].StartTime.Before(windowEnd))
+}
+
+// cancelInstance removes a single occurrence of a local series whose
+// instance was deleted remotely
+func (s *Syncer) cancelInstance(ctx context.Context, sync *entities.GoogleCalendarSync, remoteEvent *services.RemoteEvent) (outcome, error) {
+	if remoteEvent.OriginalStartTime == nil {
+		return outcomeSkipped, nil
+	}
+
+	series, err := s.localEvent(ctx, sync, remoteEvent.RecurringEventID)
+	if err != nil {
+		return outcomeNone, err
+	}
+	if series == nil {
+		return outcomeSkipped, nil
+	}
+
+	cancelled, err := s.cancelOccurrence(ctx, series, *remoteEvent.OriginalStartTime)
+	if err != nil || !cancelled {
+		return outcomeSkipped, err
+	}
+	return outcomeDeleted, nil
+}
+
+// overrideInstance stores a remotely moved or edited instance of a series
+// as an exception of the local series, keyed by its original start.
+// Instances that already match the local occurrence, such as the echo of an
+// override pushed by the sync, are skipped.
+func (s *Syncer) overrideInstance(ctx context.Context, sync *entities.GoogleCalendarSync, remoteEvent *services.RemoteEvent) (outcome, error) {
+	if remoteEvent.OriginalStartTime == nil {
+		return outcomeSkipped, nil
+	}
+
+	series, err := s.localEvent(ctx, sync, remoteEvent.RecurringEventID)
+	if err != nil {
+		return outcomeNone, err
+	}
+	if series == nil || !series.IsRecurring() {
+		return outcomeSkipped, nil
+	}
+
+	originalStart := *remoteEvent.OriginalStartTime
+	exception, err := s.exceptionRepo.GetByRecurrenceID(ctx, series.ID, originalStart)
+	if err != nil {
+		return outcomeNone, fmt.Errorf("failed to get event exception: %w", err)
+	}
+
+	occurrence := series.Occurrence(originalStart)
+	if exception != nil && !exception.Cancelled && matchesInstance(exception.Apply(occurrence), remoteEvent) {
+		return outcomeSkipped, nil
+	}
+
+	now := time.Now()
+	if exception == nil {
+		exception = &entities.EventException{
+			ID:           uuid.New().String(),
+			EventID:      series.ID,
+			RecurrenceID: originalStart,
+			CreatedAt:    now,
+		}
+	}
+	status := remoteVersion(remoteEvent).Status
+	exception.Cancelled = false
+	exception.Title = &remoteEvent.Summary
+	exception.Description = &remoteEvent.Description
+	exception.Location = &remoteEvent.Location
+	exception.StartTime = &remoteEvent.StartTime
+	exception.EndTime = &remoteEvent.EndTime
+	exception.Attendees = remoteEvent.Attendees
+	exception.Status = &status
+	exception.UpdatedAt = now
+
+	if err := s.exceptionRepo.Upsert(ctx, exception); err != nil {
+		return outcomeNone, fmt.Errorf("failed to save event exception: %w", err)
+	}
+	return outcomeUpdated, nil
+}
+
+// matchesInstance reports whether a local occurrence has the synced fields
+// of a remote instance
+func matchesInstance(occurrence *entities.Event, remoteEvent *services.RemoteEvent) bool {
+	remote := remoteVersion(remoteEvent)
+	if occurrence.Title != remote.Title || occurrence.Description != remote.Description ||
+		occurrence.Location != remote.Location || occurrence.Status != remote.Status ||
+		!occurrence.StartTime.Equal(remote.StartTime) || !occurrence.EndTime.Equal(remote.EndTime) {
+		return false
+	}
+
+	return slices.EqualFunc(occurrence.Attendees, remote.Attendees, func(a, b entities.Attendee) bool {
+		return a.Email == b.Email && a.Name == b.Name && a.Status == b.Status
+	})
+}
+
+// localEvent returns the local copy of a remote event in the sync's
+// calendar, nil when there is none
+func (s *Syncer) localEvent(ctx context.Context, sync *entities.GoogleCalendarSync, remoteEventID string) (*entities.Event, error) {
+	link, err := s.linkRepo.GetByGoogleEventID(ctx, sync.ID, remoteEventID)
+	if err != nil {
+		return nil, err
+	}
+	if link != nil {
+		event, err := s.eventRepo.GetByID(ctx, link.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event: %w", err)
+		}
+		if event != nil {
+			return event, nil
+		}
+	}
+	return s.eventByExternalID(ctx, sync, remoteEventID)
+}
+
+// eventByExternalID finds events synced before links were stored by their
+// remote ID. Copies in other calendars, e.g. of a calendar shared with
+// another connected account, belong to other syncs.
+func (s *Syncer) eventByExternalID(ctx context.Context, sync *entities.GoogleCalendarSync, remoteEventID string) (*entities.Event, error) {
+	event, err := s.eventRepo.GetByExternalID(ctx, sync.UserID, remoteEventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event by external ID: %w", err)
+	}
+	if event == nil || event.CalendarID != sync.LocalCalendarID {
+		return nil, nil
+	}
+	return event, nil
+}
+
+// excludeOccurrences cancels the occurrences of a local series that its
+// remote series excludes
+func (s *Syncer) excludeOccurrences(ctx context.Context, series *entities.Event, excluded []time.Time) error {
+	if !series.IsRecurring() {
+		return nil
+	}
+	for _, originalStart := range excluded {
+		if _, err := s.cancelOccurrence(ctx, series, originalStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelOccurrence removes the occurrence of a local series that starts at
+// originalStart
+func (s *Syncer) cancelOccurrence(ctx context.Context, series *entities.Event, originalStart time.Time) (bool, error) {
+	exception, err := s.exceptionRepo.GetByRecurrenceID(ctx, series.ID, originalStart)
+	if err != nil {
+		return false, fmt.Errorf("failed to get event exception: %w", err)
+	}
+	if exception != nil && exception.Cancelled {
+		return false, nil
+	}
+
+	now := time.Now()
+	if exception == nil {
+		exception = &entities.EventException{
+			ID:           uuid.New().String(),
+			EventID:      series.ID,
+			RecurrenceID: originalStart,
+			CreatedAt:    now,
+		}
+	}
+	exception.Cancelled = true
+	exception.UpdatedAt = now
+
+	if err := s.exceptionRepo.Upsert(ctx, exception); err != nil {
+		return false, fmt.Errorf("failed to save event exception: %w", err)
+	}
+	return true, nil
+}
+
+// syncToRemote pushes the events of the sync's calendar created or changed
+// since the last sync to the remote calendar. A failed push is recorded on run and doesn't stop
+// the others.
+func (s *Syncer) syncToRemote(ctx context.Context, sync *entities.GoogleCalendarSync, integration *connection, run *entities.SyncRun) error {
+	// Get local events that need to be synced
+	now := time.Now()
+	timeMin := now.AddDate(0, -1, 0) // 1 month ago
+	timeMax := now.AddDate(0, 3, 0)  // 3 months ahead
+
+	if !sync.Settings.SyncPastEvents {
+		timeMin = now
+	}
+
+	localEvents, err := s.eventRepo.GetByTimeRange(ctx, sync.UserID, timeMin, timeMax, []entities.CalendarID{sync.LocalCalendarID})
+	if err != nil {
+		return err
+	}
+
+	for _, event := range localEvents {
+		result, err := s.pushLocalChanges(ctx, sync, integration, event)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			eventError := entities.SyncEventError{
+				EventID: event.ID,
+				Title:   event.Title,
+				Side:    entities.SyncSideRemote,
+				Error:   err.Error(),
+			}
+			if event.ExternalSource == string(integration.Provider) {
+				eventError.RemoteEventID = event.ExternalID
+			}
+			run.AddEventError(eventError)
+			continue
+		}
+		count(run, result)
+	}
+
+	return nil
+}
+
+// pushLocalChanges creates a local event in the remote calendar, or updates
+// its remote event when it changed since the last sync
+func (s *Syncer) pushLocalChanges(ctx context.Context, sync *entities.GoogleCalendarSync, integration *connection, event *entities.Event) (outcome, error) {
+	link, err := s.linkRepo.GetByEventID(ctx, sync.ID, event.ID)
+	if err != nil {
+		return outcomeNone, err
+	}
+
+	if link == nil {
+		// Events of other calendars, or synced before links were stored,
+		// are linked when they next change remotely
+		if event.ExternalID != "" && event.ExternalSource == string(integration.Provider) {
+			return outcomeNone, nil
+		}
+
+		exceptions, err := s.exceptions(ctx, event)
+		if err != nil {
+			return outcomeNone, err
+		}
+		remoteEvent, err := integration.provider.CreateEvent(ctx, integration.AccessToken, sync.CalendarID, event, exceptions)
+		if err != nil {
+			return outcomeNone, err
+		}
+
+		event.ExternalID = remoteEvent.ID
+		event.ExternalSource = string(integration.Provider)
+		if err := s.eventRepo.Update(ctx, event); err != nil {
+			return outcomeNone, fmt.Errorf("failed to update event: %w", err)
+		}
+		return outcomeCreated, s.saveLink(ctx, sync, event, remoteEvent.ID, remoteEvent.UpdatedAt)
+	}
+
+	if !event.UpdatedAt.After(link.LocalUpdatedAt) {
+		return outcomeNone, nil
+	}
+
+	// Events with an open conflict wait for the user
+	conflict, err := s.conflictRepo.GetOpenByEventID(ctx, sync.ID, event.ID)
+	if err != nil {
+		return outcomeNone, err
+	}
+	if conflict != nil {
+		return outcomeSkipped, nil
+	}
+
+	return outcomeUpdated, s.pushEvent(ctx, sync, integration, event, link.GoogleEventID)
+}
+
+// exceptions returns the exceptions of a recurring event, which are pushed
+// along with it
+func (s *Syncer) exceptions(ctx context.Context, event *entities.Event) ([]*entities.EventException, error) {
+	if !event.IsRecurring() {
+		return nil, nil
+	}
+
+	exceptions, err := s.exceptionRepo.GetByEventID(ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event exceptions: %w", err)
+	}
+	return exceptions, nil
+}
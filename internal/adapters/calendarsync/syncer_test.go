@@ -0,0 +1,601 @@
+package calendarsync
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/google"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/google/googlefake"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+const (
+	testUserID        entities.UserID     = "user-1"
+	testLocalCalendar entities.CalendarID = "calendar-1"
+	testEmail                             = "ada@example.com"
+)
+
+// TestGoogleSyncEndToEnd connects a Google account on googlefake, syncs its
+// calendar both ways, pushes a local edit through the outbox and picks up
+// the changes made in Google with an incremental sync
+func TestGoogleSyncEndToEnd(t *testing.T) {
+	ctx := context.Background()
+
+	server := googlefake.NewServer("client-id", "client-secret")
+	defer server.Close()
+	server.AddAccount(testEmail, "Ada Lovelace")
+
+	start := time.Now().UTC().Truncate(time.Hour).Add(48 * time.Hour)
+	dentist := insertRemoteEvent(t, server, "Dentist", start)
+	gym := insertRemoteEvent(t, server, "Gym", start.Add(2*time.Hour))
+	yoga := insertRemoteEvent(t, server, "Yoga", start.Add(4*time.Hour))
+	standUp, err := server.InsertEvent(testEmail, &calendar.Event{
+		Summary:    "Stand-up",
+		Start:      &calendar.EventDateTime{DateTime: start.Add(-time.Hour).Format(time.RFC3339), TimeZone: "UTC"},
+		End:        &calendar.EventDateTime{DateTime: start.Add(-45 * time.Minute).Format(time.RFC3339), TimeZone: "UTC"},
+		Recurrence: []string{"RRULE:FREQ=DAILY;COUNT=5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oauth := google.NewOAuth2Service(server.Config("http://localhost:8080/api/google/callback"))
+	provider := google.NewCalendarService(oauth)
+
+	integrations := &memoryIntegrations{integrations: map[entities.GoogleIntegrationID]*entities.GoogleIntegration{}}
+	syncs := &memorySyncs{syncs: map[string]*entities.GoogleCalendarSync{}}
+	links := &memoryLinks{links: map[string]*entities.GoogleEventLink{}}
+	events := &memoryEvents{events: map[entities.EventID]*entities.Event{}, syncs: syncs, links: links}
+	exceptions := &memoryExceptions{exceptions: map[string]*entities.EventException{}}
+	syncer := NewSyncer(
+		[]services.CalendarProvider{provider},
+		NewTokenSource([]services.CalendarProvider{provider}, integrations),
+		syncs,
+		events,
+		exceptions,
+		links,
+		&memoryConflicts{},
+		&memoryRuns{},
+	)
+
+	// Connect
+	flow := google.NewAuthFlow(oauth, &memoryStates{states: map[string]*entities.OAuthState{}}, 10*time.Minute)
+	authURL, state, err := flow.Begin(ctx, testUserID)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	callback, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if callback.Query().Get("state") != state {
+		t.Fatalf("callback state = %q, want %q", callback.Query().Get("state"), state)
+	}
+	tokens, err := flow.Complete(ctx, testUserID, state, callback.Query().Get("code"))
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	info, err := oauth.GetUserInfo(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("GetUserInfo: %v", err)
+	}
+	if info.Email != testEmail {
+		t.Errorf("user info email = %q, want %q", info.Email, testEmail)
+	}
+
+	integration := &entities.GoogleIntegration{
+		ID:           "integration-1",
+		UserID:       testUserID,
+		Provider:     entities.IntegrationProviderGoogle,
+		GoogleUserID: info.UserID,
+		Email:        info.Email,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.Expiry,
+		Enabled:      true,
+	}
+	integrations.integrations[integration.ID] = integration
+
+	calendars, err := syncer.ListCalendars(ctx, integration.ID)
+	if err != nil {
+		t.Fatalf("ListCalendars: %v", err)
+	}
+	if len(calendars) != 1 || calendars[0].ID != testEmail || !calendars[0].Primary {
+		t.Fatalf("calendars = %+v, want the primary calendar %s", calendars, testEmail)
+	}
+
+	sync := &entities.GoogleCalendarSync{
+		ID:                  "sync-1",
+		UserID:              testUserID,
+		GoogleIntegrationID: integration.ID,
+		CalendarID:          calendars[0].ID,
+		LocalCalendarID:     testLocalCalendar,
+		SyncDirection:       entities.SyncDirectionBidirectional,
+		SyncStatus:          entities.SyncStatusActive,
+		Settings: entities.CalendarSyncSettings{
+			SyncInterval:       15 * time.Minute,
+			ConflictResolution: entities.ConflictResolutionGoogleWins,
+		},
+	}
+	syncs.syncs[sync.ID] = sync
+
+	// Initial sync: the remote events are imported and the local one pushed
+	lunch := &entities.Event{
+		ID:         "lunch",
+		UserID:     testUserID,
+		CalendarID: testLocalCalendar,
+		Title:      "Team lunch",
+		StartTime:  start.Add(3 * time.Hour),
+		EndTime:    start.Add(4 * time.Hour),
+		Timezone:   "UTC",
+		Status:     entities.EventStatusConfirmed,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := events.Create(ctx, lunch); err != nil {
+		t.Fatal(err)
+	}
+
+	run := runSync(t, syncer, sync)
+	assertCounts(t, run, 5, 0, 0, 0)
+	if sync.SyncToken == "" {
+		t.Fatal("no sync token stored after the initial sync")
+	}
+	assertTitles(t, events.titles(), "Dentist", "Gym", "Stand-up", "Team lunch", "Yoga")
+	assertTitles(t, remoteTitles(server), "Dentist", "Gym", "Stand-up", "Team lunch", "Yoga")
+	lunch = events.mustGet(t, lunch.ID)
+	if lunch.ExternalID == "" || lunch.ExternalSource != string(entities.IntegrationProviderGoogle) {
+		t.Fatalf("pushed event has external ID %q from %q", lunch.ExternalID, lunch.ExternalSource)
+	}
+	if outbox := events.takeOutbox(); len(outbox) != 0 {
+		t.Errorf("initial sync queued %d pushes, want none", len(outbox))
+	}
+
+	// A local edit is queued in the outbox and pushed from there
+	local := events.byExternalID(dentist.Id)
+	local.Title = "Dentist (moved)"
+	local.StartTime = local.StartTime.Add(time.Hour)
+	local.EndTime = local.EndTime.Add(time.Hour)
+	local.UpdatedAt = time.Now()
+	if err := events.Update(ctx, local); err != nil {
+		t.Fatal(err)
+	}
+	outbox := events.takeOutbox()
+	if len(outbox) != 1 || outbox[0].GoogleEventID != dentist.Id {
+		t.Fatalf("outbox = %+v, want one push of %s", outbox, dentist.Id)
+	}
+	if err := syncer.Push(ctx, outbox[0]); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	pushed := server.Event(testEmail, dentist.Id)
+	if pushed.Summary != "Dentist (moved)" || pushed.Start.DateTime != start.Add(time.Hour).Format(time.RFC3339) {
+		t.Errorf("pushed event = %q at %s, want %q at %s",
+			pushed.Summary, pushed.Start.DateTime, "Dentist (moved)", start.Add(time.Hour).Format(time.RFC3339))
+	}
+
+	// Changes made in Google meanwhile; the access token expired as well
+	if _, err := server.PatchEvent(testEmail, lunch.ExternalID, &calendar.Event{Location: "Canteen"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.DeleteEvent(testEmail, yoga.Id); err != nil {
+		t.Fatal(err)
+	}
+	insertRemoteEvent(t, server, "Review", start.Add(6*time.Hour))
+	movedStart := start.Add(23*time.Hour + 30*time.Minute)
+	if _, err := server.InsertEvent(testEmail, &calendar.Event{
+		Summary:           "Stand-up (moved)",
+		RecurringEventId:  standUp.Id,
+		OriginalStartTime: &calendar.EventDateTime{DateTime: start.Add(23 * time.Hour).Format(time.RFC3339), TimeZone: "UTC"},
+		Start:             &calendar.EventDateTime{DateTime: movedStart.Format(time.RFC3339), TimeZone: "UTC"},
+		End:               &calendar.EventDateTime{DateTime: movedStart.Add(15 * time.Minute).Format(time.RFC3339), TimeZone: "UTC"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	server.ExpireAccessTokens()
+	integrations.integrations[integration.ID].ExpiresAt = time.Now()
+
+	// Incremental resync: only the changes are listed, and the echo of the
+	// pushed edit is skipped
+	tokenBefore := sync.SyncToken
+	run = runSync(t, syncer, sync)
+	assertCounts(t, run, 1, 2, 1, 1)
+	if sync.SyncToken == tokenBefore {
+		t.Error("sync token not advanced by the incremental sync")
+	}
+	if integrations.integrations[integration.ID].AccessToken == tokens.AccessToken {
+		t.Error("access token not refreshed")
+	}
+	assertTitles(t, events.titles(), "Dentist (moved)", "Gym", "Review", "Stand-up", "Team lunch")
+	if got := events.mustGet(t, lunch.ID).Location; got != "Canteen" {
+		t.Errorf("lunch location = %q, want %q", got, "Canteen")
+	}
+	if local := events.byExternalID(gym.Id); local == nil || local.Title != "Gym" {
+		t.Errorf("unchanged event = %+v, want Gym", local)
+	}
+	series := events.byExternalID(standUp.Id)
+	override, _ := exceptions.GetByRecurrenceID(ctx, series.ID, start.Add(23*time.Hour))
+	if override == nil || override.Cancelled || override.Title == nil || *override.Title != "Stand-up (moved)" ||
+		override.StartTime == nil || !override.StartTime.Equal(movedStart) {
+		t.Errorf("moved instance stored as %+v, want an override starting at %v", override, movedStart)
+	}
+
+	// Pushes queued by applying remote changes are already in Google, and
+	// remote deletions aren't pushed back at all
+	remoteLunch := server.Event(testEmail, lunch.ExternalID)
+	for _, entry := range events.takeOutbox() {
+		if entry.Operation == entities.GoogleOutboxDelete {
+			t.Errorf("remote deletion of %s queued a push", entry.GoogleEventID)
+			continue
+		}
+		if err := syncer.Push(ctx, entry); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+	if got := server.Event(testEmail, lunch.ExternalID); got.Updated != remoteLunch.Updated {
+		t.Errorf("echo pushed back to Google: updated %s, was %s", got.Updated, remoteLunch.Updated)
+	}
+}
+
+func runSync(t *testing.T, syncer *Syncer, sync *entities.GoogleCalendarSync) *entities.SyncRun {
+	t.Helper()
+
+	result, err := syncer.Run(context.Background(), sync, "worker-1", entities.SyncTriggerManual)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Err != nil {
+		t.Fatalf("sync failed: %v", result.Err)
+	}
+	return result.Run
+}
+
+func assertCounts(t *testing.T, run *entities.SyncRun, created, updated, deleted, skipped int) {
+	t.Helper()
+
+	if run.Created != created || run.Updated != updated || run.Deleted != deleted || run.Skipped != skipped {
+		t.Errorf("created, updated, deleted, skipped = %d, %d, %d, %d, want %d, %d, %d, %d",
+			run.Created, run.Updated, run.Deleted, run.Skipped, created, updated, deleted, skipped)
+	}
+}
+
+func assertTitles(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func insertRemoteEvent(t *testing.T, server *googlefake.Server, summary string, start time.Time) *calendar.Event {
+	t.Helper()
+
+	event, err := server.InsertEvent(testEmail, &calendar.Event{
+		Summary: summary,
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339), TimeZone: "UTC"},
+		End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339), TimeZone: "UTC"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func remoteTitles(server *googlefake.Server) []string {
+	var titles []string
+	for _, event := range server.Events(testEmail) {
+		titles = append(titles, event.Summary)
+	}
+	return titles
+}
+
+// memoryEvents keeps events in memory. Like the postgres repository, an
+// update of a linked event queues a push for each sync to Google.
+type memoryEvents struct {
+	repositories.EventRepository
+	events map[entities.EventID]*entities.Event
+	syncs  *memorySyncs
+	links  *memoryLinks
+	outbox []*entities.GoogleOutboxEntry
+}
+
+func (m *memoryEvents) Create(_ context.Context, event *entities.Event) error {
+	stored := *event
+	m.events[event.ID] = &stored
+	return nil
+}
+
+func (m *memoryEvents) GetByID(_ context.Context, id entities.EventID) (*entities.Event, error) {
+	stored, ok := m.events[id]
+	if !ok {
+		return nil, nil
+	}
+	event := *stored
+	return &event, nil
+}
+
+func (m *memoryEvents) GetByExternalID(_ context.Context, userID entities.UserID, externalID string) (*entities.Event, error) {
+	for _, stored := range m.events {
+		if stored.UserID == userID && stored.ExternalID == externalID {
+			event := *stored
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryEvents) GetByTimeRange(_ context.Context, userID entities.UserID, start, end time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
+	var events []*entities.Event
+	for _, stored := range m.events {
+		if stored.UserID == userID && slices.Contains(calendarIDs, stored.CalendarID) &&
+			stored.EndTime.After(start) && stored.StartTime.Before(end) {
+			event := *stored
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
+
+func (m *memoryEvents) Update(_ context.Context, event *entities.Event) error {
+	stored := *event
+	m.events[event.ID] = &stored
+	m.queuePushes(event, entities.GoogleOutboxUpdate)
+	return nil
+}
+
+func (m *memoryEvents) Delete(_ context.Context, id entities.EventID) error {
+	if event, ok := m.events[id]; ok {
+		m.queuePushes(event, entities.GoogleOutboxDelete)
+	}
+	delete(m.events, id)
+	return nil
+}
+
+func (m *memoryEvents) DeleteSynced(_ context.Context, id entities.EventID) error {
+	delete(m.events, id)
+	return nil
+}
+
+// queuePushes queues the change like the outbox of the postgres repository
+func (m *memoryEvents) queuePushes(event *entities.Event, operation entities.GoogleOutboxOperation) {
+	for _, link := range m.links.links {
+		sync := m.syncs.syncs[link.CalendarSyncID]
+		if link.EventID != event.ID || sync.LocalCalendarID != event.CalendarID || sync.SyncDirection == entities.SyncDirectionFromGoogle {
+			continue
+		}
+		m.outbox = append(m.outbox, &entities.GoogleOutboxEntry{
+			CalendarSyncID: sync.ID,
+			UserID:         sync.UserID,
+			EventID:        event.ID,
+			GoogleEventID:  link.GoogleEventID,
+			Operation:      operation,
+			Status:         entities.GoogleOutboxPending,
+		})
+	}
+}
+
+func (m *memoryEvents) mustGet(t *testing.T, id entities.EventID) *entities.Event {
+	t.Helper()
+
+	event, _ := m.GetByID(context.Background(), id)
+	if event == nil {
+		t.Fatalf("event %s not found", id)
+	}
+	return event
+}
+
+func (m *memoryEvents) byExternalID(externalID string) *entities.Event {
+	event, _ := m.GetByExternalID(context.Background(), testUserID, externalID)
+	return event
+}
+
+func (m *memoryEvents) titles() []string {
+	var titles []string
+	for _, event := range m.events {
+		titles = append(titles, event.Title)
+	}
+	return titles
+}
+
+// takeOutbox returns the queued pushes and empties the outbox
+func (m *memoryEvents) takeOutbox() []*entities.GoogleOutboxEntry {
+	outbox := m.outbox
+	m.outbox = nil
+	return outbox
+}
+
+// memoryExceptions keeps an exception per event and recurrence ID, as the
+// unique key of the event_exceptions table does
+type memoryExceptions struct {
+	repositories.EventExceptionRepository
+	exceptions map[string]*entities.EventException
+}
+
+func exceptionKey(eventID entities.EventID, recurrenceID time.Time) string {
+	return fmt.Sprintf("%s/%d", eventID, recurrenceID.Unix())
+}
+
+func (m *memoryExceptions) GetByEventID(_ context.Context, eventID entities.EventID) ([]*entities.EventException, error) {
+	var exceptions []*entities.EventException
+	for _, stored := range m.exceptions {
+		if stored.EventID == eventID {
+			exception := *stored
+			exceptions = append(exceptions, &exception)
+		}
+	}
+	return exceptions, nil
+}
+
+func (m *memoryExceptions) GetByRecurrenceID(_ context.Context, eventID entities.EventID, recurrenceID time.Time) (*entities.EventException, error) {
+	stored, ok := m.exceptions[exceptionKey(eventID, recurrenceID)]
+	if !ok {
+		return nil, nil
+	}
+	exception := *stored
+	return &exception, nil
+}
+
+func (m *memoryExceptions) Upsert(_ context.Context, exception *entities.EventException) error {
+	stored := *exception
+	m.exceptions[exceptionKey(exception.EventID, exception.RecurrenceID)] = &stored
+	return nil
+}
+
+// memoryLinks keeps a link per sync and event, as the unique key of the
+// google_event_links table does
+type memoryLinks struct {
+	repositories.GoogleEventLinkRepository
+	links map[string]*entities.GoogleEventLink
+}
+
+func (m *memoryLinks) Upsert(_ context.Context, link *entities.GoogleEventLink) error {
+	stored := *link
+	m.links[link.CalendarSyncID+"/"+string(link.EventID)] = &stored
+	return nil
+}
+
+func (m *memoryLinks) GetByGoogleEventID(_ context.Context, calendarSyncID, googleEventID string) (*entities.GoogleEventLink, error) {
+	for _, stored := range m.links {
+		if stored.CalendarSyncID == calendarSyncID && stored.GoogleEventID == googleEventID {
+			link := *stored
+			return &link, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryLinks) GetByEventID(_ context.Context, calendarSyncID string, eventID entities.EventID) (*entities.GoogleEventLink, error) {
+	stored, ok := m.links[calendarSyncID+"/"+string(eventID)]
+	if !ok {
+		return nil, nil
+	}
+	link := *stored
+	return &link, nil
+}
+
+func (m *memoryLinks) GetBySyncID(_ context.Context, calendarSyncID string) ([]*entities.GoogleEventLink, error) {
+	var links []*entities.GoogleEventLink
+	for _, stored := range m.links {
+		if stored.CalendarSyncID == calendarSyncID {
+			link := *stored
+			links = append(links, &link)
+		}
+	}
+	return links, nil
+}
+
+// memoryConflicts never has open conflicts; syncs in the test let Google win
+type memoryConflicts struct {
+	repositories.GoogleSyncConflictRepository
+}
+
+func (m *memoryConflicts) GetOpenByEventID(context.Context, string, entities.EventID) (*entities.GoogleSyncConflict, error) {
+	return nil, nil
+}
+
+type memoryRuns struct {
+	repositories.SyncRunRepository
+	runs []*entities.SyncRun
+}
+
+func (m *memoryRuns) Create(_ context.Context, run *entities.SyncRun) error {
+	m.runs = append(m.runs, run)
+	return nil
+}
+
+func (m *memoryRuns) Prune(context.Context, string, int) error {
+	return nil
+}
+
+type memorySyncs struct {
+	repositories.GoogleCalendarSyncRepository
+	syncs map[string]*entities.GoogleCalendarSync
+}
+
+func (m *memorySyncs) GetByID(_ context.Context, id string) (*entities.GoogleCalendarSync, error) {
+	stored, ok := m.syncs[id]
+	if !ok {
+		return nil, nil
+	}
+	sync := *stored
+	return &sync, nil
+}
+
+func (m *memorySyncs) UpdateSyncStatus(_ context.Context, id string, status entities.CalendarSyncStatus, lastSyncAt *time.Time, syncError string) error {
+	m.syncs[id].SyncStatus = status
+	m.syncs[id].LastSyncAt = lastSyncAt
+	m.syncs[id].LastSyncError = syncError
+	return nil
+}
+
+func (m *memorySyncs) UpdateSyncToken(_ context.Context, id string, syncToken string) error {
+	m.syncs[id].SyncToken = syncToken
+	return nil
+}
+
+func (m *memorySyncs) Release(_ context.Context, id string, _ string, nextSyncAt time.Time, failureCount int) error {
+	m.syncs[id].NextSyncAt = &nextSyncAt
+	m.syncs[id].FailureCount = failureCount
+	return nil
+}
+
+type memoryIntegrations struct {
+	repositories.GoogleIntegrationRepository
+	integrations map[entities.GoogleIntegrationID]*entities.GoogleIntegration
+}
+
+func (m *memoryIntegrations) GetByID(_ context.Context, id entities.GoogleIntegrationID) (*entities.GoogleIntegration, error) {
+	stored, ok := m.integrations[id]
+	if !ok {
+		return nil, nil
+	}
+	integration := *stored
+	return &integration, nil
+}
+
+func (m *memoryIntegrations) UpdateTokens(_ context.Context, id entities.GoogleIntegrationID, accessToken, refreshToken string, expiresAt time.Time) error {
+	m.integrations[id].AccessToken = accessToken
+	m.integrations[id].RefreshToken = refreshToken
+	m.integrations[id].ExpiresAt = expiresAt
+	return nil
+}
+
+func (m *memoryIntegrations) MarkNeedsReconsent(_ context.Context, id entities.GoogleIntegrationID) error {
+	m.integrations[id].NeedsReconsent = true
+	return nil
+}
+
+type memoryStates struct {
+	repositories.OAuthStateRepository
+	states map[string]*entities.OAuthState
+}
+
+func (m *memoryStates) Create(_ context.Context, state *entities.OAuthState) error {
+	m.states[state.StateHash] = state
+	return nil
+}
+
+func (m *memoryStates) GetByHash(_ context.Context, stateHash string) (*entities.OAuthState, error) {
+	return m.states[stateHash], nil
+}
+
+func (m *memoryStates) MarkUsed(_ context.Context, stateHash string) (bool, error) {
+	state, ok := m.states[stateHash]
+	if !ok || state.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	state.UsedAt = &now
+	return true, nil
+}
+
+func (m *memoryStates) DeleteExpired(context.Context, time.Time) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package calendarsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+// tokenRefreshTimeout bounds a refresh, which outlives the context of the
+// caller that started it since others may be waiting for it
+const tokenRefreshTimeout = 30 * time.Second
+
+// ErrReconsentRequired is returned for integrations whose refresh token the
+// provider rejected, until the user connects the account again
+var ErrReconsentRequired = errors.New("integration needs re-consent")
+
+// TokenSource hands out integrations with valid access tokens, refreshing
+// tokens that are about to expire. Concurrent refreshes of an integration
+// share a single provider call. When the provider rejects a refresh token
+// the integration is marked as needing re-consent and isn't refreshed again
+// until the user reconnects the account.
+//
+// Replicas refresh independently; the token stored last wins, which is
+// fine as providers keep earlier access tokens valid until they expire.
+type TokenSource struct {
+	providers       map[entities.IntegrationProvider]services.CalendarProvider
+	integrationRepo repositories.GoogleIntegrationRepository
+	refreshes       singleflight.Group
+}
+
+func NewTokenSource(
+	providers []services.CalendarProvider,
+	integrationRepo repositories.GoogleIntegrationRepository,
+) *TokenSource {
+	byName := make(map[entities.IntegrationProvider]services.CalendarProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &TokenSource{
+		providers:       byName,
+		integrationRepo: integrationRepo,
+	}
+}
+
+// Integration returns an integration whose access token is not about to
+// expire
+func (t *TokenSource) Integration(ctx context.Context, id entities.GoogleIntegrationID) (*entities.GoogleIntegration, error) {
+	integration, err := t.integrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integration: %w", err)
+	}
+	if integration == nil {
+		return nil, ErrIntegrationNotFound
+	}
+	if integration.NeedsReconsent {
+		return nil, ErrReconsentRequired
+	}
+	if !integration.IsTokenExpiringSoon() {
+		return integration, nil
+	}
+
+	return t.refresh(ctx, id, time.Now().Add(5*time.Minute))
+}
+
+// Refresh refreshes the token of an integration when it expires before
+// expiresBefore, so that it is valid when next used
+func (t *TokenSource) Refresh(ctx context.Context, id entities.GoogleIntegrationID, expiresBefore time.Time) error {
+	_, err := t.refresh(ctx, id, expiresBefore)
+	return err
+}
+
+func (t *TokenSource) refresh(ctx context.Context, id entities.GoogleIntegrationID, expiresBefore time.Time) (*entities.GoogleIntegration, error) {
+	result, err, _ := t.refreshes.Do(string(id), func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenRefreshTimeout)
+		defer cancel()
+
+		// Reloaded, as a refresh that just finished may have stored a new token
+		integration, err := t.integrationRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get integration: %w", err)
+		}
+		if integration == nil {
+			return nil, ErrIntegrationNotFound
+		}
+		if integration.NeedsReconsent {
+			return nil, ErrReconsentRequired
+		}
+		if integration.ExpiresAt.After(expiresBefore) {
+			return integration, nil
+		}
+
+		provider, ok := t.providers[integration.Provider]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrProviderNotConfigured, integration.Provider)
+		}
+
+		newTokens, err := provider.RefreshToken(ctx, integration.RefreshToken)
+		if errors.Is(err, services.ErrInvalidGrant) {
+			if err := t.integrationRepo.MarkNeedsReconsent(ctx, id); err != nil {
+				return nil, fmt.Errorf("failed to flag integration for re-consent: %w", err)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrReconsentRequired, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w for %s: %w", ErrTokenRefreshFailed, integration.Provider, err)
+		}
+
+		// Providers only return a refresh token when they rotate it
+		refreshToken := newTokens.RefreshToken
+		if refreshToken == "" {
+			refreshToken = integration.RefreshToken
+		}
+
+		if err := t.integrationRepo.UpdateTokens(ctx, integration.ID,
+			newTokens.AccessToken, refreshToken, newTokens.Expiry); err != nil {
+			return nil, fmt.Errorf("failed to update tokens: %w", err)
+		}
+
+		integration.AccessToken = newTokens.AccessToken
+		integration.RefreshToken = refreshToken
+		integration.ExpiresAt = newTokens.Expiry
+		return integration, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every caller gets its own copy
+	integration := *result.(*entities.GoogleIntegration)
+	return &integration, nil
+}
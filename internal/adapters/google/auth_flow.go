@@ -0,0 +1,133 @@
+package google
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+const (
+	// secretBytes is the entropy of OAuth states
+	secretBytes = 32
+
+	// expiredStateRetention is how long expired states are kept, so a late
+	// callback is told that its state expired rather than that it is unknown
+	expiredStateRetention = 24 * time.Hour
+)
+
+var (
+	// ErrAuthStateNotFound is returned for states that were never issued
+	ErrAuthStateNotFound = errors.New("oauth state not found")
+	// ErrAuthStateExpired is returned for states older than their TTL
+	ErrAuthStateExpired = errors.New("oauth state expired")
+	// ErrAuthStateUsed is returned when a state is presented again
+	ErrAuthStateUsed = errors.New("oauth state already used")
+	// ErrAuthStateForeign is returned for states issued to another user
+	ErrAuthStateForeign = errors.New("oauth state issued to another user")
+	// ErrCodeExchange is returned when Google rejects the authorization code
+	ErrCodeExchange = errors.New("authorization code exchange failed")
+)
+
+// AuthFlow runs the Google connect flow. Every flow gets a random state,
+// stored hashed and bound to the user who started it, which the callback
+// consumes once within the state TTL. The code exchange is protected with
+// PKCE.
+type AuthFlow struct {
+	oauth2Service *OAuth2Service
+	stateRepo     repositories.OAuthStateRepository
+	stateTTL      time.Duration
+}
+
+func NewAuthFlow(oauth2Service *OAuth2Service, stateRepo repositories.OAuthStateRepository, stateTTL time.Duration) *AuthFlow {
+	return &AuthFlow{
+		oauth2Service: oauth2Service,
+		stateRepo:     stateRepo,
+		stateTTL:      stateTTL,
+	}
+}
+
+// Begin starts connecting a user's Google account and returns the consent
+// page URL along with its state
+func (f *AuthFlow) Begin(ctx context.Context, userID entities.UserID) (authURL, state string, err error) {
+	state, err = generateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	authState := &entities.OAuthState{
+		StateHash:    hashSecret(state),
+		UserID:       userID,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		ExpiresAt:    now.Add(f.stateTTL),
+		CreatedAt:    now,
+	}
+	if err := f.stateRepo.Create(ctx, authState); err != nil {
+		return "", "", err
+	}
+
+	// Abandoned flows leave their states behind
+	_ = f.stateRepo.DeleteExpired(ctx, now.Add(-expiredStateRetention))
+
+	return f.oauth2Service.GetAuthURL(state, authState.CodeVerifier), state, nil
+}
+
+// Complete consumes the state of a callback and exchanges its authorization
+// code for tokens
+func (f *AuthFlow) Complete(ctx context.Context, userID entities.UserID, state, code string) (*GoogleTokens, error) {
+	stateHash := hashSecret(state)
+
+	authState, err := f.stateRepo.GetByHash(ctx, stateHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth state: %w", err)
+	}
+	switch {
+	case authState == nil:
+		return nil, ErrAuthStateNotFound
+	case authState.UserID != userID:
+		return nil, ErrAuthStateForeign
+	case authState.UsedAt != nil:
+		return nil, ErrAuthStateUsed
+	case authState.IsExpired():
+		return nil, ErrAuthStateExpired
+	}
+
+	// Concurrent callbacks with the same state race here; one wins
+	used, err := f.stateRepo.MarkUsed(ctx, stateHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use oauth state: %w", err)
+	}
+	if !used {
+		return nil, ErrAuthStateUsed
+	}
+
+	tokens, err := f.oauth2Service.ExchangeCode(ctx, code, authState.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCodeExchange, err)
+	}
+	return tokens, nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret returns the stored form of a secret
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,12 +2,17 @@ package google
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
+	"golang.org/x/oauth2"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
 )
 
 type CalendarService struct {
@@ -20,28 +25,29 @@ func NewCalendarService(oauth2Service *OAuth2Service) *CalendarService {
 	}
 }
 
-type GoogleCalendarEvent struct {
-	ID          string    `json:"id"`
-	Summary     string    `json:"summary"`
-	Description string    `json:"description"`
-	Location    string    `json:"location"`
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	AllDay      bool      `json:"all_day"`
-	CalendarID  string    `json:"calendar_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+// Name implements services.CalendarProvider
+func (s *CalendarService) Name() entities.IntegrationProvider {
+	return entities.IntegrationProviderGoogle
 }
 
-type CalendarListItem struct {
-	ID          string `json:"id"`
-	Summary     string `json:"summary"`
-	Description string `json:"description"`
-	Primary     bool   `json:"primary"`
-	AccessRole  string `json:"access_role"`
+// RefreshToken implements services.CalendarProvider
+func (s *CalendarService) RefreshToken(ctx context.Context, refreshToken string) (*services.ProviderTokens, error) {
+	tokens, err := s.oauth2Service.RefreshToken(ctx, refreshToken)
+	if isInvalidGrant(err) {
+		return nil, fmt.Errorf("%w: %w", services.ErrInvalidGrant, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &services.ProviderTokens{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Expiry:       tokens.Expiry,
+	}, nil
 }
 
-func (s *CalendarService) GetCalendars(ctx context.Context, accessToken string) ([]*CalendarListItem, error) {
+func (s *CalendarService) ListCalendars(ctx context.Context, accessToken string) ([]*services.RemoteCalendar, error) {
 	service, err := s.oauth2Service.CreateCalendarService(ctx, accessToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create calendar service: %w", err)
@@ -52,9 +58,9 @@ func (s *CalendarService) GetCalendars(ctx context.Context, accessToken string)
 		return nil, fmt.Errorf("failed to list calendars: %w", err)
 	}
 
-	var calendars []*CalendarListItem
+	var calendars []*services.RemoteCalendar
 	for _, item := range calendarList.Items {
-		calendars = append(calendars, &CalendarListItem{
+		calendars = append(calendars, &services.RemoteCalendar{
 			ID:          item.Id,
 			Summary:     item.Summary,
 			Description: item.Description,
@@ -66,7 +72,7 @@ func (s *CalendarService) GetCalendars(ctx context.Context, accessToken string)
 	return calendars, nil
 }
 
-func (s *CalendarService) GetEvents(ctx context.Context, accessToken, calendarID string, timeMin, timeMax time.Time) ([]*GoogleCalendarEvent, error) {
+func (s *CalendarService) GetEvents(ctx context.Context, accessToken, calendarID string, timeMin, timeMax time.Time) ([]*services.RemoteEvent, error) {
 	service, err := s.oauth2Service.CreateCalendarService(ctx, accessToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create calendar service: %w", err)
@@ -75,77 +81,183 @@ func (s *CalendarService) GetEvents(ctx context.Context, accessToken, calendarID
 	eventsCall := service.Events.List(calendarID).
 		TimeMin(timeMin.Format(time.RFC3339)).
 		TimeMax(timeMax.Format(time.RFC3339)).
-		SingleEvents(true).
-		OrderBy("startTime")
+		SingleEvents(false)
 
 	events, err := eventsCall.Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list events: %w", err)
 	}
 
-	var googleEvents []*GoogleCalendarEvent
+	var googleEvents []*services.RemoteEvent
 	for _, event := range events.Items {
-		googleEvent := s.convertCalendarEvent(event, calendarID)
+		googleEvent := fromGoogleEvent(event, calendarID, events.TimeZone)
 		googleEvents = append(googleEvents, googleEvent)
 	}
 
 	return googleEvents, nil
 }
 
-func (s *CalendarService) CreateEvent(ctx context.Context, accessToken, calendarID string, event *entities.Event) (*GoogleCalendarEvent, error) {
+// ListEventChanges lists the events changed since the listing that returned
+// syncToken, including deleted ones. Without a sync token, or when Google
+// expired it (410 Gone), all events ending after timeMin are listed
+// instead.
+func (s *CalendarService) ListEventChanges(ctx context.Context, accessToken, calendarID, syncToken string, timeMin time.Time) (*services.RemoteEventChanges, error) {
 	service, err := s.oauth2Service.CreateCalendarService(ctx, accessToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create calendar service: %w", err)
 	}
 
-	googleEvent := &calendar.Event{
-		Summary:     event.Title,
-		Description: event.Description,
-		Location:    event.Location,
-		Start: &calendar.EventDateTime{
-			DateTime: event.StartTime.Format(time.RFC3339),
-			TimeZone: "UTC",
-		},
-		End: &calendar.EventDateTime{
-			DateTime: event.EndTime.Format(time.RFC3339),
-			TimeZone: "UTC",
-		},
+	if syncToken != "" {
+		changes, err := s.listEventPages(ctx, service, calendarID, func(call *calendar.EventsListCall) {
+			call.SyncToken(syncToken)
+		})
+		var apiErr *googleapi.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusGone {
+			return changes, err
+		}
 	}
 
-	createdEvent, err := service.Events.Insert(calendarID, googleEvent).Do()
+	changes, err := s.listEventPages(ctx, service, calendarID, func(call *calendar.EventsListCall) {
+		call.TimeMin(timeMin.Format(time.RFC3339))
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create event: %w", err)
+		return nil, err
 	}
+	changes.FullSync = true
+	return changes, nil
+}
+
+// listEventPages follows all pages of an event listing. Deleted events are
+// included so that a full listing also reports recent cancellations.
+func (s *CalendarService) listEventPages(ctx context.Context, service *calendar.Service, calendarID string, configure func(*calendar.EventsListCall)) (*services.RemoteEventChanges, error) {
+	changes := &services.RemoteEventChanges{}
+	pageToken := ""
+	for {
+		call := service.Events.List(calendarID).
+			Context(ctx).
+			ShowDeleted(true).
+			SingleEvents(false).
+			MaxResults(250)
+		configure(call)
+		if pageToken != "" {
+			call.PageToken(pageToken)
+		}
+
+		events, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
 
-	return s.convertCalendarEvent(createdEvent, calendarID), nil
+		for _, event := range events.Items {
+			changes.Events = append(changes.Events, fromGoogleEvent(event, calendarID, events.TimeZone))
+		}
+
+		if events.NextPageToken == "" {
+			changes.DeltaToken = events.NextSyncToken
+			return changes, nil
+		}
+		pageToken = events.NextPageToken
+	}
 }
 
-func (s *CalendarService) UpdateEvent(ctx context.Context, accessToken, calendarID, eventID string, event *entities.Event) (*GoogleCalendarEvent, error) {
+func (s *CalendarService) CreateEvent(ctx context.Context, accessToken, calendarID string, event *entities.Event, exceptions []*entities.EventException) (*services.RemoteEvent, error) {
 	service, err := s.oauth2Service.CreateCalendarService(ctx, accessToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create calendar service: %w", err)
 	}
 
-	googleEvent := &calendar.Event{
-		Summary:     event.Title,
-		Description: event.Description,
-		Location:    event.Location,
-		Start: &calendar.EventDateTime{
-			DateTime: event.StartTime.Format(time.RFC3339),
-			TimeZone: "UTC",
-		},
-		End: &calendar.EventDateTime{
-			DateTime: event.EndTime.Format(time.RFC3339),
-			TimeZone: "UTC",
-		},
+	createdEvent, err := service.Events.Insert(calendarID, toGoogleEvent(event, exceptions)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+	if err := s.pushInstances(ctx, service, calendarID, createdEvent.Id, event, exceptions); err != nil {
+		return nil, err
+	}
+
+	return fromGoogleEvent(createdEvent, calendarID, eventTimeZone(event)), nil
+}
+
+// UpdateEvent patches the remote event, so fields that are not synced, such
+// as reminders or conference links, are kept. The overridden occurrences of
+// a series are patched as well.
+func (s *CalendarService) UpdateEvent(ctx context.Context, accessToken, calendarID, eventID string, event *entities.Event, exceptions []*entities.EventException) (*services.RemoteEvent, error) {
+	service, err := s.oauth2Service.CreateCalendarService(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar service: %w", err)
 	}
 
-	updatedEvent, err := service.Events.Update(calendarID, eventID, googleEvent).Do()
+	updatedEvent, err := service.Events.Patch(calendarID, eventID, toGoogleEvent(event, exceptions)).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to update event: %w", err)
 	}
+	if err := s.pushInstances(ctx, service, calendarID, eventID, event, exceptions); err != nil {
+		return nil, err
+	}
+
+	return fromGoogleEvent(updatedEvent, calendarID, eventTimeZone(event)), nil
+}
+
+// pushInstances patches the Google instances of the occurrences a local
+// series overrides. Instances are looked up by their original start; one
+// Google doesn't list is inserted as an exception of the series.
+func (s *CalendarService) pushInstances(ctx context.Context, service *calendar.Service, calendarID, seriesID string, event *entities.Event, exceptions []*entities.EventException) error {
+	if !event.IsRecurring() {
+		return nil
+	}
+
+	for _, exception := range exceptions {
+		if exception.Cancelled {
+			continue
+		}
+		instance := toGoogleInstance(event, exception)
+
+		existing, err := s.findInstance(ctx, service, calendarID, seriesID, instance.OriginalStartTime, event.TimeLocation())
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if _, err := service.Events.Patch(calendarID, existing.Id, instance).Context(ctx).Do(); err != nil {
+				return fmt.Errorf("failed to update instance: %w", err)
+			}
+			continue
+		}
+
+		instance.RecurringEventId = seriesID
+		if _, err := service.Events.Insert(calendarID, instance).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to create instance: %w", err)
+		}
+	}
 
-	return s.convertCalendarEvent(updatedEvent, calendarID), nil
+	return nil
+}
+
+// findInstance returns the instance of a series originally starting at
+// originalStart, or nil
+func (s *CalendarService) findInstance(ctx context.Context, service *calendar.Service, calendarID, seriesID string, originalStart *calendar.EventDateTime, loc *time.Location) (*calendar.Event, error) {
+	value := originalStart.DateTime
+	if value == "" {
+		value = originalStart.Date
+	}
+	want, _ := parseEventDateTime(originalStart, loc)
+
+	instances, err := service.Events.Instances(calendarID, seriesID).
+		Context(ctx).
+		OriginalStart(value).
+		ShowDeleted(true).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instances: %w", err)
+	}
+
+	for _, instance := range instances.Items {
+		if instance.OriginalStartTime == nil {
+			continue
+		}
+		if start, _ := parseEventDateTime(instance.OriginalStartTime, loc); start.Equal(want) {
+			return instance, nil
+		}
+	}
+	return nil, nil
 }
 
 func (s *CalendarService) DeleteEvent(ctx context.Context, accessToken, calendarID, eventID string) error {
@@ -154,7 +266,10 @@ func (s *CalendarService) DeleteEvent(ctx context.Context, accessToken, calendar
 		return fmt.Errorf("failed to create calendar service: %w", err)
 	}
 
-	err = service.Events.Delete(calendarID, eventID).Do()
+	err = service.Events.Delete(calendarID, eventID).Context(ctx).Do()
+	if isGone(err) {
+		return services.ErrRemoteEventNotFound
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
@@ -162,7 +277,55 @@ func (s *CalendarService) DeleteEvent(ctx context.Context, accessToken, calendar
 	return nil
 }
 
-func (s *CalendarService) SyncEvents(ctx context.Context, accessToken, calendarID string, localEvents []*entities.Event) ([]*GoogleCalendarEvent, error) {
+// WatchEvents opens a channel on which Google notifies address of changes to
+// the calendar's events. Notifications carry token so the receiver can verify
+// them; Google may expire the channel before ttl.
+func (s *CalendarService) WatchEvents(ctx context.Context, accessToken, calendarID, channelID, address, token string, ttl time.Duration) (*entities.WatchChannel, error) {
+	service, err := s.oauth2Service.CreateCalendarService(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar service: %w", err)
+	}
+
+	channel := &calendar.Channel{
+		Id:      channelID,
+		Type:    "web_hook",
+		Address: address,
+		Token:   token,
+		Params:  map[string]string{"ttl": fmt.Sprintf("%d", int64(ttl.Seconds()))},
+	}
+
+	created, err := service.Events.Watch(calendarID, channel).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch events: %w", err)
+	}
+
+	return &entities.WatchChannel{
+		ID:         created.Id,
+		ResourceID: created.ResourceId,
+		ExpiresAt:  time.UnixMilli(created.Expiration),
+	}, nil
+}
+
+// StopWatch stops the notifications of a channel opened by WatchEvents.
+// Channels that already expired are ignored.
+func (s *CalendarService) StopWatch(ctx context.Context, accessToken string, channel *entities.WatchChannel) error {
+	service, err := s.oauth2Service.CreateCalendarService(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("failed to create calendar service: %w", err)
+	}
+
+	err = service.Channels.Stop(&calendar.Channel{Id: channel.ID, ResourceId: channel.ResourceID}).Context(ctx).Do()
+	if isGone(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stop channel: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CalendarService) SyncEvents(ctx context.Context, accessToken, calendarID string, localEvents []*entities.Event) ([]*services.RemoteEvent, error) {
 	// Get events from Google Calendar
 	now := time.Now()
 	pastMonth := now.AddDate(0, -1, 0)
@@ -178,56 +341,14 @@ func (s *CalendarService) SyncEvents(ctx context.Context, accessToken, calendarI
 	return googleEvents, nil
 }
 
-func (s *CalendarService) convertCalendarEvent(event *calendar.Event, calendarID string) *GoogleCalendarEvent {
-	googleEvent := &GoogleCalendarEvent{
-		ID:          event.Id,
-		Summary:     event.Summary,
-		Description: event.Description,
-		Location:    event.Location,
-		CalendarID:  calendarID,
-		AllDay:      false,
-	}
-
-	// Parse start time
-	if event.Start != nil {
-		if event.Start.DateTime != "" {
-			if startTime, err := time.Parse(time.RFC3339, event.Start.DateTime); err == nil {
-				googleEvent.StartTime = startTime
-			}
-		} else if event.Start.Date != "" {
-			if startTime, err := time.Parse("2006-01-02", event.Start.Date); err == nil {
-				googleEvent.StartTime = startTime
-				googleEvent.AllDay = true
-			}
-		}
-	}
-
-	// Parse end time
-	if event.End != nil {
-		if event.End.DateTime != "" {
-			if endTime, err := time.Parse(time.RFC3339, event.End.DateTime); err == nil {
-				googleEvent.EndTime = endTime
-			}
-		} else if event.End.Date != "" {
-			if endTime, err := time.Parse("2006-01-02", event.End.Date); err == nil {
-				googleEvent.EndTime = endTime
-				googleEvent.AllDay = true
-			}
-		}
-	}
-
-	// Parse created/updated times
-	if event.Created != "" {
-		if createdTime, err := time.Parse(time.RFC3339, event.Created); err == nil {
-			googleEvent.CreatedAt = createdTime
-		}
-	}
-
-	if event.Updated != "" {
-		if updatedTime, err := time.Parse(time.RFC3339, event.Updated); err == nil {
-			googleEvent.UpdatedAt = updatedTime
-		}
-	}
+// isInvalidGrant reports whether Google rejected a refresh token
+func isInvalidGrant(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	return errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant"
+}
 
-	return googleEvent
-}
\ No newline at end of file
+// isGone reports whether Google answered that the resource doesn't exist
+func isGone(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone)
+}
@@ -0,0 +1,343 @@
+package google
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+const (
+	dateLayout            = "2006-01-02"
+	icalDateLayout        = "20060102"
+	icalDateTimeLayout    = "20060102T150405"
+	icalUTCDateTimeLayout = "20060102T150405Z"
+)
+
+// eventColors is Google Calendar's event palette by colorId. Colors are
+// stored locally as hex values and pushed as the closest palette entry.
+var eventColors = map[string]string{
+	"1":  "#a4bdfc",
+	"2":  "#7ae7bf",
+	"3":  "#dbadff",
+	"4":  "#ff887c",
+	"5":  "#fbd75b",
+	"6":  "#ffb878",
+	"7":  "#46d6db",
+	"8":  "#e1e1e1",
+	"9":  "#5484ed",
+	"10": "#51b749",
+	"11": "#dc2127",
+}
+
+var attendeeStatuses = map[string]entities.AttendeeStatus{
+	"needsAction": entities.AttendeeStatusPending,
+	"accepted":    entities.AttendeeStatusAccepted,
+	"declined":    entities.AttendeeStatusDeclined,
+	"tentative":   entities.AttendeeStatusTentative,
+}
+
+// fromGoogleEvent converts a Google event. Events without a time zone of
+// their own, such as all-day ones, are in calendarTimeZone, which listings
+// report for the whole calendar.
+func fromGoogleEvent(event *calendar.Event, calendarID, calendarTimeZone string) *services.RemoteEvent {
+	remoteEvent := &services.RemoteEvent{
+		ID:               event.Id,
+		Summary:          event.Summary,
+		Description:      event.Description,
+		Location:         event.Location,
+		TimeZone:         calendarTimeZone,
+		Status:           entities.EventStatusConfirmed,
+		Color:            eventColors[event.ColorId],
+		Transparent:      event.Transparency == "transparent",
+		CalendarID:       calendarID,
+		RecurringEventID: event.RecurringEventId,
+		Cancelled:        event.Status == "cancelled",
+	}
+	if event.Start != nil && event.Start.TimeZone != "" {
+		remoteEvent.TimeZone = event.Start.TimeZone
+	}
+	loc := loadLocation(remoteEvent.TimeZone)
+
+	if event.Start != nil {
+		remoteEvent.StartTime, remoteEvent.AllDay = parseEventDateTime(event.Start, loc)
+	}
+	if event.End != nil {
+		remoteEvent.EndTime, _ = parseEventDateTime(event.End, loc)
+	}
+	if event.OriginalStartTime != nil {
+		if originalStart, _ := parseEventDateTime(event.OriginalStartTime, loc); !originalStart.IsZero() {
+			remoteEvent.OriginalStartTime = &originalStart
+		}
+	}
+
+	if event.Status == "tentative" {
+		remoteEvent.Status = entities.EventStatusTentative
+	}
+
+	// Rooms and other resources are not people to invite
+	remoteEvent.Attendees = []entities.Attendee{}
+	for _, attendee := range event.Attendees {
+		if attendee.Resource || attendee.Email == "" {
+			continue
+		}
+		status, ok := attendeeStatuses[attendee.ResponseStatus]
+		if !ok {
+			status = entities.AttendeeStatusPending
+		}
+		remoteEvent.Attendees = append(remoteEvent.Attendees, entities.Attendee{
+			Email:  attendee.Email,
+			Name:   attendee.DisplayName,
+			Status: status,
+		})
+	}
+
+	// RDATE lines are not supported
+	for _, line := range event.Recurrence {
+		name, _, _ := strings.Cut(strings.ToUpper(line), ":")
+		name, _, _ = strings.Cut(name, ";")
+		switch name {
+		case "RRULE":
+			if rule, err := entities.ParseRRULE(line); err == nil {
+				remoteEvent.Recurrence = rule
+			}
+		case "EXDATE":
+			remoteEvent.ExcludedDates = append(remoteEvent.ExcludedDates, parseExDate(line, loc)...)
+		}
+	}
+
+	if createdTime, err := time.Parse(time.RFC3339, event.Created); err == nil {
+		remoteEvent.CreatedAt = createdTime
+	}
+	if updatedTime, err := time.Parse(time.RFC3339, event.Updated); err == nil {
+		remoteEvent.UpdatedAt = updatedTime
+	}
+
+	return remoteEvent
+}
+
+// toGoogleEvent converts a local event for inserting or patching. Every
+// synced field is sent, even when empty, so a patch clears what was removed
+// locally while fields the app doesn't know, such as reminders, are kept.
+func toGoogleEvent(event *entities.Event, exceptions []*entities.EventException) *calendar.Event {
+	timezone := eventTimeZone(event)
+	loc := event.TimeLocation()
+
+	googleEvent := &calendar.Event{
+		Summary:      event.Title,
+		Description:  event.Description,
+		Location:     event.Location,
+		Start:        eventDateTime(event.StartTime, event.AllDay, timezone, loc),
+		End:          eventDateTime(event.EndTime, event.AllDay, timezone, loc),
+		Status:       "confirmed",
+		Transparency: "opaque",
+		ColorId:      colorID(event.Color),
+		Recurrence:   eventRecurrence(event, exceptions, loc),
+		ForceSendFields: []string{
+			"Attendees", "Description", "Location", "Recurrence", "Summary",
+		},
+	}
+
+	// Cancelled local events are still shown; Google would delete them
+	if event.Status == entities.EventStatusTentative {
+		googleEvent.Status = "tentative"
+	}
+	if event.Transparent {
+		googleEvent.Transparency = "transparent"
+	}
+	if googleEvent.ColorId == "" {
+		googleEvent.NullFields = append(googleEvent.NullFields, "ColorId")
+	}
+
+	for _, attendee := range event.Attendees {
+		if attendee.Email == "" {
+			continue
+		}
+		responseStatus := "needsAction"
+		for googleStatus, status := range attendeeStatuses {
+			if status == attendee.Status {
+				responseStatus = googleStatus
+			}
+		}
+		googleEvent.Attendees = append(googleEvent.Attendees, &calendar.EventAttendee{
+			Email:          attendee.Email,
+			DisplayName:    attendee.Name,
+			ResponseStatus: responseStatus,
+		})
+	}
+
+	return googleEvent
+}
+
+// toGoogleInstance converts an overridden occurrence of a local series into
+// the Google instance of the series it modifies. The instance is found, or
+// created, by its original start.
+func toGoogleInstance(series *entities.Event, exception *entities.EventException) *calendar.Event {
+	occurrence := exception.Apply(series.Occurrence(exception.RecurrenceID))
+
+	instance := toGoogleEvent(occurrence, nil)
+	instance.ForceSendFields = slices.DeleteFunc(instance.ForceSendFields, func(field string) bool {
+		return field == "Recurrence"
+	})
+	instance.OriginalStartTime = eventDateTime(exception.RecurrenceID, series.AllDay, eventTimeZone(series), series.TimeLocation())
+	return instance
+}
+
+// parseEventDateTime returns the time of a timed or all-day EventDateTime;
+// dates start at the first instant of the day in loc. It returns the zero time when neither parses.
+func parseEventDateTime(dt *calendar.EventDateTime, loc *time.Location) (time.Time, bool) {
+	if dt.DateTime != "" {
+		if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+			return t, false
+		}
+	} else if dt.Date != "" {
+		if t, err := time.Parse(dateLayout, dt.Date); err == nil {
+			return entities.StartOfDay(t, loc), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// eventDateTime returns the start or end of a local event. The other of
+// date and dateTime is nulled so a patch can switch between them.
+func eventDateTime(t time.Time, allDay bool, timezone string, loc *time.Location) *calendar.EventDateTime {
+	if allDay {
+		return &calendar.EventDateTime{
+			Date:       t.In(loc).Format(dateLayout),
+			TimeZone:   timezone,
+			NullFields: []string{"DateTime"},
+		}
+	}
+	return &calendar.EventDateTime{
+		DateTime:   t.Format(time.RFC3339),
+		TimeZone:   timezone,
+		NullFields: []string{"Date"},
+	}
+}
+
+// eventTimeZone returns the IANA time zone recurring events expand in
+func eventTimeZone(event *entities.Event) string {
+	if event.Timezone == "" {
+		return "UTC"
+	}
+	return event.Timezone
+}
+
+// eventRecurrence returns the RRULE of a recurring event and an EXDATE for
+// its cancelled occurrences, in the event's time zone. Modified occurrences
+// are pushed as instances, see toGoogleInstance.
+func eventRecurrence(event *entities.Event, exceptions []*entities.EventException, loc *time.Location) []string {
+	if event.Recurrence == nil {
+		return nil
+	}
+
+	rrule := event.Recurrence.ToRRULE()
+	if rrule == "" {
+		return nil
+	}
+	recurrence := []string{"RRULE:" + rrule}
+
+	var exdates []string
+	for _, exception := range exceptions {
+		if !exception.Cancelled {
+			continue
+		}
+		if event.AllDay {
+			exdates = append(exdates, exception.RecurrenceID.In(loc).Format(icalDateLayout))
+		} else {
+			exdates = append(exdates, exception.RecurrenceID.In(loc).Format(icalDateTimeLayout))
+		}
+	}
+	if len(exdates) == 0 {
+		return recurrence
+	}
+
+	if event.AllDay {
+		return append(recurrence, "EXDATE;VALUE=DATE:"+strings.Join(exdates, ","))
+	}
+	return append(recurrence, "EXDATE;TZID="+loc.String()+":"+strings.Join(exdates, ","))
+}
+
+// parseExDate parses the dates of an EXDATE line. Values without a TZID
+// or UTC suffix, including dates, are in loc, the zone of the series.
+func parseExDate(line string, loc *time.Location) []time.Time {
+	params, values, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil
+	}
+	for _, param := range strings.Split(params, ";")[1:] {
+		if strings.HasPrefix(strings.ToUpper(param), "TZID=") {
+			loc = loadLocation(param[len("TZID="):])
+		}
+	}
+
+	var dates []time.Time
+	for _, value := range strings.Split(values, ",") {
+		value = strings.TrimSpace(value)
+		var t time.Time
+		var err error
+		switch len(value) {
+		case len(icalDateLayout):
+			t, err = time.Parse(icalDateLayout, value)
+			t = entities.StartOfDay(t, loc)
+		case len(icalUTCDateTimeLayout):
+			t, err = time.Parse(icalUTCDateTimeLayout, value)
+		default:
+			t, err = time.ParseInLocation(icalDateTimeLayout, value, loc)
+		}
+		if err == nil {
+			dates = append(dates, t)
+		}
+	}
+	return dates
+}
+
+// colorID returns the palette entry closest to a hex color, or "" for the
+// calendar's color
+func colorID(color string) string {
+	r, g, b, ok := parseHexColor(color)
+	if !ok {
+		return ""
+	}
+
+	closest := ""
+	closestDistance := math.MaxInt
+	for id, paletteColor := range eventColors {
+		pr, pg, pb, _ := parseHexColor(paletteColor)
+		distance := (r-pr)*(r-pr) + (g-pg)*(g-pg) + (b-pb)*(b-pb)
+		if distance < closestDistance || (distance == closestDistance && id < closest) {
+			closest = id
+			closestDistance = distance
+		}
+	}
+	return closest
+}
+
+func parseHexColor(color string) (int, int, int, bool) {
+	hex, ok := strings.CutPrefix(color, "#")
+	if !ok || len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(value >> 16), int(value >> 8 & 0xff), int(value & 0xff), true
+}
+
+func loadLocation(timezone string) *time.Location {
+	if timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
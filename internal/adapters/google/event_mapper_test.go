@@ -0,0 +1,253 @@
+package google
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// mapperCases are recorded Google events in testdata/events. Instances of a
+// series are never pushed on their own, so they only map one way.
+var mapperCases = []struct {
+	name             string
+	calendarTimeZone string
+	instance         bool
+}{
+	{name: "timed_timezone", calendarTimeZone: "Europe/Berlin"},
+	{name: "all_day", calendarTimeZone: "Europe/Berlin"},
+	{name: "attendees", calendarTimeZone: "Europe/Berlin"},
+	{name: "recurring_exdate", calendarTimeZone: "Europe/Berlin"},
+	{name: "modified_instance", calendarTimeZone: "Europe/Berlin", instance: true},
+	{name: "cancelled_instance", calendarTimeZone: "Europe/Berlin", instance: true},
+	{name: "color", calendarTimeZone: "UTC"},
+	{name: "transparency", calendarTimeZone: "Asia/Tokyo"},
+}
+
+func TestFromGoogleEvent(t *testing.T) {
+	for _, tc := range mapperCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remote := fromGoogleEvent(readGoogleEvent(t, tc.name), "primary", tc.calendarTimeZone)
+			assertGolden(t, tc.name+".remote.golden", remote)
+		})
+	}
+}
+
+func TestToGoogleEvent(t *testing.T) {
+	for _, tc := range mapperCases {
+		if tc.instance {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			remote := fromGoogleEvent(readGoogleEvent(t, tc.name), "primary", tc.calendarTimeZone)
+			event, exceptions := localEvent(remote)
+			assertGolden(t, tc.name+".google.golden", toGoogleEvent(event, exceptions))
+		})
+	}
+}
+
+// TestGoogleEventRoundTrip pushes the local copy of each event back and
+// reads it again, which must not change any synced field
+func TestGoogleEventRoundTrip(t *testing.T) {
+	for _, tc := range mapperCases {
+		if tc.instance {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			want := fromGoogleEvent(readGoogleEvent(t, tc.name), "primary", tc.calendarTimeZone)
+			event, exceptions := localEvent(want)
+
+			// Through JSON, as Google receives it
+			data, err := json.Marshal(toGoogleEvent(event, exceptions))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var pushed calendar.Event
+			if err := json.Unmarshal(data, &pushed); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			got := fromGoogleEvent(&pushed, "primary", tc.calendarTimeZone)
+
+			if got.Summary != want.Summary || got.Description != want.Description || got.Location != want.Location {
+				t.Errorf("text = %q, %q, %q, want %q, %q, %q",
+					got.Summary, got.Description, got.Location, want.Summary, want.Description, want.Location)
+			}
+			if !got.StartTime.Equal(want.StartTime) || !got.EndTime.Equal(want.EndTime) || got.AllDay != want.AllDay {
+				t.Errorf("time = %v - %v (all day %v), want %v - %v (all day %v)",
+					got.StartTime, got.EndTime, got.AllDay, want.StartTime, want.EndTime, want.AllDay)
+			}
+			if got.TimeZone != want.TimeZone {
+				t.Errorf("time zone = %q, want %q", got.TimeZone, want.TimeZone)
+			}
+			if got.Status != want.Status || got.Color != want.Color || got.Transparent != want.Transparent {
+				t.Errorf("status, color, transparent = %q, %q, %v, want %q, %q, %v",
+					got.Status, got.Color, got.Transparent, want.Status, want.Color, want.Transparent)
+			}
+			if recurrenceString(got.Recurrence) != recurrenceString(want.Recurrence) {
+				t.Errorf("recurrence = %q, want %q", recurrenceString(got.Recurrence), recurrenceString(want.Recurrence))
+			}
+			assertSameJSON(t, "attendees", got.Attendees, want.Attendees)
+			assertSameJSON(t, "excluded dates", utc(got.ExcludedDates), utc(want.ExcludedDates))
+		})
+	}
+}
+
+// TestToGoogleInstance pushes an occurrence of a series that was moved and
+// renamed locally as the Google instance it modifies
+func TestToGoogleInstance(t *testing.T) {
+	remote := fromGoogleEvent(readGoogleEvent(t, "recurring_exdate"), "primary", "Europe/Berlin")
+	series, exceptions := localEvent(remote)
+
+	berlin := series.TimeLocation()
+	title := "Standup (moved)"
+	start := time.Date(2024, 4, 8, 10, 0, 0, 0, berlin)
+	end := time.Date(2024, 4, 8, 10, 30, 0, 0, berlin)
+	modified := &entities.EventException{
+		EventID:      series.ID,
+		RecurrenceID: time.Date(2024, 4, 8, 9, 0, 0, 0, berlin),
+		Title:        &title,
+		StartTime:    &start,
+		EndTime:      &end,
+	}
+
+	// Only cancelled occurrences are excluded from the series
+	pushed := toGoogleEvent(series, append(exceptions, modified))
+	if got, want := pushed.Recurrence, toGoogleEvent(series, exceptions).Recurrence; !slices.Equal(got, want) {
+		t.Errorf("series recurrence = %q, want %q", got, want)
+	}
+
+	instance := toGoogleInstance(series, modified)
+	assertGolden(t, "recurring_exdate.instance.golden", instance)
+
+	// Read back as Google lists the instance
+	instance.Id = remote.ID + "_20240408T070000Z"
+	instance.RecurringEventId = remote.ID
+	data, err := json.Marshal(instance)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var listed calendar.Event
+	if err := json.Unmarshal(data, &listed); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	got := fromGoogleEvent(&listed, "primary", "Europe/Berlin")
+
+	if got.RecurringEventID != remote.ID || got.OriginalStartTime == nil || !got.OriginalStartTime.Equal(modified.RecurrenceID) {
+		t.Errorf("instance of %q originally at %v, want %q at %v",
+			got.RecurringEventID, got.OriginalStartTime, remote.ID, modified.RecurrenceID)
+	}
+	if got.Summary != title || !got.StartTime.Equal(start) || !got.EndTime.Equal(end) {
+		t.Errorf("instance = %q, %v - %v, want %q, %v - %v", got.Summary, got.StartTime, got.EndTime, title, start, end)
+	}
+	if got.Recurrence != nil {
+		t.Errorf("instance recurrence = %q, want none", got.Recurrence.ToRRULE())
+	}
+}
+
+// localEvent is the local copy the sync stores for a remote event, with
+// the occurrences the series excludes as cancelled exceptions
+func localEvent(remote *services.RemoteEvent) (*entities.Event, []*entities.EventException) {
+	event := &entities.Event{
+		ID:          "event-1",
+		Title:       remote.Summary,
+		Description: remote.Description,
+		Location:    remote.Location,
+		StartTime:   remote.StartTime,
+		EndTime:     remote.EndTime,
+		Timezone:    remote.TimeZone,
+		AllDay:      remote.AllDay,
+		Recurrence:  remote.Recurrence,
+		Attendees:   remote.Attendees,
+		Status:      remote.Status,
+		Color:       remote.Color,
+		Transparent: remote.Transparent,
+	}
+
+	var exceptions []*entities.EventException
+	for _, excluded := range remote.ExcludedDates {
+		exceptions = append(exceptions, &entities.EventException{
+			EventID:      event.ID,
+			RecurrenceID: excluded,
+			Cancelled:    true,
+		})
+	}
+	return event, exceptions
+}
+
+func readGoogleEvent(t *testing.T, name string) *calendar.Event {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "events", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event calendar.Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("failed to parse %s: %v", name, err)
+	}
+	return &event
+}
+
+// assertGolden compares the JSON of v with a golden file, which -update
+// rewrites instead
+func assertGolden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "events", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run the tests with -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs:\n got: %s\nwant: %s", name, got, want)
+	}
+}
+
+func assertSameJSON(t *testing.T, what string, got, want interface{}) {
+	t.Helper()
+
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("%s = %s, want %s", what, gotJSON, wantJSON)
+	}
+}
+
+func recurrenceString(rule *entities.RecurrenceRule) string {
+	if rule == nil {
+		return ""
+	}
+	return rule.ToRRULE()
+}
+
+func utc(times []time.Time) []time.Time {
+	converted := make([]time.Time, len(times))
+	for i, t := range times {
+		converted[i] = t.UTC()
+	}
+	return converted
+}
@@ -0,0 +1,738 @@
+package googlefake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+const (
+	// defaultMaxResults is the page size of event listings without maxResults
+	defaultMaxResults = 250
+
+	// channelTTL is the lifetime of watch channels without a ttl parameter
+	channelTTL = 7 * 24 * time.Hour
+
+	dateLayout = "2006-01-02"
+)
+
+type fakeCalendar struct {
+	entry  *calendar.CalendarListEntry
+	owner  *account
+	events []*storedEvent // In the order they were inserted
+}
+
+// storedEvent is an event along with the number of its last change
+type storedEvent struct {
+	event *calendar.Event
+	seq   int64
+}
+
+// channel is a watch channel opened on the events of a calendar
+type channel struct {
+	calendarID    string
+	id            string
+	resourceID    string
+	token         string
+	address       string
+	expiresAt     time.Time
+	messageNumber int
+}
+
+// InsertEvent creates an event in a calendar as its owner would in Google
+// Calendar. The created event is returned.
+func (s *Server) InsertEvent(calendarID string, event *calendar.Event) (*calendar.Event, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	created, err := s.insertEvent(calendarID, body)
+	s.mu.Unlock()
+	return created, err
+}
+
+// PatchEvent changes an event as its owner would in Google Calendar. Only
+// the fields set in patch, or listed in its ForceSendFields and NullFields,
+// are changed.
+func (s *Server) PatchEvent(calendarID, eventID string, patch *calendar.Event) (*calendar.Event, error) {
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	patched, err := s.patchEvent(calendarID, eventID, body)
+	s.mu.Unlock()
+	return patched, err
+}
+
+// DeleteEvent deletes an event as its owner would in Google Calendar. Like
+// in Google, it is kept as cancelled and listed as such.
+func (s *Server) DeleteEvent(calendarID, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteEvent(calendarID, eventID)
+}
+
+// Event returns an event of a calendar, including cancelled ones, or nil
+func (s *Server) Event(calendarID, eventID string) *calendar.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.storedEvent(calendarID, eventID)
+	if err != nil {
+		return nil
+	}
+	return cloneEvent(stored.event)
+}
+
+// Events returns the events of a calendar that are not cancelled
+func (s *Server) Events(calendarID string) []*calendar.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cal, ok := s.calendars[calendarID]
+	if !ok {
+		return nil
+	}
+
+	var events []*calendar.Event
+	for _, stored := range cal.events {
+		if stored.event.Status != "cancelled" {
+			events = append(events, cloneEvent(stored.event))
+		}
+	}
+	return events
+}
+
+// ExpireSyncTokens expires all issued sync tokens, so that the next
+// incremental listings fail with 410 Gone and require a full sync
+func (s *Server) ExpireSyncTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.minSyncSeq = s.seq + 1
+}
+
+func (s *Server) addCalendar(owner *account, id, summary, timeZone string, primary bool) {
+	s.calendars[id] = &fakeCalendar{
+		entry: &calendar.CalendarListEntry{
+			Kind:       "calendar#calendarListEntry",
+			Id:         id,
+			Summary:    summary,
+			TimeZone:   timeZone,
+			AccessRole: "owner",
+			Primary:    primary,
+		},
+		owner: owner,
+	}
+}
+
+func (s *Server) handleCalendarList(w http.ResponseWriter, r *http.Request, acc *account) {
+	s.mu.Lock()
+	items := []*calendar.CalendarListEntry{}
+	for _, cal := range s.calendars {
+		if cal.owner == acc {
+			items = append(items, cal.entry)
+		}
+	}
+	s.mu.Unlock()
+
+	// The primary calendar comes first
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Primary != items[j].Primary {
+			return items[i].Primary
+		}
+		return items[i].Id < items[j].Id
+	})
+
+	writeJSON(w, http.StatusOK, &calendar.CalendarList{
+		Kind:  "calendar#calendarList",
+		Items: items,
+	})
+}
+
+// handleListEvents lists events either changed since a sync token or within
+// timeMin and timeMax, a page at a time. Page tokens hold the change number
+// of the first page and the position of the next event, so changes made
+// while paging are listed by the next sync rather than on a later page.
+func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request, acc *account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cal, err := s.ownedCalendar(r.PathValue("calendarId"), acc)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	maxResults := defaultMaxResults
+	if value := query.Get("maxResults"); value != "" {
+		if maxResults, err = strconv.Atoi(value); err != nil || maxResults < 1 {
+			writeError(w, invalid("Invalid value for maxResults"))
+			return
+		}
+	}
+
+	snapshot, offset := s.seq, 0
+	if pageToken := query.Get("pageToken"); pageToken != "" {
+		if _, err := fmt.Sscanf(pageToken, "page_%d_%d", &snapshot, &offset); err != nil {
+			writeError(w, invalid("Invalid page token value"))
+			return
+		}
+	}
+
+	var filter func(*storedEvent) bool
+	if syncToken := query.Get("syncToken"); syncToken != "" {
+		if query.Get("timeMin") != "" || query.Get("timeMax") != "" {
+			writeError(w, invalid("Sync token can't be combined with timeMin or timeMax"))
+			return
+		}
+		var since int64
+		if _, err := fmt.Sscanf(syncToken, "sync_%d", &since); err != nil {
+			writeError(w, invalid("Invalid sync token value"))
+			return
+		}
+		if since < s.minSyncSeq {
+			writeError(w, &apiError{code: http.StatusGone, reason: "fullSyncRequired", message: "Sync token is no longer valid, a full sync is required."})
+			return
+		}
+		// Changes are listed along with deletions
+		filter = func(stored *storedEvent) bool { return stored.seq > since }
+	} else {
+		timeMin, err := parseTimeParam(query.Get("timeMin"))
+		if err != nil {
+			writeError(w, invalid("Invalid value for timeMin"))
+			return
+		}
+		timeMax, err := parseTimeParam(query.Get("timeMax"))
+		if err != nil {
+			writeError(w, invalid("Invalid value for timeMax"))
+			return
+		}
+		showDeleted := query.Get("showDeleted") == "true"
+		loc := loadLocation(cal.entry.TimeZone)
+		filter = func(stored *storedEvent) bool {
+			if stored.event.Status == "cancelled" && !showDeleted {
+				return false
+			}
+			return overlaps(stored.event, timeMin, timeMax, loc)
+		}
+	}
+
+	events := &calendar.Events{
+		Kind:     "calendar#events",
+		Summary:  cal.entry.Summary,
+		TimeZone: cal.entry.TimeZone,
+		Items:    []*calendar.Event{},
+	}
+	for i := offset; i < len(cal.events); i++ {
+		stored := cal.events[i]
+		if stored.seq > snapshot || !filter(stored) {
+			continue
+		}
+		if len(events.Items) == maxResults {
+			events.NextPageToken = fmt.Sprintf("page_%d_%d", snapshot, i)
+			break
+		}
+		events.Items = append(events.Items, stored.event)
+	}
+	if events.NextPageToken == "" {
+		events.NextSyncToken = fmt.Sprintf("sync_%d", snapshot)
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (s *Server) handleGetEvent(w http.ResponseWriter, r *http.Request, acc *account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownedCalendar(r.PathValue("calendarId"), acc); err != nil {
+		writeError(w, err)
+		return
+	}
+	stored, err := s.storedEvent(r.PathValue("calendarId"), r.PathValue("eventId"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stored.event)
+}
+
+// handleListInstances lists the stored exceptions of a recurring event,
+// only the one originally starting at originalStart when it is given
+func (s *Server) handleListInstances(w http.ResponseWriter, r *http.Request, acc *account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cal, err := s.ownedCalendar(r.PathValue("calendarId"), acc)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	seriesID := r.PathValue("eventId")
+	if _, err := s.storedEvent(cal.entry.Id, seriesID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	showDeleted := query.Get("showDeleted") == "true"
+	var originalStart time.Time
+	if value := query.Get("originalStart"); value != "" {
+		if originalStart, err = parseTimeParam(value); err != nil {
+			if originalStart, err = time.ParseInLocation(dateLayout, value, loadLocation(cal.entry.TimeZone)); err != nil {
+				writeError(w, invalid("Invalid value for originalStart"))
+				return
+			}
+		}
+	}
+
+	events := &calendar.Events{
+		Kind:     "calendar#events",
+		Summary:  cal.entry.Summary,
+		TimeZone: cal.entry.TimeZone,
+		Items:    []*calendar.Event{},
+	}
+	for _, stored := range cal.events {
+		event := stored.event
+		if event.RecurringEventId != seriesID || (event.Status == "cancelled" && !showDeleted) {
+			continue
+		}
+		if !originalStart.IsZero() {
+			if event.OriginalStartTime == nil {
+				continue
+			}
+			start, _, err := parseEventTime(event.OriginalStartTime, loadLocation(event.OriginalStartTime.TimeZone))
+			if err != nil || !start.Equal(originalStart) {
+				continue
+			}
+		}
+		events.Items = append(events.Items, event)
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (s *Server) handleInsertEvent(w http.ResponseWriter, r *http.Request, acc *account) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, invalid("Failed to read request"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownedCalendar(r.PathValue("calendarId"), acc); err != nil {
+		writeError(w, err)
+		return
+	}
+	created, err := s.insertEvent(r.PathValue("calendarId"), body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, created)
+}
+
+func (s *Server) handlePatchEvent(w http.ResponseWriter, r *http.Request, acc *account) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, invalid("Failed to read request"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownedCalendar(r.PathValue("calendarId"), acc); err != nil {
+		writeError(w, err)
+		return
+	}
+	patched, err := s.patchEvent(r.PathValue("calendarId"), r.PathValue("eventId"), body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, patched)
+}
+
+func (s *Server) handleDeleteEvent(w http.ResponseWriter, r *http.Request, acc *account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownedCalendar(r.PathValue("calendarId"), acc); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.deleteEvent(r.PathValue("calendarId"), r.PathValue("eventId")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleWatch opens a channel that is notified of every change to the
+// calendar's events, starting with a sync message
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request, acc *account) {
+	var request calendar.Channel
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Id == "" || request.Address == "" {
+		writeError(w, invalid("Channel id and address are required"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calendarID := r.PathValue("calendarId")
+	if _, err := s.ownedCalendar(calendarID, acc); err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, ok := s.channels[request.Id]; ok {
+		writeError(w, &apiError{code: http.StatusBadRequest, reason: "channelIdNotUnique", message: "Channel id not unique"})
+		return
+	}
+
+	ttl := channelTTL
+	if value, err := strconv.Atoi(request.Params["ttl"]); err == nil && value > 0 {
+		ttl = min(time.Duration(value)*time.Second, channelTTL)
+	}
+	ch := &channel{
+		calendarID: calendarID,
+		id:         request.Id,
+		resourceID: newID(),
+		token:      request.Token,
+		address:    request.Address,
+		expiresAt:  time.Now().Add(ttl),
+	}
+	s.channels[ch.id] = ch
+	s.notify(ch, "sync")
+
+	writeJSON(w, http.StatusOK, &calendar.Channel{
+		Kind:        "api#channel",
+		Id:          ch.id,
+		ResourceId:  ch.resourceID,
+		ResourceUri: s.URL + "/calendar/v3/calendars/" + calendarID + "/events",
+		Token:       ch.token,
+		Expiration:  ch.expiresAt.UnixMilli(),
+	})
+}
+
+func (s *Server) handleStopChannel(w http.ResponseWriter, r *http.Request, acc *account) {
+	var request calendar.Channel
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, invalid("Invalid channel"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.channels[request.Id]
+	if !ok || ch.resourceID != request.ResourceId {
+		writeError(w, notFound(fmt.Sprintf("Channel '%s' not found for project", request.Id)))
+		return
+	}
+	delete(s.channels, ch.id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) insertEvent(calendarID string, body []byte) (*calendar.Event, error) {
+	cal, ok := s.calendars[calendarID]
+	if !ok {
+		return nil, notFound("Not Found")
+	}
+
+	var event calendar.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, invalid("Invalid event")
+	}
+	if err := validateEvent(&event); err != nil {
+		return nil, err
+	}
+
+	if event.Id == "" {
+		event.Id = newID()
+	} else if _, err := s.storedEvent(calendarID, event.Id); err == nil {
+		return nil, &apiError{code: http.StatusConflict, reason: "duplicate", message: "The requested identifier already exists."}
+	}
+	if event.Status == "" {
+		event.Status = "confirmed"
+	}
+	now := s.updateTime()
+	event.Kind = "calendar#event"
+	event.Created = now
+	event.ICalUID = event.Id + "@google.com"
+	event.HtmlLink = s.URL + "/calendar/event?eid=" + event.Id
+	event.Creator = &calendar.EventCreator{Email: cal.owner.email, Self: true}
+	event.Organizer = &calendar.EventOrganizer{Email: calendarID, Self: true}
+
+	stored := &storedEvent{event: &event}
+	cal.events = append(cal.events, stored)
+	s.touch(calendarID, stored, now)
+	return cloneEvent(stored.event), nil
+}
+
+// patchEvent merges a patch into an event: objects are merged field by
+// field, null clears a field and anything else replaces it
+func (s *Server) patchEvent(calendarID, eventID string, body []byte) (*calendar.Event, error) {
+	stored, err := s.storedEvent(calendarID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch map[string]any
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, invalid("Invalid event")
+	}
+	current, err := json.Marshal(stored.event)
+	if err != nil {
+		return nil, err
+	}
+	var merged map[string]any
+	if err := json.Unmarshal(current, &merged); err != nil {
+		return nil, err
+	}
+	mergePatch(merged, patch)
+
+	// Fields Google manages can't be patched
+	for _, field := range []string{"id", "kind", "created", "updated", "iCalUID", "htmlLink", "creator", "sequence", "etag"} {
+		delete(merged, field)
+	}
+	mergedJSON, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var event calendar.Event
+	if err := json.Unmarshal(mergedJSON, &event); err != nil {
+		return nil, invalid("Invalid event")
+	}
+	if err := validateEvent(&event); err != nil {
+		return nil, err
+	}
+
+	event.Id = stored.event.Id
+	event.Kind = stored.event.Kind
+	event.Created = stored.event.Created
+	event.ICalUID = stored.event.ICalUID
+	event.HtmlLink = stored.event.HtmlLink
+	event.Creator = stored.event.Creator
+	event.Sequence = stored.event.Sequence + 1
+	if event.Status == "" {
+		event.Status = "confirmed"
+	}
+
+	stored.event = &event
+	s.touch(calendarID, stored, s.updateTime())
+	return cloneEvent(stored.event), nil
+}
+
+func (s *Server) deleteEvent(calendarID, eventID string) error {
+	stored, err := s.storedEvent(calendarID, eventID)
+	if err != nil {
+		return err
+	}
+	if stored.event.Status == "cancelled" {
+		return &apiError{code: http.StatusGone, reason: "deleted", message: "Resource has been deleted"}
+	}
+
+	stored.event.Status = "cancelled"
+	s.touch(calendarID, stored, s.updateTime())
+	return nil
+}
+
+// touch numbers a change to an event and notifies the channels watching
+// its calendar
+func (s *Server) touch(calendarID string, stored *storedEvent, updated string) {
+	s.seq++
+	stored.seq = s.seq
+	stored.event.Updated = updated
+	stored.event.Etag = fmt.Sprintf(`"%d"`, s.seq)
+
+	for _, ch := range s.channels {
+		if ch.calendarID == calendarID {
+			s.notify(ch, "exists")
+		}
+	}
+}
+
+// notify posts a push notification to the address of a channel. Deliveries
+// run in the background, as Google's do, and expired channels are dropped.
+func (s *Server) notify(ch *channel, state string) {
+	if time.Now().After(ch.expiresAt) {
+		delete(s.channels, ch.id)
+		return
+	}
+
+	request, err := http.NewRequest(http.MethodPost, ch.address, nil)
+	if err != nil {
+		return
+	}
+	request.Header.Set("X-Goog-Channel-ID", ch.id)
+	request.Header.Set("X-Goog-Channel-Token", ch.token)
+	request.Header.Set("X-Goog-Channel-Expiration", ch.expiresAt.UTC().Format(http.TimeFormat))
+	request.Header.Set("X-Goog-Resource-ID", ch.resourceID)
+	request.Header.Set("X-Goog-Resource-State", state)
+	request.Header.Set("X-Goog-Resource-URI", s.URL+"/calendar/v3/calendars/"+ch.calendarID+"/events")
+	request.Header.Set("X-Goog-Message-Number", strconv.Itoa(ch.messageNumber+1))
+	ch.messageNumber++
+
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+		if response, err := s.notifier.Do(request); err == nil {
+			response.Body.Close()
+		}
+	}()
+}
+
+// updateTime returns the time of a change in Google's format. Changes made
+// within the same millisecond get increasing times, so every change is
+// newer than the one before.
+func (s *Server) updateTime() string {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if !now.After(s.lastUpdate) {
+		now = s.lastUpdate.Add(time.Millisecond)
+	}
+	s.lastUpdate = now
+	return now.Format("2006-01-02T15:04:05.000Z")
+}
+
+// ownedCalendar returns a calendar of acc
+func (s *Server) ownedCalendar(calendarID string, acc *account) (*fakeCalendar, error) {
+	cal, ok := s.calendars[calendarID]
+	if !ok || cal.owner != acc {
+		return nil, notFound("Not Found")
+	}
+	return cal, nil
+}
+
+func (s *Server) storedEvent(calendarID, eventID string) (*storedEvent, error) {
+	cal, ok := s.calendars[calendarID]
+	if !ok {
+		return nil, notFound("Not Found")
+	}
+	for _, stored := range cal.events {
+		if stored.event.Id == eventID {
+			return stored, nil
+		}
+	}
+	return nil, notFound("Not Found")
+}
+
+// validateEvent checks that an event has a start and an end of the same
+// kind, with the end after the start
+func validateEvent(event *calendar.Event) error {
+	if event.Start == nil || event.End == nil {
+		return &apiError{code: http.StatusBadRequest, reason: "required", message: "Missing end time."}
+	}
+
+	loc := loadLocation(event.Start.TimeZone)
+	start, startAllDay, err := parseEventTime(event.Start, loc)
+	if err != nil {
+		return invalid("Invalid start time.")
+	}
+	end, endAllDay, err := parseEventTime(event.End, loc)
+	if err != nil {
+		return invalid("Invalid end time.")
+	}
+	if startAllDay != endAllDay {
+		return invalid("Start and end times must either both be date or both be dateTime.")
+	}
+	if end.Before(start) {
+		return &apiError{code: http.StatusBadRequest, reason: "timeRangeEmpty", message: "The specified time range is empty."}
+	}
+	return nil
+}
+
+// overlaps reports whether an event ends after timeMin and starts before
+// timeMax. Recurring series overlap when they start before timeMax.
+func overlaps(event *calendar.Event, timeMin, timeMax time.Time, loc *time.Location) bool {
+	if event.Start == nil || event.End == nil {
+		return true
+	}
+	if event.Start.TimeZone != "" {
+		loc = loadLocation(event.Start.TimeZone)
+	}
+	start, _, _ := parseEventTime(event.Start, loc)
+	end, _, _ := parseEventTime(event.End, loc)
+
+	if !timeMax.IsZero() && !start.Before(timeMax) {
+		return false
+	}
+	if len(event.Recurrence) > 0 {
+		return true
+	}
+	return timeMin.IsZero() || end.After(timeMin)
+}
+
+func parseEventTime(dt *calendar.EventDateTime, loc *time.Location) (time.Time, bool, error) {
+	if dt.DateTime != "" {
+		t, err := time.Parse(time.RFC3339, dt.DateTime)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation(dateLayout, dt.Date, loc)
+	return t, true, err
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func mergePatch(target, patch map[string]any) {
+	for key, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(target, key)
+		case map[string]any:
+			current, ok := target[key].(map[string]any)
+			if !ok {
+				current = map[string]any{}
+			}
+			mergePatch(current, value)
+			target[key] = current
+		default:
+			target[key] = value
+		}
+	}
+}
+
+// cloneEvent copies an event, so callers can't change stored ones
+func cloneEvent(event *calendar.Event) *calendar.Event {
+	body, _ := json.Marshal(event)
+	var clone calendar.Event
+	json.Unmarshal(body, &clone)
+	return &clone
+}
+
+func loadLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(strings.TrimSpace(timezone))
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func invalid(message string) error {
+	return &apiError{code: http.StatusBadRequest, reason: "invalid", message: message}
+}
+
+func notFound(message string) error {
+	return &apiError{code: http.StatusNotFound, reason: "notFound", message: message}
+}
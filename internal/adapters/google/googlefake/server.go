@@ -0,0 +1,434 @@
+// Package googlefake is an in-process stand-in for the parts of Google's
+// OAuth2 and Calendar v3 APIs the google adapter uses: the consent page,
+// the token endpoint, user info, the calendar list, and listing, inserting,
+// patching, deleting and watching events and looking up their instances. It lets the connect, sync, edit
+// and resync flow run offline against the real adapter code.
+//
+// Events are kept as Calendar API resources and changes are numbered, so
+// sync tokens return exactly the events changed since the listing that
+// issued them. Recurring series are stored and listed as series; their
+// instances are not expanded, so only instances stored as exceptions of a
+// series, with its ID as recurringEventId, are found.
+package googlefake
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/google"
+)
+
+// TokenLifetime is how long issued access tokens are valid
+const TokenLifetime = time.Hour
+
+// Server is a fake Google API server listening on a local port. Accounts
+// and their calendars are added with AddAccount and AddCalendar; changes a
+// user would make in Google Calendar itself are made with InsertEvent,
+// PatchEvent and DeleteEvent.
+type Server struct {
+	// URL is the root of the server, e.g. http://127.0.0.1:port
+	URL string
+
+	clientID     string
+	clientSecret string
+	server       *httptest.Server
+	notifier     *http.Client
+	deliveries   sync.WaitGroup
+
+	mu            sync.Mutex
+	accounts      []*account
+	calendars     map[string]*fakeCalendar
+	codes         map[string]*authCode
+	accessTokens  map[string]*accessToken
+	refreshTokens map[string]*account
+	channels      map[string]*channel
+	seq           int64 // Numbers changes to events; sync tokens hold it
+	minSyncSeq    int64 // Sync tokens issued before it are expired
+	lastUpdate    time.Time
+}
+
+type account struct {
+	id    string
+	email string
+	name  string
+}
+
+// authCode is an authorization code issued by the consent page
+type authCode struct {
+	account       *account
+	redirectURI   string
+	codeChallenge string
+}
+
+type accessToken struct {
+	account   *account
+	expiresAt time.Time
+}
+
+// NewServer starts a server that accepts the given OAuth client. It must be
+// closed when done.
+func NewServer(clientID, clientSecret string) *Server {
+	s := &Server{
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		notifier:      &http.Client{Timeout: 10 * time.Second},
+		calendars:     make(map[string]*fakeCalendar),
+		codes:         make(map[string]*authCode),
+		accessTokens:  make(map[string]*accessToken),
+		refreshTokens: make(map[string]*account),
+		channels:      make(map[string]*channel),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /o/oauth2/auth", s.handleAuth)
+	mux.HandleFunc("POST /token", s.handleToken)
+	mux.HandleFunc("GET /oauth2/v1/tokeninfo", s.handleTokenInfo)
+	mux.HandleFunc("GET /oauth2/v2/userinfo", s.authenticated(s.handleUserInfo))
+	mux.HandleFunc("GET /calendar/v3/users/me/calendarList", s.authenticated(s.handleCalendarList))
+	mux.HandleFunc("GET /calendar/v3/calendars/{calendarId}/events", s.authenticated(s.handleListEvents))
+	mux.HandleFunc("POST /calendar/v3/calendars/{calendarId}/events", s.authenticated(s.handleInsertEvent))
+	mux.HandleFunc("POST /calendar/v3/calendars/{calendarId}/events/watch", s.authenticated(s.handleWatch))
+	mux.HandleFunc("GET /calendar/v3/calendars/{calendarId}/events/{eventId}", s.authenticated(s.handleGetEvent))
+	mux.HandleFunc("GET /calendar/v3/calendars/{calendarId}/events/{eventId}/instances", s.authenticated(s.handleListInstances))
+	mux.HandleFunc("PATCH /calendar/v3/calendars/{calendarId}/events/{eventId}", s.authenticated(s.handlePatchEvent))
+	mux.HandleFunc("DELETE /calendar/v3/calendars/{calendarId}/events/{eventId}", s.authenticated(s.handleDeleteEvent))
+	mux.HandleFunc("POST /calendar/v3/channels/stop", s.authenticated(s.handleStopChannel))
+
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts the server down once pending push notifications are delivered
+func (s *Server) Close() {
+	s.deliveries.Wait()
+	s.server.Close()
+}
+
+// Config returns the configuration of a google.OAuth2Service talking to
+// this server
+func (s *Server) Config(redirectURL string) google.Config {
+	return google.Config{
+		ClientID:     s.clientID,
+		ClientSecret: s.clientSecret,
+		RedirectURL:  redirectURL,
+		APIURL:       s.URL + "/",
+		AuthURL:      s.URL + "/o/oauth2/auth",
+		TokenURL:     s.URL + "/token",
+		HTTPClient:   s.server.Client(),
+	}
+}
+
+// AddAccount adds a Google account along with its primary calendar, whose
+// ID is the email. Accounts are picked on the consent page by the
+// login_hint parameter, or the first account is.
+func (s *Server) AddAccount(email, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc := &account{id: newID(), email: email, name: name}
+	s.accounts = append(s.accounts, acc)
+	s.addCalendar(acc, email, email, "UTC", true)
+}
+
+// AddCalendar adds a secondary calendar to an account
+func (s *Server) AddCalendar(email, calendarID, summary, timeZone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc := s.account(email)
+	if acc == nil {
+		return fmt.Errorf("unknown account %q", email)
+	}
+	if _, ok := s.calendars[calendarID]; ok {
+		return fmt.Errorf("calendar %q already exists", calendarID)
+	}
+	s.addCalendar(acc, calendarID, summary, timeZone, false)
+	return nil
+}
+
+// Authorize stands in for the user granting consent on the page authURL
+// points at. It returns the callback URL, with the code and state, that
+// Google would redirect the browser to.
+func (s *Server) Authorize(authURL string) (*url.URL, error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth URL: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorize(parsed.Query())
+}
+
+// ExpireAccessTokens expires all issued access tokens, so that API calls
+// fail until tokens are refreshed
+func (s *Server) ExpireAccessTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.accessTokens {
+		token.expiresAt = time.Time{}
+	}
+}
+
+// RevokeAccount revokes all tokens of an account, as when the user removes
+// the app's access; refreshing its tokens fails with invalid_grant
+func (s *Server) RevokeAccount(email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, access := range s.accessTokens {
+		if access.account.email == email {
+			delete(s.accessTokens, token)
+		}
+	}
+	for token, acc := range s.refreshTokens {
+		if acc.email == email {
+			delete(s.refreshTokens, token)
+		}
+	}
+}
+
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	callback, err := s.authorize(r.URL.Query())
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+// authorize issues an authorization code for the account of the login hint
+func (s *Server) authorize(query url.Values) (*url.URL, error) {
+	if query.Get("client_id") != s.clientID {
+		return nil, errors.New("invalid_client")
+	}
+	if query.Get("response_type") != "code" {
+		return nil, errors.New("unsupported_response_type")
+	}
+	if query.Get("code_challenge") != "" && query.Get("code_challenge_method") != "S256" {
+		return nil, errors.New("invalid code_challenge_method")
+	}
+
+	callback, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !callback.IsAbs() {
+		return nil, errors.New("invalid redirect_uri")
+	}
+
+	acc := s.account(query.Get("login_hint"))
+	if acc == nil && query.Get("login_hint") == "" && len(s.accounts) > 0 {
+		acc = s.accounts[0]
+	}
+	if acc == nil {
+		return nil, errors.New("no account to sign in with")
+	}
+
+	code := newID()
+	s.codes[code] = &authCode{
+		account:       acc,
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+
+	params := callback.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	callback.RawQuery = params.Encode()
+	return callback, nil
+}
+
+// handleToken exchanges authorization codes and refresh tokens. Client
+// credentials are accepted in the Authorization header or the form, as
+// the oauth2 package may send either.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.clientID || clientSecret != s.clientSecret {
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code, ok := s.codes[r.PostForm.Get("code")]
+		if !ok || code.redirectURI != r.PostForm.Get("redirect_uri") || !verifyChallenge(code.codeChallenge, r.PostForm.Get("code_verifier")) {
+			writeTokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		// Codes are single use
+		delete(s.codes, r.PostForm.Get("code"))
+
+		refreshToken := "1//" + newID()
+		s.refreshTokens[refreshToken] = code.account
+		s.writeAccessToken(w, code.account, refreshToken)
+	case "refresh_token":
+		acc, ok := s.refreshTokens[r.PostForm.Get("refresh_token")]
+		if !ok {
+			writeTokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		// Like Google, refreshing doesn't rotate the refresh token
+		s.writeAccessToken(w, acc, "")
+	default:
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+	}
+}
+
+func (s *Server) writeAccessToken(w http.ResponseWriter, acc *account, refreshToken string) {
+	token := "ya29." + newID()
+	s.accessTokens[token] = &accessToken{account: acc, expiresAt: time.Now().Add(TokenLifetime)}
+
+	response := map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(TokenLifetime.Seconds()),
+		"scope":        "https://www.googleapis.com/auth/calendar.readonly https://www.googleapis.com/auth/calendar.events",
+	}
+	if refreshToken != "" {
+		response["refresh_token"] = refreshToken
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleTokenInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	acc := s.tokenAccount(r.URL.Query().Get("access_token"))
+	s.mu.Unlock()
+	if acc == nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issued_to": s.clientID,
+		"user_id":   acc.id,
+		"email":     acc.email,
+	})
+}
+
+func (s *Server) handleUserInfo(w http.ResponseWriter, r *http.Request, acc *account) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":             acc.id,
+		"email":          acc.email,
+		"verified_email": true,
+		"name":           acc.name,
+	})
+}
+
+// authenticated resolves the account of the bearer token of API requests
+func (s *Server) authenticated(handler func(http.ResponseWriter, *http.Request, *account)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		acc := s.tokenAccount(token)
+		s.mu.Unlock()
+		if acc == nil {
+			writeError(w, &apiError{code: http.StatusUnauthorized, reason: "authError", message: "Invalid Credentials"})
+			return
+		}
+		handler(w, r, acc)
+	}
+}
+
+// tokenAccount returns the account of a valid access token
+func (s *Server) tokenAccount(token string) *account {
+	access, ok := s.accessTokens[token]
+	if !ok || !time.Now().Before(access.expiresAt) {
+		return nil
+	}
+	return access.account
+}
+
+func (s *Server) account(email string) *account {
+	for _, acc := range s.accounts {
+		if acc.email == email {
+			return acc
+		}
+	}
+	return nil
+}
+
+// verifyChallenge checks a PKCE verifier against the S256 challenge of a
+// code; codes issued without a challenge need no verifier
+func verifyChallenge(challenge, verifier string) bool {
+	if challenge == "" {
+		return true
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+}
+
+// apiError is an error response of the Calendar API
+type apiError struct {
+	code    int
+	reason  string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("googleapi: Error %d: %s, %s", e.code, e.message, e.reason)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = &apiError{code: http.StatusInternalServerError, reason: "backendError", message: err.Error()}
+	}
+
+	writeJSON(w, apiErr.code, map[string]any{
+		"error": map[string]any{
+			"code":    apiErr.code,
+			"message": apiErr.message,
+			"errors": []map[string]string{
+				{"domain": "global", "reason": apiErr.reason, "message": apiErr.message},
+			},
+		},
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+var idEncoding = base32.NewEncoding("0123456789abcdefghijklmnopqrstuv").WithPadding(base32.NoPadding)
+
+// newID returns a random ID in the alphabet of Google event IDs
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return idEncoding.EncodeToString(b)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -13,40 +14,88 @@ import (
 	"google.golang.org/api/option"
 )
 
+// DefaultAPIURL is the root of Google's APIs, under which the Calendar v3
+// and OAuth2 user info endpoints live
+const DefaultAPIURL = "https://www.googleapis.com/"
+
+// Config configures the Google OAuth2 client and the APIs it calls. The URLs
+// and client can point at a stand-in such as googlefake.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	APIURL       string       // Root of Google's APIs; DefaultAPIURL when empty
+	AuthURL      string       // Overrides the consent page
+	TokenURL     string       // Overrides the token endpoint
+	HTTPClient   *http.Client // Used for API and token requests
+}
+
 type OAuth2Service struct {
-	config *oauth2.Config
+	config     *oauth2.Config
+	apiURL     string
+	httpClient *http.Client
 }
 
 type GoogleTokens struct {
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
+	AccessToken  string    `json:"-"`
+	RefreshToken string    `json:"-"`
 	TokenType    string    `json:"token_type"`
 	Expiry       time.Time `json:"expiry"`
 }
 
-func NewOAuth2Service(clientID, clientSecret, redirectURL string) *OAuth2Service {
-	config := &oauth2.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		RedirectURL:  redirectURL,
-		Scopes: []string{
-			calendar.CalendarReadonlyScope,
-			calendar.CalendarEventsScope,
-		},
-		Endpoint: google.Endpoint,
+func NewOAuth2Service(config Config) *OAuth2Service {
+	endpoint := google.Endpoint
+	if config.AuthURL != "" {
+		endpoint.AuthURL = config.AuthURL
+	}
+	if config.TokenURL != "" {
+		endpoint.TokenURL = config.TokenURL
+	}
+
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
 	}
 
 	return &OAuth2Service{
-		config: config,
+		config: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Scopes: []string{
+				calendar.CalendarReadonlyScope,
+				calendar.CalendarEventsScope,
+			},
+			Endpoint: endpoint,
+		},
+		apiURL:     apiURL,
+		httpClient: httpClient,
 	}
 }
 
-func (s *OAuth2Service) GetAuthURL(state string) string {
-	return s.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+// clientContext makes the oauth2 package send its requests through the
+// configured HTTP client
+func (s *OAuth2Service) clientContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, s.httpClient)
+}
+
+// GetAuthURL returns the consent page URL. The PKCE challenge is derived
+// from codeVerifier, which ExchangeCode must be given.
+func (s *OAuth2Service) GetAuthURL(state, codeVerifier string) string {
+	return s.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce,
+		oauth2.S256ChallengeOption(codeVerifier))
 }
 
-func (s *OAuth2Service) ExchangeCode(ctx context.Context, code string) (*GoogleTokens, error) {
-	token, err := s.config.Exchange(ctx, code)
+func (s *OAuth2Service) ExchangeCode(ctx context.Context, code, codeVerifier string) (*GoogleTokens, error) {
+	token, err := s.config.Exchange(s.clientContext(ctx), code, oauth2.VerifierOption(codeVerifier))
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
@@ -64,7 +113,7 @@ func (s *OAuth2Service) RefreshToken(ctx context.Context, refreshToken string) (
 		RefreshToken: refreshToken,
 	}
 
-	tokenSource := s.config.TokenSource(ctx, token)
+	tokenSource := s.config.TokenSource(s.clientContext(ctx), token)
 	newToken, err := tokenSource.Token()
 	if err != nil {
 		return nil, fmt.Errorf("failed to refresh token: %w", err)
@@ -83,8 +132,11 @@ func (s *OAuth2Service) CreateCalendarService(ctx context.Context, accessToken s
 		AccessToken: accessToken,
 	}
 
-	client := s.config.Client(ctx, token)
-	service, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	client := s.config.Client(s.clientContext(ctx), token)
+	service, err := calendar.NewService(ctx,
+		option.WithHTTPClient(client),
+		option.WithEndpoint(s.apiURL+"calendar/v3/"),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create calendar service: %w", err)
 	}
@@ -97,10 +149,10 @@ func (s *OAuth2Service) ValidateToken(ctx context.Context, accessToken string) e
 		AccessToken: accessToken,
 	}
 
-	client := s.config.Client(ctx, token)
+	client := s.config.Client(s.clientContext(ctx), token)
 
 	// Test the token by making a simple API call
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v1/tokeninfo?access_token=" + accessToken)
+	resp, err := client.Get(s.apiURL + "oauth2/v1/tokeninfo?access_token=" + accessToken)
 	if err != nil {
 		return fmt.Errorf("failed to validate token: %w", err)
 	}
@@ -114,7 +166,7 @@ func (s *OAuth2Service) ValidateToken(ctx context.Context, accessToken string) e
 }
 
 type TokenInfo struct {
-	UserID string `json:"user_id"`
+	UserID string `json:"id"`
 	Email  string `json:"email"`
 	Name   string `json:"name"`
 }
@@ -124,9 +176,9 @@ func (s *OAuth2Service) GetUserInfo(ctx context.Context, accessToken string) (*T
 		AccessToken: accessToken,
 	}
 
-	client := s.config.Client(ctx, token)
+	client := s.config.Client(s.clientContext(ctx), token)
 
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+	resp, err := client.Get(s.apiURL + "oauth2/v2/userinfo")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -0,0 +1,503 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// ImportedEvent is a VEVENT series read from an iCalendar file. Event has no
+// ID or owner yet; Exceptions carry EXDATEs and overridden occurrences
+// (VEVENTs with a RECURRENCE-ID) and have no ID or EventID either. Err is set
+// instead when the VEVENT could not be decoded.
+type ImportedEvent struct {
+	UID        string
+	Event      *entities.Event
+	Exceptions []*entities.EventException
+	Err        error
+}
+
+// Decode reads all VEVENTs of an iCalendar stream. Only a malformed stream is
+// an error; invalid events are returned with Err set. Times with an unknown
+// TZID use the offset of the matching VTIMEZONE and are stored in UTC;
+// floating times use X-WR-TIMEZONE, or UTC when the calendar has none.
+func Decode(r io.Reader) ([]*ImportedEvent, error) {
+	roots, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []*ImportedEvent
+	found := false
+	for _, root := range roots {
+		if root.Name != "VCALENDAR" {
+			continue
+		}
+		found = true
+
+		imported = append(imported, decodeCalendar(root)...)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no VCALENDAR found")
+	}
+
+	return imported, nil
+}
+
+// decoder resolves time zones within one VCALENDAR
+type decoder struct {
+	zones       map[string]*time.Location
+	defaultZone *time.Location
+}
+
+func decodeCalendar(cal *component) []*ImportedEvent {
+	d := &decoder{
+		zones:       make(map[string]*time.Location),
+		defaultZone: time.UTC,
+	}
+
+	if prop := cal.get("X-WR-TIMEZONE"); prop != nil {
+		if loc, err := time.LoadLocation(strings.TrimSpace(prop.Value)); err == nil {
+			d.defaultZone = loc
+		}
+	}
+	for _, tz := range cal.children("VTIMEZONE") {
+		if prop := tz.get("TZID"); prop != nil {
+			d.zones[prop.Value] = resolveTimezone(prop.Value, tz)
+		}
+	}
+
+	// Series first, then the overridden occurrences that belong to them.
+	// Duplicate UIDs are kept so callers can report them.
+	var events []*ImportedEvent
+	byUID := make(map[string]*ImportedEvent)
+	var overrides []*component
+	for _, vevent := range cal.children("VEVENT") {
+		if vevent.get("RECURRENCE-ID") != nil {
+			overrides = append(overrides, vevent)
+			continue
+		}
+
+		imported, err := d.decodeEvent(vevent)
+		if err != nil {
+			events = append(events, &ImportedEvent{UID: propertyText(vevent, "UID"), Err: err})
+			continue
+		}
+		if _, exists := byUID[imported.UID]; !exists && imported.UID != "" {
+			byUID[imported.UID] = imported
+		}
+		events = append(events, imported)
+	}
+
+	for _, vevent := range overrides {
+		uid := propertyText(vevent, "UID")
+		imported, ok := byUID[uid]
+		if !ok {
+			// An occurrence without its series is imported as a single event
+			standalone, err := d.decodeEvent(vevent)
+			if err != nil {
+				events = append(events, &ImportedEvent{UID: uid, Err: err})
+				continue
+			}
+			if uid != "" {
+				byUID[uid] = standalone
+			}
+			events = append(events, standalone)
+			continue
+		}
+		if imported.Err != nil || !imported.Event.IsRecurring() {
+			continue
+		}
+
+		exception, err := d.decodeOverride(imported.Event, vevent)
+		if err != nil {
+			imported.Err = err
+			continue
+		}
+		imported.Exceptions = append(imported.Exceptions, exception)
+	}
+
+	return events
+}
+
+func (d *decoder) decodeEvent(vevent *component) (*ImportedEvent, error) {
+	uid := propertyText(vevent, "UID")
+
+	start, loc, allDay, err := d.dateTime(vevent.get("DTSTART"), d.defaultZone)
+	if err != nil {
+		return nil, fmt.Errorf("event %q: invalid DTSTART: %w", uid, err)
+	}
+
+	end, err := d.endTime(vevent, start, loc, allDay)
+	if err != nil {
+		return nil, fmt.Errorf("event %q: %w", uid, err)
+	}
+
+	event := &entities.Event{
+		Title:       propertyText(vevent, "SUMMARY"),
+		Description: propertyText(vevent, "DESCRIPTION"),
+		Location:    propertyText(vevent, "LOCATION"),
+		StartTime:   start,
+		EndTime:     end,
+		Timezone:    timezoneName(loc),
+		AllDay:      allDay,
+		Status:      decodeStatus(propertyText(vevent, "STATUS")),
+		Attendees:   decodeAttendees(vevent),
+	}
+	if vevent.get("RECURRENCE-ID") == nil {
+		if prop := vevent.get("RRULE"); prop != nil {
+			rule, err := entities.ParseRRULE(prop.Value)
+			if err != nil {
+				return nil, fmt.Errorf("event %q: %w", uid, err)
+			}
+			event.Recurrence = rule
+		}
+	}
+
+	imported := &ImportedEvent{
+		UID:   uid,
+		Event: event,
+	}
+
+	if event.IsRecurring() {
+		for _, prop := range vevent.all("EXDATE") {
+			exdates, err := d.dateTimeList(prop, loc)
+			if err != nil {
+				return nil, fmt.Errorf("event %q: invalid EXDATE: %w", uid, err)
+			}
+			for _, exdate := range exdates {
+				imported.Exceptions = append(imported.Exceptions, &entities.EventException{
+					RecurrenceID: exdate,
+					Cancelled:    true,
+				})
+			}
+		}
+	}
+
+	return imported, nil
+}
+
+// decodeOverride turns a VEVENT with RECURRENCE-ID into an exception of
+// series, overriding only the values that differ from the series
+func (d *decoder) decodeOverride(series *entities.Event, vevent *component) (*entities.EventException, error) {
+	uid := propertyText(vevent, "UID")
+
+	recurrenceID, _, _, err := d.dateTime(vevent.get("RECURRENCE-ID"), series.TimeLocation())
+	if err != nil {
+		return nil, fmt.Errorf("event %q: invalid RECURRENCE-ID: %w", uid, err)
+	}
+
+	overridden, err := d.decodeEvent(vevent)
+	if err != nil {
+		return nil, err
+	}
+	occurrence := overridden.Event
+
+	exception := &entities.EventException{
+		RecurrenceID: recurrenceID,
+	}
+	if occurrence.Status == entities.EventStatusCancelled {
+		exception.Cancelled = true
+		return exception, nil
+	}
+
+	if occurrence.Title != series.Title {
+		exception.Title = &occurrence.Title
+	}
+	if occurrence.Description != series.Description {
+		exception.Description = &occurrence.Description
+	}
+	if occurrence.Location != series.Location {
+		exception.Location = &occurrence.Location
+	}
+	if !occurrence.StartTime.Equal(recurrenceID) {
+		exception.StartTime = &occurrence.StartTime
+	}
+	if occurrence.EndTime.Sub(occurrence.StartTime) != series.Duration() || exception.StartTime != nil {
+		exception.EndTime = &occurrence.EndTime
+	}
+	if occurrence.Status != series.Status {
+		exception.Status = &occurrence.Status
+	}
+	if !sameAttendees(occurrence.Attendees, series.Attendees) {
+		exception.Attendees = occurrence.Attendees
+	}
+
+	return exception, nil
+}
+
+// endTime reads DTEND or DURATION. Without either, all-day events last one
+// day and timed events end when they start.
+func (d *decoder) endTime(vevent *component, start time.Time, loc *time.Location, allDay bool) (time.Time, error) {
+	if prop := vevent.get("DTEND"); prop != nil {
+		end, _, _, err := d.dateTime(prop, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid DTEND: %w", err)
+		}
+		return end, nil
+	}
+
+	if prop := vevent.get("DURATION"); prop != nil {
+		duration, err := parseDuration(prop.Value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid DURATION: %w", err)
+		}
+		return start.Add(duration), nil
+	}
+
+	if allDay {
+		return entities.StartOfDay(entities.DateIn(start, loc).AddDate(0, 0, 1), loc), nil
+	}
+	return start, nil
+}
+
+// dateTime parses a DATE or DATE-TIME property. Floating times and dates,
+// which start at the first instant of the day, are interpreted in the
+// floating zone.
+func (d *decoder) dateTime(prop *property, floating *time.Location) (time.Time, *time.Location, bool, error) {
+	if prop == nil {
+		return time.Time{}, nil, false, fmt.Errorf("missing value")
+	}
+
+	value := strings.TrimSpace(prop.Value)
+
+	if strings.EqualFold(prop.param("VALUE"), "DATE") || len(value) == len(dateFormat) {
+		t, err := time.Parse(dateFormat, value)
+		return entities.StartOfDay(t, floating), floating, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcDateTimeFormat, value)
+		return t, time.UTC, false, err
+	}
+
+	loc := floating
+	if tzid := prop.param("TZID"); tzid != "" {
+		loc = d.location(tzid)
+	}
+
+	t, err := time.ParseInLocation(dateTimeFormat, value, loc)
+	return t, loc, false, err
+}
+
+// dateTimeList parses a comma separated EXDATE value; floating values are
+// interpreted in the zone of the series
+func (d *decoder) dateTimeList(prop *property, seriesLoc *time.Location) ([]time.Time, error) {
+	var times []time.Time
+	for _, value := range strings.Split(prop.Value, ",") {
+		item := &property{Name: prop.Name, Params: prop.Params, Value: value}
+		t, _, _, err := d.dateTime(item, seriesLoc)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+func (d *decoder) location(tzid string) *time.Location {
+	if loc, ok := d.zones[tzid]; ok {
+		return loc
+	}
+
+	loc := resolveTimezone(tzid, nil)
+	d.zones[tzid] = loc
+	return loc
+}
+
+// resolveTimezone maps a TZID to an IANA zone. Vendor prefixes such as
+// "/mozilla.org/20050126_1/Europe/Berlin" are stripped; names that still
+// can't be loaded fall back to the standard offset of the VTIMEZONE.
+func resolveTimezone(tzid string, vtimezone *component) *time.Location {
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc
+	}
+
+	segments := strings.Split(strings.Trim(tzid, "/"), "/")
+	for n := 3; n >= 2; n-- {
+		if len(segments) < n {
+			continue
+		}
+		if loc, err := time.LoadLocation(strings.Join(segments[len(segments)-n:], "/")); err == nil {
+			return loc
+		}
+	}
+
+	if vtimezone != nil {
+		observances := vtimezone.children("STANDARD")
+		if len(observances) == 0 {
+			observances = vtimezone.children("DAYLIGHT")
+		}
+		if len(observances) > 0 {
+			if prop := observances[len(observances)-1].get("TZOFFSETTO"); prop != nil {
+				if offset, err := parseOffset(prop.Value); err == nil {
+					return time.FixedZone(tzid, offset)
+				}
+			}
+		}
+	}
+
+	return time.UTC
+}
+
+// timezoneName returns the Event.Timezone value for a location. Fixed
+// offsets derived from unknown TZIDs are stored as UTC.
+func timezoneName(loc *time.Location) string {
+	if loc == nil {
+		return "UTC"
+	}
+	if _, err := time.LoadLocation(loc.String()); err != nil || loc.String() == "Local" {
+		return "UTC"
+	}
+	return loc.String()
+}
+
+func decodeStatus(status string) entities.EventStatus {
+	switch strings.ToUpper(status) {
+	case "TENTATIVE":
+		return entities.EventStatusTentative
+	case "CANCELLED":
+		return entities.EventStatusCancelled
+	}
+	return entities.EventStatusConfirmed
+}
+
+func decodeAttendees(vevent *component) []entities.Attendee {
+	var attendees []entities.Attendee
+	for _, prop := range vevent.all("ATTENDEE") {
+		email := strings.TrimSpace(prop.Value)
+		if len(email) >= 7 && strings.EqualFold(email[:7], "mailto:") {
+			email = email[7:]
+		}
+		if email == "" {
+			continue
+		}
+
+		attendees = append(attendees, entities.Attendee{
+			Email:  email,
+			Name:   prop.param("CN"),
+			Status: decodeParticipationStatus(prop.param("PARTSTAT")),
+		})
+	}
+	return attendees
+}
+
+func decodeParticipationStatus(partstat string) entities.AttendeeStatus {
+	switch strings.ToUpper(partstat) {
+	case "ACCEPTED":
+		return entities.AttendeeStatusAccepted
+	case "DECLINED":
+		return entities.AttendeeStatusDeclined
+	case "TENTATIVE":
+		return entities.AttendeeStatusTentative
+	}
+	return entities.AttendeeStatusPending
+}
+
+func sameAttendees(a, b []entities.Attendee) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Email != b[i].Email || a[i].Name != b[i].Name || a[i].Status != b[i].Status {
+			return false
+		}
+	}
+	return true
+}
+
+func propertyText(comp *component, name string) string {
+	prop := comp.get(name)
+	if prop == nil {
+		return ""
+	}
+	return strings.TrimSpace(unescapeText(prop.Value))
+}
+
+// parseDuration parses an RFC 5545 duration such as "PT1H30M", "P1D" or "-P1W"
+func parseDuration(value string) (time.Duration, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, ch := range s[1:] {
+		switch {
+		case ch >= '0' && ch <= '9':
+			number += string(ch)
+			continue
+		case ch == 'T':
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = ""
+
+		switch {
+		case ch == 'W' && !inTime:
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case ch == 'D' && !inTime:
+			total += time.Duration(n) * 24 * time.Hour
+		case ch == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case ch == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case ch == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return sign * total, nil
+}
+
+// parseOffset parses a UTC offset such as "+0100" or "-053000" into seconds
+func parseOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid offset %q", value)
+	}
+
+	sign := 1
+	switch value[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return 0, fmt.Errorf("invalid offset %q", value)
+	}
+
+	digits := value[1:]
+	hours, err1 := strconv.Atoi(digits[0:2])
+	minutes, err2 := strconv.Atoi(digits[2:4])
+	seconds := 0
+	var err3 error
+	if len(digits) == 6 {
+		seconds, err3 = strconv.Atoi(digits[4:6])
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid offset %q", value)
+	}
+
+	return sign * (hours*3600 + minutes*60 + seconds), nil
+}
@@ -0,0 +1,301 @@
+package ical
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+const (
+	// ProdID identifies this application in generated calendars
+	ProdID = "-//Smart Goal Calendar//Smart Goal Calendar//EN"
+
+	// ImportSource is the ExternalSource of events created from iCalendar
+	// data, i.e. imported files and CalDAV clients; their ExternalID holds
+	// the original UID
+	ImportSource = "ics"
+
+	// uidDomain makes event IDs globally unique UIDs
+	uidDomain = "smart-goal-calendar"
+)
+
+// Calendar is a VCALENDAR to be encoded
+type Calendar struct {
+	Name   string
+	Events []*entities.Event
+
+	// Exceptions of recurring events, keyed by event ID. Cancelled
+	// occurrences become EXDATEs, modified ones separate VEVENTs with a
+	// RECURRENCE-ID.
+	Exceptions map[entities.EventID][]*entities.EventException
+
+	// AllDay items such as goal deadlines, written as transparent all-day events
+	AllDay []AllDayItem
+
+	// Timestamp is written as DTSTAMP; the current time when zero
+	Timestamp time.Time
+}
+
+// AllDayItem is a dated entry without a time of day
+type AllDayItem struct {
+	UID         string
+	Title       string
+	Description string
+	Date        time.Time // Only the date is used
+	UpdatedAt   time.Time
+}
+
+// Encode writes the calendar in iCalendar format
+func Encode(w io.Writer, cal *Calendar) error {
+	lw := &lineWriter{w: w}
+
+	stamp := cal.Timestamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	lw.line("BEGIN", "VCALENDAR")
+	lw.line("VERSION", "2.0")
+	lw.line("PRODID", ProdID)
+	lw.line("CALSCALE", "GREGORIAN")
+	lw.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		lw.line("X-WR-CALNAME", escapeText(cal.Name))
+	}
+
+	for _, zr := range cal.zoneRanges(stamp) {
+		writeTimezone(lw, zr)
+	}
+
+	for _, event := range cal.Events {
+		exceptions := cal.Exceptions[event.ID]
+		writeEvent(lw, event, exceptions, stamp)
+
+		for _, exception := range exceptions {
+			if exception.Cancelled || !event.IsRecurring() {
+				continue
+			}
+			writeModifiedOccurrence(lw, event, exception, stamp)
+		}
+	}
+
+	for _, item := range cal.AllDay {
+		writeAllDayItem(lw, item, stamp)
+	}
+
+	lw.line("END", "VCALENDAR")
+	return lw.err
+}
+
+// EventUID returns the iCalendar UID of an event. Imported events keep the
+// UID they were imported with so re-importing an export updates them.
+func EventUID(event *entities.Event) string {
+	if event.ExternalSource == ImportSource && event.ExternalID != "" {
+		return event.ExternalID
+	}
+	return string(event.ID) + "@" + uidDomain
+}
+
+// EventIDFromUID returns the event ID a UID produced by EventUID refers to.
+// It reports false for UIDs that carry an ExternalID instead.
+func EventIDFromUID(uid string) (entities.EventID, bool) {
+	id, ok := strings.CutSuffix(uid, "@"+uidDomain)
+	if !ok {
+		return "", false
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", false
+	}
+	return entities.EventID(id), true
+}
+
+func writeEvent(lw *lineWriter, event *entities.Event, exceptions []*entities.EventException, stamp time.Time) {
+	loc := eventLocation(event)
+
+	lw.line("BEGIN", "VEVENT")
+	writeEventProperties(lw, EventUID(event), event, loc, stamp)
+
+	if event.IsRecurring() {
+		if rrule := event.Recurrence.ToRRULE(); rrule != "" {
+			lw.line("RRULE", rrule)
+		}
+		for _, exception := range exceptions {
+			if exception.Cancelled {
+				lw.line(eventTimeProperty("EXDATE", event, exception.RecurrenceID, loc))
+			}
+		}
+	}
+
+	lw.line("END", "VEVENT")
+}
+
+func writeModifiedOccurrence(lw *lineWriter, event *entities.Event, exception *entities.EventException, stamp time.Time) {
+	loc := eventLocation(event)
+	occurrence := exception.Apply(event.Occurrence(exception.RecurrenceID))
+	occurrence.UpdatedAt = exception.UpdatedAt
+
+	lw.line("BEGIN", "VEVENT")
+	writeEventProperties(lw, EventUID(event), occurrence, loc, stamp)
+	lw.line(eventTimeProperty("RECURRENCE-ID", event, exception.RecurrenceID, loc))
+	lw.line("END", "VEVENT")
+}
+
+func writeAllDayItem(lw *lineWriter, item AllDayItem, stamp time.Time) {
+	day := time.Date(item.Date.Year(), item.Date.Month(), item.Date.Day(), 0, 0, 0, 0, time.UTC)
+
+	lw.line("BEGIN", "VEVENT")
+	lw.line("UID", item.UID)
+	lw.line("DTSTAMP", stamp.UTC().Format(utcDateTimeFormat))
+	if !item.UpdatedAt.IsZero() {
+		lw.line("LAST-MODIFIED", item.UpdatedAt.UTC().Format(utcDateTimeFormat))
+	}
+	lw.line("DTSTART;VALUE=DATE", day.Format(dateFormat))
+	lw.line("DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format(dateFormat))
+	lw.line("SUMMARY", escapeText(item.Title))
+	if item.Description != "" {
+		lw.line("DESCRIPTION", escapeText(item.Description))
+	}
+	lw.line("TRANSP", "TRANSPARENT")
+	lw.line("END", "VEVENT")
+}
+
+// writeEventProperties writes the properties shared by series and occurrences.
+// Occurrences keep the UID of their series.
+func writeEventProperties(lw *lineWriter, uid string, event *entities.Event, loc *time.Location, stamp time.Time) {
+	lw.line("UID", uid)
+	lw.line("DTSTAMP", stamp.UTC().Format(utcDateTimeFormat))
+	if !event.CreatedAt.IsZero() {
+		lw.line("CREATED", event.CreatedAt.UTC().Format(utcDateTimeFormat))
+	}
+	if !event.UpdatedAt.IsZero() {
+		lw.line("LAST-MODIFIED", event.UpdatedAt.UTC().Format(utcDateTimeFormat))
+	}
+
+	lw.line(eventTimeProperty("DTSTART", event, event.StartTime, loc))
+	lw.line(eventTimeProperty("DTEND", event, event.EndTime, loc))
+	lw.line("SUMMARY", escapeText(event.Title))
+	if event.Description != "" {
+		lw.line("DESCRIPTION", escapeText(event.Description))
+	}
+	if event.Location != "" {
+		lw.line("LOCATION", escapeText(event.Location))
+	}
+	if status := eventStatus(event.Status); status != "" {
+		lw.line("STATUS", status)
+	}
+
+	for _, attendee := range event.Attendees {
+		writeAttendee(lw, attendee)
+	}
+}
+
+// eventTimeProperty writes times of all-day events as dates
+func eventTimeProperty(name string, event *entities.Event, t time.Time, loc *time.Location) (string, string) {
+	if event.AllDay {
+		return name + ";VALUE=DATE", t.In(event.TimeLocation()).Format(dateFormat)
+	}
+	return dateTimeProperty(name, t, loc)
+}
+
+func writeAttendee(lw *lineWriter, attendee entities.Attendee) {
+	if attendee.Email == "" {
+		return
+	}
+
+	params := []string{"ATTENDEE", "ROLE=REQ-PARTICIPANT", "PARTSTAT=" + participationStatus(attendee.Status)}
+	if attendee.Name != "" {
+		params = append(params, "CN="+quoteParam(attendee.Name))
+	}
+
+	lw.line(strings.Join(params, ";"), "mailto:"+attendee.Email)
+}
+
+func eventStatus(status entities.EventStatus) string {
+	switch status {
+	case entities.EventStatusTentative:
+		return "TENTATIVE"
+	case entities.EventStatusConfirmed:
+		return "CONFIRMED"
+	case entities.EventStatusCancelled:
+		return "CANCELLED"
+	}
+	return ""
+}
+
+func participationStatus(status entities.AttendeeStatus) string {
+	switch status {
+	case entities.AttendeeStatusAccepted:
+		return "ACCEPTED"
+	case entities.AttendeeStatusDeclined:
+		return "DECLINED"
+	case entities.AttendeeStatusTentative:
+		return "TENTATIVE"
+	}
+	return "NEEDS-ACTION"
+}
+
+// eventLocation returns the time zone the event is written in, or nil for UTC
+func eventLocation(event *entities.Event) *time.Location {
+	if event.Timezone == "" || event.Timezone == "UTC" {
+		return nil
+	}
+
+	loc, err := time.LoadLocation(event.Timezone)
+	if err != nil || loc == time.UTC {
+		return nil
+	}
+	return loc
+}
+
+// zoneRanges collects the time zones referenced by events together with the
+// span of time each of them has to cover, sorted by TZID
+func (cal *Calendar) zoneRanges(stamp time.Time) []*zoneRange {
+	ranges := make(map[string]*zoneRange)
+
+	for _, event := range cal.Events {
+		loc := eventLocation(event)
+		if loc == nil {
+			continue
+		}
+
+		zr, ok := ranges[loc.String()]
+		if !ok {
+			zr = &zoneRange{loc: loc}
+			ranges[loc.String()] = zr
+		}
+
+		zr.include(event.StartTime)
+		zr.include(event.EndTime)
+		for _, exception := range cal.Exceptions[event.ID] {
+			zr.include(exception.RecurrenceID)
+			if exception.StartTime != nil {
+				zr.include(*exception.StartTime)
+			}
+			if exception.EndTime != nil {
+				zr.include(*exception.EndTime)
+			}
+		}
+
+		if event.IsRecurring() {
+			if event.Recurrence.Until != nil {
+				zr.include(*event.Recurrence.Until)
+			} else {
+				zr.include(stamp.AddDate(recurringHorizon, 0, 0))
+			}
+		}
+	}
+
+	result := make([]*zoneRange, 0, len(ranges))
+	for _, zr := range ranges {
+		result = append(result, zr)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].loc.String() < result[j].loc.String()
+	})
+
+	return result
+}
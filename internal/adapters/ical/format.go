@@ -0,0 +1,97 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateTimeFormat    = "20060102T150405"
+	utcDateTimeFormat = "20060102T150405Z"
+	dateFormat        = "20060102"
+
+	// maxLineOctets is the RFC 5545 content line limit, excluding CRLF
+	maxLineOctets = 75
+)
+
+// lineWriter writes folded content lines and keeps the first error
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(name, value string) {
+	lw.write(name + ":" + value)
+}
+
+func (lw *lineWriter) linef(name, format string, args ...interface{}) {
+	lw.line(name, fmt.Sprintf(format, args...))
+}
+
+// write folds the line into chunks of at most 75 octets without splitting
+// UTF-8 sequences; continuation lines start with a single space
+func (lw *lineWriter) write(line string) {
+	if lw.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+
+	_, lw.err = io.WriteString(lw.w, b.String())
+}
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// quoteParam quotes a parameter value; DQUOTE itself is not allowed inside
+func quoteParam(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+// dateTimeProperty returns the property name with its TZID parameter and the
+// value for t: local time with TZID, or UTC when the zone is UTC
+func dateTimeProperty(name string, t time.Time, loc *time.Location) (string, string) {
+	if loc == nil || loc == time.UTC {
+		return name, t.UTC().Format(utcDateTimeFormat)
+	}
+	return fmt.Sprintf("%s;TZID=%s", name, loc.String()), t.In(loc).Format(dateTimeFormat)
+}
+
+// formatOffset formats a UTC offset in seconds as +HHMM or +HHMMSS
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	hours, minutes, seconds := offset/3600, offset%3600/60, offset%60
+	if seconds != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, hours, minutes)
+}
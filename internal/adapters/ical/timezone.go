@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"time"
+)
+
+// recurringHorizon is how far past the last known date VTIMEZONE
+// observances are generated for open-ended recurring events
+const recurringHorizon = 10
+
+// zoneRange is the span of time a VTIMEZONE block has to describe
+type zoneRange struct {
+	loc   *time.Location
+	start time.Time
+	end   time.Time
+}
+
+func (zr *zoneRange) include(t time.Time) {
+	if zr.start.IsZero() || t.Before(zr.start) {
+		zr.start = t
+	}
+	if t.After(zr.end) {
+		zr.end = t
+	}
+}
+
+// writeTimezone writes a VTIMEZONE block with one observance per UTC offset
+// change within the range, using the transitions of the Go time zone database
+func writeTimezone(lw *lineWriter, zr *zoneRange) {
+	loc := zr.loc
+	from := time.Date(zr.start.In(loc).Year(), time.January, 1, 0, 0, 0, 0, loc)
+	to := time.Date(zr.end.In(loc).Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+
+	lw.line("BEGIN", "VTIMEZONE")
+	lw.line("TZID", loc.String())
+
+	// The observance in effect at the start of the range
+	name, offset := from.Zone()
+	writeObservance(lw, from.IsDST(), name, offset, offset, from)
+
+	t := from
+	for {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || !end.Before(to) {
+			break
+		}
+
+		_, fromOffset := t.Zone()
+		next := end.In(loc)
+		toName, toOffset := next.Zone()
+		if toOffset != fromOffset {
+			// DTSTART is the local time of the transition before it happens
+			onset := end.In(time.FixedZone("", fromOffset))
+			writeObservance(lw, next.IsDST(), toName, fromOffset, toOffset, onset)
+		}
+		t = next
+	}
+
+	lw.line("END", "VTIMEZONE")
+}
+
+func writeObservance(lw *lineWriter, dst bool, name string, fromOffset, toOffset int, onset time.Time) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+
+	lw.line("BEGIN", kind)
+	lw.line("DTSTART", onset.Format(dateTimeFormat))
+	lw.line("TZOFFSETFROM", formatOffset(fromOffset))
+	lw.line("TZOFFSETTO", formatOffset(toOffset))
+	if name != "" && name[0] != '+' && name[0] != '-' {
+		lw.line("TZNAME", escapeText(name))
+	}
+	lw.line("END", kind)
+}
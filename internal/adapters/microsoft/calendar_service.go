@@ -0,0 +1,422 @@
+package microsoft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/microsoft"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/valueobjects"
+)
+
+const (
+	// DefaultGraphURL is the root of the Microsoft Graph v1.0 API
+	DefaultGraphURL = "https://graph.microsoft.com/v1.0"
+
+	// requestTimeout bounds a single Graph request
+	requestTimeout = 30 * time.Second
+
+	// listHorizon is how far ahead of now a full listing reaches. Graph only
+	// tracks changes within the window of the listing that started a delta
+	// sequence.
+	listHorizon = 2 * 365 * 24 * time.Hour
+
+	// graphDateTimeLayout is the layout of Graph's wall clock times
+	graphDateTimeLayout = "2006-01-02T15:04:05.9999999"
+)
+
+// Config configures the Outlook calendar provider
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	Tenant       string       // Azure AD tenant; "common" when empty
+	GraphURL     string       // Root of the Graph API; DefaultGraphURL when empty
+	TokenURL     string       // Overrides the token endpoint of the tenant
+	HTTPClient   *http.Client // Used for Graph and token requests
+}
+
+// CalendarService syncs Outlook calendars through Microsoft Graph. Changes
+// are listed with calendarView delta queries, which return the occurrences
+// of recurring series rather than the series; the series masters of changed
+// occurrences are fetched along with them. Deleted occurrences can't be
+// told apart from deleted single events, so they only remove single events.
+// Likewise, occurrences cancelled locally are not pushed, and attendees are
+// only read, since Outlook would email invitations to added ones.
+type CalendarService struct {
+	oauth2Config *oauth2.Config
+	graphURL     string
+	httpClient   *http.Client
+}
+
+func NewCalendarService(config Config) *CalendarService {
+	endpoint := microsoft.AzureADEndpoint(config.Tenant)
+	if config.TokenURL != "" {
+		endpoint.TokenURL = config.TokenURL
+	}
+
+	graphURL := strings.TrimSuffix(config.GraphURL, "/")
+	if graphURL == "" {
+		graphURL = DefaultGraphURL
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+
+	return &CalendarService{
+		oauth2Config: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint:     endpoint,
+			Scopes:       []string{"offline_access", "User.Read", "Calendars.ReadWrite"},
+		},
+		graphURL:   graphURL,
+		httpClient: httpClient,
+	}
+}
+
+// Name implements services.CalendarProvider
+func (s *CalendarService) Name() entities.IntegrationProvider {
+	return entities.IntegrationProviderMicrosoft
+}
+
+// RefreshToken implements services.CalendarProvider
+func (s *CalendarService) RefreshToken(ctx context.Context, refreshToken string) (*services.ProviderTokens, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, s.httpClient)
+
+	token, err := s.oauth2Config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+		return nil, fmt.Errorf("%w: %w", services.ErrInvalidGrant, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return &services.ProviderTokens{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	}, nil
+}
+
+// ListCalendars implements services.CalendarProvider. The default calendar
+// is reported as primary.
+func (s *CalendarService) ListCalendars(ctx context.Context, accessToken string) ([]*services.RemoteCalendar, error) {
+	var calendars []*services.RemoteCalendar
+
+	link := s.graphURL + "/me/calendars"
+	for link != "" {
+		var page graphPage[graphCalendar]
+		if err := s.do(ctx, accessToken, http.MethodGet, link, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list calendars: %w", err)
+		}
+
+		for _, item := range page.Value {
+			accessRole := "reader"
+			if item.CanEdit {
+				accessRole = "writer"
+			}
+			if item.IsDefaultCalendar {
+				accessRole = "owner"
+			}
+
+			calendars = append(calendars, &services.RemoteCalendar{
+				ID:         item.ID,
+				Summary:    item.Name,
+				Primary:    item.IsDefaultCalendar,
+				AccessRole: accessRole,
+			})
+		}
+
+		if link = page.NextLink; link != "" {
+			if err := s.checkLink(link); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return calendars, nil
+}
+
+// ListEventChanges implements services.CalendarProvider. The delta token is
+// the delta link of the previous listing; Graph expires it with 410 Gone.
+func (s *CalendarService) ListEventChanges(ctx context.Context, accessToken, calendarID, deltaToken string, timeMin time.Time) (*services.RemoteEventChanges, error) {
+	if deltaToken != "" {
+		if err := s.checkLink(deltaToken); err != nil {
+			return nil, err
+		}
+
+		changes, err := s.listEventPages(ctx, accessToken, calendarID, deltaToken)
+		if !isStatus(err, http.StatusGone) {
+			return changes, err
+		}
+	}
+
+	windowEnd := time.Now().Add(listHorizon).UTC()
+	query := url.Values{}
+	query.Set("startDateTime", timeMin.UTC().Format(time.RFC3339))
+	query.Set("endDateTime", windowEnd.Format(time.RFC3339))
+	link := s.calendarURL(calendarID) + "/calendarView/delta?" + query.Encode()
+
+	changes, err := s.listEventPages(ctx, accessToken, calendarID, link)
+	if err != nil {
+		return nil, err
+	}
+	changes.FullSync = true
+	changes.WindowEnd = windowEnd
+	return changes, nil
+}
+
+// listEventPages follows all pages of a delta listing and adds the series
+// masters of the occurrences it returned
+func (s *CalendarService) listEventPages(ctx context.Context, accessToken, calendarID, link string) (*services.RemoteEventChanges, error) {
+	changes := &services.RemoteEventChanges{}
+	masters := make(map[string]bool) // Series masters to fetch; false once listed
+	for {
+		var page graphPage[graphEvent]
+		if err := s.do(ctx, accessToken, http.MethodGet, link, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for i := range page.Value {
+			event := &page.Value[i]
+			if event.Type == "seriesMaster" {
+				masters[event.ID] = false
+			} else if _, seen := masters[event.SeriesMasterID]; event.SeriesMasterID != "" && !seen {
+				masters[event.SeriesMasterID] = true
+			}
+			changes.Events = append(changes.Events, s.convertEvent(event, calendarID))
+		}
+
+		if page.DeltaLink != "" {
+			changes.DeltaToken = page.DeltaLink
+			break
+		}
+		if page.NextLink == "" {
+			break
+		}
+		if err := s.checkLink(page.NextLink); err != nil {
+			return nil, err
+		}
+		link = page.NextLink
+	}
+
+	for masterID, fetch := range masters {
+		if !fetch {
+			continue
+		}
+
+		var master graphEvent
+		err := s.do(ctx, accessToken, http.MethodGet, s.eventURL(calendarID, masterID), nil, &master)
+		if isStatus(err, http.StatusNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get series master: %w", err)
+		}
+		changes.Events = append(changes.Events, s.convertEvent(&master, calendarID))
+	}
+
+	return changes, nil
+}
+
+// CreateEvent implements services.CalendarProvider
+func (s *CalendarService) CreateEvent(ctx context.Context, accessToken, calendarID string, event *entities.Event, exceptions []*entities.EventException) (*services.RemoteEvent, error) {
+	body, err := toGraphEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var created graphEvent
+	if err := s.do(ctx, accessToken, http.MethodPost, s.calendarURL(calendarID)+"/events", body, &created); err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	return s.convertEvent(&created, calendarID), nil
+}
+
+// UpdateEvent implements services.CalendarProvider
+func (s *CalendarService) UpdateEvent(ctx context.Context, accessToken, calendarID, eventID string, event *entities.Event, exceptions []*entities.EventException) (*services.RemoteEvent, error) {
+	body, err := toGraphEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated graphEvent
+	if err := s.do(ctx, accessToken, http.MethodPatch, s.eventURL(calendarID, eventID), body, &updated); err != nil {
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+
+	return s.convertEvent(&updated, calendarID), nil
+}
+
+// DeleteEvent implements services.CalendarProvider
+func (s *CalendarService) DeleteEvent(ctx context.Context, accessToken, calendarID, eventID string) error {
+	err := s.do(ctx, accessToken, http.MethodDelete, s.eventURL(calendarID, eventID), nil, nil)
+	if isStatus(err, http.StatusNotFound, http.StatusGone) {
+		return services.ErrRemoteEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CalendarService) calendarURL(calendarID string) string {
+	return s.graphURL + "/me/calendars/" + url.PathEscape(calendarID)
+}
+
+func (s *CalendarService) eventURL(calendarID, eventID string) string {
+	return s.calendarURL(calendarID) + "/events/" + url.PathEscape(eventID)
+}
+
+// toGraphEvent converts a local event; times are sent as wall clock times
+// in the event's time zone so that recurring series expand in it. All-day
+// events are sent as whole days, which Outlook requires.
+func toGraphEvent(event *entities.Event) (*graphEvent, error) {
+	timezone := event.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event time zone %q: %w", timezone, err)
+	}
+
+	start := event.StartTime.In(loc)
+	end := event.EndTime.In(loc)
+	if event.AllDay {
+		// Graph takes the dates as midnights, even where a zone skips midnight
+		start = entities.DateIn(event.StartTime, loc)
+		end = entities.DateIn(event.EndTime, loc)
+	}
+
+	graph := &graphEvent{
+		Subject:  event.Title,
+		Body:     graphItemBody{ContentType: "text", Content: event.Description},
+		Location: graphLocation{DisplayName: event.Location},
+		Start:    graphDateTimeZone{DateTime: start.Format(graphDateTimeLayout), TimeZone: timezone},
+		End:      graphDateTimeZone{DateTime: end.Format(graphDateTimeLayout), TimeZone: timezone},
+		IsAllDay: event.AllDay,
+		ShowAs:   "busy",
+	}
+	switch {
+	case event.Transparent:
+		graph.ShowAs = "free"
+	case event.Status == entities.EventStatusTentative:
+		graph.ShowAs = "tentative"
+	}
+
+	if event.Recurrence != nil {
+		if graph.Recurrence, err = toGraphRecurrence(event.Recurrence, start, timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	return graph, nil
+}
+
+// convertEvent converts a Graph event. Occurrences and exceptions of series
+// carry the ID of their series master as RecurringEventID.
+func (s *CalendarService) convertEvent(event *graphEvent, calendarID string) *services.RemoteEvent {
+	remoteEvent := &services.RemoteEvent{
+		ID:          event.ID,
+		Summary:     event.Subject,
+		Description: event.Body.Content,
+		Location:    event.Location.DisplayName,
+		StartTime:   parseDateTimeZone(event.Start),
+		EndTime:     parseDateTimeZone(event.End),
+		AllDay:      event.IsAllDay,
+		Status:      entities.EventStatusConfirmed,
+		Transparent: event.ShowAs == "free",
+		CalendarID:  calendarID,
+		Cancelled:   event.IsCancelled || event.Removed != nil,
+	}
+	if event.ShowAs == "tentative" {
+		remoteEvent.Status = entities.EventStatusTentative
+	}
+
+	// Graph reports Windows time zone names unless the event was created
+	// with an IANA one; only the latter are kept
+	if loc, err := time.LoadLocation(event.OriginalStartTimeZone); event.OriginalStartTimeZone != "" && err == nil {
+		remoteEvent.TimeZone = event.OriginalStartTimeZone
+
+		// All-day events span whole days of their own zone rather than of
+		// the zone requested in the Prefer header
+		if event.IsAllDay {
+			remoteEvent.StartTime = parseDateTimeZone(graphDateTimeZone{DateTime: event.Start.DateTime, TimeZone: loc.String()})
+			remoteEvent.EndTime = parseDateTimeZone(graphDateTimeZone{DateTime: event.End.DateTime, TimeZone: loc.String()})
+		}
+	}
+
+	remoteEvent.Attendees = []entities.Attendee{}
+	for _, attendee := range event.Attendees {
+		if attendee.EmailAddress.Address == "" {
+			continue
+		}
+		remoteEvent.Attendees = append(remoteEvent.Attendees, entities.Attendee{
+			Email:  attendee.EmailAddress.Address,
+			Name:   attendee.EmailAddress.Name,
+			Status: attendeeStatus(attendee.Status.Response),
+		})
+	}
+
+	if event.Type == "occurrence" || event.Type == "exception" {
+		remoteEvent.RecurringEventID = event.SeriesMasterID
+	}
+	if event.OriginalStart != "" {
+		if originalStart, err := time.Parse(time.RFC3339, event.OriginalStart); err == nil {
+			remoteEvent.OriginalStartTime = &originalStart
+		}
+	}
+	if event.Type == "seriesMaster" && event.Recurrence != nil {
+		remoteEvent.Recurrence = fromGraphRecurrence(event.Recurrence)
+	}
+
+	if createdTime, err := time.Parse(time.RFC3339, event.CreatedDateTime); err == nil {
+		remoteEvent.CreatedAt = createdTime
+	}
+	if updatedTime, err := time.Parse(time.RFC3339, event.LastModifiedDateTime); err == nil {
+		remoteEvent.UpdatedAt = updatedTime
+	}
+
+	return remoteEvent
+}
+
+func attendeeStatus(response string) entities.AttendeeStatus {
+	switch response {
+	case "accepted", "organizer":
+		return entities.AttendeeStatusAccepted
+	case "tentativelyAccepted":
+		return entities.AttendeeStatusTentative
+	case "declined":
+		return entities.AttendeeStatusDeclined
+	}
+	return entities.AttendeeStatusPending
+}
+
+// parseDateTimeZone returns the time of a Graph wall clock time, or the
+// zero time when it can't be parsed
+func parseDateTimeZone(dt graphDateTimeZone) time.Time {
+	loc, err := time.LoadLocation(dt.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	t, err := time.Parse(graphDateTimeLayout, dt.DateTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return valueobjects.LocalTime(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), loc).UTC()
+}
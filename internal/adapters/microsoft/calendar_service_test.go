@@ -0,0 +1,434 @@
+package microsoft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+const (
+	testAccessToken = "graph-access-token"
+	testCalendarID  = "AAMkAGI2TG93AAA="
+)
+
+// graphStub stands in for the calendar endpoints of Microsoft Graph. A full
+// delta listing spans two pages; its delta link lists the changes made
+// since, and the expired delta token answers 410 Gone as Graph does.
+type graphStub struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests []string        // Method and path of every request
+	received map[string]any  // Decoded bodies by method
+	events   map[string]bool // IDs of existing events
+}
+
+func newGraphStub(t *testing.T) *graphStub {
+	stub := &graphStub{
+		t:        t,
+		received: make(map[string]any),
+		events:   map[string]bool{"evt-single": true, "evt-master": true},
+	}
+	stub.server = httptest.NewServer(http.HandlerFunc(stub.serve))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func (g *graphStub) service() *CalendarService {
+	return NewCalendarService(Config{
+		ClientID: "client-id",
+		GraphURL: g.server.URL + "/v1.0/",
+	})
+}
+
+func (g *graphStub) calendarURL() string {
+	return g.server.URL + "/v1.0/me/calendars/" + testCalendarID
+}
+
+func (g *graphStub) serve(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests = append(g.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+		writeGraphError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Access token is empty.")
+		return
+	}
+	if !strings.Contains(r.Header.Get("Prefer"), `outlook.timezone="UTC"`) {
+		g.t.Errorf("%s %s without the UTC Prefer header", r.Method, r.URL.Path)
+	}
+
+	prefix := "/v1.0/me/calendars/" + testCalendarID
+	path, ok := strings.CutPrefix(r.URL.Path, prefix)
+	if !ok {
+		writeGraphError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "/calendarView/delta":
+		g.serveDelta(w, r)
+	case r.Method == http.MethodGet && path == "/events/evt-master":
+		writeJSON(w, http.StatusOK, seriesMaster)
+	case r.Method == http.MethodPost && path == "/events":
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		g.received[r.Method] = body
+
+		created := maps.Clone(body)
+		created["id"] = "evt-created"
+		created["type"] = "singleInstance"
+		created["lastModifiedDateTime"] = "2024-04-02T08:00:00Z"
+		writeJSON(w, http.StatusCreated, created)
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "/events/"):
+		id := strings.TrimPrefix(path, "/events/")
+		if !g.events[id] {
+			writeGraphError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		g.received[r.Method] = body
+
+		updated := maps.Clone(body)
+		updated["id"] = id
+		updated["type"] = "singleInstance"
+		writeJSON(w, http.StatusOK, updated)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/events/"):
+		id := strings.TrimPrefix(path, "/events/")
+		if !g.events[id] {
+			writeGraphError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+			return
+		}
+		delete(g.events, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeGraphError(w, http.StatusBadRequest, "BadRequest", "Unsupported request "+r.Method+" "+path)
+	}
+}
+
+func (g *graphStub) serveDelta(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case query.Get("$deltatoken") == "expired":
+		writeGraphError(w, http.StatusGone, "SyncStateNotFound", "The sync state generation is not found.")
+	case query.Get("$deltatoken") == "after-full":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"value": []any{
+				map[string]any{
+					"id":                   "evt-single",
+					"type":                 "singleInstance",
+					"subject":              "Lunch with Kim (moved)",
+					"start":                map[string]any{"dateTime": "2024-04-03T11:30:00.0000000", "timeZone": "UTC"},
+					"end":                  map[string]any{"dateTime": "2024-04-03T12:30:00.0000000", "timeZone": "UTC"},
+					"lastModifiedDateTime": "2024-04-02T09:00:00Z",
+				},
+			},
+			"@odata.deltaLink": g.calendarURL() + "/calendarView/delta?$deltatoken=after-changes",
+		})
+	case query.Get("$skiptoken") == "page-2":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"value": []any{
+				occurrence,
+				map[string]any{
+					"id":       "evt-removed",
+					"@removed": map[string]any{"reason": "deleted"},
+				},
+			},
+			"@odata.deltaLink": g.calendarURL() + "/calendarView/delta?$deltatoken=after-full",
+		})
+	case query.Get("startDateTime") != "" && query.Get("endDateTime") != "":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"value":           []any{singleInstance},
+			"@odata.nextLink": g.calendarURL() + "/calendarView/delta?$skiptoken=page-2",
+		})
+	default:
+		writeGraphError(w, http.StatusBadRequest, "BadRequest", "Unexpected delta query "+r.URL.RawQuery)
+	}
+}
+
+var singleInstance = map[string]any{
+	"id":                    "evt-single",
+	"type":                  "singleInstance",
+	"subject":               "Lunch with Kim",
+	"body":                  map[string]any{"contentType": "text", "content": "Usual place"},
+	"location":              map[string]any{"displayName": "Cafe Central"},
+	"start":                 map[string]any{"dateTime": "2024-04-03T10:30:00.0000000", "timeZone": "UTC"},
+	"end":                   map[string]any{"dateTime": "2024-04-03T11:30:00.0000000", "timeZone": "UTC"},
+	"isAllDay":              false,
+	"showAs":                "tentative",
+	"originalStartTimeZone": "Europe/Vienna",
+	"attendees": []any{
+		map[string]any{
+			"emailAddress": map[string]any{"name": "Kim Lee", "address": "kim@example.com"},
+			"status":       map[string]any{"response": "tentativelyAccepted"},
+		},
+	},
+	"createdDateTime":      "2024-03-28T15:02:11Z",
+	"lastModifiedDateTime": "2024-03-29T08:15:42Z",
+}
+
+var occurrence = map[string]any{
+	"id":                    "evt-master_occurrence",
+	"type":                  "occurrence",
+	"seriesMasterId":        "evt-master",
+	"subject":               "Weekly review",
+	"start":                 map[string]any{"dateTime": "2024-04-05T14:00:00.0000000", "timeZone": "UTC"},
+	"end":                   map[string]any{"dateTime": "2024-04-05T15:00:00.0000000", "timeZone": "UTC"},
+	"originalStart":         "2024-04-05T14:00:00Z",
+	"originalStartTimeZone": "Europe/Vienna",
+}
+
+var seriesMaster = map[string]any{
+	"id":                    "evt-master",
+	"type":                  "seriesMaster",
+	"subject":               "Weekly review",
+	"start":                 map[string]any{"dateTime": "2024-03-01T14:00:00.0000000", "timeZone": "UTC"},
+	"end":                   map[string]any{"dateTime": "2024-03-01T15:00:00.0000000", "timeZone": "UTC"},
+	"originalStartTimeZone": "Europe/Vienna",
+	"recurrence": map[string]any{
+		"pattern": map[string]any{"type": "weekly", "interval": 1, "daysOfWeek": []string{"friday"}, "firstDayOfWeek": "monday"},
+		"range":   map[string]any{"type": "noEnd", "startDate": "2024-03-01", "recurrenceTimeZone": "Europe/Vienna"},
+	},
+	"lastModifiedDateTime": "2024-03-01T09:00:00Z",
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeGraphError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"code": code, "message": message}})
+}
+
+func TestListEventChangesFullListing(t *testing.T) {
+	stub := newGraphStub(t)
+	timeMin := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	changes, err := stub.service().ListEventChanges(context.Background(), testAccessToken, testCalendarID, "", timeMin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !changes.FullSync || changes.WindowEnd.IsZero() {
+		t.Errorf("FullSync = %v, WindowEnd = %v, want a full listing with its window", changes.FullSync, changes.WindowEnd)
+	}
+	if want := stub.calendarURL() + "/calendarView/delta?$deltatoken=after-full"; changes.DeltaToken != want {
+		t.Errorf("DeltaToken = %q, want %q", changes.DeltaToken, want)
+	}
+
+	events := make(map[string]*services.RemoteEvent)
+	for _, event := range changes.Events {
+		events[event.ID] = event
+	}
+	if len(changes.Events) != 4 || len(events) != 4 {
+		t.Fatalf("listed %d events, want both pages and the series master", len(changes.Events))
+	}
+
+	single := events["evt-single"]
+	wantStart := time.Date(2024, 4, 3, 10, 30, 0, 0, time.UTC)
+	if single.Summary != "Lunch with Kim" || single.Description != "Usual place" || single.Location != "Cafe Central" {
+		t.Errorf("single event = %+v", single)
+	}
+	if !single.StartTime.Equal(wantStart) || !single.EndTime.Equal(wantStart.Add(time.Hour)) || single.TimeZone != "Europe/Vienna" {
+		t.Errorf("single event time = %v - %v in %q", single.StartTime, single.EndTime, single.TimeZone)
+	}
+	if single.Status != entities.EventStatusTentative {
+		t.Errorf("single event status = %q, want tentative", single.Status)
+	}
+	if len(single.Attendees) != 1 || single.Attendees[0].Email != "kim@example.com" || single.Attendees[0].Status != entities.AttendeeStatusTentative {
+		t.Errorf("attendees = %+v", single.Attendees)
+	}
+
+	if removed := events["evt-removed"]; !removed.Cancelled {
+		t.Errorf("@removed item not reported as cancelled: %+v", removed)
+	}
+
+	if got := events["evt-master_occurrence"].RecurringEventID; got != "evt-master" {
+		t.Errorf("occurrence RecurringEventID = %q, want the series master", got)
+	}
+	master := events["evt-master"]
+	if master.Recurrence == nil || master.Recurrence.ToRRULE() != "FREQ=WEEKLY;BYDAY=FR" {
+		t.Errorf("series master recurrence = %+v", master.Recurrence)
+	}
+}
+
+func TestListEventChangesIncremental(t *testing.T) {
+	stub := newGraphStub(t)
+	deltaLink := stub.calendarURL() + "/calendarView/delta?$deltatoken=after-full"
+
+	changes, err := stub.service().ListEventChanges(context.Background(), testAccessToken, testCalendarID, deltaLink, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changes.FullSync {
+		t.Error("incremental listing reported as full")
+	}
+	if len(changes.Events) != 1 || changes.Events[0].Summary != "Lunch with Kim (moved)" {
+		t.Errorf("changes = %+v", changes.Events)
+	}
+	if want := stub.calendarURL() + "/calendarView/delta?$deltatoken=after-changes"; changes.DeltaToken != want {
+		t.Errorf("DeltaToken = %q, want %q", changes.DeltaToken, want)
+	}
+}
+
+func TestListEventChangesExpiredToken(t *testing.T) {
+	stub := newGraphStub(t)
+	deltaLink := stub.calendarURL() + "/calendarView/delta?$deltatoken=expired"
+
+	changes, err := stub.service().ListEventChanges(context.Background(), testAccessToken, testCalendarID, deltaLink, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.FullSync || len(changes.Events) != 4 {
+		t.Errorf("FullSync = %v with %d events, want a full listing after 410 Gone", changes.FullSync, len(changes.Events))
+	}
+}
+
+func TestListEventChangesForeignLink(t *testing.T) {
+	stub := newGraphStub(t)
+
+	// The access token must never be sent elsewhere
+	_, err := stub.service().ListEventChanges(context.Background(), testAccessToken, testCalendarID,
+		"https://attacker.example.com/v1.0/me/calendars/x/calendarView/delta?$deltatoken=1", time.Now())
+	if err == nil {
+		t.Fatal("foreign delta link accepted")
+	}
+	if len(stub.requests) != 0 {
+		t.Errorf("requests = %v, want none", stub.requests)
+	}
+}
+
+func TestCreateEvent(t *testing.T) {
+	stub := newGraphStub(t)
+	vienna, _ := time.LoadLocation("Europe/Vienna")
+	event := &entities.Event{
+		Title:       "Team offsite",
+		Description: "Bring a laptop",
+		Location:    "Lakeside",
+		StartTime:   time.Date(2024, 4, 10, 9, 0, 0, 0, vienna),
+		EndTime:     time.Date(2024, 4, 10, 17, 0, 0, 0, vienna),
+		Timezone:    "Europe/Vienna",
+		Transparent: true,
+		Recurrence:  &entities.RecurrenceRule{Frequency: entities.FrequencyMonthly, Interval: 1, ByDay: []entities.Weekday{"2WE"}},
+	}
+
+	created, err := stub.service().CreateEvent(context.Background(), testAccessToken, testCalendarID, event, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != "evt-created" {
+		t.Errorf("created ID = %q", created.ID)
+	}
+
+	body := stub.received[http.MethodPost].(map[string]any)
+	if body["subject"] != "Team offsite" || body["showAs"] != "free" || body["isAllDay"] != false {
+		t.Errorf("body = %v", body)
+	}
+	start := body["start"].(map[string]any)
+	if start["dateTime"] != "2024-04-10T09:00:00" || start["timeZone"] != "Europe/Vienna" {
+		t.Errorf("start = %v, want the wall clock time in the event's zone", start)
+	}
+	pattern := body["recurrence"].(map[string]any)["pattern"].(map[string]any)
+	if pattern["type"] != "relativeMonthly" || pattern["index"] != "second" {
+		t.Errorf("recurrence pattern = %v", pattern)
+	}
+}
+
+func TestCreateAllDayEvent(t *testing.T) {
+	stub := newGraphStub(t)
+	start, end := entities.AllDayTimes(
+		time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		mustLoadLocation(t, "Europe/Vienna"),
+	)
+	event := &entities.Event{Title: "Trip", StartTime: start, EndTime: end, Timezone: "Europe/Vienna", AllDay: true}
+
+	if _, err := stub.service().CreateEvent(context.Background(), testAccessToken, testCalendarID, event, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	body := stub.received[http.MethodPost].(map[string]any)
+	if body["isAllDay"] != true || body["recurrence"] != nil {
+		t.Errorf("body = %v", body)
+	}
+	// Across the change to summer time
+	if got := body["end"].(map[string]any)["dateTime"]; got != "2024-04-01T00:00:00" {
+		t.Errorf("end = %v, want the midnight of the day after", got)
+	}
+}
+
+func TestUpdateEvent(t *testing.T) {
+	stub := newGraphStub(t)
+	event := &entities.Event{
+		Title:     "Lunch with Kim",
+		StartTime: time.Date(2024, 4, 3, 10, 30, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 4, 3, 11, 30, 0, 0, time.UTC),
+	}
+
+	updated, err := stub.service().UpdateEvent(context.Background(), testAccessToken, testCalendarID, "evt-single", event, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != "evt-single" || !updated.StartTime.Equal(event.StartTime) {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	// Sent as null, so that the patch ends a series
+	body := stub.received[http.MethodPatch].(map[string]any)
+	if recurrence, ok := body["recurrence"]; !ok || recurrence != nil {
+		t.Errorf("recurrence = %v (sent: %v), want null", recurrence, ok)
+	}
+
+	_, err = stub.service().UpdateEvent(context.Background(), testAccessToken, testCalendarID, "evt-unknown", event, nil)
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusNotFound || graphErr.Code != "ErrorItemNotFound" {
+		t.Errorf("error = %v, want the Graph 404", err)
+	}
+}
+
+func TestDeleteEvent(t *testing.T) {
+	stub := newGraphStub(t)
+	service := stub.service()
+
+	if err := service.DeleteEvent(context.Background(), testAccessToken, testCalendarID, "evt-single"); err != nil {
+		t.Fatal(err)
+	}
+	err := service.DeleteEvent(context.Background(), testAccessToken, testCalendarID, "evt-single")
+	if !errors.Is(err, services.ErrRemoteEventNotFound) {
+		t.Errorf("deleting twice = %v, want %v", err, services.ErrRemoteEventNotFound)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	stub := newGraphStub(t)
+
+	_, err := stub.service().ListEventChanges(context.Background(), "expired-token", testCalendarID, "", time.Now())
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("error = %v, want the Graph 401", err)
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
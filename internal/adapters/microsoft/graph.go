@@ -0,0 +1,178 @@
+package microsoft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// preferHeader asks Graph for times in UTC, plain text bodies and small pages
+const preferHeader = `outlook.timezone="UTC", outlook.body-content-type="text", odata.maxpagesize=100`
+
+// GraphError is an error response of Microsoft Graph
+type GraphError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("graph: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// graphPage is a page of a collection or delta listing. Delta listings end
+// with a delta link instead of a next link.
+type graphPage[T any] struct {
+	Value     []T    `json:"value"`
+	NextLink  string `json:"@odata.nextLink"`
+	DeltaLink string `json:"@odata.deltaLink"`
+}
+
+type graphCalendar struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	CanEdit           bool   `json:"canEdit"`
+	IsDefaultCalendar bool   `json:"isDefaultCalendar"`
+}
+
+// graphEvent is an event as Graph reads and writes it. Read-only fields are
+// omitted from requests; recurrence is always sent so that updates clear it.
+type graphEvent struct {
+	ID                    string             `json:"id,omitempty"`
+	Subject               string             `json:"subject"`
+	Body                  graphItemBody      `json:"body"`
+	Location              graphLocation      `json:"location"`
+	Start                 graphDateTimeZone  `json:"start"`
+	End                   graphDateTimeZone  `json:"end"`
+	IsAllDay              bool               `json:"isAllDay"`
+	ShowAs                string             `json:"showAs,omitempty"`    // free, tentative, busy, oof or workingElsewhere
+	Attendees             []graphAttendee    `json:"attendees,omitempty"` // Read only; Outlook would email invitations
+	Recurrence            *graphRecurrence   `json:"recurrence"`
+	IsCancelled           bool               `json:"isCancelled,omitempty"`
+	Type                  string             `json:"type,omitempty"` // singleInstance, occurrence, exception or seriesMaster
+	SeriesMasterID        string             `json:"seriesMasterId,omitempty"`
+	OriginalStart         string             `json:"originalStart,omitempty"`
+	OriginalStartTimeZone string             `json:"originalStartTimeZone,omitempty"`
+	CreatedDateTime       string             `json:"createdDateTime,omitempty"`
+	LastModifiedDateTime  string             `json:"lastModifiedDateTime,omitempty"`
+	Removed               *graphRemovedState `json:"@removed,omitempty"` // Set on deletions in delta listings
+}
+
+type graphItemBody struct {
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+type graphLocation struct {
+	DisplayName string `json:"displayName"`
+}
+
+type graphAttendee struct {
+	EmailAddress struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	} `json:"emailAddress"`
+	Status struct {
+		Response string `json:"response"` // none, organizer, tentativelyAccepted, accepted, declined or notResponded
+	} `json:"status"`
+}
+
+// graphDateTimeZone is a wall clock time in a time zone, without offset
+type graphDateTimeZone struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type graphRemovedState struct {
+	Reason string `json:"reason"`
+}
+
+type graphErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// do sends a Graph request and decodes the response into out, if given
+func (s *CalendarService) do(ctx context.Context, accessToken, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Prefer", preferHeader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	graphErr := &GraphError{StatusCode: resp.StatusCode}
+
+	var body graphErrorResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		graphErr.Code = body.Error.Code
+		graphErr.Message = body.Error.Message
+	}
+	if graphErr.Code == "" {
+		graphErr.Code = http.StatusText(resp.StatusCode)
+	}
+	return graphErr
+}
+
+// checkLink checks that a next or delta link returned by Graph points at
+// the configured Graph root, so access tokens are only ever sent there
+func (s *CalendarService) checkLink(link string) error {
+	if !strings.HasPrefix(link, s.graphURL+"/") {
+		return fmt.Errorf("unexpected Graph link %q", link)
+	}
+	return nil
+}
+
+// isStatus reports whether Graph answered with one of the status codes
+func isStatus(err error, codes ...int) bool {
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) {
+		return false
+	}
+	for _, code := range codes {
+		if graphErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
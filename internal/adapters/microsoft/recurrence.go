@@ -0,0 +1,277 @@
+package microsoft
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// ErrUnsupportedRecurrence is returned for recurrence rules Outlook can't
+// express, e.g. hourly ones or ones with several BYMONTHDAY values
+var ErrUnsupportedRecurrence = errors.New("recurrence rule not supported by Outlook")
+
+// graphRecurrence is a Graph patternedRecurrence
+type graphRecurrence struct {
+	Pattern graphRecurrencePattern `json:"pattern"`
+	Range   graphRecurrenceRange   `json:"range"`
+}
+
+type graphRecurrencePattern struct {
+	Type           string   `json:"type"` // daily, weekly, absoluteMonthly, relativeMonthly, absoluteYearly or relativeYearly
+	Interval       int      `json:"interval"`
+	Month          int      `json:"month,omitempty"`
+	DayOfMonth     int      `json:"dayOfMonth,omitempty"`
+	DaysOfWeek     []string `json:"daysOfWeek,omitempty"`
+	FirstDayOfWeek string   `json:"firstDayOfWeek,omitempty"`
+	Index          string   `json:"index,omitempty"` // first, second, third, fourth or last
+}
+
+type graphRecurrenceRange struct {
+	Type                string `json:"type"` // noEnd, endDate or numbered
+	StartDate           string `json:"startDate"`
+	EndDate             string `json:"endDate,omitempty"`
+	NumberOfOccurrences int    `json:"numberOfOccurrences,omitempty"`
+	RecurrenceTimeZone  string `json:"recurrenceTimeZone,omitempty"`
+}
+
+const graphDateLayout = "2006-01-02"
+
+var graphWeekdays = map[entities.Weekday]string{
+	entities.WeekdayMonday:    "monday",
+	entities.WeekdayTuesday:   "tuesday",
+	entities.WeekdayWednesday: "wednesday",
+	entities.WeekdayThursday:  "thursday",
+	entities.WeekdayFriday:    "friday",
+	entities.WeekdaySaturday:  "saturday",
+	entities.WeekdaySunday:    "sunday",
+}
+
+var graphIndexes = map[int]string{1: "first", 2: "second", 3: "third", 4: "fourth", -1: "last"}
+
+// toGraphRecurrence converts a recurrence rule to the pattern and range of a
+// series starting at start, which is in the event's time zone
+func toGraphRecurrence(rule *entities.RecurrenceRule, start time.Time, timezone string) (*graphRecurrence, error) {
+	if len(rule.BySecond) > 0 || len(rule.ByMinute) > 0 || len(rule.ByHour) > 0 ||
+		len(rule.ByYearDay) > 0 || len(rule.ByWeekNo) > 0 || len(rule.ByMonth) > 1 {
+		return nil, ErrUnsupportedRecurrence
+	}
+
+	pattern := graphRecurrencePattern{Interval: max(rule.Interval, 1)}
+	switch rule.Frequency {
+	case entities.FrequencyDaily:
+		if len(rule.ByMonthDay) > 0 || len(rule.ByMonth) > 0 || len(rule.BySetPos) > 0 {
+			return nil, ErrUnsupportedRecurrence
+		}
+		pattern.Type = "daily"
+		if len(rule.ByDay) > 0 {
+			// Every weekday and the like; only daily rules of interval 1
+			// match a weekly pattern
+			if pattern.Interval != 1 {
+				return nil, ErrUnsupportedRecurrence
+			}
+			days, err := graphDays(rule.ByDay)
+			if err != nil {
+				return nil, err
+			}
+			pattern.Type = "weekly"
+			pattern.DaysOfWeek = days
+		}
+	case entities.FrequencyWeekly:
+		if len(rule.ByMonthDay) > 0 || len(rule.ByMonth) > 0 || len(rule.BySetPos) > 0 {
+			return nil, ErrUnsupportedRecurrence
+		}
+		byDay := rule.ByDay
+		if len(byDay) == 0 {
+			byDay = []entities.Weekday{weekdayOf(start)}
+		}
+		days, err := graphDays(byDay)
+		if err != nil {
+			return nil, err
+		}
+		pattern.Type = "weekly"
+		pattern.DaysOfWeek = days
+		pattern.FirstDayOfWeek = graphWeekdays[entities.WeekdayMonday]
+		if rule.WeekStart != "" {
+			pattern.FirstDayOfWeek = graphWeekdays[rule.WeekStart]
+		}
+	case entities.FrequencyMonthly, entities.FrequencyYearly:
+		if err := setMonthlyPattern(&pattern, rule, start); err != nil {
+			return nil, err
+		}
+		if rule.Frequency == entities.FrequencyYearly {
+			pattern.Month = int(start.Month())
+			if len(rule.ByMonth) == 1 {
+				pattern.Month = int(rule.ByMonth[0])
+			}
+			pattern.Type = map[string]string{
+				"absoluteMonthly": "absoluteYearly",
+				"relativeMonthly": "relativeYearly",
+			}[pattern.Type]
+		} else if len(rule.ByMonth) > 0 {
+			return nil, ErrUnsupportedRecurrence
+		}
+	default:
+		return nil, ErrUnsupportedRecurrence
+	}
+
+	recurrenceRange := graphRecurrenceRange{
+		Type:               "noEnd",
+		StartDate:          start.Format(graphDateLayout),
+		RecurrenceTimeZone: timezone,
+	}
+	switch {
+	case rule.Count != nil:
+		recurrenceRange.Type = "numbered"
+		recurrenceRange.NumberOfOccurrences = *rule.Count
+	case rule.Until != nil:
+		recurrenceRange.Type = "endDate"
+		recurrenceRange.EndDate = rule.Until.In(start.Location()).Format(graphDateLayout)
+	}
+
+	return &graphRecurrence{Pattern: pattern, Range: recurrenceRange}, nil
+}
+
+// setMonthlyPattern sets the day within the month of a monthly or yearly
+// pattern: a day of the month, or a weekday and its index
+func setMonthlyPattern(pattern *graphRecurrencePattern, rule *entities.RecurrenceRule, start time.Time) error {
+	switch {
+	case len(rule.ByDay) == 1 && len(rule.BySetPos) == 0:
+		n, day := rule.ByDay[0].Ordinal()
+		index, ok := graphIndexes[n]
+		if !ok || len(rule.ByMonthDay) > 0 {
+			return ErrUnsupportedRecurrence
+		}
+		pattern.Type = "relativeMonthly"
+		pattern.DaysOfWeek = []string{graphWeekdays[day]}
+		pattern.Index = index
+	case len(rule.ByDay) > 0:
+		// E.g. the first weekday of the month
+		if len(rule.BySetPos) != 1 || len(rule.ByMonthDay) > 0 {
+			return ErrUnsupportedRecurrence
+		}
+		index, ok := graphIndexes[rule.BySetPos[0]]
+		if !ok {
+			return ErrUnsupportedRecurrence
+		}
+		days, err := graphDays(rule.ByDay)
+		if err != nil {
+			return err
+		}
+		pattern.Type = "relativeMonthly"
+		pattern.DaysOfWeek = days
+		pattern.Index = index
+	case len(rule.BySetPos) > 0 || len(rule.ByMonthDay) > 1:
+		return ErrUnsupportedRecurrence
+	case len(rule.ByMonthDay) == 1:
+		if rule.ByMonthDay[0] < 1 {
+			return ErrUnsupportedRecurrence
+		}
+		pattern.Type = "absoluteMonthly"
+		pattern.DayOfMonth = rule.ByMonthDay[0]
+	default:
+		pattern.Type = "absoluteMonthly"
+		pattern.DayOfMonth = start.Day()
+	}
+	return nil
+}
+
+// graphDays converts plain weekdays; ordinal ones are unsupported
+func graphDays(weekdays []entities.Weekday) ([]string, error) {
+	days := make([]string, 0, len(weekdays))
+	for _, weekday := range weekdays {
+		day, ok := graphWeekdays[weekday]
+		if !ok {
+			return nil, ErrUnsupportedRecurrence
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func weekdayOf(t time.Time) entities.Weekday {
+	return []entities.Weekday{
+		entities.WeekdaySunday, entities.WeekdayMonday, entities.WeekdayTuesday,
+		entities.WeekdayWednesday, entities.WeekdayThursday, entities.WeekdayFriday,
+		entities.WeekdaySaturday,
+	}[t.Weekday()]
+}
+
+// fromGraphRecurrence converts the recurrence of a series master to a
+// recurrence rule, or nil when the pattern is unknown
+func fromGraphRecurrence(recurrence *graphRecurrence) *entities.RecurrenceRule {
+	pattern := recurrence.Pattern
+	rule := &entities.RecurrenceRule{Interval: max(pattern.Interval, 1)}
+
+	days := make([]entities.Weekday, 0, len(pattern.DaysOfWeek))
+	for _, name := range pattern.DaysOfWeek {
+		for weekday, graphName := range graphWeekdays {
+			if graphName == name {
+				days = append(days, weekday)
+			}
+		}
+	}
+
+	switch pattern.Type {
+	case "daily":
+		rule.Frequency = entities.FrequencyDaily
+	case "weekly":
+		rule.Frequency = entities.FrequencyWeekly
+		rule.ByDay = days
+		for weekday, graphName := range graphWeekdays {
+			if graphName == pattern.FirstDayOfWeek && weekday != entities.WeekdayMonday {
+				rule.WeekStart = weekday
+			}
+		}
+	case "absoluteMonthly", "absoluteYearly":
+		rule.Frequency = entities.FrequencyMonthly
+		rule.ByMonthDay = []int{pattern.DayOfMonth}
+	case "relativeMonthly", "relativeYearly":
+		rule.Frequency = entities.FrequencyMonthly
+		setByIndex(rule, days, pattern.Index)
+	default:
+		return nil
+	}
+
+	if pattern.Type == "absoluteYearly" || pattern.Type == "relativeYearly" {
+		rule.Frequency = entities.FrequencyYearly
+		rule.ByMonth = []entities.Month{entities.Month(pattern.Month)}
+	}
+
+	switch recurrence.Range.Type {
+	case "numbered":
+		count := recurrence.Range.NumberOfOccurrences
+		rule.Count = &count
+	case "endDate":
+		loc, err := time.LoadLocation(recurrence.Range.RecurrenceTimeZone)
+		if err != nil {
+			loc = time.UTC
+		}
+		if endDate, err := time.ParseInLocation(graphDateLayout, recurrence.Range.EndDate, loc); err == nil {
+			// The end date is inclusive
+			until := endDate.AddDate(0, 0, 1).Add(-time.Second).UTC()
+			rule.Until = &until
+		}
+	}
+
+	return rule
+}
+
+// setByIndex sets the nth of the weekdays, e.g. the last Friday or the first
+// weekday of the month
+func setByIndex(rule *entities.RecurrenceRule, days []entities.Weekday, index string) {
+	n := 1
+	for i, name := range graphIndexes {
+		if name == index {
+			n = i
+		}
+	}
+
+	if len(days) == 1 {
+		rule.ByDay = []entities.Weekday{entities.Weekday(fmt.Sprintf("%d%s", n, days[0]))}
+		return
+	}
+	rule.ByDay = days
+	rule.BySetPos = []int{n}
+}
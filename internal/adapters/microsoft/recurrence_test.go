@@ -0,0 +1,147 @@
+package microsoft
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+func TestGraphRecurrence(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 4, 1, 9, 0, 0, 0, berlin) // A Monday
+
+	weekdays := []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+	noEnd := graphRecurrenceRange{Type: "noEnd", StartDate: "2024-04-01", RecurrenceTimeZone: "Europe/Berlin"}
+
+	tests := []struct {
+		rrule   string
+		pattern graphRecurrencePattern
+		rng     graphRecurrenceRange
+		back    string // The rule read back from Graph, when it differs
+	}{
+		{
+			rrule:   "FREQ=DAILY;INTERVAL=2",
+			pattern: graphRecurrencePattern{Type: "daily", Interval: 2},
+			rng:     noEnd,
+		},
+		{
+			rrule:   "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			pattern: graphRecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: weekdays},
+			rng:     noEnd,
+			back:    "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		},
+		{
+			rrule:   "FREQ=WEEKLY;COUNT=10;BYDAY=MO,WE",
+			pattern: graphRecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday", "wednesday"}, FirstDayOfWeek: "monday"},
+			rng:     graphRecurrenceRange{Type: "numbered", StartDate: "2024-04-01", NumberOfOccurrences: 10, RecurrenceTimeZone: "Europe/Berlin"},
+		},
+		{
+			// The until date is inclusive in the series' zone
+			rrule:   "FREQ=WEEKLY;UNTIL=20240630T215959Z;WKST=SU",
+			pattern: graphRecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday"}, FirstDayOfWeek: "sunday"},
+			rng:     graphRecurrenceRange{Type: "endDate", StartDate: "2024-04-01", EndDate: "2024-06-30", RecurrenceTimeZone: "Europe/Berlin"},
+			back:    "FREQ=WEEKLY;UNTIL=20240630T215959Z;BYDAY=MO;WKST=SU",
+		},
+		{
+			rrule:   "FREQ=MONTHLY;BYDAY=-1FR",
+			pattern: graphRecurrencePattern{Type: "relativeMonthly", Interval: 1, DaysOfWeek: []string{"friday"}, Index: "last"},
+			rng:     noEnd,
+		},
+		{
+			rrule:   "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=1",
+			pattern: graphRecurrencePattern{Type: "relativeMonthly", Interval: 1, DaysOfWeek: weekdays, Index: "first"},
+			rng:     noEnd,
+		},
+		{
+			rrule:   "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=15",
+			pattern: graphRecurrencePattern{Type: "absoluteMonthly", Interval: 3, DayOfMonth: 15},
+			rng:     noEnd,
+		},
+		{
+			rrule:   "FREQ=MONTHLY",
+			pattern: graphRecurrencePattern{Type: "absoluteMonthly", Interval: 1, DayOfMonth: 1},
+			rng:     noEnd,
+			back:    "FREQ=MONTHLY;BYMONTHDAY=1",
+		},
+		{
+			rrule:   "FREQ=YEARLY;BYMONTHDAY=24;BYMONTH=12",
+			pattern: graphRecurrencePattern{Type: "absoluteYearly", Interval: 1, Month: 12, DayOfMonth: 24},
+			rng:     noEnd,
+		},
+		{
+			rrule:   "FREQ=YEARLY;BYDAY=2SU;BYMONTH=5",
+			pattern: graphRecurrencePattern{Type: "relativeYearly", Interval: 1, Month: 5, DaysOfWeek: []string{"sunday"}, Index: "second"},
+			rng:     noEnd,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rrule, func(t *testing.T) {
+			rule, err := entities.ParseRRULE(tt.rrule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recurrence, err := toGraphRecurrence(rule, start, "Europe/Berlin")
+			if err != nil {
+				t.Fatalf("toGraphRecurrence: %v", err)
+			}
+			if !reflect.DeepEqual(recurrence.Pattern, tt.pattern) {
+				t.Errorf("pattern = %+v, want %+v", recurrence.Pattern, tt.pattern)
+			}
+			if recurrence.Range != tt.rng {
+				t.Errorf("range = %+v, want %+v", recurrence.Range, tt.rng)
+			}
+
+			back := tt.back
+			if back == "" {
+				back = tt.rrule
+			}
+			if got := fromGraphRecurrence(recurrence).ToRRULE(); got != back {
+				t.Errorf("read back as %q, want %q", got, back)
+			}
+		})
+	}
+}
+
+func TestGraphRecurrenceUnsupported(t *testing.T) {
+	start := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	for _, rrule := range []string{
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=2;BYDAY=MO,FR",
+		"FREQ=DAILY;BYHOUR=9,17",
+		"FREQ=MONTHLY;BYMONTHDAY=1,15",
+		"FREQ=MONTHLY;BYMONTHDAY=-1",
+		"FREQ=MONTHLY;BYDAY=3MO;BYMONTHDAY=15",
+		"FREQ=MONTHLY;BYMONTH=6",
+		"FREQ=YEARLY;BYMONTH=6,12",
+		"FREQ=YEARLY;BYYEARDAY=100",
+	} {
+		t.Run(rrule, func(t *testing.T) {
+			rule, err := entities.ParseRRULE(rrule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := toGraphRecurrence(rule, start, "UTC"); !errors.Is(err, ErrUnsupportedRecurrence) {
+				t.Errorf("error = %v, want %v", err, ErrUnsupportedRecurrence)
+			}
+		})
+	}
+}
+
+func TestFromGraphRecurrenceUnknownPattern(t *testing.T) {
+	recurrence := &graphRecurrence{
+		Pattern: graphRecurrencePattern{Type: "hourly", Interval: 1},
+		Range:   graphRecurrenceRange{Type: "noEnd", StartDate: "2024-04-01"},
+	}
+	if rule := fromGraphRecurrence(recurrence); rule != nil {
+		t.Errorf("rule = %q, want nil", rule.ToRRULE())
+	}
+}
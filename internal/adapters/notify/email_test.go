@@ -0,0 +1,253 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+// smtpStub is an in-process SMTP server speaking just enough of the
+// protocol for one plain text delivery. It offers neither STARTTLS nor AUTH.
+type smtpStub struct {
+	listener  net.Listener
+	rcptReply string // Reply to RCPT TO, e.g. "550 5.1.1 No such user"
+
+	mu       sync.Mutex
+	received []receivedMail
+}
+
+type receivedMail struct {
+	from, to string
+	data     string
+}
+
+func newSMTPStub(t *testing.T, rcptReply string) *smtpStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{listener: listener, rcptReply: rcptReply}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) config() SMTPConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return SMTPConfig{
+		Host: "127.0.0.1",
+		Port: addr.Port,
+		From: "Smart Calendar <reminders@example.com>",
+	}
+}
+
+func (s *smtpStub) mails() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.received...)
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP stub")
+
+	var current receivedMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			current = receivedMail{from: addressArg(arg)}
+			tp.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			current.to = addressArg(arg)
+			tp.PrintfLine("%s", s.rcptReply)
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+			s.mu.Lock()
+			s.received = append(s.received, current)
+			s.mu.Unlock()
+			tp.PrintfLine("250 2.0.0 Queued")
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 2.0.0 OK")
+		case "QUIT":
+			tp.PrintfLine("221 2.0.0 Bye")
+			return
+		default:
+			tp.PrintfLine("502 5.5.2 Command not implemented")
+		}
+	}
+}
+
+// addressArg returns the address of a MAIL FROM or RCPT TO argument
+func addressArg(arg string) string {
+	_, addr, _ := strings.Cut(arg, "<")
+	addr, _, _ = strings.Cut(addr, ">")
+	return addr
+}
+
+func testReminder() (*entities.Notification, *entities.User) {
+	notification := &entities.Notification{
+		ID:            "notification-1",
+		UserID:        "user-1",
+		EventID:       "event-1",
+		EventTitle:    "Café planning",
+		Location:      "Room 4",
+		StartsAt:      time.Date(2024, 4, 1, 7, 0, 0, 0, time.UTC),
+		Timezone:      "Europe/Berlin",
+		Channel:       entities.ReminderChannelEmail,
+		MinutesBefore: 15,
+	}
+	user := &entities.User{
+		ID:       "user-1",
+		Email:    "ada@example.org",
+		Profile:  entities.UserProfile{Timezone: "Europe/Berlin"},
+		Settings: entities.UserSettings{NotificationEnabled: true, TimeFormat: "24h"},
+	}
+	return notification, user
+}
+
+func TestEmailNotifierDelivers(t *testing.T) {
+	server := newSMTPStub(t, "250 2.1.5 OK")
+	notification, user := testReminder()
+
+	if err := NewEmailNotifier(server.config()).Notify(context.Background(), notification, user); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	mails := server.mails()
+	if len(mails) != 1 {
+		t.Fatalf("received %d mails, want 1", len(mails))
+	}
+	received := mails[0]
+	if received.from != "reminders@example.com" || received.to != "ada@example.org" {
+		t.Errorf("envelope = %q -> %q, want reminders@example.com -> ada@example.org", received.from, received.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(received.data))
+	if err != nil {
+		t.Fatalf("failed to parse mail: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("failed to decode subject: %v", err)
+	}
+	headers := map[string]string{
+		"Subject":                   subject,
+		"From":                      msg.Header.Get("From"),
+		"To":                        msg.Header.Get("To"),
+		"Message-ID":                msg.Header.Get("Message-ID"),
+		"Content-Type":              msg.Header.Get("Content-Type"),
+		"Content-Transfer-Encoding": msg.Header.Get("Content-Transfer-Encoding"),
+	}
+	wantHeaders := map[string]string{
+		"Subject":                   "Reminder: Café planning",
+		"From":                      `"Smart Calendar" <reminders@example.com>`,
+		"To":                        "<ada@example.org>",
+		"Message-ID":                "<notification-1@example.com>",
+		"Content-Type":              "text/plain; charset=utf-8",
+		"Content-Transfer-Encoding": "quoted-printable",
+	}
+	for name, want := range wantHeaders {
+		if headers[name] != want {
+			t.Errorf("%s = %q, want %q", name, headers[name], want)
+		}
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	want := "Café planning starts at 09:00 on Monday, April 1 (Europe/Berlin).\nLocation: Room 4\n"
+	if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestEmailNotifierFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		rcptReply     string
+		email         string
+		undeliverable bool
+	}{
+		{
+			name:          "unknown mailbox",
+			rcptReply:     "550 5.1.1 No such user",
+			email:         "ada@example.org",
+			undeliverable: true,
+		},
+		{
+			// Temporary failures are retried
+			name:      "mailbox busy",
+			rcptReply: "451 4.3.0 Try again later",
+			email:     "ada@example.org",
+		},
+		{
+			name:          "invalid address",
+			rcptReply:     "250 2.1.5 OK",
+			email:         "not an address",
+			undeliverable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPStub(t, tt.rcptReply)
+			notification, user := testReminder()
+			user.Email = tt.email
+
+			err := NewEmailNotifier(server.config()).Notify(context.Background(), notification, user)
+			if err == nil {
+				t.Fatal("Notify succeeded, want an error")
+			}
+			if undeliverable := errors.Is(err, services.ErrUndeliverable); undeliverable != tt.undeliverable {
+				t.Errorf("error = %v, undeliverable = %v, want %v", err, undeliverable, tt.undeliverable)
+			}
+			if mails := server.mails(); len(mails) != 0 {
+				t.Errorf("received %d mails, want none", len(mails))
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// InAppNotifier delivers in-app reminders. The notification itself is what
+// the user sees, so once sent it shows up among their notifications.
+type InAppNotifier struct{}
+
+func NewInAppNotifier() *InAppNotifier {
+	return &InAppNotifier{}
+}
+
+func (n *InAppNotifier) Channel() entities.ReminderChannel {
+	return entities.ReminderChannelInApp
+}
+
+func (n *InAppNotifier) Notify(ctx context.Context, notification *entities.Notification, user *entities.User) error {
+	return nil
+}
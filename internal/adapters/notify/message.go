@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// reminderText returns the subject and body of a reminder. Times are shown
+// in the user's time zone and time format; all-day events by their date.
+func reminderText(notification *entities.Notification, user *entities.User) (string, string) {
+	subject := "Reminder: " + notification.EventTitle
+
+	var body strings.Builder
+	body.WriteString(notification.EventTitle)
+	if notification.AllDay {
+		start := notification.StartsAt.In(location(notification.Timezone))
+		fmt.Fprintf(&body, " is on %s.", start.Format("Monday, January 2"))
+	} else {
+		loc := location(user.Profile.Timezone)
+		if user.Profile.Timezone == "" {
+			loc = location(notification.Timezone)
+		}
+		clock := "15:04"
+		if user.Settings.TimeFormat == "12h" {
+			clock = "3:04 PM"
+		}
+		start := notification.StartsAt.In(loc)
+		fmt.Fprintf(&body, " starts at %s on %s (%s).", start.Format(clock), start.Format("Monday, January 2"), loc)
+	}
+	if notification.Location != "" {
+		fmt.Fprintf(&body, "\nLocation: %s", notification.Location)
+	}
+	body.WriteString("\n")
+
+	return subject, body.String()
+}
+
+// location loads a time zone, UTC when it is unknown
+func location(timezone string) *time.Location {
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+const (
+	// retryBaseDelay is the delay after the first failed delivery; it
+	// doubles with every further failure up to retryMaxDelay. Reminders are
+	// of little use late, so both are short.
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 15 * time.Minute
+)
+
+// Scheduler finds the reminders falling due and queues them as
+// notifications. A reminder is queued once however often it is seen due,
+// so overlapping windows, and schedulers of several replicas, are fine.
+type Scheduler struct {
+	userRepo         repositories.UserRepository
+	calendarRepo     repositories.CalendarRepository
+	eventRepo        repositories.EventRepository
+	exceptionRepo    repositories.EventExceptionRepository
+	notificationRepo repositories.NotificationRepository
+}
+
+func NewScheduler(
+	userRepo repositories.UserRepository,
+	calendarRepo repositories.CalendarRepository,
+	eventRepo repositories.EventRepository,
+	exceptionRepo repositories.EventExceptionRepository,
+	notificationRepo repositories.NotificationRepository,
+) *Scheduler {
+	return &Scheduler{
+		userRepo:         userRepo,
+		calendarRepo:     calendarRepo,
+		eventRepo:        eventRepo,
+		exceptionRepo:    exceptionRepo,
+		notificationRepo: notificationRepo,
+	}
+}
+
+// QueueDue queues the reminders due within (from, to] of the users who
+// turned notifications on, and returns how many were queued. A user whose
+// reminders fail doesn't keep the others from being queued.
+func (s *Scheduler) QueueDue(ctx context.Context, from, to time.Time) (int, error) {
+	users, err := s.userRepo.GetWithNotificationsEnabled(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	queued := 0
+	var errs []error
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return queued, ctx.Err()
+		}
+
+		n, err := s.queueUser(ctx, user, from, to)
+		queued += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", user.ID, err))
+		}
+	}
+
+	return queued, errors.Join(errs...)
+}
+
+func (s *Scheduler) queueUser(ctx context.Context, user *entities.User, from, to time.Time) (int, error) {
+	events, err := s.dueEvents(ctx, user.ID, from, to.Add(entities.MaxReminderLead))
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	calendars, err := s.calendarRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get calendars: %w", err)
+	}
+	byID := make(map[entities.CalendarID]*entities.Calendar, len(calendars))
+	for _, calendar := range calendars {
+		byID[calendar.ID] = calendar
+	}
+
+	queued := 0
+	for _, event := range events {
+		if event.Status == entities.EventStatusCancelled {
+			continue
+		}
+
+		for _, reminder := range event.EffectiveReminders(byID[event.CalendarID], user.Settings) {
+			dueAt := event.StartTime.Add(-reminder.Lead())
+			if !dueAt.After(from) || dueAt.After(to) {
+				continue
+			}
+
+			ok, err := s.notificationRepo.Enqueue(ctx, entities.NewNotification(event, reminder))
+			if err != nil {
+				return queued, err
+			}
+			if ok {
+				queued++
+			}
+		}
+	}
+
+	return queued, nil
+}
+
+// dueEvents returns the events and occurrences of recurring events of a
+// user that start within (start, end]. Moved occurrences may start outside
+// of it; the due times of their reminders are checked anyway.
+func (s *Scheduler) dueEvents(ctx context.Context, userID entities.UserID, start, end time.Time) ([]*entities.Event, error) {
+	stored, err := s.eventRepo.GetStartingBetween(ctx, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	recurring, err := s.eventRepo.GetRecurringStartingBefore(ctx, userID, end, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring events: %w", err)
+	}
+
+	events := make([]*entities.Event, 0, len(stored))
+	for _, event := range stored {
+		if !event.IsRecurring() {
+			events = append(events, event)
+		}
+	}
+	if len(recurring) == 0 {
+		return events, nil
+	}
+
+	eventIDs := make([]entities.EventID, len(recurring))
+	for i, event := range recurring {
+		eventIDs[i] = event.ID
+	}
+	exceptions, err := s.exceptionRepo.GetByEventIDs(ctx, eventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event exceptions: %w", err)
+	}
+	byEvent := make(map[entities.EventID][]*entities.EventException)
+	for _, exception := range exceptions {
+		byEvent[exception.EventID] = append(byEvent[exception.EventID], exception)
+	}
+
+	for _, event := range recurring {
+		occurrences := event.OccurrencesBetween(start, end)
+		events = append(events, event.ResolveOccurrences(occurrences, byEvent[event.ID])...)
+	}
+
+	return events, nil
+}
+
+// RetryDelay returns the delay before retrying a notification that failed
+// attempts times. Half of it is random so that notifications failing
+// together, e.g. while the mail server is down, don't retry together.
+func RetryDelay(attempts int) time.Duration {
+	delay := retryMaxDelay
+	if attempts < 20 {
+		delay = min(retryBaseDelay<<(attempts-1), retryMaxDelay)
+	}
+	return delay/2 + rand.N(delay/2)
+}
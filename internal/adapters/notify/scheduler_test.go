@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+// fakeReminderUsers stands in for the user repository
+type fakeReminderUsers struct {
+	repositories.UserRepository
+	users []*entities.User
+}
+
+func (f *fakeReminderUsers) GetWithNotificationsEnabled(context.Context) ([]*entities.User, error) {
+	return f.users, nil
+}
+
+// fakeReminderEvents returns the single and recurring events of every user
+type fakeReminderEvents struct {
+	repositories.EventRepository
+	events []*entities.Event
+}
+
+func (f *fakeReminderEvents) GetStartingBetween(_ context.Context, _ entities.UserID, start, end time.Time) ([]*entities.Event, error) {
+	var events []*entities.Event
+	for _, event := range f.events {
+		if event.StartTime.After(start) && !event.StartTime.After(end) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (f *fakeReminderEvents) GetRecurringStartingBefore(_ context.Context, _ entities.UserID, before time.Time, _ []entities.CalendarID) ([]*entities.Event, error) {
+	var events []*entities.Event
+	for _, event := range f.events {
+		if event.IsRecurring() && event.StartTime.Before(before) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+type fakeReminderExceptions struct {
+	repositories.EventExceptionRepository
+}
+
+func (f *fakeReminderExceptions) GetByEventIDs(context.Context, []entities.EventID) ([]*entities.EventException, error) {
+	return nil, nil
+}
+
+type fakeReminderCalendars struct {
+	repositories.CalendarRepository
+}
+
+func (f *fakeReminderCalendars) GetByUserID(context.Context, entities.UserID) ([]*entities.Calendar, error) {
+	return nil, nil
+}
+
+// reminderKey is the unique key of the notifications table
+type reminderKey struct {
+	eventID       entities.EventID
+	startsAt      time.Time
+	channel       entities.ReminderChannel
+	minutesBefore int
+}
+
+// fakeNotificationQueue keeps queued notifications unique by reminderKey,
+// as the notifications table does
+type fakeNotificationQueue struct {
+	repositories.NotificationRepository
+	queued map[reminderKey]*entities.Notification
+}
+
+func (f *fakeNotificationQueue) Enqueue(_ context.Context, notification *entities.Notification) (bool, error) {
+	key := reminderKey{
+		eventID:       notification.EventID,
+		startsAt:      notification.StartsAt.UTC(),
+		channel:       notification.Channel,
+		minutesBefore: notification.MinutesBefore,
+	}
+	if _, ok := f.queued[key]; ok {
+		return false, nil
+	}
+	f.queued[key] = notification
+	return true, nil
+}
+
+func TestSchedulerQueuesRemindersOnce(t *testing.T) {
+	daily, err := entities.ParseRRULE("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 4, 1, 8, 0, 0, 0, time.UTC)
+
+	user := &entities.User{
+		ID: "user-1",
+		Settings: entities.UserSettings{
+			NotificationEnabled: true,
+			DefaultReminders:    []entities.Reminder{{MinutesBefore: 10, Channel: entities.ReminderChannelEmail}},
+		},
+	}
+	events := &fakeReminderEvents{events: []*entities.Event{
+		{
+			// Due at 8:05 by the user's default reminder
+			ID:        "meeting",
+			UserID:    user.ID,
+			Title:     "Meeting",
+			StartTime: now.Add(15 * time.Minute),
+			EndTime:   now.Add(45 * time.Minute),
+			Timezone:  "UTC",
+			Status:    entities.EventStatusConfirmed,
+		},
+		{
+			// The occurrence of 8:20 today is due at 8:10 and 8:15
+			ID:         "stand-up",
+			UserID:     user.ID,
+			Title:      "Stand-up",
+			StartTime:  now.AddDate(0, 0, -7).Add(20 * time.Minute),
+			EndTime:    now.AddDate(0, 0, -7).Add(35 * time.Minute),
+			Timezone:   "UTC",
+			Recurrence: daily,
+			Reminders: []entities.Reminder{
+				{MinutesBefore: 10, Channel: entities.ReminderChannelEmail},
+				{MinutesBefore: 5, Channel: entities.ReminderChannelInApp},
+			},
+			Status: entities.EventStatusConfirmed,
+		},
+	}}
+	queue := &fakeNotificationQueue{queued: make(map[reminderKey]*entities.Notification)}
+
+	scheduler := NewScheduler(
+		&fakeReminderUsers{users: []*entities.User{user}},
+		&fakeReminderCalendars{},
+		events,
+		&fakeReminderExceptions{},
+		queue,
+	)
+
+	// The second window overlaps the first, as the windows of a scheduler
+	// catching up or of two replicas do
+	windows := []struct {
+		from, to time.Time
+		queued   int
+	}{
+		{from: now, to: now.Add(20 * time.Minute), queued: 3},
+		{from: now.Add(-5 * time.Minute), to: now.Add(20 * time.Minute), queued: 0},
+	}
+	for _, window := range windows {
+		queued, err := scheduler.QueueDue(context.Background(), window.from, window.to)
+		if err != nil {
+			t.Fatalf("QueueDue: %v", err)
+		}
+		if queued != window.queued {
+			t.Errorf("QueueDue(%v, %v) queued %d, want %d", window.from, window.to, queued, window.queued)
+		}
+	}
+
+	want := []reminderKey{
+		{eventID: "meeting", startsAt: now.Add(15 * time.Minute), channel: entities.ReminderChannelEmail, minutesBefore: 10},
+		{eventID: "stand-up", startsAt: now.Add(20 * time.Minute), channel: entities.ReminderChannelEmail, minutesBefore: 10},
+		{eventID: "stand-up", startsAt: now.Add(20 * time.Minute), channel: entities.ReminderChannelInApp, minutesBefore: 5},
+	}
+	if len(queue.queued) != len(want) {
+		t.Errorf("%d notifications queued, want %d", len(queue.queued), len(want))
+	}
+	for _, key := range want {
+		notification, ok := queue.queued[key]
+		if !ok {
+			t.Errorf("no notification queued for %+v", key)
+			continue
+		}
+		if wantDue := key.startsAt.Add(-time.Duration(key.minutesBefore) * time.Minute); !notification.DueAt.Equal(wantDue) {
+			t.Errorf("%+v due at %v, want %v", key, notification.DueAt, wantDue)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+// ErrNotificationsDisabled is returned for notifications of users who
+// turned notifications off after they were queued
+var ErrNotificationsDisabled = errors.New("notifications are disabled")
+
+// Sender delivers queued notifications through the Notifier of their
+// channel. Channels without a notifier, such as email without an SMTP
+// server, can't be delivered.
+type Sender struct {
+	userRepo  repositories.UserRepository
+	notifiers map[entities.ReminderChannel]services.Notifier
+}
+
+func NewSender(userRepo repositories.UserRepository, notifiers []services.Notifier) *Sender {
+	byChannel := make(map[entities.ReminderChannel]services.Notifier, len(notifiers))
+	for _, notifier := range notifiers {
+		byChannel[notifier.Channel()] = notifier
+	}
+
+	return &Sender{
+		userRepo:  userRepo,
+		notifiers: byChannel,
+	}
+}
+
+// Send delivers a notification to its user, unless the user turned
+// notifications off meanwhile
+func (s *Sender) Send(ctx context.Context, notification *entities.Notification) error {
+	user, err := s.userRepo.GetByID(ctx, notification.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || !user.Settings.NotificationEnabled {
+		return ErrNotificationsDisabled
+	}
+
+	notifier, ok := s.notifiers[notification.Channel]
+	if !ok {
+		return fmt.Errorf("%w: channel %s is not configured", services.ErrUndeliverable, notification.Channel)
+	}
+
+	return notifier.Notify(ctx, notification, user)
+}
@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+// webhookTimeout bounds a delivery when the notifier has no HTTP client
+const webhookTimeout = 10 * time.Second
+
+// WebhookPayload is the JSON body posted for a reminder
+type WebhookPayload struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"` // Always "event.reminder"
+	EventID       string    `json:"event_id"`
+	EventTitle    string    `json:"event_title"`
+	Location      string    `json:"location,omitempty"`
+	StartsAt      time.Time `json:"starts_at"`
+	AllDay        bool      `json:"all_day"`
+	Timezone      string    `json:"timezone"`
+	MinutesBefore int       `json:"minutes_before"`
+	DueAt         time.Time `json:"due_at"`
+}
+
+// errNonPublicAddress is returned when a webhook resolves to an address
+// that isn't public
+var errNonPublicAddress = errors.New("webhook address is not public")
+
+// WebhookNotifier posts reminders to the webhook URL of their user. Every
+// request carries the notification ID in X-Notification-ID, the same on
+// retries, and, with a secret, the hex HMAC-SHA256 of the body in
+// X-Notification-Signature as "sha256=<hex>". Responses other than 2xx
+// are retried, except for client errors and redirects, which aren't
+// followed.
+type WebhookNotifier struct {
+	httpClient *http.Client
+	secret     []byte
+}
+
+// NewWebhookNotifier returns a notifier signing requests with secret,
+// unless it is empty. httpClient may be nil. Unless it has a Transport of
+// its own, requests only connect to public addresses, checked when dialing
+// so that a name can't be rebound to an internal address after validation.
+func NewWebhookNotifier(httpClient *http.Client, secret string) *WebhookNotifier {
+	client := &http.Client{Timeout: webhookTimeout}
+	if httpClient != nil {
+		client = new(http.Client)
+		*client = *httpClient
+	}
+	if client.Transport == nil {
+		client.Transport = publicTransport()
+	}
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &WebhookNotifier{
+		httpClient: client,
+		secret:     []byte(secret),
+	}
+}
+
+// publicTransport is a transport that refuses to connect to addresses that
+// aren't public. It doesn't use proxies, which would be dialed instead.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !services.IsPublicAddress(addr.Addr()) {
+				return fmt.Errorf("%w: %s", errNonPublicAddress, address)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+func (n *WebhookNotifier) Channel() entities.ReminderChannel {
+	return entities.ReminderChannelWebhook
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification *entities.Notification, user *entities.User) error {
+	if user.Settings.WebhookURL == "" {
+		return fmt.Errorf("%w: no webhook URL set", services.ErrUndeliverable)
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		ID:            notification.ID,
+		Type:          "event.reminder",
+		EventID:       string(notification.EventID),
+		EventTitle:    notification.EventTitle,
+		Location:      notification.Location,
+		StartsAt:      notification.StartsAt,
+		AllDay:        notification.AllDay,
+		Timezone:      notification.Timezone,
+		MinutesBefore: notification.MinutesBefore,
+		DueAt:         notification.DueAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, user.Settings.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: invalid webhook URL: %v", services.ErrUndeliverable, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-ID", notification.ID)
+	if len(n.secret) > 0 {
+		mac := hmac.New(sha256.New, n.secret)
+		mac.Write(body)
+		req.Header.Set("X-Notification-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if errors.Is(err, errNonPublicAddress) {
+		return fmt.Errorf("%w: %v", services.ErrUndeliverable, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		return fmt.Errorf("%w: webhook responded with %s, redirects are not followed", services.ErrUndeliverable, resp.Status)
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return fmt.Errorf("%w: webhook responded with %s", services.ErrUndeliverable, resp.Status)
+	default:
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+const calendarFeedColumns = `
+	id, user_id, token_hash, include_goals, include_milestones,
+	last_accessed_at, revoked_at, created_at, updated_at`
+
+type calendarFeedRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCalendarFeedRepository(db *pgxpool.Pool) repositories.CalendarFeedRepository {
+	return &calendarFeedRepository{db: db}
+}
+
+func (r *calendarFeedRepository) Replace(ctx context.Context, feed *entities.CalendarFeed) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	revokeQuery := `
+		UPDATE calendar_feeds
+		SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := tx.Exec(ctx, revokeQuery, feed.UserID, feed.CreatedAt); err != nil {
+		return fmt.Errorf("failed to revoke calendar feed: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO calendar_feeds (
+			id, user_id, token_hash, include_goals, include_milestones, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.Exec(ctx, insertQuery,
+		feed.ID,
+		feed.UserID,
+		feed.TokenHash,
+		feed.IncludeGoals,
+		feed.IncludeMilestones,
+		feed.CreatedAt,
+		feed.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create calendar feed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *calendarFeedRepository) GetActiveByTokenHash(ctx context.Context, tokenHash string) (*entities.CalendarFeed, error) {
+	query := `
+		SELECT ` + calendarFeedColumns + `
+		FROM calendar_feeds
+		WHERE token_hash = $1 AND revoked_at IS NULL`
+
+	feed, err := scanCalendarFeed(r.db.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+func (r *calendarFeedRepository) GetActiveByUserID(ctx context.Context, userID entities.UserID) (*entities.CalendarFeed, error) {
+	query := `
+		SELECT ` + calendarFeedColumns + `
+		FROM calendar_feeds
+		WHERE user_id = $1 AND revoked_at IS NULL`
+
+	feed, err := scanCalendarFeed(r.db.QueryRow(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+func (r *calendarFeedRepository) UpdateSettings(ctx context.Context, id entities.CalendarFeedID, includeGoals, includeMilestones bool) error {
+	query := `
+		UPDATE calendar_feeds
+		SET include_goals = $2, include_milestones = $3
+		WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, id, includeGoals, includeMilestones)
+	if err != nil {
+		return fmt.Errorf("failed to update calendar feed: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *calendarFeedRepository) UpdateLastAccessed(ctx context.Context, id entities.CalendarFeedID, at time.Time) error {
+	query := `
+		UPDATE calendar_feeds
+		SET last_accessed_at = $2
+		WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, query, id, at); err != nil {
+		return fmt.Errorf("failed to update calendar feed access time: %w", err)
+	}
+
+	return nil
+}
+
+func (r *calendarFeedRepository) RevokeByUserID(ctx context.Context, userID entities.UserID) (bool, error) {
+	query := `
+		UPDATE calendar_feeds
+		SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, userID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke calendar feed: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func scanCalendarFeed(row pgx.Row) (*entities.CalendarFeed, error) {
+	var feed entities.CalendarFeed
+	err := row.Scan(
+		&feed.ID,
+		&feed.UserID,
+		&feed.TokenHash,
+		&feed.IncludeGoals,
+		&feed.IncludeMilestones,
+		&feed.LastAccessedAt,
+		&feed.RevokedAt,
+		&feed.CreatedAt,
+		&feed.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &feed, nil
+}
@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const calendarColumns = `
+	id, user_id, name, color, timezone, default_reminders, provider,
+	read_only, visible, is_default, created_at, updated_at`
+
+type calendarRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCalendarRepository(db *pgxpool.Pool) repositories.CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+func (r *calendarRepository) Create(ctx context.Context, calendar *entities.Calendar) error {
+	remindersJSON, err := marshalReminders(calendar.DefaultReminders)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO calendars (
+			id, user_id, name, color, timezone, default_reminders, provider,
+			read_only, visible, is_default, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err = r.db.Exec(ctx, query,
+		calendar.ID,
+		calendar.UserID,
+		calendar.Name,
+		calendar.Color,
+		calendar.Timezone,
+		remindersJSON,
+		nullableProvider(calendar.Provider),
+		calendar.ReadOnly,
+		calendar.Visible,
+		calendar.IsDefault,
+		calendar.CreatedAt,
+		calendar.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create calendar: %w", err)
+	}
+
+	return nil
+}
+
+func (r *calendarRepository) GetByID(ctx context.Context, id entities.CalendarID) (*entities.Calendar, error) {
+	query := `
+		SELECT ` + calendarColumns + `
+		FROM calendars
+		WHERE id = $1`
+
+	calendar, err := scanCalendar(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	return calendar, nil
+}
+
+func (r *calendarRepository) GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Calendar, error) {
+	query := `
+		SELECT ` + calendarColumns + `
+		FROM calendars
+		WHERE user_id = $1
+		ORDER BY is_default DESC, created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendars: %w", err)
+	}
+	defer rows.Close()
+
+	var calendars []*entities.Calendar
+	for rows.Next() {
+		calendar, err := scanCalendar(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar: %w", err)
+		}
+		calendars = append(calendars, calendar)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate calendars: %w", err)
+	}
+
+	return calendars, nil
+}
+
+func (r *calendarRepository) GetOrCreateDefault(ctx context.Context, userID entities.UserID) (*entities.Calendar, error) {
+	// Concurrent first uses race on the unique default index; the loser
+	// reads the calendar the winner created
+	insertQuery := `
+		INSERT INTO calendars (user_id, name, timezone, is_default)
+		SELECT id, $2, COALESCE(NULLIF(profile->>'timezone', ''), 'UTC'), TRUE
+		FROM users
+		WHERE id = $1
+		ON CONFLICT (user_id) WHERE is_default DO NOTHING`
+
+	if _, err := r.db.Exec(ctx, insertQuery, userID, entities.DefaultCalendarName); err != nil {
+		return nil, fmt.Errorf("failed to create default calendar: %w", err)
+	}
+
+	query := `
+		SELECT ` + calendarColumns + `
+		FROM calendars
+		WHERE user_id = $1 AND is_default`
+
+	calendar, err := scanCalendar(r.db.QueryRow(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get default calendar: %w", err)
+	}
+
+	return calendar, nil
+}
+
+func (r *calendarRepository) Update(ctx context.Context, calendar *entities.Calendar) error {
+	remindersJSON, err := marshalReminders(calendar.DefaultReminders)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE calendars
+		SET name = $2, color = $3, timezone = $4, default_reminders = $5,
+			provider = $6, read_only = $7, visible = $8
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err = r.db.QueryRow(ctx, query,
+		calendar.ID,
+		calendar.Name,
+		calendar.Color,
+		calendar.Timezone,
+		remindersJSON,
+		nullableProvider(calendar.Provider),
+		calendar.ReadOnly,
+		calendar.Visible,
+	).Scan(&calendar.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to update calendar: %w", err)
+	}
+
+	return nil
+}
+
+func (r *calendarRepository) Delete(ctx context.Context, id entities.CalendarID) error {
+	// Events and the sync of the calendar are removed by their foreign keys
+	query := `DELETE FROM calendars WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanCalendar(row pgx.Row) (*entities.Calendar, error) {
+	var calendar entities.Calendar
+	var remindersJSON []byte
+	var provider *string
+
+	err := row.Scan(
+		&calendar.ID,
+		&calendar.UserID,
+		&calendar.Name,
+		&calendar.Color,
+		&calendar.Timezone,
+		&remindersJSON,
+		&provider,
+		&calendar.ReadOnly,
+		&calendar.Visible,
+		&calendar.IsDefault,
+		&calendar.CreatedAt,
+		&calendar.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(remindersJSON, &calendar.DefaultReminders); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal default reminders: %w", err)
+	}
+	if provider != nil {
+		calendar.Provider = entities.IntegrationProvider(*provider)
+	}
+
+	return &calendar, nil
+}
+
+func marshalReminders(reminders []entities.Reminder) ([]byte, error) {
+	if reminders == nil {
+		reminders = []entities.Reminder{}
+	}
+	remindersJSON, err := json.Marshal(reminders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reminders: %w", err)
+	}
+	return remindersJSON, nil
+}
+
+func nullableProvider(provider entities.IntegrationProvider) *string {
+	if provider == "" {
+		return nil
+	}
+	value := string(provider)
+	return &value
+}
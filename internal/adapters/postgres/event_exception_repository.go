@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+type eventExceptionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewEventExceptionRepository(pool *pgxpool.Pool) repositories.EventExceptionRepository {
+	return &eventExceptionRepository{pool: pool}
+}
+
+const eventExceptionColumns = `
+	id, event_id, recurrence_id, cancelled, title, description, start_time,
+	end_time, location, attendees, status, created_at, updated_at`
+
+const upsertEventExceptionQuery = `
+	INSERT INTO event_exceptions (` + eventExceptionColumns + `
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	ON CONFLICT (event_id, recurrence_id) DO UPDATE SET
+		cancelled = EXCLUDED.cancelled,
+		title = EXCLUDED.title,
+		description = EXCLUDED.description,
+		start_time = EXCLUDED.start_time,
+		end_time = EXCLUDED.end_time,
+		location = EXCLUDED.location,
+		attendees = EXCLUDED.attendees,
+		status = EXCLUDED.status,
+		updated_at = EXCLUDED.updated_at`
+
+func (r *eventExceptionRepository) Upsert(ctx context.Context, exception *entities.EventException) error {
+	_, err := r.pool.Exec(ctx, upsertEventExceptionQuery,
+		exception.ID, exception.EventID, exception.RecurrenceID, exception.Cancelled,
+		exception.Title, exception.Description, exception.StartTime, exception.EndTime,
+		exception.Location, exception.Attendees, exception.Status,
+		exception.CreatedAt, exception.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save event exception: %w", err)
+	}
+
+	return nil
+}
+
+func (r *eventExceptionRepository) GetByRecurrenceID(ctx context.Context, eventID entities.EventID, recurrenceID time.Time) (*entities.EventException, error) {
+	query := `
+		SELECT ` + eventExceptionColumns + `
+		FROM event_exceptions
+		WHERE event_id = $1 AND recurrence_id = $2`
+
+	exception, err := scanEventException(r.pool.QueryRow(ctx, query, eventID, recurrenceID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get event exception: %w", err)
+	}
+
+	return exception, nil
+}
+
+func (r *eventExceptionRepository) GetByEventID(ctx context.Context, eventID entities.EventID) ([]*entities.EventException, error) {
+	return r.GetByEventIDs(ctx, []entities.EventID{eventID})
+}
+
+func (r *eventExceptionRepository) GetByEventIDs(ctx context.Context, eventIDs []entities.EventID) ([]*entities.EventException, error) {
+	if len(eventIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(eventIDs))
+	for i, id := range eventIDs {
+		ids[i] = string(id)
+	}
+
+	query := `
+		SELECT ` + eventExceptionColumns + `
+		FROM event_exceptions
+		WHERE event_id = ANY($1::uuid[])
+		ORDER BY recurrence_id ASC`
+
+	rows, err := r.pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event exceptions: %w", err)
+	}
+	defer rows.Close()
+
+	var exceptions []*entities.EventException
+	for rows.Next() {
+		exception, err := scanEventException(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event exception: %w", err)
+		}
+		exceptions = append(exceptions, exception)
+	}
+
+	return exceptions, nil
+}
+
+func (r *eventExceptionRepository) DeleteFrom(ctx context.Context, eventID entities.EventID, from time.Time) error {
+	query := `DELETE FROM event_exceptions WHERE event_id = $1 AND recurrence_id >= $2`
+
+	_, err := r.pool.Exec(ctx, query, eventID, from)
+	if err != nil {
+		return fmt.Errorf("failed to delete event exceptions: %w", err)
+	}
+
+	return nil
+}
+
+func scanEventException(row pgx.Row) (*entities.EventException, error) {
+	var exception entities.EventException
+	err := row.Scan(
+		&exception.ID, &exception.EventID, &exception.RecurrenceID, &exception.Cancelled,
+		&exception.Title, &exception.Description, &exception.StartTime, &exception.EndTime,
+		&exception.Location, &exception.Attendees, &exception.Status,
+		&exception.CreatedAt, &exception.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exception, nil
+}
@@ -19,19 +19,21 @@ func NewEventRepository(pool *pgxpool.Pool) repositories.EventRepository {
 	return &eventRepository{pool: pool}
 }
 
-func (r *eventRepository) Create(ctx context.Context, event *entities.Event) error {
-	query := `
-		INSERT INTO events (
-			id, user_id, goal_id, title, description, start_time, end_time,
-			timezone, recurrence, location, attendees, status, external_id,
-			external_source, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+const insertEventQuery = `
+	INSERT INTO events (
+		id, user_id, goal_id, title, description, start_time, end_time,
+		timezone, recurrence, location, attendees, status, external_id,
+		external_source, all_day, color, transparent, created_at, updated_at,
+		calendar_id, reminders
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`
 
-	_, err := r.pool.Exec(ctx, query,
+func (r *eventRepository) Create(ctx context.Context, event *entities.Event) error {
+	_, err := r.pool.Exec(ctx, insertEventQuery,
 		event.ID, event.UserID, event.GoalID, event.Title, event.Description,
 		event.StartTime, event.EndTime, event.Timezone, event.Recurrence,
 		event.Location, event.Attendees, event.Status, event.ExternalID,
-		event.ExternalSource, event.CreatedAt, event.UpdatedAt,
+		event.ExternalSource, event.AllDay, event.Color, event.Transparent,
+		event.CreatedAt, event.UpdatedAt, event.CalendarID, event.Reminders,
 	)
 	
 	if err != nil {
@@ -43,18 +45,21 @@ func (r *eventRepository) Create(ctx context.Context, event *entities.Event) err
 
 func (r *eventRepository) GetByID(ctx context.Context, id entities.EventID) (*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE id = $1`
 
 	var event entities.Event
 	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+		&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 		&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 		&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-		&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+		&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+		&event.CreatedAt, &event.UpdatedAt,
+		&event.PushStatus, &event.PushError,
 	)
 
 	if err != nil {
@@ -69,9 +74,10 @@ func (r *eventRepository) GetByID(ctx context.Context, id entities.EventID) (*en
 
 func (r *eventRepository) GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1
 		ORDER BY start_time ASC`
@@ -86,10 +92,12 @@ func (r *eventRepository) GetByUserID(ctx context.Context, userID entities.UserI
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -100,16 +108,18 @@ func (r *eventRepository) GetByUserID(ctx context.Context, userID entities.UserI
 	return events, nil
 }
 
-func (r *eventRepository) GetByUserIDAndTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time) ([]*entities.Event, error) {
+func (r *eventRepository) GetByUserIDAndTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1 AND start_time >= $2 AND end_time <= $3
+		  AND ($4::uuid[] IS NULL OR calendar_id = ANY($4))
 		ORDER BY start_time ASC`
 
-	rows, err := r.pool.Query(ctx, query, userID, start, end)
+	rows, err := r.pool.Query(ctx, query, userID, start, end, calendarIDStrings(calendarIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events by time range: %w", err)
 	}
@@ -119,10 +129,12 @@ func (r *eventRepository) GetByUserIDAndTimeRange(ctx context.Context, userID en
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -134,15 +146,52 @@ func (r *eventRepository) GetByUserIDAndTimeRange(ctx context.Context, userID en
 }
 
 // GetByTimeRange is an alias for GetByUserIDAndTimeRange for consistency
-func (r *eventRepository) GetByTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time) ([]*entities.Event, error) {
-	return r.GetByUserIDAndTimeRange(ctx, userID, start, end)
+func (r *eventRepository) GetByTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
+	return r.GetByUserIDAndTimeRange(ctx, userID, start, end, calendarIDs)
+}
+
+func (r *eventRepository) GetStartingBetween(ctx context.Context, userID entities.UserID, start, end time.Time) ([]*entities.Event, error) {
+	query := `
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
+			   timezone, recurrence, location, attendees, status, external_id,
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
+		FROM events
+		WHERE user_id = $1 AND start_time > $2 AND start_time <= $3
+		ORDER BY start_time ASC`
+
+	rows, err := r.pool.Query(ctx, query, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events starting in range: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entities.Event
+	for rows.Next() {
+		var event entities.Event
+		err := rows.Scan(
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
+			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
+			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, nil
 }
 
 func (r *eventRepository) GetByGoalID(ctx context.Context, goalID entities.GoalID) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE goal_id = $1
 		ORDER BY start_time ASC`
@@ -157,10 +206,12 @@ func (r *eventRepository) GetByGoalID(ctx context.Context, goalID entities.GoalI
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -173,18 +224,21 @@ func (r *eventRepository) GetByGoalID(ctx context.Context, goalID entities.GoalI
 
 func (r *eventRepository) GetByExternalID(ctx context.Context, userID entities.UserID, externalID string) (*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1 AND external_id = $2`
 
 	var event entities.Event
 	err := r.pool.QueryRow(ctx, query, userID, externalID).Scan(
-		&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+		&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 		&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 		&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-		&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+		&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+		&event.CreatedAt, &event.UpdatedAt,
+		&event.PushStatus, &event.PushError,
 	)
 
 	if err != nil {
@@ -199,9 +253,10 @@ func (r *eventRepository) GetByExternalID(ctx context.Context, userID entities.U
 
 func (r *eventRepository) GetByExternalSource(ctx context.Context, userID entities.UserID, source string) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1 AND external_source = $2
 		ORDER BY start_time ASC`
@@ -216,10 +271,12 @@ func (r *eventRepository) GetByExternalSource(ctx context.Context, userID entiti
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -230,17 +287,63 @@ func (r *eventRepository) GetByExternalSource(ctx context.Context, userID entiti
 	return events, nil
 }
 
-func (r *eventRepository) GetUpcoming(ctx context.Context, userID entities.UserID, limit int) ([]*entities.Event, error) {
+// GetAllDayBetween selects all-day events around the dates, whatever their
+// zone, and keeps those whose own dates overlap
+func (r *eventRepository) GetAllDayBetween(ctx context.Context, userID entities.UserID, start, end time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
-		WHERE user_id = $1 AND start_time > NOW() AND status != 'cancelled'
+		WHERE user_id = $1 AND all_day
+		  AND start_time < $3::timestamptz + INTERVAL '1 day'
+		  AND end_time > $2::timestamptz - INTERVAL '1 day'
+		  AND ($4::uuid[] IS NULL OR calendar_id = ANY($4))
+		ORDER BY start_time ASC`
+
+	rows, err := r.pool.Query(ctx, query, userID, start, end, calendarIDStrings(calendarIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all-day events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entities.Event
+	for rows.Next() {
+		var event entities.Event
+		err := rows.Scan(
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
+			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
+			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if event.OverlapsDates(start, end) {
+			events = append(events, &event)
+		}
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetUpcoming(ctx context.Context, userID entities.UserID, limit int, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
+	query := `
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
+			   timezone, recurrence, location, attendees, status, external_id,
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
+		FROM events 
+		WHERE user_id = $1 AND status != 'cancelled'
+		  AND (start_time > NOW() OR (all_day AND end_time > NOW() - INTERVAL '1 day'))
+		  AND ($3::uuid[] IS NULL OR calendar_id = ANY($3))
 		ORDER BY start_time ASC
 		LIMIT $2`
 
-	rows, err := r.pool.Query(ctx, query, userID, limit)
+	rows, err := r.pool.Query(ctx, query, userID, limit, calendarIDStrings(calendarIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get upcoming events: %w", err)
 	}
@@ -250,10 +353,12 @@ func (r *eventRepository) GetUpcoming(ctx context.Context, userID entities.UserI
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -264,18 +369,21 @@ func (r *eventRepository) GetUpcoming(ctx context.Context, userID entities.UserI
 	return events, nil
 }
 
-func (r *eventRepository) GetForToday(ctx context.Context, userID entities.UserID, timezone string) ([]*entities.Event, error) {
+func (r *eventRepository) GetForToday(ctx context.Context, userID entities.UserID, timezone string, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1 
-		  AND DATE(start_time AT TIME ZONE $2) = CURRENT_DATE
+		  AND DATE(start_time AT TIME ZONE $2) = DATE(NOW() AT TIME ZONE $2)
+		  AND NOT all_day
 		  AND status != 'cancelled'
+		  AND ($3::uuid[] IS NULL OR calendar_id = ANY($3))
 		ORDER BY start_time ASC`
 
-	rows, err := r.pool.Query(ctx, query, userID, timezone)
+	rows, err := r.pool.Query(ctx, query, userID, timezone, calendarIDStrings(calendarIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get today's events: %w", err)
 	}
@@ -285,10 +393,12 @@ func (r *eventRepository) GetForToday(ctx context.Context, userID entities.UserI
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -299,16 +409,56 @@ func (r *eventRepository) GetForToday(ctx context.Context, userID entities.UserI
 	return events, nil
 }
 
-func (r *eventRepository) GetRecurring(ctx context.Context, userID entities.UserID) ([]*entities.Event, error) {
+func (r *eventRepository) GetRecurring(ctx context.Context, userID entities.UserID, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1 AND recurrence IS NOT NULL AND recurrence != '{}'
+		  AND ($2::uuid[] IS NULL OR calendar_id = ANY($2))
 		ORDER BY start_time ASC`
 
-	rows, err := r.pool.Query(ctx, query, userID)
+	rows, err := r.pool.Query(ctx, query, userID, calendarIDStrings(calendarIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entities.Event
+	for rows.Next() {
+		var event entities.Event
+		err := rows.Scan(
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
+			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
+			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetRecurringStartingBefore(ctx context.Context, userID entities.UserID, before time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error) {
+	query := `
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
+			   timezone, recurrence, location, attendees, status, external_id,
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
+		FROM events 
+		WHERE user_id = $1 AND recurrence IS NOT NULL AND recurrence != '{}'
+		  AND start_time < $2 AND status != 'cancelled'
+		  AND ($3::uuid[] IS NULL OR calendar_id = ANY($3))
+		ORDER BY start_time ASC`
+
+	rows, err := r.pool.Query(ctx, query, userID, before, calendarIDStrings(calendarIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recurring events: %w", err)
 	}
@@ -318,10 +468,12 @@ func (r *eventRepository) GetRecurring(ctx context.Context, userID entities.User
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -334,9 +486,10 @@ func (r *eventRepository) GetRecurring(ctx context.Context, userID entities.User
 
 func (r *eventRepository) GetByStatus(ctx context.Context, userID entities.UserID, status entities.EventStatus) ([]*entities.Event, error) {
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1 AND status = $2
 		ORDER BY start_time ASC`
@@ -351,10 +504,12 @@ func (r *eventRepository) GetByStatus(ctx context.Context, userID entities.UserI
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -366,57 +521,146 @@ func (r *eventRepository) GetByStatus(ctx context.Context, userID entities.UserI
 }
 
 func (r *eventRepository) Update(ctx context.Context, event *entities.Event) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queued, err := updateEvent(ctx, tx, event)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if queued {
+		markPushPending(event)
+	}
+
+	return nil
+}
+
+// updateEvent writes the event and queues the push of the change, see
+// queueGooglePushes
+func updateEvent(ctx context.Context, tx pgx.Tx, event *entities.Event) (bool, error) {
 	query := `
 		UPDATE events 
 		SET goal_id = $2, title = $3, description = $4, start_time = $5, end_time = $6,
 			timezone = $7, recurrence = $8, location = $9, attendees = $10, status = $11,
-			external_id = $12, external_source = $13, updated_at = $14
-		WHERE id = $1`
-
-	_, err := r.pool.Exec(ctx, query,
+			external_id = $12, external_source = $13, all_day = $14, color = $15,
+			transparent = $16, updated_at = $17, calendar_id = $18, reminders = $19
+		WHERE id = $1
+		RETURNING updated_at`
+
+	// The update trigger sets updated_at; sync change detection compares
+	// against the stored value
+	err := tx.QueryRow(ctx, query,
 		event.ID, event.GoalID, event.Title, event.Description, event.StartTime,
 		event.EndTime, event.Timezone, event.Recurrence, event.Location,
 		event.Attendees, event.Status, event.ExternalID, event.ExternalSource,
-		event.UpdatedAt,
-	)
+		event.AllDay, event.Color, event.Transparent, event.UpdatedAt,
+		event.CalendarID, event.Reminders,
+	).Scan(&event.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("failed to update event: %w", err)
+		return false, fmt.Errorf("failed to update event: %w", err)
 	}
 
-	return nil
+	return queueGooglePushes(ctx, tx, event.ID, entities.GoogleOutboxUpdate)
+}
+
+func markPushPending(event *entities.Event) {
+	pending := entities.EventPushStatusPending
+	event.PushStatus = &pending
+	event.PushError = nil
 }
 
 func (r *eventRepository) Delete(ctx context.Context, id entities.EventID) error {
+	return r.delete(ctx, id, true)
+}
+
+func (r *eventRepository) DeleteSynced(ctx context.Context, id entities.EventID) error {
+	return r.delete(ctx, id, false)
+}
+
+// delete deletes the event, queueing a push of the deletion if push is set
+func (r *eventRepository) delete(ctx context.Context, id entities.EventID, push bool) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Queued before the delete, which removes the links
+	if push {
+		if _, err := queueGooglePushes(ctx, tx, id, entities.GoogleOutboxDelete); err != nil {
+			return err
+		}
+	}
+
 	query := `DELETE FROM events WHERE id = $1`
 
-	_, err := r.pool.Exec(ctx, query, id)
+	_, err = tx.Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
+// queueGooglePushes queues a push of the change to the Google event the
+// event is linked to by the sync of its calendar, if that sync writes to
+// Google, and marks the event as pending when there is one
+func queueGooglePushes(ctx context.Context, tx pgx.Tx, id entities.EventID, operation entities.GoogleOutboxOperation) (bool, error) {
+	query := `
+		INSERT INTO google_outbox (calendar_sync_id, user_id, event_id, google_event_id, operation)
+		SELECT l.calendar_sync_id, s.user_id, l.event_id, l.google_event_id, $2
+		FROM google_event_links l
+		JOIN google_calendar_syncs s ON s.id = l.calendar_sync_id
+		JOIN events e ON e.id = l.event_id AND e.calendar_id = s.local_calendar_id
+		WHERE l.event_id = $1
+		  AND s.sync_direction IN ('to_google', 'bidirectional')`
+
+	result, err := tx.Exec(ctx, query, id, operation)
+	if err != nil {
+		return false, fmt.Errorf("failed to queue Google push: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+	if operation == entities.GoogleOutboxDelete {
+		return true, nil
+	}
+
+	statusQuery := `UPDATE events SET push_status = 'pending', push_error = NULL WHERE id = $1`
+
+	if _, err := tx.Exec(ctx, statusQuery, id); err != nil {
+		return false, fmt.Errorf("failed to update push status: %w", err)
+	}
+
+	return true, nil
+}
+
 func (r *eventRepository) BulkCreate(ctx context.Context, events []*entities.Event) error {
 	if len(events) == 0 {
 		return nil
 	}
 
-	query := `
-		INSERT INTO events (
-			id, user_id, goal_id, title, description, start_time, end_time,
-			timezone, recurrence, location, attendees, status, external_id,
-			external_source, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
-
 	batch := &pgx.Batch{}
 	for _, event := range events {
-		batch.Queue(query,
+		batch.Queue(insertEventQuery,
 			event.ID, event.UserID, event.GoalID, event.Title, event.Description,
 			event.StartTime, event.EndTime, event.Timezone, event.Recurrence,
 			event.Location, event.Attendees, event.Status, event.ExternalID,
-			event.ExternalSource, event.CreatedAt, event.UpdatedAt,
+			event.ExternalSource, event.AllDay, event.Color, event.Transparent,
+			event.CreatedAt, event.UpdatedAt, event.CalendarID, event.Reminders,
 		)
 	}
 
@@ -433,16 +677,78 @@ func (r *eventRepository) BulkCreate(ctx context.Context, events []*entities.Eve
 	return nil
 }
 
-func (r *eventRepository) HasConflict(ctx context.Context, userID entities.UserID, start, end time.Time, excludeEventID *entities.EventID) (bool, error) {
+func (r *eventRepository) Import(ctx context.Context, created, updated []*entities.Event, exceptions []*entities.EventException) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, event := range created {
+		_, err := tx.Exec(ctx, insertEventQuery,
+			event.ID, event.UserID, event.GoalID, event.Title, event.Description,
+			event.StartTime, event.EndTime, event.Timezone, event.Recurrence,
+			event.Location, event.Attendees, event.Status, event.ExternalID,
+			event.ExternalSource, event.AllDay, event.Color, event.Transparent,
+			event.CreatedAt, event.UpdatedAt, event.CalendarID, event.Reminders,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create event: %w", err)
+		}
+	}
+
+	queued := make([]bool, len(updated))
+	for i, event := range updated {
+		// The imported exceptions replace the existing ones
+		if _, err := tx.Exec(ctx, `DELETE FROM event_exceptions WHERE event_id = $1`, event.ID); err != nil {
+			return fmt.Errorf("failed to delete event exceptions: %w", err)
+		}
+
+		if queued[i], err = updateEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	for _, exception := range exceptions {
+		_, err := tx.Exec(ctx, upsertEventExceptionQuery,
+			exception.ID, exception.EventID, exception.RecurrenceID, exception.Cancelled,
+			exception.Title, exception.Description, exception.StartTime, exception.EndTime,
+			exception.Location, exception.Attendees, exception.Status,
+			exception.CreatedAt, exception.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save event exception: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i, event := range updated {
+		if queued[i] {
+			markPushPending(event)
+		}
+	}
+
+	return nil
+}
+
+func (r *eventRepository) HasConflict(ctx context.Context, userID entities.UserID, start, end time.Time, excludeEventID *entities.EventID, includeAllDay bool) (bool, error) {
 	query := `
 		SELECT COUNT(*) 
 		FROM events 
 		WHERE user_id = $1 
 		  AND status != 'cancelled'
+		  AND NOT transparent
 		  AND (start_time < $3 AND end_time > $2)`
 
 	args := []interface{}{userID, start, end}
 
+	if !includeAllDay {
+		query += " AND NOT all_day"
+	}
+
 	if excludeEventID != nil {
 		query += " AND id != $4"
 		args = append(args, *excludeEventID)
@@ -468,9 +774,10 @@ func (r *eventRepository) GetByUserIDPaginated(ctx context.Context, userID entit
 
 	// Get paginated results
 	query := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1
 		ORDER BY start_time ASC
@@ -486,10 +793,12 @@ func (r *eventRepository) GetByUserIDPaginated(ctx context.Context, userID entit
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan event: %w", err)
@@ -502,9 +811,10 @@ func (r *eventRepository) GetByUserIDPaginated(ctx context.Context, userID entit
 
 func (r *eventRepository) Search(ctx context.Context, userID entities.UserID, query string, limit int) ([]*entities.Event, error) {
 	searchQuery := `
-		SELECT id, user_id, goal_id, title, description, start_time, end_time,
+		SELECT id, user_id, calendar_id, goal_id, title, description, start_time, end_time,
 			   timezone, recurrence, location, attendees, status, external_id,
-			   external_source, created_at, updated_at
+			   external_source, all_day, color, transparent, reminders, created_at,
+			   updated_at, push_status, push_error
 		FROM events 
 		WHERE user_id = $1 
 		  AND (title ILIKE $2 OR description ILIKE $2 OR location ILIKE $2)
@@ -522,10 +832,12 @@ func (r *eventRepository) Search(ctx context.Context, userID entities.UserID, qu
 	for rows.Next() {
 		var event entities.Event
 		err := rows.Scan(
-			&event.ID, &event.UserID, &event.GoalID, &event.Title, &event.Description,
+			&event.ID, &event.UserID, &event.CalendarID, &event.GoalID, &event.Title, &event.Description,
 			&event.StartTime, &event.EndTime, &event.Timezone, &event.Recurrence,
 			&event.Location, &event.Attendees, &event.Status, &event.ExternalID,
-			&event.ExternalSource, &event.CreatedAt, &event.UpdatedAt,
+			&event.ExternalSource, &event.AllDay, &event.Color, &event.Transparent, &event.Reminders,
+			&event.CreatedAt, &event.UpdatedAt,
+			&event.PushStatus, &event.PushError,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -534,4 +846,16 @@ func (r *eventRepository) Search(ctx context.Context, userID entities.UserID, qu
 	}
 
 	return events, nil
-}
\ No newline at end of file
+}
+// calendarIDStrings converts calendar IDs to a uuid[] parameter; nil stays
+// NULL, which matches events of every calendar
+func calendarIDStrings(ids []entities.CalendarID) []string {
+	if ids == nil {
+		return nil
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = string(id)
+	}
+	return values
+}
@@ -12,6 +12,12 @@ import (
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
 )
 
+// dueSyncCondition matches syncs the scheduler runs now, see
+// GoogleCalendarSync.NeedsSync
+const dueSyncCondition = `sync_status IN ('active', 'error')
+			  AND COALESCE((settings->>'auto_sync')::BOOLEAN, TRUE)
+			  AND (next_sync_at IS NULL OR next_sync_at <= NOW())`
+
 type googleCalendarSyncRepository struct {
 	db *pgxpool.Pool
 }
@@ -28,10 +34,10 @@ func (r *googleCalendarSyncRepository) Create(ctx context.Context, sync *entitie
 
 	query := `
 		INSERT INTO google_calendar_syncs (
-			id, user_id, google_integration_id, calendar_id, calendar_name,
+			id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
 			sync_direction, sync_status, last_sync_at, last_sync_error,
 			sync_token, settings, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
 
 	_, err = r.db.Exec(ctx, query,
 		sync.ID,
@@ -39,6 +45,7 @@ func (r *googleCalendarSyncRepository) Create(ctx context.Context, sync *entitie
 		sync.GoogleIntegrationID,
 		sync.CalendarID,
 		sync.CalendarName,
+		sync.LocalCalendarID,
 		sync.SyncDirection,
 		sync.SyncStatus,
 		sync.LastSyncAt,
@@ -54,9 +61,11 @@ func (r *googleCalendarSyncRepository) Create(ctx context.Context, sync *entitie
 
 func (r *googleCalendarSyncRepository) GetByID(ctx context.Context, id string) (*entities.GoogleCalendarSync, error) {
 	query := `
-		SELECT id, user_id, google_integration_id, calendar_id, calendar_name,
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
 			   sync_direction, sync_status, last_sync_at, last_sync_error,
-			   sync_token, settings, created_at, updated_at
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
 		FROM google_calendar_syncs
 		WHERE id = $1`
 
@@ -65,9 +74,11 @@ func (r *googleCalendarSyncRepository) GetByID(ctx context.Context, id string) (
 
 func (r *googleCalendarSyncRepository) GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.GoogleCalendarSync, error) {
 	query := `
-		SELECT id, user_id, google_integration_id, calendar_id, calendar_name,
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
 			   sync_direction, sync_status, last_sync_at, last_sync_error,
-			   sync_token, settings, created_at, updated_at
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
 		FROM google_calendar_syncs
 		WHERE user_id = $1
 		ORDER BY created_at DESC`
@@ -83,9 +94,11 @@ func (r *googleCalendarSyncRepository) GetByUserID(ctx context.Context, userID e
 
 func (r *googleCalendarSyncRepository) GetByIntegrationID(ctx context.Context, integrationID entities.GoogleIntegrationID) ([]*entities.GoogleCalendarSync, error) {
 	query := `
-		SELECT id, user_id, google_integration_id, calendar_id, calendar_name,
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
 			   sync_direction, sync_status, last_sync_at, last_sync_error,
-			   sync_token, settings, created_at, updated_at
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
 		FROM google_calendar_syncs
 		WHERE google_integration_id = $1
 		ORDER BY created_at DESC`
@@ -99,15 +112,17 @@ func (r *googleCalendarSyncRepository) GetByIntegrationID(ctx context.Context, i
 	return r.scanCalendarSyncs(rows)
 }
 
-func (r *googleCalendarSyncRepository) GetByCalendarID(ctx context.Context, userID entities.UserID, calendarID string) (*entities.GoogleCalendarSync, error) {
+func (r *googleCalendarSyncRepository) GetByCalendarID(ctx context.Context, integrationID entities.GoogleIntegrationID, calendarID string) (*entities.GoogleCalendarSync, error) {
 	query := `
-		SELECT id, user_id, google_integration_id, calendar_id, calendar_name,
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
 			   sync_direction, sync_status, last_sync_at, last_sync_error,
-			   sync_token, settings, created_at, updated_at
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
 		FROM google_calendar_syncs
-		WHERE user_id = $1 AND calendar_id = $2`
+		WHERE google_integration_id = $1 AND calendar_id = $2`
 
-	return r.scanCalendarSync(r.db.QueryRow(ctx, query, userID, calendarID))
+	return r.scanCalendarSync(r.db.QueryRow(ctx, query, integrationID, calendarID))
 }
 
 func (r *googleCalendarSyncRepository) Update(ctx context.Context, sync *entities.GoogleCalendarSync) error {
@@ -183,7 +198,14 @@ func (r *googleCalendarSyncRepository) UpdateSyncToken(ctx context.Context, id s
 }
 
 func (r *googleCalendarSyncRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM google_calendar_syncs WHERE id = $1`
+	// The calendar of the sync stays with its events as a local calendar
+	query := `
+		WITH deleted AS (
+			DELETE FROM google_calendar_syncs WHERE id = $1
+			RETURNING local_calendar_id
+		)
+		UPDATE calendars SET provider = NULL, read_only = FALSE
+		WHERE id IN (SELECT local_calendar_id FROM deleted)`
 
 	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
@@ -199,14 +221,14 @@ func (r *googleCalendarSyncRepository) Delete(ctx context.Context, id string) er
 
 func (r *googleCalendarSyncRepository) GetNeedingSync(ctx context.Context) ([]*entities.GoogleCalendarSync, error) {
 	query := `
-		SELECT id, user_id, google_integration_id, calendar_id, calendar_name,
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
 			   sync_direction, sync_status, last_sync_at, last_sync_error,
-			   sync_token, settings, created_at, updated_at
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
 		FROM google_calendar_syncs
-		WHERE sync_status = 'active'
-		  AND (last_sync_at IS NULL OR 
-			   last_sync_at <= NOW() - INTERVAL '15 minutes')
-		ORDER BY last_sync_at ASC NULLS FIRST`
+		WHERE ` + dueSyncCondition + `
+		ORDER BY next_sync_at ASC NULLS FIRST`
 
 	rows, err := r.db.Query(ctx, query)
 	if err != nil {
@@ -217,11 +239,159 @@ func (r *googleCalendarSyncRepository) GetNeedingSync(ctx context.Context) ([]*e
 	return r.scanCalendarSyncs(rows)
 }
 
+func (r *googleCalendarSyncRepository) ClaimDue(ctx context.Context, workerID string, limit int, lease time.Duration) ([]*entities.GoogleCalendarSync, error) {
+	// SKIP LOCKED lets concurrent workers claim disjoint batches; the lease
+	// keeps the rows away from other workers while the syncs run
+	query := `
+		UPDATE google_calendar_syncs
+		SET locked_by = $1, locked_until = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM google_calendar_syncs
+			WHERE ` + dueSyncCondition + `
+			  AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY next_sync_at ASC NULLS FIRST
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
+			   sync_direction, sync_status, last_sync_at, last_sync_error,
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified`
+
+	rows, err := r.db.Query(ctx, query, workerID, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim calendar syncs: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanCalendarSyncs(rows)
+}
+
+func (r *googleCalendarSyncRepository) Claim(ctx context.Context, id string, workerID string, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE google_calendar_syncs
+		SET locked_by = $2, locked_until = NOW() + make_interval(secs => $3)
+		WHERE id = $1 AND (locked_until IS NULL OR locked_until < NOW())`
+
+	result, err := r.db.Exec(ctx, query, id, workerID, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim calendar sync: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *googleCalendarSyncRepository) Release(ctx context.Context, id string, workerID string, nextSyncAt time.Time, failureCount int) error {
+	query := `
+		UPDATE google_calendar_syncs
+		SET locked_by = NULL, locked_until = NULL, next_sync_at = $3, failure_count = $4,
+			notified = FALSE
+		WHERE id = $1 AND locked_by = $2`
+
+	result, err := r.db.Exec(ctx, query, id, workerID, nextSyncAt, failureCount)
+	if err != nil {
+		return fmt.Errorf("failed to release calendar sync: %w", err)
+	}
+
+	// The lease expired and another worker took over
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *googleCalendarSyncRepository) GetByChannelID(ctx context.Context, channelID string) (*entities.GoogleCalendarSync, error) {
+	query := `
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
+			   sync_direction, sync_status, last_sync_at, last_sync_error,
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
+		FROM google_calendar_syncs
+		WHERE channel_id = $1`
+
+	return r.scanCalendarSync(r.db.QueryRow(ctx, query, channelID))
+}
+
+func (r *googleCalendarSyncRepository) GetNeedingChannel(ctx context.Context, provider entities.IntegrationProvider, expiresBefore time.Time) ([]*entities.GoogleCalendarSync, error) {
+	// Watched syncs are the ones GoogleCalendarSync.NeedsWatch accepts
+	query := `
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
+			   sync_direction, sync_status, last_sync_at, last_sync_error,
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
+		FROM google_calendar_syncs
+		WHERE google_integration_id IN (
+				SELECT id FROM google_integrations WHERE provider = $2
+			  )
+		  AND CASE
+				WHEN sync_status IN ('active', 'error')
+				 AND COALESCE((settings->>'auto_sync')::BOOLEAN, TRUE)
+				 AND sync_direction <> 'to_google'
+				THEN channel_id IS NULL OR channel_expires_at < $1
+				ELSE channel_id IS NOT NULL
+			  END
+		ORDER BY channel_expires_at ASC NULLS FIRST`
+
+	rows, err := r.db.Query(ctx, query, expiresBefore, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar syncs needing a channel: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanCalendarSyncs(rows)
+}
+
+func (r *googleCalendarSyncRepository) SwapChannel(ctx context.Context, id string, oldChannelID *string, channel *entities.WatchChannel) (bool, error) {
+	var channelID, resourceID, tokenHash *string
+	var expiresAt *time.Time
+	if channel != nil {
+		channelID = &channel.ID
+		resourceID = &channel.ResourceID
+		tokenHash = &channel.TokenHash
+		expiresAt = &channel.ExpiresAt
+	}
+
+	query := `
+		UPDATE google_calendar_syncs
+		SET channel_id = $3, channel_resource_id = $4, channel_token_hash = $5,
+			channel_expires_at = $6
+		WHERE id = $1 AND channel_id IS NOT DISTINCT FROM $2`
+
+	result, err := r.db.Exec(ctx, query, id, oldChannelID, channelID, resourceID, tokenHash, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to swap watch channel: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *googleCalendarSyncRepository) ScheduleNow(ctx context.Context, id string) error {
+	// Syncs backing off after failures keep their retry time
+	query := `
+		UPDATE google_calendar_syncs
+		SET next_sync_at = NOW(), notified = TRUE
+		WHERE id = $1 AND failure_count = 0`
+
+	_, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule calendar sync: %w", err)
+	}
+
+	return nil
+}
+
 func (r *googleCalendarSyncRepository) GetActive(ctx context.Context) ([]*entities.GoogleCalendarSync, error) {
 	query := `
-		SELECT id, user_id, google_integration_id, calendar_id, calendar_name,
+		SELECT id, user_id, google_integration_id, calendar_id, calendar_name, local_calendar_id,
 			   sync_direction, sync_status, last_sync_at, last_sync_error,
-			   sync_token, settings, created_at, updated_at
+			   sync_token, settings, created_at, updated_at,
+			   next_sync_at, failure_count, channel_id, channel_resource_id,
+			   channel_token_hash, channel_expires_at, notified
 		FROM google_calendar_syncs
 		WHERE sync_status = 'active'
 		ORDER BY created_at DESC`
@@ -247,6 +417,7 @@ func (r *googleCalendarSyncRepository) scanCalendarSync(row interface{}) (*entit
 
 	var sync entities.GoogleCalendarSync
 	var settingsJSON []byte
+	var channel nullableWatchChannel
 
 	err := scanner.Scan(
 		&sync.ID,
@@ -254,6 +425,7 @@ func (r *googleCalendarSyncRepository) scanCalendarSync(row interface{}) (*entit
 		&sync.GoogleIntegrationID,
 		&sync.CalendarID,
 		&sync.CalendarName,
+		&sync.LocalCalendarID,
 		&sync.SyncDirection,
 		&sync.SyncStatus,
 		&sync.LastSyncAt,
@@ -262,6 +434,13 @@ func (r *googleCalendarSyncRepository) scanCalendarSync(row interface{}) (*entit
 		&settingsJSON,
 		&sync.CreatedAt,
 		&sync.UpdatedAt,
+		&sync.NextSyncAt,
+		&sync.FailureCount,
+		&channel.ID,
+		&channel.ResourceID,
+		&channel.TokenHash,
+		&channel.ExpiresAt,
+		&sync.Notified,
 	)
 
 	if err != nil {
@@ -275,6 +454,7 @@ func (r *googleCalendarSyncRepository) scanCalendarSync(row interface{}) (*entit
 	if err := json.Unmarshal(settingsJSON, &sync.Settings); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
 	}
+	sync.Channel = channel.get()
 
 	return &sync, nil
 }
@@ -294,6 +474,7 @@ func (r *googleCalendarSyncRepository) scanCalendarSyncs(rows interface{}) ([]*e
 	for scanner.Next() {
 		var sync entities.GoogleCalendarSync
 		var settingsJSON []byte
+		var channel nullableWatchChannel
 
 		err := scanner.Scan(
 			&sync.ID,
@@ -301,6 +482,7 @@ func (r *googleCalendarSyncRepository) scanCalendarSyncs(rows interface{}) ([]*e
 			&sync.GoogleIntegrationID,
 			&sync.CalendarID,
 			&sync.CalendarName,
+			&sync.LocalCalendarID,
 			&sync.SyncDirection,
 			&sync.SyncStatus,
 			&sync.LastSyncAt,
@@ -309,6 +491,13 @@ func (r *googleCalendarSyncRepository) scanCalendarSyncs(rows interface{}) ([]*e
 			&settingsJSON,
 			&sync.CreatedAt,
 			&sync.UpdatedAt,
+			&sync.NextSyncAt,
+			&sync.FailureCount,
+			&channel.ID,
+			&channel.ResourceID,
+			&channel.TokenHash,
+			&channel.ExpiresAt,
+			&sync.Notified,
 		)
 
 		if err != nil {
@@ -319,9 +508,30 @@ func (r *googleCalendarSyncRepository) scanCalendarSyncs(rows interface{}) ([]*e
 		if err := json.Unmarshal(settingsJSON, &sync.Settings); err != nil {
 			continue
 		}
+		sync.Channel = channel.get()
 
 		syncs = append(syncs, &sync)
 	}
 
 	return syncs, nil
-}
\ No newline at end of file
+}
+// nullableWatchChannel scans the channel columns, which are all NULL for
+// syncs without a channel
+type nullableWatchChannel struct {
+	ID         *string
+	ResourceID *string
+	TokenHash  *string
+	ExpiresAt  *time.Time
+}
+
+func (c nullableWatchChannel) get() *entities.WatchChannel {
+	if c.ID == nil || c.ResourceID == nil || c.TokenHash == nil || c.ExpiresAt == nil {
+		return nil
+	}
+	return &entities.WatchChannel{
+		ID:         *c.ID,
+		ResourceID: *c.ResourceID,
+		TokenHash:  *c.TokenHash,
+		ExpiresAt:  *c.ExpiresAt,
+	}
+}
@@ -10,14 +10,19 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
 )
 
+// googleIntegrationRepository stores OAuth tokens encrypted. Each token is
+// bound to its integration and column, so sealed tokens can't be swapped
+// between rows.
 type googleIntegrationRepository struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	cipher services.TokenCipher
 }
 
-func NewGoogleIntegrationRepository(db *pgxpool.Pool) repositories.GoogleIntegrationRepository {
-	return &googleIntegrationRepository{db: db}
+func NewGoogleIntegrationRepository(db *pgxpool.Pool, cipher services.TokenCipher) repositories.GoogleIntegrationRepository {
+	return &googleIntegrationRepository{db: db, cipher: cipher}
 }
 
 func (r *googleIntegrationRepository) Create(ctx context.Context, integration *entities.GoogleIntegration) error {
@@ -26,21 +31,27 @@ func (r *googleIntegrationRepository) Create(ctx context.Context, integration *e
 		return fmt.Errorf("failed to marshal scopes: %w", err)
 	}
 
+	accessToken, refreshToken, err := r.encryptTokens(integration.ID, integration.AccessToken, integration.RefreshToken)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO google_integrations (
-			id, user_id, google_user_id, email, name, access_token, 
+			id, user_id, provider, google_user_id, email, name, access_token, 
 			refresh_token, token_type, expires_at, scopes, calendar_id, 
 			enabled, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
 
 	_, err = r.db.Exec(ctx, query,
 		integration.ID,
 		integration.UserID,
+		integration.Provider,
 		integration.GoogleUserID,
 		integration.Email,
 		integration.Name,
-		integration.AccessToken,
-		integration.RefreshToken,
+		accessToken,
+		refreshToken,
 		integration.TokenType,
 		integration.ExpiresAt,
 		scopesJSON,
@@ -55,35 +66,42 @@ func (r *googleIntegrationRepository) Create(ctx context.Context, integration *e
 
 func (r *googleIntegrationRepository) GetByID(ctx context.Context, id entities.GoogleIntegrationID) (*entities.GoogleIntegration, error) {
 	query := `
-		SELECT id, user_id, google_user_id, email, name, access_token, 
+		SELECT id, user_id, provider, google_user_id, email, name, access_token, 
 			   refresh_token, token_type, expires_at, scopes, calendar_id, 
-			   enabled, created_at, updated_at
+			   enabled, needs_reconsent, created_at, updated_at
 		FROM google_integrations
 		WHERE id = $1`
 
 	return r.scanIntegration(r.db.QueryRow(ctx, query, id))
 }
 
-func (r *googleIntegrationRepository) GetByUserID(ctx context.Context, userID entities.UserID) (*entities.GoogleIntegration, error) {
+func (r *googleIntegrationRepository) GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.GoogleIntegration, error) {
 	query := `
-		SELECT id, user_id, google_user_id, email, name, access_token, 
+		SELECT id, user_id, provider, google_user_id, email, name, access_token, 
 			   refresh_token, token_type, expires_at, scopes, calendar_id, 
-			   enabled, created_at, updated_at
+			   enabled, needs_reconsent, created_at, updated_at
 		FROM google_integrations
-		WHERE user_id = $1`
+		WHERE user_id = $1
+		ORDER BY created_at ASC`
 
-	return r.scanIntegration(r.db.QueryRow(ctx, query, userID))
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanIntegrations(rows)
 }
 
-func (r *googleIntegrationRepository) GetByGoogleUserID(ctx context.Context, googleUserID string) (*entities.GoogleIntegration, error) {
+func (r *googleIntegrationRepository) GetByGoogleUserID(ctx context.Context, provider entities.IntegrationProvider, googleUserID string) (*entities.GoogleIntegration, error) {
 	query := `
-		SELECT id, user_id, google_user_id, email, name, access_token, 
+		SELECT id, user_id, provider, google_user_id, email, name, access_token, 
 			   refresh_token, token_type, expires_at, scopes, calendar_id, 
-			   enabled, created_at, updated_at
+			   enabled, needs_reconsent, created_at, updated_at
 		FROM google_integrations
-		WHERE google_user_id = $1`
+		WHERE provider = $1 AND google_user_id = $2`
 
-	return r.scanIntegration(r.db.QueryRow(ctx, query, googleUserID))
+	return r.scanIntegration(r.db.QueryRow(ctx, query, provider, googleUserID))
 }
 
 func (r *googleIntegrationRepository) Update(ctx context.Context, integration *entities.GoogleIntegration) error {
@@ -92,11 +110,16 @@ func (r *googleIntegrationRepository) Update(ctx context.Context, integration *e
 		return fmt.Errorf("failed to marshal scopes: %w", err)
 	}
 
+	accessToken, refreshToken, err := r.encryptTokens(integration.ID, integration.AccessToken, integration.RefreshToken)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE google_integrations
 		SET google_user_id = $2, email = $3, name = $4, access_token = $5,
 			refresh_token = $6, token_type = $7, expires_at = $8, scopes = $9,
-			calendar_id = $10, enabled = $11, updated_at = $12
+			calendar_id = $10, enabled = $11, needs_reconsent = $12, updated_at = $13
 		WHERE id = $1`
 
 	result, err := r.db.Exec(ctx, query,
@@ -104,13 +127,14 @@ func (r *googleIntegrationRepository) Update(ctx context.Context, integration *e
 		integration.GoogleUserID,
 		integration.Email,
 		integration.Name,
-		integration.AccessToken,
-		integration.RefreshToken,
+		accessToken,
+		refreshToken,
 		integration.TokenType,
 		integration.ExpiresAt,
 		scopesJSON,
 		integration.CalendarID,
 		integration.Enabled,
+		integration.NeedsReconsent,
 		time.Now(),
 	)
 
@@ -126,9 +150,15 @@ func (r *googleIntegrationRepository) Update(ctx context.Context, integration *e
 }
 
 func (r *googleIntegrationRepository) UpdateTokens(ctx context.Context, id entities.GoogleIntegrationID, accessToken, refreshToken string, expiresAt time.Time) error {
+	accessToken, refreshToken, err := r.encryptTokens(id, accessToken, refreshToken)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE google_integrations
-		SET access_token = $2, refresh_token = $3, expires_at = $4, updated_at = $5
+		SET access_token = $2, refresh_token = $3, expires_at = $4,
+			needs_reconsent = FALSE, updated_at = $5
 		WHERE id = $1`
 
 	result, err := r.db.Exec(ctx, query, id, accessToken, refreshToken, expiresAt, time.Now())
@@ -143,8 +173,35 @@ func (r *googleIntegrationRepository) UpdateTokens(ctx context.Context, id entit
 	return nil
 }
 
+func (r *googleIntegrationRepository) MarkNeedsReconsent(ctx context.Context, id entities.GoogleIntegrationID) error {
+	query := `
+		UPDATE google_integrations
+		SET needs_reconsent = TRUE, updated_at = $2
+		WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, id, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func (r *googleIntegrationRepository) Delete(ctx context.Context, id entities.GoogleIntegrationID) error {
-	query := `DELETE FROM google_integrations WHERE id = $1`
+	// Syncs go with the integration; their calendars stay as local calendars
+	query := `
+		WITH detached AS (
+			UPDATE calendars SET provider = NULL, read_only = FALSE
+			WHERE id IN (
+				SELECT local_calendar_id FROM google_calendar_syncs
+				WHERE google_integration_id = $1
+			)
+		)
+		DELETE FROM google_integrations WHERE id = $1`
 
 	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
@@ -160,11 +217,11 @@ func (r *googleIntegrationRepository) Delete(ctx context.Context, id entities.Go
 
 func (r *googleIntegrationRepository) GetExpiringSoon(ctx context.Context, beforeTime time.Time) ([]*entities.GoogleIntegration, error) {
 	query := `
-		SELECT id, user_id, google_user_id, email, name, access_token, 
+		SELECT id, user_id, provider, google_user_id, email, name, access_token, 
 			   refresh_token, token_type, expires_at, scopes, calendar_id, 
-			   enabled, created_at, updated_at
+			   enabled, needs_reconsent, created_at, updated_at
 		FROM google_integrations
-		WHERE expires_at <= $1 AND enabled = true
+		WHERE expires_at <= $1 AND enabled = true AND needs_reconsent = false
 		ORDER BY expires_at ASC`
 
 	rows, err := r.db.Query(ctx, query, beforeTime)
@@ -178,9 +235,9 @@ func (r *googleIntegrationRepository) GetExpiringSoon(ctx context.Context, befor
 
 func (r *googleIntegrationRepository) GetActive(ctx context.Context) ([]*entities.GoogleIntegration, error) {
 	query := `
-		SELECT id, user_id, google_user_id, email, name, access_token, 
+		SELECT id, user_id, provider, google_user_id, email, name, access_token, 
 			   refresh_token, token_type, expires_at, scopes, calendar_id, 
-			   enabled, created_at, updated_at
+			   enabled, needs_reconsent, created_at, updated_at
 		FROM google_integrations
 		WHERE enabled = true
 		ORDER BY created_at DESC`
@@ -194,6 +251,98 @@ func (r *googleIntegrationRepository) GetActive(ctx context.Context) ([]*entitie
 	return r.scanIntegrations(rows)
 }
 
+func (r *googleIntegrationRepository) ReencryptTokens(ctx context.Context) (int, error) {
+	type storedTokens struct {
+		id           entities.GoogleIntegrationID
+		accessToken  string
+		refreshToken string
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT id, access_token, refresh_token FROM google_integrations`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get Google integration tokens: %w", err)
+	}
+
+	var stored []storedTokens
+	for rows.Next() {
+		var tokens storedTokens
+		if err := rows.Scan(&tokens.id, &tokens.accessToken, &tokens.refreshToken); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan Google integration tokens: %w", err)
+		}
+		stored = append(stored, tokens)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get Google integration tokens: %w", err)
+	}
+
+	// Tokens refreshed meanwhile are already sealed with the active key, so
+	// rows that changed are left alone
+	query := `
+		UPDATE google_integrations
+		SET access_token = $4, refresh_token = $5
+		WHERE id = $1 AND access_token = $2 AND refresh_token = $3`
+
+	updated := 0
+	for _, tokens := range stored {
+		accessToken, accessStale, err := r.cipher.Decrypt(tokens.accessToken, tokenAssociatedData(tokens.id, "access_token"))
+		if err != nil {
+			return updated, fmt.Errorf("failed to decrypt access token of integration %s: %w", tokens.id, err)
+		}
+		refreshToken, refreshStale, err := r.cipher.Decrypt(tokens.refreshToken, tokenAssociatedData(tokens.id, "refresh_token"))
+		if err != nil {
+			return updated, fmt.Errorf("failed to decrypt refresh token of integration %s: %w", tokens.id, err)
+		}
+		if !accessStale && !refreshStale {
+			continue
+		}
+
+		newAccessToken, newRefreshToken, err := r.encryptTokens(tokens.id, accessToken, refreshToken)
+		if err != nil {
+			return updated, err
+		}
+
+		result, err := r.db.Exec(ctx, query, tokens.id, tokens.accessToken, tokens.refreshToken, newAccessToken, newRefreshToken)
+		if err != nil {
+			return updated, fmt.Errorf("failed to re-encrypt tokens of integration %s: %w", tokens.id, err)
+		}
+		updated += int(result.RowsAffected())
+	}
+
+	return updated, nil
+}
+
+func (r *googleIntegrationRepository) encryptTokens(id entities.GoogleIntegrationID, accessToken, refreshToken string) (string, string, error) {
+	sealedAccessToken, err := r.cipher.Encrypt(accessToken, tokenAssociatedData(id, "access_token"))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+	sealedRefreshToken, err := r.cipher.Encrypt(refreshToken, tokenAssociatedData(id, "refresh_token"))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt refresh token: %w", err)
+	}
+	return sealedAccessToken, sealedRefreshToken, nil
+}
+
+func (r *googleIntegrationRepository) decryptTokens(integration *entities.GoogleIntegration) error {
+	var err error
+	integration.AccessToken, _, err = r.cipher.Decrypt(integration.AccessToken, tokenAssociatedData(integration.ID, "access_token"))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt access token: %w", err)
+	}
+	integration.RefreshToken, _, err = r.cipher.Decrypt(integration.RefreshToken, tokenAssociatedData(integration.ID, "refresh_token"))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt refresh token: %w", err)
+	}
+	return nil
+}
+
+// tokenAssociatedData binds a sealed token to its row and column
+func tokenAssociatedData(id entities.GoogleIntegrationID, column string) string {
+	return "google_integrations:" + string(id) + ":" + column
+}
+
 func (r *googleIntegrationRepository) scanIntegration(row interface{}) (*entities.GoogleIntegration, error) {
 	type rowScanner interface {
 		Scan(dest ...interface{}) error
@@ -210,6 +359,7 @@ func (r *googleIntegrationRepository) scanIntegration(row interface{}) (*entitie
 	err := scanner.Scan(
 		&integration.ID,
 		&integration.UserID,
+		&integration.Provider,
 		&integration.GoogleUserID,
 		&integration.Email,
 		&integration.Name,
@@ -220,6 +370,7 @@ func (r *googleIntegrationRepository) scanIntegration(row interface{}) (*entitie
 		&scopesJSON,
 		&integration.CalendarID,
 		&integration.Enabled,
+		&integration.NeedsReconsent,
 		&integration.CreatedAt,
 		&integration.UpdatedAt,
 	)
@@ -236,6 +387,10 @@ func (r *googleIntegrationRepository) scanIntegration(row interface{}) (*entitie
 		return nil, fmt.Errorf("failed to unmarshal scopes: %w", err)
 	}
 
+	if err := r.decryptTokens(&integration); err != nil {
+		return nil, err
+	}
+
 	return &integration, nil
 }
 
@@ -258,6 +413,7 @@ func (r *googleIntegrationRepository) scanIntegrations(rows interface{}) ([]*ent
 		err := scanner.Scan(
 			&integration.ID,
 			&integration.UserID,
+			&integration.Provider,
 			&integration.GoogleUserID,
 			&integration.Email,
 			&integration.Name,
@@ -268,6 +424,7 @@ func (r *googleIntegrationRepository) scanIntegrations(rows interface{}) ([]*ent
 			&scopesJSON,
 			&integration.CalendarID,
 			&integration.Enabled,
+			&integration.NeedsReconsent,
 			&integration.CreatedAt,
 			&integration.UpdatedAt,
 		)
@@ -281,6 +438,10 @@ func (r *googleIntegrationRepository) scanIntegrations(rows interface{}) ([]*ent
 			continue
 		}
 
+		if err := r.decryptTokens(&integration); err != nil {
+			continue
+		}
+
 		integrations = append(integrations, &integration)
 	}
 
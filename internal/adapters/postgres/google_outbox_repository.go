@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+const googleOutboxColumns = `
+	id, calendar_sync_id, user_id, event_id, google_event_id, operation,
+	status, attempts, COALESCE(last_error, ''), next_attempt_at, created_at, updated_at`
+
+type googleOutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGoogleOutboxRepository(db *pgxpool.Pool) repositories.GoogleOutboxRepository {
+	return &googleOutboxRepository{db: db}
+}
+
+func (r *googleOutboxRepository) ClaimDue(ctx context.Context, workerID string, limit int, lease time.Duration) ([]*entities.GoogleOutboxEntry, error) {
+	// An entry waits while an older one of the same event is pending, even
+	// when that one is leased or backing off
+	query := `
+		UPDATE google_outbox
+		SET locked_by = $1, locked_until = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT o.id
+			FROM google_outbox o
+			WHERE o.status = 'pending'
+			  AND o.next_attempt_at <= NOW()
+			  AND (o.locked_until IS NULL OR o.locked_until < NOW())
+			  AND NOT EXISTS (
+				  SELECT 1 FROM google_outbox older
+				  WHERE older.calendar_sync_id = o.calendar_sync_id
+				    AND older.event_id = o.event_id
+				    AND older.status = 'pending'
+				    AND older.created_at < o.created_at
+			  )
+			ORDER BY o.created_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + googleOutboxColumns
+
+	rows, err := r.db.Query(ctx, query, workerID, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entities.GoogleOutboxEntry
+	for rows.Next() {
+		var entry entities.GoogleOutboxEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.CalendarSyncID,
+			&entry.UserID,
+			&entry.EventID,
+			&entry.GoogleEventID,
+			&entry.Operation,
+			&entry.Status,
+			&entry.Attempts,
+			&entry.LastError,
+			&entry.NextAttemptAt,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
+
+func (r *googleOutboxRepository) Complete(ctx context.Context, id string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE google_outbox
+		SET status = 'done', locked_by = NULL, locked_until = NULL
+		WHERE id = $1
+		RETURNING event_id`
+
+	var eventID entities.EventID
+	if err := tx.QueryRow(ctx, query, id).Scan(&eventID); err != nil {
+		if err == pgx.ErrNoRows {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to complete outbox entry: %w", err)
+	}
+
+	// A failed push of another calendar keeps the event failed
+	statusQuery := `
+		UPDATE events
+		SET push_status = 'synced', push_error = NULL
+		WHERE id = $1
+		  AND push_status = 'pending'
+		  AND NOT EXISTS (
+			  SELECT 1 FROM google_outbox
+			  WHERE event_id = $1 AND status = 'pending'
+		  )`
+
+	if _, err := tx.Exec(ctx, statusQuery, eventID); err != nil {
+		return fmt.Errorf("failed to update push status: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *googleOutboxRepository) Retry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	return r.recordFailure(ctx, id, entities.GoogleOutboxPending, lastError, nextAttemptAt)
+}
+
+func (r *googleOutboxRepository) Fail(ctx context.Context, id string, lastError string) error {
+	return r.recordFailure(ctx, id, entities.GoogleOutboxFailed, lastError, time.Now())
+}
+
+// recordFailure stores a failed attempt on the entry and its error on the
+// event. The event only turns failed once the entry gives up.
+func (r *googleOutboxRepository) recordFailure(ctx context.Context, id string, status entities.GoogleOutboxStatus, lastError string, nextAttemptAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE google_outbox
+		SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4,
+			locked_by = NULL, locked_until = NULL
+		WHERE id = $1
+		RETURNING event_id`
+
+	var eventID entities.EventID
+	if err := tx.QueryRow(ctx, query, id, status, lastError, nextAttemptAt).Scan(&eventID); err != nil {
+		if err == pgx.ErrNoRows {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to record outbox failure: %w", err)
+	}
+
+	pushStatus := entities.EventPushStatusPending
+	if status == entities.GoogleOutboxFailed {
+		pushStatus = entities.EventPushStatusFailed
+	}
+
+	statusQuery := `UPDATE events SET push_status = $2, push_error = $3 WHERE id = $1`
+
+	if _, err := tx.Exec(ctx, statusQuery, eventID, pushStatus, lastError); err != nil {
+		return fmt.Errorf("failed to update push status: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
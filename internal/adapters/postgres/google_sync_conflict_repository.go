@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+const googleEventLinkColumns = `
+	id, calendar_sync_id, event_id, google_event_id,
+	local_updated_at, remote_updated_at, synced_at`
+
+const googleSyncConflictColumns = `
+	id, calendar_sync_id, user_id, event_id, google_event_id,
+	local_version, remote_version, resolution, created_at, resolved_at`
+
+type googleEventLinkRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGoogleEventLinkRepository(db *pgxpool.Pool) repositories.GoogleEventLinkRepository {
+	return &googleEventLinkRepository{db: db}
+}
+
+func (r *googleEventLinkRepository) Upsert(ctx context.Context, link *entities.GoogleEventLink) error {
+	// A local event may have been relinked to another Google event, e.g.
+	// after it was recreated there
+	query := `
+		INSERT INTO google_event_links (` + googleEventLinkColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (calendar_sync_id, event_id) DO UPDATE SET
+			google_event_id = EXCLUDED.google_event_id,
+			local_updated_at = EXCLUDED.local_updated_at,
+			remote_updated_at = EXCLUDED.remote_updated_at,
+			synced_at = EXCLUDED.synced_at
+		RETURNING id`
+
+	err := r.db.QueryRow(ctx, query,
+		link.ID, link.CalendarSyncID, link.EventID, link.GoogleEventID,
+		link.LocalUpdatedAt, link.RemoteUpdatedAt, link.SyncedAt,
+	).Scan(&link.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save Google event link: %w", err)
+	}
+
+	return nil
+}
+
+func (r *googleEventLinkRepository) GetByGoogleEventID(ctx context.Context, calendarSyncID string, googleEventID string) (*entities.GoogleEventLink, error) {
+	query := `
+		SELECT ` + googleEventLinkColumns + `
+		FROM google_event_links
+		WHERE calendar_sync_id = $1 AND google_event_id = $2`
+
+	return r.get(ctx, query, calendarSyncID, googleEventID)
+}
+
+func (r *googleEventLinkRepository) GetByEventID(ctx context.Context, calendarSyncID string, eventID entities.EventID) (*entities.GoogleEventLink, error) {
+	query := `
+		SELECT ` + googleEventLinkColumns + `
+		FROM google_event_links
+		WHERE calendar_sync_id = $1 AND event_id = $2`
+
+	return r.get(ctx, query, calendarSyncID, eventID)
+}
+
+func (r *googleEventLinkRepository) GetBySyncID(ctx context.Context, calendarSyncID string) ([]*entities.GoogleEventLink, error) {
+	query := `
+		SELECT ` + googleEventLinkColumns + `
+		FROM google_event_links
+		WHERE calendar_sync_id = $1`
+
+	rows, err := r.db.Query(ctx, query, calendarSyncID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Google event links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*entities.GoogleEventLink
+	for rows.Next() {
+		link, err := scanGoogleEventLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Google event link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (r *googleEventLinkRepository) get(ctx context.Context, query string, args ...interface{}) (*entities.GoogleEventLink, error) {
+	link, err := scanGoogleEventLink(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Google event link: %w", err)
+	}
+
+	return link, nil
+}
+
+func scanGoogleEventLink(row pgx.Row) (*entities.GoogleEventLink, error) {
+	var link entities.GoogleEventLink
+	err := row.Scan(
+		&link.ID,
+		&link.CalendarSyncID,
+		&link.EventID,
+		&link.GoogleEventID,
+		&link.LocalUpdatedAt,
+		&link.RemoteUpdatedAt,
+		&link.SyncedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+type googleSyncConflictRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGoogleSyncConflictRepository(db *pgxpool.Pool) repositories.GoogleSyncConflictRepository {
+	return &googleSyncConflictRepository{db: db}
+}
+
+func (r *googleSyncConflictRepository) Upsert(ctx context.Context, conflict *entities.GoogleSyncConflict) error {
+	localJSON, err := json.Marshal(conflict.LocalVersion)
+	if err != nil {
+		return fmt.Errorf("failed to marshal local version: %w", err)
+	}
+	remoteJSON, err := json.Marshal(conflict.RemoteVersion)
+	if err != nil {
+		return fmt.Errorf("failed to marshal remote version: %w", err)
+	}
+
+	query := `
+		INSERT INTO google_sync_conflicts (` + googleSyncConflictColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULL, $8, NULL)
+		ON CONFLICT (calendar_sync_id, event_id) WHERE resolved_at IS NULL DO UPDATE SET
+			google_event_id = EXCLUDED.google_event_id,
+			local_version = EXCLUDED.local_version,
+			remote_version = EXCLUDED.remote_version
+		RETURNING id, created_at`
+
+	err = r.db.QueryRow(ctx, query,
+		conflict.ID, conflict.CalendarSyncID, conflict.UserID, conflict.EventID,
+		conflict.GoogleEventID, localJSON, remoteJSON, conflict.CreatedAt,
+	).Scan(&conflict.ID, &conflict.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save Google sync conflict: %w", err)
+	}
+
+	return nil
+}
+
+func (r *googleSyncConflictRepository) GetByID(ctx context.Context, id string) (*entities.GoogleSyncConflict, error) {
+	query := `
+		SELECT ` + googleSyncConflictColumns + `
+		FROM google_sync_conflicts
+		WHERE id = $1`
+
+	conflict, err := scanGoogleSyncConflict(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Google sync conflict: %w", err)
+	}
+
+	return conflict, nil
+}
+
+func (r *googleSyncConflictRepository) GetOpenByEventID(ctx context.Context, calendarSyncID string, eventID entities.EventID) (*entities.GoogleSyncConflict, error) {
+	query := `
+		SELECT ` + googleSyncConflictColumns + `
+		FROM google_sync_conflicts
+		WHERE calendar_sync_id = $1 AND event_id = $2 AND resolved_at IS NULL`
+
+	conflict, err := scanGoogleSyncConflict(r.db.QueryRow(ctx, query, calendarSyncID, eventID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Google sync conflict: %w", err)
+	}
+
+	return conflict, nil
+}
+
+func (r *googleSyncConflictRepository) GetOpenBySyncID(ctx context.Context, calendarSyncID string) ([]*entities.GoogleSyncConflict, error) {
+	query := `
+		SELECT ` + googleSyncConflictColumns + `
+		FROM google_sync_conflicts
+		WHERE calendar_sync_id = $1 AND resolved_at IS NULL
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, calendarSyncID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Google sync conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	var conflicts []*entities.GoogleSyncConflict
+	for rows.Next() {
+		conflict, err := scanGoogleSyncConflict(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Google sync conflict: %w", err)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, nil
+}
+
+func (r *googleSyncConflictRepository) Resolve(ctx context.Context, id string, choice entities.ConflictChoice, resolvedAt time.Time) error {
+	query := `
+		UPDATE google_sync_conflicts
+		SET resolution = $2, resolved_at = $3
+		WHERE id = $1 AND resolved_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, id, choice, resolvedAt)
+	if err != nil {
+		return fmt.Errorf("failed to resolve Google sync conflict: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanGoogleSyncConflict(row pgx.Row) (*entities.GoogleSyncConflict, error) {
+	var conflict entities.GoogleSyncConflict
+	var localJSON, remoteJSON []byte
+
+	err := row.Scan(
+		&conflict.ID,
+		&conflict.CalendarSyncID,
+		&conflict.UserID,
+		&conflict.EventID,
+		&conflict.GoogleEventID,
+		&localJSON,
+		&remoteJSON,
+		&conflict.Resolution,
+		&conflict.CreatedAt,
+		&conflict.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(localJSON, &conflict.LocalVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal local version: %w", err)
+	}
+	if err := json.Unmarshal(remoteJSON, &conflict.RemoteVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal remote version: %w", err)
+	}
+
+	return &conflict, nil
+}
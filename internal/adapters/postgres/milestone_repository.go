@@ -157,6 +157,38 @@ func (r *milestoneRepository) GetUpcomingByUserID(ctx context.Context, userID en
 	return milestones, nil
 }
 
+func (r *milestoneRepository) GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Milestone, error) {
+	query := `
+		SELECT m.id, m.goal_id, m.title, m.description, m.target_date,
+			   m.completed, m.completed_at, m.created_at
+		FROM milestones m
+		JOIN goals g ON m.goal_id = g.id
+		WHERE g.user_id = $1
+		ORDER BY m.target_date ASC`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user milestones: %w", err)
+	}
+	defer rows.Close()
+
+	var milestones []*entities.Milestone
+	for rows.Next() {
+		var milestone entities.Milestone
+		err := rows.Scan(
+			&milestone.ID, &milestone.GoalID, &milestone.Title, &milestone.Description,
+			&milestone.TargetDate, &milestone.Completed, &milestone.CompletedAt,
+			&milestone.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan milestone: %w", err)
+		}
+		milestones = append(milestones, &milestone)
+	}
+
+	return milestones, nil
+}
+
 func (r *milestoneRepository) Update(ctx context.Context, milestone *entities.Milestone) error {
 	query := `
 		UPDATE milestones 
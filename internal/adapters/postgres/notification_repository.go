@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const notificationColumns = `
+	id, user_id, event_id, event_title, location, starts_at, all_day, timezone,
+	channel, minutes_before, due_at, status, attempts, COALESCE(last_error, ''),
+	next_attempt_at, sent_at, read_at, created_at, updated_at`
+
+type notificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) repositories.NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Enqueue(ctx context.Context, notification *entities.Notification) (bool, error) {
+	query := `
+		INSERT INTO notifications (
+			user_id, event_id, event_title, location, starts_at, all_day, timezone,
+			channel, minutes_before, due_at, status, next_attempt_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (event_id, starts_at, channel, minutes_before) DO NOTHING
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRow(ctx, query,
+		notification.UserID,
+		notification.EventID,
+		notification.EventTitle,
+		notification.Location,
+		notification.StartsAt,
+		notification.AllDay,
+		notification.Timezone,
+		notification.Channel,
+		notification.MinutesBefore,
+		notification.DueAt,
+		notification.Status,
+		notification.NextAttemptAt,
+	).Scan(&notification.ID, &notification.CreatedAt, &notification.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *notificationRepository) ClaimDue(ctx context.Context, workerID string, limit int, lease time.Duration) ([]*entities.Notification, error) {
+	query := `
+		UPDATE notifications
+		SET locked_by = $1, locked_until = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM notifications
+			WHERE status = 'pending'
+			  AND next_attempt_at <= NOW()
+			  AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY next_attempt_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+
+	rows, err := r.db.Query(ctx, query, workerID, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	return collectNotifications(rows)
+}
+
+func (r *notificationRepository) Complete(ctx context.Context, id string) error {
+	query := `
+		UPDATE notifications
+		SET status = 'sent', sent_at = NOW(), locked_by = NULL, locked_until = NULL
+		WHERE id = $1`
+
+	return r.exec(ctx, query, "failed to complete notification", id)
+}
+
+func (r *notificationRepository) Skip(ctx context.Context, id string, reason string) error {
+	query := `
+		UPDATE notifications
+		SET status = 'skipped', last_error = $2, locked_by = NULL, locked_until = NULL
+		WHERE id = $1`
+
+	return r.exec(ctx, query, "failed to skip notification", id, reason)
+}
+
+func (r *notificationRepository) Retry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	return r.recordFailure(ctx, id, entities.NotificationPending, lastError, nextAttemptAt)
+}
+
+func (r *notificationRepository) Fail(ctx context.Context, id string, lastError string) error {
+	return r.recordFailure(ctx, id, entities.NotificationFailed, lastError, time.Now())
+}
+
+func (r *notificationRepository) recordFailure(ctx context.Context, id string, status entities.NotificationStatus, lastError string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE notifications
+		SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4,
+			locked_by = NULL, locked_until = NULL
+		WHERE id = $1`
+
+	return r.exec(ctx, query, "failed to record notification failure", id, status, lastError, nextAttemptAt)
+}
+
+func (r *notificationRepository) GetInApp(ctx context.Context, userID entities.UserID, unreadOnly bool, limit int) ([]*entities.Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1 AND channel = 'in_app' AND status = 'sent'
+		  AND (NOT $2 OR read_at IS NULL)
+		ORDER BY due_at DESC
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	return collectNotifications(rows)
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, id string, userID entities.UserID) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2 AND channel = 'in_app' AND status = 'sent'`
+
+	return r.exec(ctx, query, "failed to mark notification as read", id, userID)
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID entities.UserID) error {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1 AND channel = 'in_app' AND status = 'sent' AND read_at IS NULL`
+
+	if _, err := r.db.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	return nil
+}
+
+// exec runs an update of a single notification; sql.ErrNoRows when there
+// is none to update
+func (r *notificationRepository) exec(ctx context.Context, query, errMessage string, args ...any) error {
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", errMessage, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func collectNotifications(rows pgx.Rows) ([]*entities.Notification, error) {
+	defer rows.Close()
+
+	var notifications []*entities.Notification
+	for rows.Next() {
+		var notification entities.Notification
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.EventID,
+			&notification.EventTitle,
+			&notification.Location,
+			&notification.StartsAt,
+			&notification.AllDay,
+			&notification.Timezone,
+			&notification.Channel,
+			&notification.MinutesBefore,
+			&notification.DueAt,
+			&notification.Status,
+			&notification.Attempts,
+			&notification.LastError,
+			&notification.NextAttemptAt,
+			&notification.SentAt,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+			&notification.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notifications: %w", err)
+	}
+
+	return notifications, nil
+}
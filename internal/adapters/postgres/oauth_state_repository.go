@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+type oauthStateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthStateRepository(db *pgxpool.Pool) repositories.OAuthStateRepository {
+	return &oauthStateRepository{db: db}
+}
+
+func (r *oauthStateRepository) Create(ctx context.Context, state *entities.OAuthState) error {
+	query := `
+		INSERT INTO oauth_states (state_hash, user_id, code_verifier, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.db.Exec(ctx, query,
+		state.StateHash,
+		state.UserID,
+		state.CodeVerifier,
+		state.ExpiresAt,
+		state.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create OAuth state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *oauthStateRepository) GetByHash(ctx context.Context, stateHash string) (*entities.OAuthState, error) {
+	query := `
+		SELECT state_hash, user_id, code_verifier, expires_at, used_at, created_at
+		FROM oauth_states
+		WHERE state_hash = $1`
+
+	var state entities.OAuthState
+	err := r.db.QueryRow(ctx, query, stateHash).Scan(
+		&state.StateHash,
+		&state.UserID,
+		&state.CodeVerifier,
+		&state.ExpiresAt,
+		&state.UsedAt,
+		&state.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get OAuth state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (r *oauthStateRepository) MarkUsed(ctx context.Context, stateHash string) (bool, error) {
+	query := `
+		UPDATE oauth_states
+		SET used_at = $2
+		WHERE state_hash = $1 AND used_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, stateHash, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to mark OAuth state as used: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *oauthStateRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM oauth_states WHERE expires_at < $1`
+
+	if _, err := r.db.Exec(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to delete expired OAuth states: %w", err)
+	}
+
+	return nil
+}
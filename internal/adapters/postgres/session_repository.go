@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+type sessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) repositories.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entities.Session, token *entities.RefreshToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sessionQuery := `
+		INSERT INTO user_sessions (
+			id, user_id, user_agent, ip_address, last_used_at, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err = tx.Exec(ctx, sessionQuery,
+		session.ID,
+		session.UserID,
+		session.UserAgent,
+		session.IPAddress,
+		session.LastUsedAt,
+		session.ExpiresAt,
+		session.CreatedAt,
+		session.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if err := r.insertRefreshToken(ctx, tx, token); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id entities.SessionID) (*entities.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, last_used_at, expires_at,
+			   revoked_at, revoked_reason, created_at, updated_at
+		FROM user_sessions
+		WHERE id = $1`
+
+	session, err := scanSession(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, last_used_at, expires_at,
+			   revoked_at, revoked_reason, created_at, updated_at
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*entities.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) GetRefreshToken(ctx context.Context, id string) (*entities.RefreshToken, error) {
+	query := `
+		SELECT id, session_id, user_id, expires_at, used_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE id = $1`
+
+	var token entities.RefreshToken
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&token.ID,
+		&token.SessionID,
+		&token.UserID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.ReplacedBy,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *sessionRepository) RotateRefreshToken(ctx context.Context, oldTokenID string, newToken *entities.RefreshToken, sessionExpiresAt time.Time) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Only one caller can win the race for an unused token
+	markQuery := `
+		UPDATE refresh_tokens
+		SET used_at = $2, replaced_by = $3
+		WHERE id = $1 AND used_at IS NULL`
+
+	result, err := tx.Exec(ctx, markQuery, oldTokenID, newToken.CreatedAt, newToken.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token as used: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := r.insertRefreshToken(ctx, tx, newToken); err != nil {
+		return false, err
+	}
+
+	sessionQuery := `
+		UPDATE user_sessions
+		SET last_used_at = $2, expires_at = $3
+		WHERE id = $1`
+
+	if _, err := tx.Exec(ctx, sessionQuery, newToken.SessionID, newToken.CreatedAt, sessionExpiresAt); err != nil {
+		return false, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id entities.SessionID, reason string) error {
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = COALESCE(revoked_at, $2),
+			revoked_reason = COALESCE(revoked_reason, $3)
+		WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, id, time.Now(), reason)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeAllByUserID(ctx context.Context, userID entities.UserID, exceptID entities.SessionID, reason string) error {
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = $3, revoked_reason = $4
+		WHERE user_id = $1 AND revoked_at IS NULL AND ($2 = '' OR id::text <> $2)`
+
+	_, err := r.db.Exec(ctx, query, userID, string(exceptID), time.Now(), reason)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) insertRefreshToken(ctx context.Context, tx pgx.Tx, token *entities.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, session_id, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := tx.Exec(ctx, query,
+		token.ID,
+		token.SessionID,
+		token.UserID,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func scanSession(row pgx.Row) (*entities.Session, error) {
+	var session entities.Session
+	var revokedReason *string
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&revokedReason,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedReason != nil {
+		session.RevokedReason = *revokedReason
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+const syncRunColumns = `
+	id, calendar_sync_id, user_id, trigger, started_at, finished_at,
+	created_count, updated_count, deleted_count, skipped_count, failed_count,
+	error, event_errors`
+
+type syncRunRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSyncRunRepository(db *pgxpool.Pool) repositories.SyncRunRepository {
+	return &syncRunRepository{db: db}
+}
+
+func (r *syncRunRepository) Create(ctx context.Context, run *entities.SyncRun) error {
+	eventErrors := run.EventErrors
+	if eventErrors == nil {
+		eventErrors = []entities.SyncEventError{}
+	}
+	eventErrorsJSON, err := json.Marshal(eventErrors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event errors: %w", err)
+	}
+
+	query := `
+		INSERT INTO sync_runs (` + syncRunColumns + `, duration_ms
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+	_, err = r.db.Exec(ctx, query,
+		run.ID, run.CalendarSyncID, run.UserID, run.Trigger, run.StartedAt, run.FinishedAt,
+		run.Created, run.Updated, run.Deleted, run.Skipped, run.Failed,
+		run.Error, eventErrorsJSON, run.Duration().Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sync run: %w", err)
+	}
+
+	return nil
+}
+
+func (r *syncRunRepository) GetBySyncID(ctx context.Context, calendarSyncID string, offset, limit int) ([]*entities.SyncRun, int64, error) {
+	countQuery := `SELECT COUNT(*) FROM sync_runs WHERE calendar_sync_id = $1`
+	var totalCount int64
+	if err := r.db.QueryRow(ctx, countQuery, calendarSyncID).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to get sync runs count: %w", err)
+	}
+
+	query := `
+		SELECT ` + syncRunColumns + `
+		FROM sync_runs
+		WHERE calendar_sync_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, calendarSyncID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get sync runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*entities.SyncRun
+	for rows.Next() {
+		run, err := scanSyncRun(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan sync run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, totalCount, nil
+}
+
+func (r *syncRunRepository) Prune(ctx context.Context, calendarSyncID string, keep int) error {
+	query := `
+		DELETE FROM sync_runs
+		WHERE calendar_sync_id = $1
+		  AND id NOT IN (
+			SELECT id
+			FROM sync_runs
+			WHERE calendar_sync_id = $1
+			ORDER BY started_at DESC
+			LIMIT $2
+		  )`
+
+	if _, err := r.db.Exec(ctx, query, calendarSyncID, keep); err != nil {
+		return fmt.Errorf("failed to prune sync runs: %w", err)
+	}
+
+	return nil
+}
+
+func scanSyncRun(row pgx.Row) (*entities.SyncRun, error) {
+	var run entities.SyncRun
+	var eventErrorsJSON []byte
+
+	err := row.Scan(
+		&run.ID,
+		&run.CalendarSyncID,
+		&run.UserID,
+		&run.Trigger,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.Created,
+		&run.Updated,
+		&run.Deleted,
+		&run.Skipped,
+		&run.Failed,
+		&run.Error,
+		&eventErrorsJSON,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(eventErrorsJSON, &run.EventErrors); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event errors: %w", err)
+	}
+
+	return &run, nil
+}
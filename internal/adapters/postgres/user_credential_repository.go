@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+type userCredentialRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserCredentialRepository(db *pgxpool.Pool) repositories.UserCredentialRepository {
+	return &userCredentialRepository{db: db}
+}
+
+func (r *userCredentialRepository) Create(ctx context.Context, credential *entities.UserCredential) error {
+	query := `
+		INSERT INTO user_credentials (user_id, password_hash, password_changed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.db.Exec(ctx, query,
+		credential.UserID,
+		credential.PasswordHash,
+		credential.PasswordChangedAt,
+		credential.CreatedAt,
+		credential.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user credential: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userCredentialRepository) GetByUserID(ctx context.Context, userID entities.UserID) (*entities.UserCredential, error) {
+	query := `
+		SELECT user_id, password_hash, password_changed_at, created_at, updated_at
+		FROM user_credentials
+		WHERE user_id = $1`
+
+	var credential entities.UserCredential
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&credential.UserID,
+		&credential.PasswordHash,
+		&credential.PasswordChangedAt,
+		&credential.CreatedAt,
+		&credential.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user credential: %w", err)
+	}
+
+	return &credential, nil
+}
+
+// UpdatePasswordHash stores a new hash. changed is false when the same
+// password is only being rehashed with new parameters.
+func (r *userCredentialRepository) UpdatePasswordHash(ctx context.Context, userID entities.UserID, passwordHash string, changed bool) error {
+	query := `
+		UPDATE user_credentials
+		SET password_hash = $2,
+			password_changed_at = CASE WHEN $3 THEN $4 ELSE password_changed_at END,
+			updated_at = $4
+		WHERE user_id = $1`
+
+	result, err := r.db.Exec(ctx, query, userID, passwordHash, changed, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -189,4 +189,48 @@ func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool,
 	err := r.db.QueryRow(ctx, query, email).Scan(&exists)
 	
 	return exists, err
-}
\ No newline at end of file
+}
+func (r *userRepository) GetWithNotificationsEnabled(ctx context.Context) ([]*entities.User, error) {
+	query := `
+		SELECT id, email, name, profile, settings, created_at, updated_at
+		FROM users
+		WHERE (settings->>'notification_enabled')::boolean
+		ORDER BY created_at ASC`
+	
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var users []*entities.User
+	for rows.Next() {
+		var user entities.User
+		var profileJSON, settingsJSON []byte
+		
+		err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Name,
+			&profileJSON,
+			&settingsJSON,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		
+		if err := json.Unmarshal(profileJSON, &user.Profile); err != nil {
+			return nil, err
+		}
+		
+		if err := json.Unmarshal(settingsJSON, &user.Settings); err != nil {
+			return nil, err
+		}
+		
+		users = append(users, &user)
+	}
+	
+	return users, rows.Err()
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// CreateCalendarCommand represents a command to create a local calendar
+type CreateCalendarCommand struct {
+	UserID           entities.UserID     `json:"user_id" validate:"required"`
+	Name             string              `json:"name" validate:"required"`
+	Color            string              `json:"color"`
+	Timezone         string              `json:"timezone"` // The default calendar's timezone when empty
+	DefaultReminders []entities.Reminder `json:"default_reminders"`
+	Visible          *bool               `json:"visible,omitempty"` // Visible unless false
+}
+
+// UpdateCalendarCommand represents a command to change a calendar. Fields
+// not given are kept.
+type UpdateCalendarCommand struct {
+	CalendarID       entities.CalendarID  `json:"calendar_id" validate:"required"`
+	UserID           entities.UserID      `json:"user_id" validate:"required"`
+	Name             *string              `json:"name,omitempty"`
+	Color            *string              `json:"color,omitempty"`
+	Timezone         *string              `json:"timezone,omitempty"`
+	DefaultReminders *[]entities.Reminder `json:"default_reminders,omitempty"`
+	Visible          *bool                `json:"visible,omitempty"`
+}
+
+// DeleteCalendarCommand represents a command to delete a calendar with its
+// events and sync
+type DeleteCalendarCommand struct {
+	CalendarID entities.CalendarID `json:"calendar_id" validate:"required"`
+	UserID     entities.UserID     `json:"user_id" validate:"required"`
+}
+
+// Results
+type CreateCalendarResult struct {
+	Calendar *entities.Calendar `json:"calendar"`
+}
+
+type UpdateCalendarResult struct {
+	Calendar *entities.Calendar `json:"calendar"`
+}
+
+type DeleteCalendarResult struct {
+	DeletedAt time.Time `json:"deleted_at"`
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// RotateCalendarFeedCommand represents a command to issue a new feed token,
+// revoking the previous one. Settings not given are kept from the previous feed.
+type RotateCalendarFeedCommand struct {
+	UserID            entities.UserID `json:"user_id" validate:"required"`
+	IncludeGoals      *bool           `json:"include_goals,omitempty"`
+	IncludeMilestones *bool           `json:"include_milestones,omitempty"`
+}
+
+// UpdateCalendarFeedCommand represents a command to change what the active feed includes
+type UpdateCalendarFeedCommand struct {
+	UserID            entities.UserID `json:"user_id" validate:"required"`
+	IncludeGoals      *bool           `json:"include_goals,omitempty"`
+	IncludeMilestones *bool           `json:"include_milestones,omitempty"`
+}
+
+// RevokeCalendarFeedCommand represents a command to disable the user's feed
+type RevokeCalendarFeedCommand struct {
+	UserID entities.UserID `json:"user_id" validate:"required"`
+}
+
+// Results
+type RotateCalendarFeedResult struct {
+	Feed  *entities.CalendarFeed `json:"feed"`
+	Token string                 `json:"token"` // Only available right after rotation
+}
+
+type UpdateCalendarFeedResult struct {
+	Feed *entities.CalendarFeed `json:"feed"`
+}
+
+type RevokeCalendarFeedResult struct {
+	RevokedAt time.Time `json:"revoked_at"`
+}
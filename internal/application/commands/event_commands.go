@@ -9,15 +9,20 @@ import (
 
 type CreateEventCommand struct {
 	UserID         entities.UserID         `json:"user_id"`
+	CalendarID     *entities.CalendarID    `json:"calendar_id,omitempty"` // The user's default calendar when nil
 	GoalID         *entities.GoalID        `json:"goal_id,omitempty"`
 	Title          string                  `json:"title"`
 	Description    string                  `json:"description"`
 	StartTime      time.Time               `json:"start_time"`
 	EndTime        time.Time               `json:"end_time"`
-	Timezone       string                  `json:"timezone"`
+	Timezone       string                  `json:"timezone"` // The calendar's timezone when empty
+	AllDay         bool                    `json:"all_day"`
+	StartDate      *time.Time              `json:"start_date,omitempty"` // For all-day events instead of the times
+	EndDate        *time.Time              `json:"end_date,omitempty"`   // Exclusive; the day after StartDate when nil
 	Recurrence     *entities.RecurrenceRule `json:"recurrence,omitempty"`
 	Location       string                  `json:"location"`
 	Attendees      []entities.Attendee     `json:"attendees"`
+	Reminders      []entities.Reminder     `json:"reminders,omitempty"` // The calendar's or user's defaults when nil
 	Status         entities.EventStatus    `json:"status"`
 	ExternalID     string                  `json:"external_id"`
 	ExternalSource string                  `json:"external_source"`
@@ -29,36 +34,46 @@ type CreateEventResult struct {
 }
 
 type UpdateEventCommand struct {
-	EventID        entities.EventID         `json:"event_id"`
-	UserID         entities.UserID          `json:"user_id"`
-	GoalID         *entities.GoalID         `json:"goal_id,omitempty"`
-	Title          *string                  `json:"title,omitempty"`
-	Description    *string                  `json:"description,omitempty"`
-	StartTime      *time.Time               `json:"start_time,omitempty"`
-	EndTime        *time.Time               `json:"end_time,omitempty"`
-	Timezone       *string                  `json:"timezone,omitempty"`
-	Recurrence     *entities.RecurrenceRule `json:"recurrence,omitempty"`
-	Location       *string                  `json:"location,omitempty"`
-	Attendees      *[]entities.Attendee     `json:"attendees,omitempty"`
-	Status         *entities.EventStatus    `json:"status,omitempty"`
-	ExternalID     *string                  `json:"external_id,omitempty"`
-	ExternalSource *string                  `json:"external_source,omitempty"`
+	EventID             entities.EventID         `json:"event_id"`
+	UserID              entities.UserID          `json:"user_id"`
+	CalendarID          *entities.CalendarID     `json:"calendar_id,omitempty"` // Moves the event to another calendar
+	GoalID              *entities.GoalID         `json:"goal_id,omitempty"`
+	Title               *string                  `json:"title,omitempty"`
+	Description         *string                  `json:"description,omitempty"`
+	StartTime           *time.Time               `json:"start_time,omitempty"`
+	EndTime             *time.Time               `json:"end_time,omitempty"`
+	Timezone            *string                  `json:"timezone,omitempty"`
+	AllDay              *bool                    `json:"all_day,omitempty"`
+	StartDate           *time.Time               `json:"start_date,omitempty"` // For all-day events instead of the times
+	EndDate             *time.Time               `json:"end_date,omitempty"`   // Exclusive
+	Recurrence          *entities.RecurrenceRule `json:"recurrence,omitempty"`
+	Location            *string                  `json:"location,omitempty"`
+	Attendees           *[]entities.Attendee     `json:"attendees,omitempty"`
+	Reminders           *[]entities.Reminder     `json:"reminders,omitempty"`
+	UseDefaultReminders bool                     `json:"use_default_reminders,omitempty"` // Resets the reminders to the defaults
+	Status              *entities.EventStatus    `json:"status,omitempty"`
+	ExternalID          *string                  `json:"external_id,omitempty"`
+	ExternalSource      *string                  `json:"external_source,omitempty"`
+	Scope               entities.RecurrenceScope `json:"scope,omitempty"` // For recurring events: this, this_and_following or all
 }
 
 type UpdateEventResult struct {
-	UpdatedAt time.Time `json:"updated_at"`
+	EventID   entities.EventID `json:"event_id,omitempty"` // Set when the change split off a new series
+	UpdatedAt time.Time        `json:"updated_at"`
 }
 
 type DeleteEventCommand struct {
-	EventID entities.EventID `json:"event_id"`
-	UserID  entities.UserID  `json:"user_id"`
+	EventID entities.EventID         `json:"event_id"`
+	UserID  entities.UserID          `json:"user_id"`
+	Scope   entities.RecurrenceScope `json:"scope,omitempty"`
 }
 
 type MoveEventCommand struct {
-	EventID   entities.EventID `json:"event_id"`
-	UserID    entities.UserID  `json:"user_id"`
-	StartTime time.Time        `json:"start_time"`
-	EndTime   time.Time        `json:"end_time"`
+	EventID   entities.EventID         `json:"event_id"`
+	UserID    entities.UserID          `json:"user_id"`
+	StartTime time.Time                `json:"start_time"`
+	EndTime   time.Time                `json:"end_time"`
+	Scope     entities.RecurrenceScope `json:"scope,omitempty"`
 }
 
 type MoveEventResult struct {
@@ -117,4 +132,48 @@ type SyncEventResult struct {
 	UpdatedCount int       `json:"updated_count"`
 	DeletedCount int       `json:"deleted_count"`
 	SyncedAt     time.Time `json:"synced_at"`
-}
\ No newline at end of file
+}
+// ImportedEvent is an event series read from an external calendar file,
+// identified there by UID. EventID is set when the UID is one of our own
+// exports. Err is set when the entry could not be read.
+type ImportedEvent struct {
+	UID        string                     `json:"uid"`
+	EventID    entities.EventID           `json:"event_id,omitempty"`
+	Event      *entities.Event            `json:"event"`
+	Exceptions []*entities.EventException `json:"exceptions,omitempty"`
+	Err        error                      `json:"-"`
+}
+
+// ImportEventsCommand imports events deduplicated by UID, which is stored as
+// ExternalID with the given ExternalSource. DryRun reports the outcome
+// without writing anything.
+type ImportEventsCommand struct {
+	UserID         entities.UserID      `json:"user_id"`
+	CalendarID     *entities.CalendarID `json:"calendar_id,omitempty"` // Of new events; the user's default calendar when nil
+	ExternalSource string               `json:"external_source"`
+	Events         []*ImportedEvent `json:"events"`
+	DryRun         bool             `json:"dry_run"`
+}
+
+type ImportAction string
+
+const (
+	ImportActionCreated ImportAction = "created"
+	ImportActionUpdated ImportAction = "updated"
+	ImportActionSkipped ImportAction = "skipped"
+)
+
+type ImportEventOutcome struct {
+	UID     string           `json:"uid"`
+	EventID entities.EventID `json:"event_id,omitempty"`
+	Action  ImportAction     `json:"action"`
+	Reason  string           `json:"reason,omitempty"`
+}
+
+type ImportEventsResult struct {
+	CreatedCount int                  `json:"created_count"`
+	UpdatedCount int                  `json:"updated_count"`
+	SkippedCount int                  `json:"skipped_count"`
+	DryRun       bool                 `json:"dry_run"`
+	Events       []ImportEventOutcome `json:"events"`
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// MarkNotificationReadCommand represents a command to mark an in-app
+// notification as read
+type MarkNotificationReadCommand struct {
+	NotificationID string          `json:"notification_id" validate:"required"`
+	UserID         entities.UserID `json:"user_id" validate:"required"`
+}
+
+// MarkAllNotificationsReadCommand represents a command to mark all in-app
+// notifications of a user as read
+type MarkAllNotificationsReadCommand struct {
+	UserID entities.UserID `json:"user_id" validate:"required"`
+}
+
+// Results
+type MarkNotificationsReadResult struct {
+	ReadAt time.Time `json:"read_at"`
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// StartSessionCommand represents a command to open a session on login or registration
+type StartSessionCommand struct {
+	UserID    entities.UserID `json:"user_id" validate:"required"`
+	UserAgent string          `json:"user_agent"`
+	IPAddress string          `json:"ip_address"`
+}
+
+// RotateRefreshTokenCommand represents a command to exchange a refresh token for a new one
+type RotateRefreshTokenCommand struct {
+	UserID         entities.UserID    `json:"user_id" validate:"required"`
+	SessionID      entities.SessionID `json:"session_id" validate:"required"`
+	RefreshTokenID string             `json:"refresh_token_id" validate:"required"`
+}
+
+// RevokeSessionCommand represents a command to revoke a single session
+type RevokeSessionCommand struct {
+	UserID    entities.UserID    `json:"user_id" validate:"required"`
+	SessionID entities.SessionID `json:"session_id" validate:"required"`
+	Reason    string             `json:"reason"`
+}
+
+// RevokeOtherSessionsCommand represents a command to revoke every session but the current one
+type RevokeOtherSessionsCommand struct {
+	UserID           entities.UserID    `json:"user_id" validate:"required"`
+	CurrentSessionID entities.SessionID `json:"current_session_id"`
+	Reason           string             `json:"reason"`
+}
+
+// Results
+type StartSessionResult struct {
+	SessionID      entities.SessionID `json:"session_id"`
+	RefreshTokenID string             `json:"refresh_token_id"`
+	ExpiresAt      time.Time          `json:"expires_at"`
+}
+
+type RotateRefreshTokenResult struct {
+	SessionID      entities.SessionID `json:"session_id"`
+	RefreshTokenID string             `json:"refresh_token_id"`
+	ExpiresAt      time.Time          `json:"expires_at"`
+}
+
+type RevokeSessionResult struct {
+	RevokedAt time.Time `json:"revoked_at"`
+}
@@ -9,6 +9,7 @@ import (
 type CreateUserCommand struct {
 	Email     string                   `json:"email" validate:"required,email"`
 	Name      string                   `json:"name" validate:"required,min=2,max=100"`
+	Password  string                   `json:"-" validate:"required,min=8"`
 	Profile   entities.UserProfile     `json:"profile"`
 	Settings  entities.UserSettings    `json:"settings"`
 }
@@ -27,6 +28,19 @@ type DeleteUserCommand struct {
 	UserID entities.UserID `json:"user_id" validate:"required"`
 }
 
+// AuthenticateUserCommand represents a command to verify user credentials
+type AuthenticateUserCommand struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"-" validate:"required"`
+}
+
+// ChangePasswordCommand represents a command to change the password of a user
+type ChangePasswordCommand struct {
+	UserID          entities.UserID `json:"user_id" validate:"required"`
+	CurrentPassword string          `json:"-" validate:"required"`
+	NewPassword     string          `json:"-" validate:"required,min=8"`
+}
+
 // CreateUserResult represents the result of creating a user
 type CreateUserResult struct {
 	UserID    entities.UserID `json:"user_id"`
@@ -41,4 +55,14 @@ type UpdateUserResult struct {
 // DeleteUserResult represents the result of deleting a user
 type DeleteUserResult struct {
 	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// AuthenticateUserResult represents the result of a successful authentication
+type AuthenticateUserResult struct {
+	User *entities.User `json:"user"`
+}
+
+// ChangePasswordResult represents the result of changing a password
+type ChangePasswordResult struct {
+	ChangedAt time.Time `json:"changed_at"`
 }
\ No newline at end of file
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+// feedTokenBytes is the amount of randomness in a feed token
+const feedTokenBytes = 32
+
+var (
+	ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+)
+
+type CalendarFeedHandler struct {
+	feedRepo      repositories.CalendarFeedRepository
+	goalRepo      repositories.GoalRepository
+	milestoneRepo repositories.MilestoneRepository
+	eventHandler  *EventHandler
+}
+
+func NewCalendarFeedHandler(
+	feedRepo repositories.CalendarFeedRepository,
+	goalRepo repositories.GoalRepository,
+	milestoneRepo repositories.MilestoneRepository,
+	eventHandler *EventHandler,
+) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		feedRepo:      feedRepo,
+		goalRepo:      goalRepo,
+		milestoneRepo: milestoneRepo,
+		eventHandler:  eventHandler,
+	}
+}
+
+// HandleRotateCalendarFeed issues a new feed token. The previous token, if
+// any, stops working immediately.
+func (h *CalendarFeedHandler) HandleRotateCalendarFeed(ctx context.Context, cmd commands.RotateCalendarFeedCommand) (*commands.RotateCalendarFeedResult, error) {
+	previous, err := h.feedRepo.GetActiveByUserID(ctx, cmd.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	token, err := generateFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	feed := &entities.CalendarFeed{
+		ID:        entities.CalendarFeedID(uuid.New().String()),
+		UserID:    cmd.UserID,
+		TokenHash: hashFeedToken(token),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if previous != nil {
+		feed.IncludeGoals = previous.IncludeGoals
+		feed.IncludeMilestones = previous.IncludeMilestones
+	}
+	if cmd.IncludeGoals != nil {
+		feed.IncludeGoals = *cmd.IncludeGoals
+	}
+	if cmd.IncludeMilestones != nil {
+		feed.IncludeMilestones = *cmd.IncludeMilestones
+	}
+
+	if err := h.feedRepo.Replace(ctx, feed); err != nil {
+		return nil, fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+
+	return &commands.RotateCalendarFeedResult{
+		Feed:  feed,
+		Token: token,
+	}, nil
+}
+
+func (h *CalendarFeedHandler) HandleUpdateCalendarFeed(ctx context.Context, cmd commands.UpdateCalendarFeedCommand) (*commands.UpdateCalendarFeedResult, error) {
+	feed, err := h.feedRepo.GetActiveByUserID(ctx, cmd.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	if feed == nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	if cmd.IncludeGoals != nil {
+		feed.IncludeGoals = *cmd.IncludeGoals
+	}
+	if cmd.IncludeMilestones != nil {
+		feed.IncludeMilestones = *cmd.IncludeMilestones
+	}
+
+	if err := h.feedRepo.UpdateSettings(ctx, feed.ID, feed.IncludeGoals, feed.IncludeMilestones); err != nil {
+		return nil, fmt.Errorf("failed to update calendar feed: %w", err)
+	}
+	feed.UpdatedAt = time.Now()
+
+	return &commands.UpdateCalendarFeedResult{
+		Feed: feed,
+	}, nil
+}
+
+func (h *CalendarFeedHandler) HandleRevokeCalendarFeed(ctx context.Context, cmd commands.RevokeCalendarFeedCommand) (*commands.RevokeCalendarFeedResult, error) {
+	revoked, err := h.feedRepo.RevokeByUserID(ctx, cmd.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke calendar feed: %w", err)
+	}
+	if !revoked {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	return &commands.RevokeCalendarFeedResult{
+		RevokedAt: time.Now(),
+	}, nil
+}
+
+func (h *CalendarFeedHandler) HandleGetCalendarFeed(ctx context.Context, query queries.GetCalendarFeedQuery) (*queries.GetCalendarFeedResult, error) {
+	feed, err := h.feedRepo.GetActiveByUserID(ctx, query.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	if feed == nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	return &queries.GetCalendarFeedResult{
+		Feed: feed,
+	}, nil
+}
+
+// HandleGetFeedCalendar resolves a feed token to the calendar it serves.
+// Unknown and revoked tokens both yield ErrCalendarFeedNotFound.
+func (h *CalendarFeedHandler) HandleGetFeedCalendar(ctx context.Context, query queries.GetFeedCalendarQuery) (*queries.GetFeedCalendarResult, error) {
+	feed, err := h.feedRepo.GetActiveByTokenHash(ctx, hashFeedToken(query.Token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	if feed == nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	// Hidden calendars stay out of the feed as they do out of the calendar view
+	exported, err := h.eventHandler.HandleExportEvents(ctx, queries.ExportEventsQuery{
+		UserID:      feed.UserID,
+		VisibleOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &queries.GetFeedCalendarResult{
+		Feed:       feed,
+		Events:     exported.Events,
+		Exceptions: exported.Exceptions,
+		ModifiedAt: feed.CreatedAt,
+	}
+
+	if feed.IncludeGoals {
+		goals, err := h.goalRepo.GetByUserID(ctx, feed.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user goals: %w", err)
+		}
+		for _, goal := range goals {
+			if goal.Deadline != nil {
+				result.Goals = append(result.Goals, goal)
+			}
+		}
+	}
+
+	if feed.IncludeMilestones {
+		milestones, err := h.milestoneRepo.GetByUserID(ctx, feed.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user milestones: %w", err)
+		}
+		result.Milestones = milestones
+	}
+
+	result.ModifiedAt = feedModifiedAt(result)
+
+	if err := h.feedRepo.UpdateLastAccessed(ctx, feed.ID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// feedModifiedAt returns the latest change to anything the feed contains
+func feedModifiedAt(result *queries.GetFeedCalendarResult) time.Time {
+	latest := result.ModifiedAt
+	touch := func(t time.Time) {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	for _, event := range result.Events {
+		touch(event.UpdatedAt)
+		for _, exception := range result.Exceptions[event.ID] {
+			touch(exception.UpdatedAt)
+		}
+	}
+	for _, goal := range result.Goals {
+		touch(goal.UpdatedAt)
+	}
+	for _, milestone := range result.Milestones {
+		touch(milestone.CreatedAt)
+		if milestone.CompletedAt != nil {
+			touch(*milestone.CompletedAt)
+		}
+	}
+
+	return latest
+}
+
+func generateFeedToken() (string, error) {
+	b := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrCalendarReadOnly = errors.New("calendar is read-only")
+	ErrDefaultCalendar  = errors.New("the default calendar can't be deleted")
+	ErrInvalidCalendar  = errors.New("invalid calendar")
+	ErrSyncedCalendar   = errors.New("a synced calendar can't be deleted while it is synced")
+)
+
+type CalendarHandler struct {
+	calendarRepo repositories.CalendarRepository
+}
+
+func NewCalendarHandler(calendarRepo repositories.CalendarRepository) *CalendarHandler {
+	return &CalendarHandler{
+		calendarRepo: calendarRepo,
+	}
+}
+
+func (h *CalendarHandler) HandleCreateCalendar(ctx context.Context, cmd commands.CreateCalendarCommand) (*commands.CreateCalendarResult, error) {
+	// New calendars default to the zone of the default calendar, which
+	// starts out with the user's timezone
+	if cmd.Timezone == "" {
+		defaultCalendar, err := h.calendarRepo.GetOrCreateDefault(ctx, cmd.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default calendar: %w", err)
+		}
+		cmd.Timezone = "UTC"
+		if defaultCalendar != nil {
+			cmd.Timezone = defaultCalendar.Timezone
+		}
+	}
+
+	now := time.Now()
+	calendar := &entities.Calendar{
+		ID:               entities.CalendarID(uuid.New().String()),
+		UserID:           cmd.UserID,
+		Name:             cmd.Name,
+		Color:            cmd.Color,
+		Timezone:         cmd.Timezone,
+		DefaultReminders: cmd.DefaultReminders,
+		Visible:          cmd.Visible == nil || *cmd.Visible,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	if err := validateCalendar(calendar); err != nil {
+		return nil, err
+	}
+
+	if err := h.calendarRepo.Create(ctx, calendar); err != nil {
+		return nil, fmt.Errorf("failed to create calendar: %w", err)
+	}
+
+	return &commands.CreateCalendarResult{
+		Calendar: calendar,
+	}, nil
+}
+
+func (h *CalendarHandler) HandleUpdateCalendar(ctx context.Context, cmd commands.UpdateCalendarCommand) (*commands.UpdateCalendarResult, error) {
+	calendar, err := h.ownedCalendar(ctx, cmd.CalendarID, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Name != nil {
+		calendar.Name = *cmd.Name
+	}
+	if cmd.Color != nil {
+		calendar.Color = *cmd.Color
+	}
+	if cmd.Timezone != nil {
+		calendar.Timezone = *cmd.Timezone
+	}
+	if cmd.DefaultReminders != nil {
+		calendar.DefaultReminders = *cmd.DefaultReminders
+	}
+	if cmd.Visible != nil {
+		calendar.Visible = *cmd.Visible
+	}
+
+	if err := validateCalendar(calendar); err != nil {
+		return nil, err
+	}
+
+	if err := h.calendarRepo.Update(ctx, calendar); err != nil {
+		return nil, fmt.Errorf("failed to update calendar: %w", err)
+	}
+
+	return &commands.UpdateCalendarResult{
+		Calendar: calendar,
+	}, nil
+}
+
+// HandleDeleteCalendar deletes a local calendar with its events. A synced
+// calendar has to be unsynced first: deleting its sync stops the watch
+// channel and keeps the calendar as a local one.
+func (h *CalendarHandler) HandleDeleteCalendar(ctx context.Context, cmd commands.DeleteCalendarCommand) (*commands.DeleteCalendarResult, error) {
+	calendar, err := h.ownedCalendar(ctx, cmd.CalendarID, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.IsDefault {
+		return nil, ErrDefaultCalendar
+	}
+	if calendar.IsSynced() {
+		return nil, ErrSyncedCalendar
+	}
+
+	if err := h.calendarRepo.Delete(ctx, calendar.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete calendar: %w", err)
+	}
+
+	return &commands.DeleteCalendarResult{
+		DeletedAt: time.Now(),
+	}, nil
+}
+
+// HandleGetCalendars lists the calendars of a user. The default calendar is
+// created on first use, so every user has at least one.
+func (h *CalendarHandler) HandleGetCalendars(ctx context.Context, query queries.GetCalendarsQuery) (*queries.GetCalendarsResult, error) {
+	if _, err := h.calendarRepo.GetOrCreateDefault(ctx, query.UserID); err != nil {
+		return nil, fmt.Errorf("failed to get default calendar: %w", err)
+	}
+
+	calendars, err := h.calendarRepo.GetByUserID(ctx, query.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendars: %w", err)
+	}
+
+	return &queries.GetCalendarsResult{
+		Calendars: calendars,
+	}, nil
+}
+
+func (h *CalendarHandler) HandleGetCalendar(ctx context.Context, query queries.GetCalendarQuery) (*queries.GetCalendarResult, error) {
+	calendar, err := h.ownedCalendar(ctx, query.CalendarID, query.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &queries.GetCalendarResult{
+		Calendar: calendar,
+	}, nil
+}
+
+// ownedCalendar loads a calendar of the user. Calendars of other users are
+// reported as not found.
+func (h *CalendarHandler) ownedCalendar(ctx context.Context, calendarID entities.CalendarID, userID entities.UserID) (*entities.Calendar, error) {
+	calendar, err := h.calendarRepo.GetByID(ctx, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+	if calendar == nil || calendar.UserID != userID {
+		return nil, ErrCalendarNotFound
+	}
+	return calendar, nil
+}
+
+func validateCalendar(calendar *entities.Calendar) error {
+	if calendar.Name == "" || len(calendar.Name) > 255 {
+		return fmt.Errorf("%w: name is required and can't exceed 255 characters", ErrInvalidCalendar)
+	}
+	if !entities.IsValidColor(calendar.Color) {
+		return fmt.Errorf("%w: color must be a hex color such as #7ae7bf", ErrInvalidCalendar)
+	}
+	if _, err := time.LoadLocation(calendar.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidCalendar, calendar.Timezone)
+	}
+	if !entities.ValidReminders(calendar.DefaultReminders) {
+		return fmt.Errorf("%w: at most %d default reminders with a known channel and at most four weeks before the event are allowed", ErrInvalidCalendar, entities.MaxReminders)
+	}
+	return nil
+}
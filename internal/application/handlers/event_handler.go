@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,38 +15,64 @@ import (
 )
 
 type EventHandler struct {
-	eventRepo repositories.EventRepository
-	goalRepo  repositories.GoalRepository
-	eventService *services.EventService
+	eventRepo     repositories.EventRepository
+	exceptionRepo repositories.EventExceptionRepository
+	calendarRepo  repositories.CalendarRepository
+	goalRepo      repositories.GoalRepository
+	eventService  *services.EventService
 }
 
 func NewEventHandler(
 	eventRepo repositories.EventRepository,
+	exceptionRepo repositories.EventExceptionRepository,
+	calendarRepo repositories.CalendarRepository,
 	goalRepo repositories.GoalRepository,
 	eventService *services.EventService,
 ) *EventHandler {
 	return &EventHandler{
-		eventRepo:    eventRepo,
-		goalRepo:     goalRepo,
-		eventService: eventService,
+		eventRepo:     eventRepo,
+		exceptionRepo: exceptionRepo,
+		calendarRepo:  calendarRepo,
+		goalRepo:      goalRepo,
+		eventService:  eventService,
 	}
 }
 
 // Command Handlers
 
 func (h *EventHandler) HandleCreateEvent(ctx context.Context, cmd commands.CreateEventCommand) (*commands.CreateEventResult, error) {
+	calendar, err := h.targetCalendar(ctx, cmd.UserID, cmd.CalendarID)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Timezone == "" {
+		cmd.Timezone = calendar.Timezone
+	}
+
+	// All-day events given by dates run from midnight to midnight in their timezone
+	if cmd.AllDay && cmd.StartDate != nil {
+		loc, err := time.LoadLocation(cmd.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %s", cmd.Timezone)
+		}
+		startDate, endDate := changeDates(*cmd.StartDate, cmd.StartDate.AddDate(0, 0, 1), cmd.StartDate, cmd.EndDate)
+		cmd.StartTime, cmd.EndTime = entities.AllDayTimes(startDate, endDate, loc)
+	}
+
 	// Validate time range
 	if cmd.EndTime.Before(cmd.StartTime) || cmd.EndTime.Equal(cmd.StartTime) {
 		return nil, fmt.Errorf("end time must be after start time")
 	}
 
-	// Check for conflicts if needed
-	hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, cmd.StartTime, cmd.EndTime, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check for conflicts: %w", err)
-	}
-	if hasConflict {
-		return nil, fmt.Errorf("event conflicts with existing event")
+	// Check for conflicts if needed; all-day events don't block time
+	if !cmd.AllDay {
+		hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, cmd.StartTime, cmd.EndTime, nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for conflicts: %w", err)
+		}
+		if hasConflict {
+			return nil, fmt.Errorf("event conflicts with existing event")
+		}
 	}
 
 	// Validate goal ownership if GoalID is provided
@@ -67,15 +94,18 @@ func (h *EventHandler) HandleCreateEvent(ctx context.Context, cmd commands.Creat
 	event := &entities.Event{
 		ID:             entities.EventID(uuid.New().String()),
 		UserID:         cmd.UserID,
+		CalendarID:     calendar.ID,
 		GoalID:         cmd.GoalID,
 		Title:          h.eventService.SanitizeEventTitle(cmd.Title),
 		Description:    h.eventService.SanitizeEventDescription(cmd.Description),
 		StartTime:      cmd.StartTime,
 		EndTime:        cmd.EndTime,
 		Timezone:       cmd.Timezone,
+		AllDay:         cmd.AllDay,
 		Recurrence:     cmd.Recurrence,
 		Location:       cmd.Location,
 		Attendees:      cmd.Attendees,
+		Reminders:      cmd.Reminders,
 		Status:         cmd.Status,
 		ExternalID:     cmd.ExternalID,
 		ExternalSource: cmd.ExternalSource,
@@ -100,105 +130,37 @@ func (h *EventHandler) HandleCreateEvent(ctx context.Context, cmd commands.Creat
 }
 
 func (h *EventHandler) HandleUpdateEvent(ctx context.Context, cmd commands.UpdateEventCommand) (*commands.UpdateEventResult, error) {
-	// Get existing event
-	event, err := h.eventRepo.GetByID(ctx, cmd.EventID)
+	// Get existing event (or the series an occurrence belongs to)
+	event, occurrenceStart, err := h.getEventForChange(ctx, cmd.EventID, cmd.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get event: %w", err)
-	}
-
-	if event == nil {
-		return nil, fmt.Errorf("event not found")
-	}
-
-	// Check ownership
-	if event.UserID != cmd.UserID {
-		return nil, fmt.Errorf("access denied: event belongs to different user")
-	}
-
-	// Update fields if provided
-	if cmd.Title != nil {
-		event.Title = h.eventService.SanitizeEventTitle(*cmd.Title)
-	}
-
-	if cmd.Description != nil {
-		event.Description = h.eventService.SanitizeEventDescription(*cmd.Description)
-	}
-
-	if cmd.StartTime != nil {
-		event.StartTime = *cmd.StartTime
-	}
-
-	if cmd.EndTime != nil {
-		event.EndTime = *cmd.EndTime
-	}
-
-	// Validate time range after updates
-	if event.EndTime.Before(event.StartTime) || event.EndTime.Equal(event.StartTime) {
-		return nil, fmt.Errorf("end time must be after start time")
-	}
-
-	// Check for conflicts if time changed
-	if cmd.StartTime != nil || cmd.EndTime != nil {
-		hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, event.StartTime, event.EndTime, &cmd.EventID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check for conflicts: %w", err)
-		}
-		if hasConflict {
-			return nil, fmt.Errorf("event conflicts with existing event")
-		}
-	}
-
-	if cmd.Timezone != nil {
-		event.Timezone = *cmd.Timezone
+		return nil, err
 	}
 
-	if cmd.Recurrence != nil {
-		event.Recurrence = cmd.Recurrence
-	}
-
-	if cmd.Location != nil {
-		event.Location = *cmd.Location
-	}
-
-	if cmd.Attendees != nil {
-		event.Attendees = *cmd.Attendees
-	}
-
-	if cmd.Status != nil {
-		event.Status = *cmd.Status
+	scope, occurrenceStart, err := resolveRecurrenceScope(event, occurrenceStart, cmd.Scope)
+	if err != nil {
+		return nil, err
 	}
 
-	if cmd.GoalID != nil {
-		// Validate goal ownership
-		goal, err := h.goalRepo.GetByID(ctx, *cmd.GoalID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get goal: %w", err)
-		}
-		if goal == nil {
-			return nil, fmt.Errorf("goal not found")
-		}
-		if goal.UserID != cmd.UserID {
-			return nil, fmt.Errorf("access denied: goal belongs to different user")
-		}
-		event.GoalID = cmd.GoalID
+	switch scope {
+	case entities.RecurrenceScopeThis:
+		return h.updateOccurrence(ctx, event, *occurrenceStart, cmd)
+	case entities.RecurrenceScopeThisAndFollowing:
+		return h.updateFollowing(ctx, event, *occurrenceStart, cmd)
 	}
 
-	if cmd.ExternalID != nil {
-		event.ExternalID = *cmd.ExternalID
+	// Changing the whole series through one of its occurrences moves every
+	// occurrence by the same offset
+	if occurrenceStart != nil {
+		cmd = shiftToSeriesStart(event, *occurrenceStart, cmd)
 	}
 
-	if cmd.ExternalSource != nil {
-		event.ExternalSource = *cmd.ExternalSource
+	if err := h.applyEventUpdate(ctx, event, cmd); err != nil {
+		return nil, err
 	}
 
 	// Update timestamp
 	event.UpdatedAt = time.Now()
 
-	// Validate updated event
-	if err := h.eventService.ValidateEventCreation(event); err != nil {
-		return nil, fmt.Errorf("event validation failed: %w", err)
-	}
-
 	// Save updated event
 	if err := h.eventRepo.Update(ctx, event); err != nil {
 		return nil, fmt.Errorf("failed to update event: %w", err)
@@ -211,22 +173,49 @@ func (h *EventHandler) HandleUpdateEvent(ctx context.Context, cmd commands.Updat
 
 func (h *EventHandler) HandleDeleteEvent(ctx context.Context, cmd commands.DeleteEventCommand) error {
 	// Get event to check ownership
-	event, err := h.eventRepo.GetByID(ctx, cmd.EventID)
+	event, occurrenceStart, err := h.getEventForChange(ctx, cmd.EventID, cmd.UserID)
 	if err != nil {
-		return fmt.Errorf("failed to get event: %w", err)
+		return err
 	}
 
-	if event == nil {
-		return fmt.Errorf("event not found")
-	}
+	scope, occurrenceStart, err := resolveRecurrenceScope(event, occurrenceStart, cmd.Scope)
+	if err != nil {
+		return err
+	}
+
+	switch scope {
+	case entities.RecurrenceScopeThis:
+		// Cancelled exception, i.e. an EXDATE
+		now := time.Now()
+		exception := &entities.EventException{
+			ID:           uuid.New().String(),
+			EventID:      event.ID,
+			RecurrenceID: *occurrenceStart,
+			Cancelled:    true,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := h.exceptionRepo.Upsert(ctx, exception); err != nil {
+			return fmt.Errorf("failed to cancel occurrence: %w", err)
+		}
+		return h.touchSeries(ctx, event, now)
 
-	// Check ownership
-	if event.UserID != cmd.UserID {
-		return fmt.Errorf("access denied: event belongs to different user")
+	case entities.RecurrenceScopeThisAndFollowing:
+		head, _ := event.Recurrence.SplitAt(event.StartTime, *occurrenceStart, event.TimeLocation())
+		event.Recurrence = head
+		event.UpdatedAt = time.Now()
+
+		if err := h.eventRepo.Update(ctx, event); err != nil {
+			return fmt.Errorf("failed to truncate recurring event: %w", err)
+		}
+		if err := h.exceptionRepo.DeleteFrom(ctx, event.ID, *occurrenceStart); err != nil {
+			return fmt.Errorf("failed to delete exceptions: %w", err)
+		}
+		return nil
 	}
 
 	// Delete event
-	if err := h.eventRepo.Delete(ctx, cmd.EventID); err != nil {
+	if err := h.eventRepo.Delete(ctx, event.ID); err != nil {
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
 
@@ -235,18 +224,26 @@ func (h *EventHandler) HandleDeleteEvent(ctx context.Context, cmd commands.Delet
 
 func (h *EventHandler) HandleMoveEvent(ctx context.Context, cmd commands.MoveEventCommand) (*commands.MoveEventResult, error) {
 	// Get existing event
-	event, err := h.eventRepo.GetByID(ctx, cmd.EventID)
+	event, _, err := h.getEventForChange(ctx, cmd.EventID, cmd.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get event: %w", err)
-	}
-
-	if event == nil {
-		return nil, fmt.Errorf("event not found")
-	}
-
-	// Check ownership
-	if event.UserID != cmd.UserID {
-		return nil, fmt.Errorf("access denied: event belongs to different user")
+		return nil, err
+	}
+
+	// Recurring events go through the scoped update
+	if event.IsRecurring() {
+		result, err := h.HandleUpdateEvent(ctx, commands.UpdateEventCommand{
+			EventID:   cmd.EventID,
+			UserID:    cmd.UserID,
+			StartTime: &cmd.StartTime,
+			EndTime:   &cmd.EndTime,
+			Scope:     cmd.Scope,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &commands.MoveEventResult{
+			UpdatedAt: result.UpdatedAt,
+		}, nil
 	}
 
 	// Validate time range
@@ -255,12 +252,14 @@ func (h *EventHandler) HandleMoveEvent(ctx context.Context, cmd commands.MoveEve
 	}
 
 	// Check for conflicts
-	hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, cmd.StartTime, cmd.EndTime, &cmd.EventID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check for conflicts: %w", err)
-	}
-	if hasConflict {
-		return nil, fmt.Errorf("event conflicts with existing event")
+	if event.BlocksTime() {
+		hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, cmd.StartTime, cmd.EndTime, &cmd.EventID, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for conflicts: %w", err)
+		}
+		if hasConflict {
+			return nil, fmt.Errorf("event conflicts with existing event")
+		}
 	}
 
 	// Update times
@@ -268,6 +267,13 @@ func (h *EventHandler) HandleMoveEvent(ctx context.Context, cmd commands.MoveEve
 	event.EndTime = cmd.EndTime
 	event.UpdatedAt = time.Now()
 
+	// All-day events have to stay on whole days
+	if event.AllDay {
+		if err := h.eventService.ValidateEventCreation(event); err != nil {
+			return nil, fmt.Errorf("event validation failed: %w", err)
+		}
+	}
+
 	// Save updated event
 	if err := h.eventRepo.Update(ctx, event); err != nil {
 		return nil, fmt.Errorf("failed to move event: %w", err)
@@ -299,13 +305,25 @@ func (h *EventHandler) HandleDuplicateEvent(ctx context.Context, cmd commands.Du
 		return nil, fmt.Errorf("end time must be after start time")
 	}
 
-	// Check for conflicts
-	hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, cmd.StartTime, cmd.EndTime, nil)
+	// Copies of events of read-only calendars go to the default calendar
+	calendarID := &originalEvent.CalendarID
+	if err := h.checkWritable(ctx, originalEvent); err != nil {
+		calendarID = nil
+	}
+	calendar, err := h.targetCalendar(ctx, cmd.UserID, calendarID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check for conflicts: %w", err)
+		return nil, err
 	}
-	if hasConflict {
-		return nil, fmt.Errorf("event conflicts with existing event")
+
+	// Check for conflicts
+	if originalEvent.BlocksTime() {
+		hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, cmd.StartTime, cmd.EndTime, nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for conflicts: %w", err)
+		}
+		if hasConflict {
+			return nil, fmt.Errorf("event conflicts with existing event")
+		}
 	}
 
 	// Create duplicated event
@@ -313,22 +331,34 @@ func (h *EventHandler) HandleDuplicateEvent(ctx context.Context, cmd commands.Du
 	newEvent := &entities.Event{
 		ID:             entities.EventID(uuid.New().String()),
 		UserID:         originalEvent.UserID,
+		CalendarID:     calendar.ID,
 		GoalID:         originalEvent.GoalID,
 		Title:          originalEvent.Title + " (Copy)",
 		Description:    originalEvent.Description,
 		StartTime:      cmd.StartTime,
 		EndTime:        cmd.EndTime,
 		Timezone:       originalEvent.Timezone,
+		AllDay:         originalEvent.AllDay,
 		Recurrence:     originalEvent.Recurrence,
 		Location:       originalEvent.Location,
 		Attendees:      originalEvent.Attendees,
+		Reminders:      originalEvent.Reminders,
 		Status:         originalEvent.Status,
+		Color:          originalEvent.Color,
+		Transparent:    originalEvent.Transparent,
 		ExternalID:     "", // Clear external references
 		ExternalSource: "",
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}
 
+	// All-day events have to stay on whole days
+	if newEvent.AllDay {
+		if err := h.eventService.ValidateEventCreation(newEvent); err != nil {
+			return nil, fmt.Errorf("event validation failed: %w", err)
+		}
+	}
+
 	// Save new event
 	if err := h.eventRepo.Create(ctx, newEvent); err != nil {
 		return nil, fmt.Errorf("failed to duplicate event: %w", err)
@@ -356,6 +386,10 @@ func (h *EventHandler) HandleChangeEventStatus(ctx context.Context, cmd commands
 		return nil, fmt.Errorf("access denied: event belongs to different user")
 	}
 
+	if err := h.checkWritable(ctx, event); err != nil {
+		return nil, err
+	}
+
 	// Update status
 	event.Status = cmd.Status
 	event.UpdatedAt = time.Now()
@@ -445,6 +479,11 @@ func (h *EventHandler) HandleUnlinkEventFromGoal(ctx context.Context, cmd comman
 // Query Handlers
 
 func (h *EventHandler) HandleGetEventByID(ctx context.Context, query queries.GetEventByIDQuery) (*queries.GetEventResult, error) {
+	// Occurrence IDs point into a recurring event
+	if recurringEventID, originalStart, ok := entities.ParseOccurrenceID(query.EventID); ok {
+		return h.getOccurrence(ctx, query.UserID, recurringEventID, originalStart)
+	}
+	
 	event, err := h.eventRepo.GetByID(ctx, query.EventID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event: %w", err)
@@ -479,11 +518,65 @@ func (h *EventHandler) HandleGetEventsByUserID(ctx context.Context, query querie
 }
 
 func (h *EventHandler) HandleGetEventsByTimeRange(ctx context.Context, query queries.GetEventsByTimeRangeQuery) (*queries.GetEventsByTimeRangeResult, error) {
-	events, err := h.eventRepo.GetByUserIDAndTimeRange(ctx, query.UserID, query.StartTime, query.EndTime)
+	loc, err := time.LoadLocation(query.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	// The days the range covers for the viewer
+	startDate := entities.DateIn(query.StartTime, loc)
+	endDate := entities.DateIn(query.EndTime, loc)
+	if !query.EndTime.Equal(entities.StartOfDay(endDate, loc)) {
+		endDate = endDate.AddDate(0, 0, 1)
+	}
+
+	calendarIDs := query.CalendarIDs
+	if calendarIDs == nil {
+		if calendarIDs, err = h.visibleCalendarIDs(ctx, query.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	timed, err := h.eventRepo.GetByUserIDAndTimeRange(ctx, query.UserID, query.StartTime, query.EndTime, calendarIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events by time range: %w", err)
 	}
 
+	events, err := h.eventRepo.GetAllDayBetween(ctx, query.UserID, startDate, endDate, calendarIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all-day events: %w", err)
+	}
+	for _, event := range timed {
+		if !event.AllDay {
+			events = append(events, event)
+		}
+	}
+
+	recurring, err := h.eventRepo.GetRecurringStartingBefore(ctx, query.UserID, query.EndTime.AddDate(0, 0, 1), calendarIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring events: %w", err)
+	}
+
+	// Same rules as the stored events: timed occurrences must lie within the
+	// range, all-day ones fall on one of its days
+	events, err = h.expandRecurring(ctx, events, recurring,
+		func(event *entities.Event) []*entities.Event {
+			if event.AllDay {
+				return event.OccurrencesOnDates(startDate, endDate)
+			}
+			return event.OccurrencesBetween(query.StartTime, query.EndTime)
+		},
+		func(occurrence *entities.Event) bool {
+			if occurrence.AllDay {
+				return occurrence.OverlapsDates(startDate, endDate)
+			}
+			return !occurrence.StartTime.Before(query.StartTime) && !occurrence.EndTime.After(query.EndTime)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &queries.GetEventsByTimeRangeResult{
 		Events: events,
 	}, nil
@@ -515,29 +608,147 @@ func (h *EventHandler) HandleGetEventsByGoalID(ctx context.Context, query querie
 }
 
 func (h *EventHandler) HandleGetUpcomingEvents(ctx context.Context, query queries.GetUpcomingEventsQuery) (*queries.GetUpcomingEventsResult, error) {
-	events, err := h.eventRepo.GetUpcoming(ctx, query.UserID, query.Limit)
+	loc, err := time.LoadLocation(query.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	now := time.Now()
+	today := entities.DateIn(now, loc)
+
+	// All-day events are upcoming until their last day is over for the viewer
+	upcoming := func(event *entities.Event) bool {
+		if event.AllDay {
+			_, endDate := event.Dates()
+			return endDate.After(today)
+		}
+		return event.StartTime.After(now)
+	}
+
+	calendarIDs := query.CalendarIDs
+	if calendarIDs == nil {
+		if calendarIDs, err = h.visibleCalendarIDs(ctx, query.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	recurring, err := h.eventRepo.GetRecurring(ctx, query.UserID, calendarIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring events: %w", err)
+	}
+
+	// Recurring series returned here are replaced by occurrences, so ask for
+	// enough rows to still fill the limit with single events
+	events, err := h.eventRepo.GetUpcoming(ctx, query.UserID, query.Limit+len(recurring), calendarIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get upcoming events: %w", err)
 	}
 
+	// The stored all-day events include some that are over for the viewer
+	var single []*entities.Event
+	for _, event := range events {
+		if upcoming(event) {
+			single = append(single, event)
+		}
+	}
+
+	events, err = h.expandRecurring(ctx, single, recurring,
+		func(event *entities.Event) []*entities.Event {
+			if event.Status == entities.EventStatusCancelled {
+				return nil
+			}
+			if event.AllDay {
+				// Occurrences that already started may not be over for the viewer yet
+				return event.OccurrencesAfter(now.AddDate(0, 0, -event.Days()-1), query.Limit+event.Days()+1)
+			}
+			return event.OccurrencesAfter(now, query.Limit)
+		},
+		func(occurrence *entities.Event) bool {
+			return upcoming(occurrence) && occurrence.Status != entities.EventStatusCancelled
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+
 	return &queries.GetUpcomingEventsResult{
 		Events: events,
 	}, nil
 }
 
 func (h *EventHandler) HandleGetTodayEvents(ctx context.Context, query queries.GetTodayEventsQuery) (*queries.GetTodayEventsResult, error) {
-	events, err := h.eventRepo.GetForToday(ctx, query.UserID, query.Timezone)
+	loc, err := time.LoadLocation(query.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	calendarIDs := query.CalendarIDs
+	if calendarIDs == nil {
+		if calendarIDs, err = h.visibleCalendarIDs(ctx, query.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := h.eventRepo.GetForToday(ctx, query.UserID, query.Timezone, calendarIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get today's events: %w", err)
 	}
 
+	today := entities.DateIn(time.Now(), loc)
+	tomorrow := today.AddDate(0, 0, 1)
+	dayStart := entities.StartOfDay(today, loc)
+	dayEnd := entities.StartOfDay(tomorrow, loc)
+
+	// All-day events on today's date, wherever they were created
+	allDay, err := h.eventRepo.GetAllDayBetween(ctx, query.UserID, today, tomorrow, calendarIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all-day events: %w", err)
+	}
+	for _, event := range allDay {
+		if event.Status != entities.EventStatusCancelled {
+			events = append(events, event)
+		}
+	}
+
+	recurring, err := h.eventRepo.GetRecurringStartingBefore(ctx, query.UserID, dayEnd.AddDate(0, 0, 1), calendarIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring events: %w", err)
+	}
+
+	// Timed occurrences that start today and all-day ones on today's date,
+	// matching how stored events are selected
+	events, err = h.expandRecurring(ctx, events, recurring,
+		func(event *entities.Event) []*entities.Event {
+			if event.AllDay {
+				return event.OccurrencesOnDates(today, tomorrow)
+			}
+			return event.OccurrencesBetween(dayStart, dayEnd.Add(-time.Nanosecond))
+		},
+		func(occurrence *entities.Event) bool {
+			if occurrence.Status == entities.EventStatusCancelled {
+				return false
+			}
+			if occurrence.AllDay {
+				return occurrence.OverlapsDates(today, tomorrow)
+			}
+			return !occurrence.StartTime.Before(dayStart) && occurrence.StartTime.Before(dayEnd)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &queries.GetTodayEventsResult{
 		Events: events,
 	}, nil
 }
 
 func (h *EventHandler) HandleGetRecurringEvents(ctx context.Context, query queries.GetRecurringEventsQuery) (*queries.GetRecurringEventsResult, error) {
-	events, err := h.eventRepo.GetRecurring(ctx, query.UserID)
+	events, err := h.eventRepo.GetRecurring(ctx, query.UserID, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recurring events: %w", err)
 	}
@@ -559,7 +770,7 @@ func (h *EventHandler) HandleSearchEvents(ctx context.Context, query queries.Sea
 }
 
 func (h *EventHandler) HandleCheckEventConflict(ctx context.Context, query queries.CheckEventConflictQuery) (*queries.CheckEventConflictResult, error) {
-	hasConflict, err := h.eventRepo.HasConflict(ctx, query.UserID, query.StartTime, query.EndTime, query.ExcludeEventID)
+	hasConflict, err := h.eventRepo.HasConflict(ctx, query.UserID, query.StartTime, query.EndTime, query.ExcludeEventID, query.IncludeAllDay)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check for conflicts: %w", err)
 	}
@@ -567,4 +778,704 @@ func (h *EventHandler) HandleCheckEventConflict(ctx context.Context, query queri
 	return &queries.CheckEventConflictResult{
 		HasConflict: hasConflict,
 	}, nil
-}
\ No newline at end of file
+}
+
+// HandleExportEvents returns the filtered events of a user with the exceptions of the recurring ones
+func (h *EventHandler) HandleExportEvents(ctx context.Context, query queries.ExportEventsQuery) (*queries.ExportEventsResult, error) {
+	events, err := h.eventRepo.GetByUserID(ctx, query.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user events: %w", err)
+	}
+
+	var visible map[entities.CalendarID]bool
+	if query.VisibleOnly {
+		calendarIDs, err := h.visibleCalendarIDs(ctx, query.UserID)
+		if err != nil {
+			return nil, err
+		}
+		visible = make(map[entities.CalendarID]bool, len(calendarIDs))
+		for _, calendarID := range calendarIDs {
+			visible[calendarID] = true
+		}
+	}
+
+	var exported, recurring []*entities.Event
+	for _, event := range events {
+		if query.VisibleOnly && !visible[event.CalendarID] {
+			continue
+		}
+		if query.GoalID != nil && (event.GoalID == nil || *event.GoalID != *query.GoalID) {
+			continue
+		}
+		if query.Status != nil && event.Status != *query.Status {
+			continue
+		}
+		if !exportOverlaps(event, query.StartTime, query.EndTime) {
+			continue
+		}
+
+		exported = append(exported, event)
+		if event.IsRecurring() {
+			recurring = append(recurring, event)
+		}
+	}
+
+	exceptions, err := h.loadExceptions(ctx, recurring)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(exported, func(i, j int) bool {
+		return exported[i].StartTime.Before(exported[j].StartTime)
+	})
+
+	return &queries.ExportEventsResult{
+		Events:     exported,
+		Exceptions: exceptions,
+	}, nil
+}
+
+// exportOverlaps reports whether the event, or any occurrence of a recurring
+// event, overlaps the optional time range
+func exportOverlaps(event *entities.Event, start, end *time.Time) bool {
+	if start != nil && end != nil && !event.IsRecurring() {
+		return event.StartTime.Before(*end) && event.EndTime.After(*start)
+	}
+	if end != nil && !event.StartTime.Before(*end) {
+		return false
+	}
+	if start == nil {
+		return true
+	}
+	if !event.IsRecurring() {
+		return event.EndTime.After(*start)
+	}
+
+	// An occurrence overlapping the range starts at most one duration before it
+	from := start.Add(-event.Duration())
+	if end != nil {
+		return len(event.OccurrencesBetween(from, *end)) > 0
+	}
+	return event.StartTime.After(from) || len(event.OccurrencesAfter(from, 1)) > 0
+}
+
+// applyEventUpdate applies the provided fields of an update command to the event and validates the result
+func (h *EventHandler) applyEventUpdate(ctx context.Context, event *entities.Event, cmd commands.UpdateEventCommand) error {
+	// Update fields if provided
+	if cmd.Title != nil {
+		event.Title = h.eventService.SanitizeEventTitle(*cmd.Title)
+	}
+
+	if cmd.Description != nil {
+		event.Description = h.eventService.SanitizeEventDescription(*cmd.Description)
+	}
+
+	// Dates of all-day events stay the same when the timezone changes
+	startDate, endDate := event.Dates()
+
+	if cmd.Timezone != nil {
+		event.Timezone = *cmd.Timezone
+	}
+
+	if cmd.AllDay != nil {
+		event.AllDay = *cmd.AllDay
+	}
+
+	if cmd.StartTime != nil {
+		event.StartTime = *cmd.StartTime
+	}
+
+	if cmd.EndTime != nil {
+		event.EndTime = *cmd.EndTime
+	}
+
+	// Dates make the event all-day; an event turned all-day without new
+	// times covers the days it was on
+	if cmd.StartDate != nil || cmd.EndDate != nil {
+		startDate, endDate = changeDates(startDate, endDate, cmd.StartDate, cmd.EndDate)
+		event.AllDay = true
+	}
+	if event.AllDay && cmd.StartTime == nil && cmd.EndTime == nil {
+		event.StartTime, event.EndTime = entities.AllDayTimes(startDate, endDate, event.TimeLocation())
+	}
+
+	// Validate time range after updates
+	if event.EndTime.Before(event.StartTime) || event.EndTime.Equal(event.StartTime) {
+		return fmt.Errorf("end time must be after start time")
+	}
+
+	// Check for conflicts if time changed
+	timeChanged := cmd.StartTime != nil || cmd.EndTime != nil || cmd.StartDate != nil || cmd.EndDate != nil || cmd.AllDay != nil
+	if timeChanged && event.BlocksTime() {
+		hasConflict, err := h.eventRepo.HasConflict(ctx, cmd.UserID, event.StartTime, event.EndTime, &event.ID, false)
+		if err != nil {
+			return fmt.Errorf("failed to check for conflicts: %w", err)
+		}
+		if hasConflict {
+			return fmt.Errorf("event conflicts with existing event")
+		}
+	}
+
+	if cmd.Recurrence != nil {
+		event.Recurrence = cmd.Recurrence
+	}
+
+	if cmd.Location != nil {
+		event.Location = *cmd.Location
+	}
+
+	if cmd.Attendees != nil {
+		event.Attendees = *cmd.Attendees
+	}
+
+	if cmd.UseDefaultReminders {
+		event.Reminders = nil
+	} else if cmd.Reminders != nil {
+		event.Reminders = *cmd.Reminders
+	}
+
+	if cmd.Status != nil {
+		event.Status = *cmd.Status
+	}
+
+	if cmd.GoalID != nil {
+		// Validate goal ownership
+		goal, err := h.goalRepo.GetByID(ctx, *cmd.GoalID)
+		if err != nil {
+			return fmt.Errorf("failed to get goal: %w", err)
+		}
+		if goal == nil {
+			return fmt.Errorf("goal not found")
+		}
+		if goal.UserID != cmd.UserID {
+			return fmt.Errorf("access denied: goal belongs to different user")
+		}
+		event.GoalID = cmd.GoalID
+	}
+
+	if cmd.ExternalID != nil {
+		event.ExternalID = *cmd.ExternalID
+	}
+
+	if cmd.ExternalSource != nil {
+		event.ExternalSource = *cmd.ExternalSource
+	}
+
+	if cmd.CalendarID != nil && *cmd.CalendarID != event.CalendarID {
+		if err := h.moveToCalendar(ctx, event, *cmd.CalendarID); err != nil {
+			return err
+		}
+	}
+
+	// Validate updated event
+	if err := h.eventService.ValidateEventCreation(event); err != nil {
+		return fmt.Errorf("event validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// updateOccurrence stores the changes of a single occurrence as an exception
+func (h *EventHandler) updateOccurrence(ctx context.Context, event *entities.Event, recurrenceID time.Time, cmd commands.UpdateEventCommand) (*commands.UpdateEventResult, error) {
+	if cmd.Recurrence != nil || cmd.GoalID != nil || cmd.CalendarID != nil || cmd.Timezone != nil || cmd.AllDay != nil || cmd.Reminders != nil || cmd.UseDefaultReminders || cmd.ExternalID != nil || cmd.ExternalSource != nil {
+		return nil, fmt.Errorf("only title, description, time, location, attendees and status can be changed for a single occurrence")
+	}
+
+	exception, err := h.exceptionRepo.GetByRecurrenceID(ctx, event.ID, recurrenceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get occurrence exception: %w", err)
+	}
+
+	now := time.Now()
+	if exception == nil {
+		exception = &entities.EventException{
+			ID:           uuid.New().String(),
+			EventID:      event.ID,
+			RecurrenceID: recurrenceID,
+			CreatedAt:    now,
+		}
+	}
+
+	// Editing a cancelled occurrence brings it back
+	exception.Cancelled = false
+
+	if cmd.Title != nil {
+		title := h.eventService.SanitizeEventTitle(*cmd.Title)
+		exception.Title = &title
+	}
+
+	if cmd.Description != nil {
+		description := h.eventService.SanitizeEventDescription(*cmd.Description)
+		exception.Description = &description
+	}
+
+	if cmd.Location != nil {
+		exception.Location = cmd.Location
+	}
+
+	if cmd.Attendees != nil {
+		exception.Attendees = *cmd.Attendees
+	}
+
+	if cmd.Status != nil {
+		exception.Status = cmd.Status
+	}
+
+	if cmd.StartTime != nil || cmd.EndTime != nil || cmd.StartDate != nil || cmd.EndDate != nil {
+		current := exception.Apply(event.Occurrence(recurrenceID))
+		start, end := current.StartTime, current.EndTime
+		if cmd.StartTime != nil {
+			start = *cmd.StartTime
+		}
+		if cmd.EndTime != nil {
+			end = *cmd.EndTime
+		}
+		if cmd.StartDate != nil || cmd.EndDate != nil {
+			if !event.AllDay {
+				return nil, fmt.Errorf("dates can only be changed for occurrences of all-day events")
+			}
+			startDate, endDate := current.Dates()
+			startDate, endDate = changeDates(startDate, endDate, cmd.StartDate, cmd.EndDate)
+			start, end = entities.AllDayTimes(startDate, endDate, event.TimeLocation())
+		}
+		exception.StartTime = &start
+		exception.EndTime = &end
+	}
+
+	// Validate the resulting occurrence
+	occurrence := exception.Apply(event.Occurrence(recurrenceID))
+	if err := h.eventService.ValidateEventCreation(occurrence); err != nil {
+		return nil, fmt.Errorf("event validation failed: %w", err)
+	}
+
+	exception.UpdatedAt = now
+	if err := h.exceptionRepo.Upsert(ctx, exception); err != nil {
+		return nil, fmt.Errorf("failed to update occurrence: %w", err)
+	}
+	if err := h.touchSeries(ctx, event, now); err != nil {
+		return nil, err
+	}
+
+	return &commands.UpdateEventResult{
+		UpdatedAt: now,
+	}, nil
+}
+
+// touchSeries marks a recurring event as changed after one of its
+// exceptions was written. Exceptions are pushed to Google along with their
+// series, cancelled ones as excluded dates and modified ones as instances,
+// so this queues the push for a synced calendar.
+func (h *EventHandler) touchSeries(ctx context.Context, event *entities.Event, now time.Time) error {
+	event.UpdatedAt = now
+	if err := h.eventRepo.Update(ctx, event); err != nil {
+		return fmt.Errorf("failed to update recurring event: %w", err)
+	}
+	return nil
+}
+
+// updateFollowing splits the series at the given occurrence: the original
+// event ends right before it and a new series with the changes takes over
+func (h *EventHandler) updateFollowing(ctx context.Context, event *entities.Event, splitAt time.Time, cmd commands.UpdateEventCommand) (*commands.UpdateEventResult, error) {
+	head, tail := event.Recurrence.SplitAt(event.StartTime, splitAt, event.TimeLocation())
+	now := time.Now()
+
+	following := event.Occurrence(splitAt)
+	following.ID = entities.EventID(uuid.New().String())
+	following.Recurrence = tail
+	following.RecurringEventID = nil
+	following.OriginalStartTime = nil
+	following.ExternalID = ""
+	following.ExternalSource = ""
+	following.CreatedAt = now
+	following.UpdatedAt = now
+
+	if err := h.applyEventUpdate(ctx, following, cmd); err != nil {
+		return nil, err
+	}
+
+	if err := h.eventRepo.Create(ctx, following); err != nil {
+		return nil, fmt.Errorf("failed to create following events: %w", err)
+	}
+
+	if err := h.moveExceptions(ctx, event, following, splitAt, cmd, now); err != nil {
+		_ = h.eventRepo.Delete(ctx, following.ID)
+		return nil, err
+	}
+
+	// Rewrite the original rule so it stops before the split
+	event.Recurrence = head
+	event.UpdatedAt = now
+
+	if err := h.eventRepo.Update(ctx, event); err != nil {
+		// Don't leave both series overlapping
+		_ = h.eventRepo.Delete(ctx, following.ID)
+		return nil, fmt.Errorf("failed to truncate recurring event: %w", err)
+	}
+
+	// Exceptions from the split on were copied to the new series
+	if err := h.exceptionRepo.DeleteFrom(ctx, event.ID, splitAt); err != nil {
+		return nil, fmt.Errorf("failed to delete exceptions: %w", err)
+	}
+
+	return &commands.UpdateEventResult{
+		EventID:   following.ID,
+		UpdatedAt: now,
+	}, nil
+}
+
+// moveExceptions copies the exceptions of the occurrences from the split on
+// to the new series that takes them over, keyed by their start in it: moved
+// along with the series' start. Those no longer matching an occurrence, e.g.
+// after a change of the rule, are dropped. At the split the changes of the
+// update take precedence over the exception.
+func (h *EventHandler) moveExceptions(ctx context.Context, event, following *entities.Event, splitAt time.Time, cmd commands.UpdateEventCommand, now time.Time) error {
+	exceptions, err := h.exceptionRepo.GetByEventID(ctx, event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get event exceptions: %w", err)
+	}
+
+	shift := following.StartTime.Sub(splitAt)
+	for _, exception := range exceptions {
+		if exception.RecurrenceID.Before(splitAt) {
+			continue
+		}
+
+		moved := *exception
+		moved.ID = uuid.New().String()
+		moved.EventID = following.ID
+		moved.RecurrenceID = exception.RecurrenceID.Add(shift)
+		moved.UpdatedAt = now
+		if !following.HasOccurrenceAt(moved.RecurrenceID) {
+			continue
+		}
+
+		// Editing a cancelled occurrence brings it back
+		if exception.RecurrenceID.Equal(splitAt) {
+			moved.Cancelled = false
+			clearUpdatedFields(&moved, cmd)
+			if !moved.IsModified() {
+				continue
+			}
+		}
+
+		if err := h.exceptionRepo.Upsert(ctx, &moved); err != nil {
+			return fmt.Errorf("failed to move event exception: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// clearUpdatedFields removes the overrides of an exception for the fields
+// an update sets
+func clearUpdatedFields(exception *entities.EventException, cmd commands.UpdateEventCommand) {
+	if cmd.Title != nil {
+		exception.Title = nil
+	}
+	if cmd.Description != nil {
+		exception.Description = nil
+	}
+	if cmd.StartTime != nil || cmd.EndTime != nil || cmd.StartDate != nil || cmd.EndDate != nil || cmd.AllDay != nil || cmd.Timezone != nil {
+		exception.StartTime, exception.EndTime = nil, nil
+	}
+	if cmd.Location != nil {
+		exception.Location = nil
+	}
+	if cmd.Attendees != nil {
+		exception.Attendees = nil
+	}
+	if cmd.Status != nil {
+		exception.Status = nil
+	}
+}
+
+// getEventForChange loads the event an ID refers to and checks ownership
+// and that its calendar can be changed. For occurrence IDs it returns the
+// recurring event and the occurrence's original start.
+func (h *EventHandler) getEventForChange(ctx context.Context, eventID entities.EventID, userID entities.UserID) (*entities.Event, *time.Time, error) {
+	var occurrenceStart *time.Time
+	if recurringEventID, originalStart, ok := entities.ParseOccurrenceID(eventID); ok {
+		eventID = recurringEventID
+		occurrenceStart = &originalStart
+	}
+
+	event, err := h.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	if event == nil {
+		return nil, nil, fmt.Errorf("event not found")
+	}
+
+	// Check ownership
+	if event.UserID != userID {
+		return nil, nil, fmt.Errorf("access denied: event belongs to different user")
+	}
+
+	if occurrenceStart != nil && !event.HasOccurrenceAt(*occurrenceStart) {
+		return nil, nil, fmt.Errorf("event not found")
+	}
+
+	if err := h.checkWritable(ctx, event); err != nil {
+		return nil, nil, err
+	}
+
+	return event, occurrenceStart, nil
+}
+
+// targetCalendar returns the calendar of the user new events go to: the
+// given one, or the default calendar when calendarID is nil
+func (h *EventHandler) targetCalendar(ctx context.Context, userID entities.UserID, calendarID *entities.CalendarID) (*entities.Calendar, error) {
+	var calendar *entities.Calendar
+	var err error
+	if calendarID == nil {
+		calendar, err = h.calendarRepo.GetOrCreateDefault(ctx, userID)
+	} else {
+		calendar, err = h.calendarRepo.GetByID(ctx, *calendarID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	if calendar == nil || calendar.UserID != userID {
+		return nil, ErrCalendarNotFound
+	}
+
+	if calendar.ReadOnly {
+		return nil, ErrCalendarReadOnly
+	}
+
+	return calendar, nil
+}
+
+// checkWritable refuses changes to events of read-only calendars, which sync
+// would overwrite
+func (h *EventHandler) checkWritable(ctx context.Context, event *entities.Event) error {
+	calendar, err := h.calendarRepo.GetByID(ctx, event.CalendarID)
+	if err != nil {
+		return fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	if calendar != nil && calendar.ReadOnly {
+		return ErrCalendarReadOnly
+	}
+
+	return nil
+}
+
+// moveToCalendar moves an event to another calendar of its user. Events of
+// synced calendars stay linked to their remote copy, so they can't leave
+// their calendar.
+func (h *EventHandler) moveToCalendar(ctx context.Context, event *entities.Event, calendarID entities.CalendarID) error {
+	current, err := h.calendarRepo.GetByID(ctx, event.CalendarID)
+	if err != nil {
+		return fmt.Errorf("failed to get calendar: %w", err)
+	}
+	if current != nil && current.IsSynced() {
+		return fmt.Errorf("events of synced calendars can't be moved to another calendar")
+	}
+
+	calendar, err := h.targetCalendar(ctx, event.UserID, &calendarID)
+	if err != nil {
+		return err
+	}
+
+	event.CalendarID = calendar.ID
+	return nil
+}
+
+// visibleCalendarIDs returns the calendars of the user shown by default
+func (h *EventHandler) visibleCalendarIDs(ctx context.Context, userID entities.UserID) ([]entities.CalendarID, error) {
+	calendars, err := h.calendarRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendars: %w", err)
+	}
+
+	calendarIDs := []entities.CalendarID{}
+	for _, calendar := range calendars {
+		if calendar.Visible {
+			calendarIDs = append(calendarIDs, calendar.ID)
+		}
+	}
+
+	return calendarIDs, nil
+}
+
+// resolveRecurrenceScope validates the requested scope. Occurrence IDs default
+// to "this", series IDs to "all". When a narrower scope is requested for a
+// series ID, it applies from the first occurrence. Splitting at the first
+// occurrence is the same as changing the whole series.
+func resolveRecurrenceScope(event *entities.Event, occurrenceStart *time.Time, scope entities.RecurrenceScope) (entities.RecurrenceScope, *time.Time, error) {
+	if !event.IsRecurring() {
+		return entities.RecurrenceScopeAll, nil, nil
+	}
+
+	if scope == "" {
+		if occurrenceStart != nil {
+			return entities.RecurrenceScopeThis, occurrenceStart, nil
+		}
+		return entities.RecurrenceScopeAll, nil, nil
+	}
+
+	if !scope.IsValid() {
+		return "", nil, fmt.Errorf("invalid scope: %s", scope)
+	}
+
+	if scope == entities.RecurrenceScopeAll {
+		return scope, occurrenceStart, nil
+	}
+
+	if occurrenceStart == nil {
+		start := event.StartTime
+		occurrenceStart = &start
+	}
+
+	if scope == entities.RecurrenceScopeThisAndFollowing && occurrenceStart.Equal(event.StartTime) {
+		return entities.RecurrenceScopeAll, occurrenceStart, nil
+	}
+
+	return scope, occurrenceStart, nil
+}
+
+// shiftToSeriesStart translates times given for an occurrence into times of
+// the series start, keeping the same offset
+func shiftToSeriesStart(event *entities.Event, occurrenceStart time.Time, cmd commands.UpdateEventCommand) commands.UpdateEventCommand {
+	if cmd.StartTime != nil {
+		start := event.StartTime.Add(cmd.StartTime.Sub(occurrenceStart))
+		cmd.StartTime = &start
+	}
+
+	if cmd.EndTime != nil {
+		end := event.StartTime.Add(cmd.EndTime.Sub(occurrenceStart))
+		cmd.EndTime = &end
+	}
+
+	// Dates move by the same number of days
+	loc := event.TimeLocation()
+	offset := entities.DateIn(event.StartTime, loc).Sub(entities.DateIn(occurrenceStart, loc))
+
+	if cmd.StartDate != nil {
+		startDate := cmd.StartDate.Add(offset)
+		cmd.StartDate = &startDate
+	}
+
+	if cmd.EndDate != nil {
+		endDate := cmd.EndDate.Add(offset)
+		cmd.EndDate = &endDate
+	}
+
+	return cmd
+}
+
+// changeDates applies new dates of an all-day event to its current ones.
+// Moving only the first day keeps the number of days.
+func changeDates(startDate, endDate time.Time, newStart, newEnd *time.Time) (time.Time, time.Time) {
+	if newStart != nil {
+		endDate = newStart.Add(endDate.Sub(startDate))
+		startDate = *newStart
+	}
+	if newEnd != nil {
+		endDate = *newEnd
+	}
+	return startDate, endDate
+}
+
+// getOccurrence resolves a single virtual occurrence of a recurring event
+func (h *EventHandler) getOccurrence(ctx context.Context, userID entities.UserID, recurringEventID entities.EventID, originalStart time.Time) (*queries.GetEventResult, error) {
+	event, err := h.eventRepo.GetByID(ctx, recurringEventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	if event == nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	// Check ownership before revealing anything about the series
+	if event.UserID != userID {
+		return nil, fmt.Errorf("access denied: event belongs to different user")
+	}
+
+	if !event.IsRecurring() || !event.HasOccurrenceAt(originalStart) {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	occurrence := event.Occurrence(originalStart)
+
+	exception, err := h.exceptionRepo.GetByRecurrenceID(ctx, event.ID, originalStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get occurrence exception: %w", err)
+	}
+
+	if exception != nil {
+		if exception.Cancelled {
+			return nil, fmt.Errorf("event not found")
+		}
+		occurrence = exception.Apply(occurrence)
+	}
+
+	return &queries.GetEventResult{
+		Event: occurrence,
+	}, nil
+}
+
+// expandRecurring replaces recurring events in the stored list with their
+// occurrences and returns everything sorted by start time. generate expands
+// a series, keep filters occurrences after exceptions have been applied.
+func (h *EventHandler) expandRecurring(
+	ctx context.Context,
+	events, recurring []*entities.Event,
+	generate func(*entities.Event) []*entities.Event,
+	keep func(*entities.Event) bool,
+) ([]*entities.Event, error) {
+	merged := make([]*entities.Event, 0, len(events))
+	for _, event := range events {
+		if !event.IsRecurring() {
+			merged = append(merged, event)
+		}
+	}
+
+	exceptions, err := h.loadExceptions(ctx, recurring)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range recurring {
+		for _, occurrence := range event.ResolveOccurrences(generate(event), exceptions[event.ID]) {
+			if keep(occurrence) {
+				merged = append(merged, occurrence)
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].StartTime.Before(merged[j].StartTime)
+	})
+
+	return merged, nil
+}
+
+// loadExceptions fetches the exceptions of the given recurring events grouped by event
+func (h *EventHandler) loadExceptions(ctx context.Context, recurring []*entities.Event) (map[entities.EventID][]*entities.EventException, error) {
+	if len(recurring) == 0 {
+		return nil, nil
+	}
+
+	eventIDs := make([]entities.EventID, len(recurring))
+	for i, event := range recurring {
+		eventIDs[i] = event.ID
+	}
+
+	exceptions, err := h.exceptionRepo.GetByEventIDs(ctx, eventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event exceptions: %w", err)
+	}
+
+	byEvent := make(map[entities.EventID][]*entities.EventException)
+	for _, exception := range exceptions {
+		byEvent[exception.EventID] = append(byEvent[exception.EventID], exception)
+	}
+
+	return byEvent, nil
+}
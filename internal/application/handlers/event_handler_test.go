@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+type memoryEvents struct {
+	repositories.EventRepository
+	events map[entities.EventID]*entities.Event
+}
+
+func (m *memoryEvents) GetByID(_ context.Context, id entities.EventID) (*entities.Event, error) {
+	stored, ok := m.events[id]
+	if !ok {
+		return nil, nil
+	}
+	event := *stored
+	return &event, nil
+}
+
+func (m *memoryEvents) Create(_ context.Context, event *entities.Event) error {
+	stored := *event
+	m.events[event.ID] = &stored
+	return nil
+}
+
+func (m *memoryEvents) Update(_ context.Context, event *entities.Event) error {
+	stored := *event
+	m.events[event.ID] = &stored
+	return nil
+}
+
+func (m *memoryEvents) Delete(_ context.Context, id entities.EventID) error {
+	delete(m.events, id)
+	return nil
+}
+
+func (m *memoryEvents) HasConflict(context.Context, entities.UserID, time.Time, time.Time, *entities.EventID, bool) (bool, error) {
+	return false, nil
+}
+
+type memoryExceptions struct {
+	repositories.EventExceptionRepository
+	exceptions []*entities.EventException
+}
+
+func (m *memoryExceptions) Upsert(_ context.Context, exception *entities.EventException) error {
+	stored := *exception
+	for i, existing := range m.exceptions {
+		if existing.EventID == exception.EventID && existing.RecurrenceID.Equal(exception.RecurrenceID) {
+			m.exceptions[i] = &stored
+			return nil
+		}
+	}
+	m.exceptions = append(m.exceptions, &stored)
+	return nil
+}
+
+func (m *memoryExceptions) GetByEventID(_ context.Context, eventID entities.EventID) ([]*entities.EventException, error) {
+	var exceptions []*entities.EventException
+	for _, stored := range m.exceptions {
+		if stored.EventID == eventID {
+			exception := *stored
+			exceptions = append(exceptions, &exception)
+		}
+	}
+	return exceptions, nil
+}
+
+func (m *memoryExceptions) DeleteFrom(_ context.Context, eventID entities.EventID, from time.Time) error {
+	kept := m.exceptions[:0]
+	for _, exception := range m.exceptions {
+		if exception.EventID != eventID || exception.RecurrenceID.Before(from) {
+			kept = append(kept, exception)
+		}
+	}
+	m.exceptions = kept
+	return nil
+}
+
+func (m *memoryExceptions) byRecurrenceID(eventID entities.EventID) map[time.Time]*entities.EventException {
+	exceptions := make(map[time.Time]*entities.EventException)
+	for _, exception := range m.exceptions {
+		if exception.EventID == eventID {
+			exceptions[exception.RecurrenceID.UTC()] = exception
+		}
+	}
+	return exceptions
+}
+
+type fakeCalendars struct {
+	repositories.CalendarRepository
+}
+
+func (fakeCalendars) GetByID(context.Context, entities.CalendarID) (*entities.Calendar, error) {
+	return nil, nil
+}
+
+func TestUpdateFollowingMovesExceptions(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return start.AddDate(0, 0, n) }
+	ptr := func(s string) *string { return &s }
+
+	series := &entities.Event{
+		ID:         "series",
+		UserID:     "user-1",
+		CalendarID: "calendar-1",
+		Title:      "Stand-up",
+		StartTime:  start,
+		EndTime:    start.Add(15 * time.Minute),
+		Timezone:   "UTC",
+		Status:     entities.EventStatusConfirmed,
+		Recurrence: &entities.RecurrenceRule{Frequency: entities.FrequencyDaily, Interval: 1},
+	}
+	movedStart, movedEnd := day(6).Add(3*time.Hour), day(6).Add(3*time.Hour+15*time.Minute)
+	events := &memoryEvents{events: map[entities.EventID]*entities.Event{series.ID: series}}
+	exceptions := &memoryExceptions{exceptions: []*entities.EventException{
+		{ID: "before", EventID: series.ID, RecurrenceID: day(2), Title: ptr("Planning")},
+		{ID: "split", EventID: series.ID, RecurrenceID: day(4), Title: ptr("Retro"), Location: ptr("Room 1")},
+		{ID: "moved", EventID: series.ID, RecurrenceID: day(6), StartTime: &movedStart, EndTime: &movedEnd},
+		{ID: "cancelled", EventID: series.ID, RecurrenceID: day(7), Cancelled: true},
+	}}
+	handler := NewEventHandler(events, exceptions, fakeCalendars{}, nil, services.NewEventService())
+
+	// Renamed and an hour later from the fifth occurrence on
+	newStart := day(4).Add(time.Hour)
+	newEnd := newStart.Add(15 * time.Minute)
+	result, err := handler.HandleUpdateEvent(ctx, commands.UpdateEventCommand{
+		EventID:   entities.OccurrenceID(series.ID, day(4)),
+		UserID:    "user-1",
+		Title:     ptr("Daily"),
+		StartTime: &newStart,
+		EndTime:   &newEnd,
+		Scope:     entities.RecurrenceScopeThisAndFollowing,
+	})
+	if err != nil {
+		t.Fatalf("HandleUpdateEvent: %v", err)
+	}
+
+	old := exceptions.byRecurrenceID(series.ID)
+	if len(old) != 1 || old[day(2)] == nil {
+		t.Errorf("exceptions of the old series = %v, want only the one before the split", old)
+	}
+
+	moved := exceptions.byRecurrenceID(result.EventID)
+	if len(moved) != 3 {
+		t.Fatalf("exceptions of the new series = %v, want 3", moved)
+	}
+	split := moved[day(4).Add(time.Hour)]
+	if split == nil || split.Title != nil || split.Location == nil || *split.Location != "Room 1" {
+		t.Errorf("split exception = %+v, want the location kept and the title of the update", split)
+	}
+	if exception := moved[day(6).Add(time.Hour)]; exception == nil || !exception.StartTime.Equal(movedStart) {
+		t.Errorf("moved exception = %+v, want its start kept at %v", exception, movedStart)
+	}
+	if exception := moved[day(7).Add(time.Hour)]; exception == nil || !exception.Cancelled {
+		t.Errorf("cancelled exception = %+v, want it still cancelled", exception)
+	}
+
+	following, _ := events.GetByID(ctx, result.EventID)
+	occurrences := following.ResolveOccurrences(following.OccurrencesBetween(day(4), day(8)), exceptions.exceptions)
+	var titles []string
+	for _, occurrence := range occurrences {
+		titles = append(titles, occurrence.Title)
+	}
+	if len(occurrences) != 3 || occurrences[0].Location != "Room 1" || !occurrences[2].StartTime.Equal(movedStart) {
+		t.Errorf("occurrences of the new series = %v", titles)
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// importPlan is what importing a single UID would do
+type importPlan struct {
+	outcome    commands.ImportEventOutcome
+	event      *entities.Event
+	exceptions []*entities.EventException
+}
+
+// HandleImportEvents creates events for new UIDs, updates events whose UID
+// was imported or exported before and skips unchanged or invalid entries.
+// All changes are written in one transaction, so a failed import changes
+// nothing.
+func (h *EventHandler) HandleImportEvents(ctx context.Context, cmd commands.ImportEventsCommand) (*commands.ImportEventsResult, error) {
+	result := &commands.ImportEventsResult{
+		DryRun: cmd.DryRun,
+		Events: make([]commands.ImportEventOutcome, 0, len(cmd.Events)),
+	}
+
+	calendar, err := h.targetCalendar(ctx, cmd.UserID, cmd.CalendarID)
+	if err != nil {
+		return nil, err
+	}
+	cmd.CalendarID = &calendar.ID
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	var created, updated []*entities.Event
+	var exceptions []*entities.EventException
+
+	for _, imported := range cmd.Events {
+		plan, err := h.planImport(ctx, cmd, imported, seen, now)
+		if err != nil {
+			return nil, err
+		}
+
+		switch plan.outcome.Action {
+		case commands.ImportActionCreated:
+			result.CreatedCount++
+			created = append(created, plan.event)
+			exceptions = append(exceptions, plan.exceptions...)
+		case commands.ImportActionUpdated:
+			result.UpdatedCount++
+			updated = append(updated, plan.event)
+			exceptions = append(exceptions, plan.exceptions...)
+		default:
+			result.SkippedCount++
+		}
+
+		result.Events = append(result.Events, plan.outcome)
+	}
+
+	if cmd.DryRun || (len(created) == 0 && len(updated) == 0) {
+		return result, nil
+	}
+
+	if err := h.eventRepo.Import(ctx, created, updated, exceptions); err != nil {
+		return nil, fmt.Errorf("failed to import events: %w", err)
+	}
+
+	return result, nil
+}
+
+func (h *EventHandler) planImport(
+	ctx context.Context,
+	cmd commands.ImportEventsCommand,
+	imported *commands.ImportedEvent,
+	seen map[string]bool,
+	now time.Time,
+) (*importPlan, error) {
+	plan := &importPlan{
+		outcome: commands.ImportEventOutcome{
+			UID:    imported.UID,
+			Action: commands.ImportActionSkipped,
+		},
+	}
+
+	switch {
+	case imported.Err != nil:
+		plan.outcome.Reason = imported.Err.Error()
+		return plan, nil
+	case imported.UID == "":
+		plan.outcome.Reason = "missing UID"
+		return plan, nil
+	case seen[imported.UID]:
+		plan.outcome.Reason = "duplicate UID"
+		return plan, nil
+	}
+	seen[imported.UID] = true
+
+	incoming := *imported.Event
+	incoming.UserID = cmd.UserID
+	incoming.Title = h.eventService.SanitizeEventTitle(incoming.Title)
+	incoming.Description = h.eventService.SanitizeEventDescription(incoming.Description)
+	incoming.ExternalID = imported.UID
+	incoming.ExternalSource = cmd.ExternalSource
+	if err := h.eventService.ValidateEventCreation(&incoming); err != nil {
+		plan.outcome.Reason = err.Error()
+		return plan, nil
+	}
+
+	existing, err := h.importedEvent(ctx, cmd, imported)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		incoming.ID = entities.EventID(uuid.New().String())
+		incoming.CalendarID = *cmd.CalendarID
+		incoming.CreatedAt = now
+		incoming.UpdatedAt = now
+
+		plan.event = &incoming
+		plan.exceptions = prepareExceptions(incoming.ID, imported.Exceptions, now)
+		plan.outcome.EventID = incoming.ID
+		plan.outcome.Action = commands.ImportActionCreated
+		return plan, nil
+	}
+
+	plan.outcome.EventID = existing.ID
+	if existing.ID != imported.EventID && existing.ExternalSource != cmd.ExternalSource {
+		plan.outcome.Reason = fmt.Sprintf("UID belongs to an event from %s", existing.ExternalSource)
+		return plan, nil
+	}
+
+	if err := h.checkWritable(ctx, existing); err != nil {
+		if errors.Is(err, ErrCalendarReadOnly) {
+			plan.outcome.Reason = err.Error()
+			return plan, nil
+		}
+		return nil, err
+	}
+
+	existingExceptions, err := h.exceptionRepo.GetByEventID(ctx, existing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event exceptions: %w", err)
+	}
+
+	if !importedEventChanged(existing, &incoming) && !exceptionsChanged(existingExceptions, imported.Exceptions) {
+		plan.outcome.Reason = "unchanged"
+		return plan, nil
+	}
+
+	updated := *existing
+	updated.Title = incoming.Title
+	updated.Description = incoming.Description
+	updated.StartTime = incoming.StartTime
+	updated.EndTime = incoming.EndTime
+	updated.Timezone = incoming.Timezone
+	updated.AllDay = incoming.AllDay
+	updated.Recurrence = incoming.Recurrence
+	updated.Location = incoming.Location
+	updated.Attendees = incoming.Attendees
+	updated.Status = incoming.Status
+	updated.UpdatedAt = now
+
+	plan.event = &updated
+	plan.exceptions = prepareExceptions(existing.ID, imported.Exceptions, now)
+	plan.outcome.Action = commands.ImportActionUpdated
+	return plan, nil
+}
+
+// importedEvent returns the event an imported UID refers to: the exported
+// event itself for our own UIDs, otherwise the event imported with it
+func (h *EventHandler) importedEvent(ctx context.Context, cmd commands.ImportEventsCommand, imported *commands.ImportedEvent) (*entities.Event, error) {
+	if imported.EventID != "" {
+		event, err := h.eventRepo.GetByID(ctx, imported.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event: %w", err)
+		}
+		if event != nil && event.UserID == cmd.UserID {
+			return event, nil
+		}
+	}
+
+	event, err := h.eventRepo.GetByExternalID(ctx, cmd.UserID, imported.UID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event by external ID: %w", err)
+	}
+	return event, nil
+}
+
+// prepareExceptions assigns IDs and the owning event to imported exceptions
+func prepareExceptions(eventID entities.EventID, imported []*entities.EventException, now time.Time) []*entities.EventException {
+	exceptions := make([]*entities.EventException, len(imported))
+	for i, exception := range imported {
+		prepared := *exception
+		prepared.ID = uuid.New().String()
+		prepared.EventID = eventID
+		prepared.CreatedAt = now
+		prepared.UpdatedAt = now
+		exceptions[i] = &prepared
+	}
+	return exceptions
+}
+
+func importedEventChanged(existing, incoming *entities.Event) bool {
+	if existing.Title != incoming.Title ||
+		existing.Description != incoming.Description ||
+		existing.Location != incoming.Location ||
+		existing.Timezone != incoming.Timezone ||
+		existing.AllDay != incoming.AllDay ||
+		existing.Status != incoming.Status ||
+		!existing.StartTime.Equal(incoming.StartTime) ||
+		!existing.EndTime.Equal(incoming.EndTime) {
+		return true
+	}
+
+	if recurrenceString(existing.Recurrence) != recurrenceString(incoming.Recurrence) {
+		return true
+	}
+
+	if len(existing.Attendees) != len(incoming.Attendees) {
+		return true
+	}
+	for i := range existing.Attendees {
+		a, b := existing.Attendees[i], incoming.Attendees[i]
+		if a.Email != b.Email || a.Name != b.Name || a.Status != b.Status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func recurrenceString(rule *entities.RecurrenceRule) string {
+	if rule == nil {
+		return ""
+	}
+	return rule.ToRRULE()
+}
+
+// exceptionsChanged compares exceptions by content, ignoring IDs and timestamps
+func exceptionsChanged(existing, incoming []*entities.EventException) bool {
+	if len(existing) != len(incoming) {
+		return true
+	}
+
+	a, b := exceptionKeys(existing), exceptionKeys(incoming)
+	for i := range a {
+		if a[i] != b[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func exceptionKeys(exceptions []*entities.EventException) []string {
+	keys := make([]string, len(exceptions))
+	for i, exception := range exceptions {
+		normalized := *exception
+		normalized.ID = ""
+		normalized.EventID = ""
+		normalized.CreatedAt = time.Time{}
+		normalized.UpdatedAt = time.Time{}
+		normalized.RecurrenceID = exception.RecurrenceID.UTC()
+		if exception.StartTime != nil {
+			start := exception.StartTime.UTC()
+			normalized.StartTime = &start
+		}
+		if exception.EndTime != nil {
+			end := exception.EndTime.UTC()
+			normalized.EndTime = &end
+		}
+
+		data, _ := json.Marshal(normalized)
+		keys[i] = string(data)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
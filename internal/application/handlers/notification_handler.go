@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type NotificationHandler struct {
+	notificationRepo repositories.NotificationRepository
+}
+
+func NewNotificationHandler(notificationRepo repositories.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// HandleGetNotifications lists the in-app notifications delivered to a user
+func (h *NotificationHandler) HandleGetNotifications(ctx context.Context, query queries.GetNotificationsQuery) (*queries.GetNotificationsResult, error) {
+	notifications, err := h.notificationRepo.GetInApp(ctx, query.UserID, query.UnreadOnly, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	return &queries.GetNotificationsResult{
+		Notifications: notifications,
+	}, nil
+}
+
+// HandleMarkNotificationRead marks an in-app notification as read. Reading
+// it again keeps the time it was first read.
+func (h *NotificationHandler) HandleMarkNotificationRead(ctx context.Context, cmd commands.MarkNotificationReadCommand) (*commands.MarkNotificationsReadResult, error) {
+	if _, err := uuid.Parse(cmd.NotificationID); err != nil {
+		return nil, ErrNotificationNotFound
+	}
+
+	if err := h.notificationRepo.MarkRead(ctx, cmd.NotificationID, cmd.UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+
+	return &commands.MarkNotificationsReadResult{
+		ReadAt: time.Now(),
+	}, nil
+}
+
+func (h *NotificationHandler) HandleMarkAllNotificationsRead(ctx context.Context, cmd commands.MarkAllNotificationsReadCommand) (*commands.MarkNotificationsReadResult, error) {
+	if err := h.notificationRepo.MarkAllRead(ctx, cmd.UserID); err != nil {
+		return nil, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	return &commands.MarkNotificationsReadResult{
+		ReadAt: time.Now(),
+	}, nil
+}
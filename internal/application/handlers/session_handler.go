@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for refresh tokens that were never issued or have expired
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrSessionRevoked is returned when the session has been revoked or has expired
+	ErrSessionRevoked = errors.New("session has been revoked")
+	// ErrSessionNotFound is returned when the session doesn't exist or belongs to another user
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+type SessionHandler struct {
+	sessionRepo   repositories.SessionRepository
+	refreshExpiry time.Duration
+}
+
+func NewSessionHandler(sessionRepo repositories.SessionRepository, refreshExpiry time.Duration) *SessionHandler {
+	return &SessionHandler{
+		sessionRepo:   sessionRepo,
+		refreshExpiry: refreshExpiry,
+	}
+}
+
+// Command Handlers
+
+func (h *SessionHandler) HandleStartSession(ctx context.Context, cmd commands.StartSessionCommand) (*commands.StartSessionResult, error) {
+	now := time.Now()
+	expiresAt := now.Add(h.refreshExpiry)
+
+	session := &entities.Session{
+		ID:         entities.SessionID(uuid.New().String()),
+		UserID:     cmd.UserID,
+		UserAgent:  cmd.UserAgent,
+		IPAddress:  cmd.IPAddress,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	token := &entities.RefreshToken{
+		ID:        uuid.New().String(),
+		SessionID: session.ID,
+		UserID:    cmd.UserID,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+
+	if err := h.sessionRepo.Create(ctx, session, token); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &commands.StartSessionResult{
+		SessionID:      session.ID,
+		RefreshTokenID: token.ID,
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+func (h *SessionHandler) HandleRotateRefreshToken(ctx context.Context, cmd commands.RotateRefreshTokenCommand) (*commands.RotateRefreshTokenResult, error) {
+	now := time.Now()
+
+	token, err := h.sessionRepo.GetRefreshToken(ctx, cmd.RefreshTokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if token == nil || token.UserID != cmd.UserID || token.SessionID != cmd.SessionID || token.IsExpired(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := h.sessionRepo.GetByID(ctx, token.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session == nil || !session.IsActive(now) {
+		return nil, ErrSessionRevoked
+	}
+
+	// A used token showing up again means it was stolen or replayed:
+	// kill the whole family so neither party can keep refreshing
+	if token.IsUsed() {
+		return nil, h.revokeOnReuse(ctx, session.ID)
+	}
+
+	expiresAt := now.Add(h.refreshExpiry)
+	newToken := &entities.RefreshToken{
+		ID:        uuid.New().String(),
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+
+	rotated, err := h.sessionRepo.RotateRefreshToken(ctx, token.ID, newToken, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	// Lost the race against a concurrent refresh with the same token
+	if !rotated {
+		return nil, h.revokeOnReuse(ctx, session.ID)
+	}
+
+	return &commands.RotateRefreshTokenResult{
+		SessionID:      session.ID,
+		RefreshTokenID: newToken.ID,
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+func (h *SessionHandler) HandleRevokeSession(ctx context.Context, cmd commands.RevokeSessionCommand) (*commands.RevokeSessionResult, error) {
+	session, err := h.sessionRepo.GetByID(ctx, cmd.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session == nil || session.UserID != cmd.UserID {
+		return nil, ErrSessionNotFound
+	}
+
+	reason := cmd.Reason
+	if reason == "" {
+		reason = entities.SessionRevokedByUser
+	}
+
+	if err := h.sessionRepo.Revoke(ctx, cmd.SessionID, reason); err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return &commands.RevokeSessionResult{
+		RevokedAt: time.Now(),
+	}, nil
+}
+
+func (h *SessionHandler) HandleRevokeOtherSessions(ctx context.Context, cmd commands.RevokeOtherSessionsCommand) (*commands.RevokeSessionResult, error) {
+	reason := cmd.Reason
+	if reason == "" {
+		reason = entities.SessionRevokedByUser
+	}
+
+	if err := h.sessionRepo.RevokeAllByUserID(ctx, cmd.UserID, cmd.CurrentSessionID, reason); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return &commands.RevokeSessionResult{
+		RevokedAt: time.Now(),
+	}, nil
+}
+
+// Query Handlers
+
+func (h *SessionHandler) HandleGetUserSessions(ctx context.Context, query queries.GetUserSessionsQuery) (*queries.GetUserSessionsResult, error) {
+	sessions, err := h.sessionRepo.GetActiveByUserID(ctx, query.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	return &queries.GetUserSessionsResult{
+		Sessions: sessions,
+	}, nil
+}
+
+// IsSessionActive reports whether access tokens of the session may still be used
+func (h *SessionHandler) IsSessionActive(ctx context.Context, userID entities.UserID, sessionID entities.SessionID) (bool, error) {
+	session, err := h.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session != nil && session.UserID == userID && session.IsActive(time.Now()), nil
+}
+
+func (h *SessionHandler) revokeOnReuse(ctx context.Context, sessionID entities.SessionID) error {
+	if err := h.sessionRepo.Revoke(ctx, sessionID, entities.SessionRevokedTokenReuse); err != nil {
+		return fmt.Errorf("failed to revoke session after token reuse: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,15 +11,33 @@ import (
 	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
 )
 
+// ErrInvalidCredentials is returned when an email/password pair does not match
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
 type UserHandler struct {
-	userRepo repositories.UserRepository
+	userRepo       repositories.UserRepository
+	credentialRepo repositories.UserCredentialRepository
+	passwordHasher services.PasswordHasher
+	dummyHash      string
 }
 
-func NewUserHandler(userRepo repositories.UserRepository) *UserHandler {
+func NewUserHandler(
+	userRepo repositories.UserRepository,
+	credentialRepo repositories.UserCredentialRepository,
+	passwordHasher services.PasswordHasher,
+) *UserHandler {
+	// Hash used to spend the same time on unknown emails as on real ones,
+	// so login response times don't reveal which accounts exist
+	dummyHash, _ := passwordHasher.Hash("smart-goal-calendar-dummy-password")
+
 	return &UserHandler{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		credentialRepo: credentialRepo,
+		passwordHasher: passwordHasher,
+		dummyHash:      dummyHash,
 	}
 }
 
@@ -35,6 +54,16 @@ func (h *UserHandler) HandleCreateUser(ctx context.Context, cmd commands.CreateU
 		return nil, fmt.Errorf("user with email %s already exists", cmd.Email)
 	}
 	
+	if cmd.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	
+	// Hash password before touching the database
+	passwordHash, err := h.passwordHasher.Hash(cmd.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	
 	// Create new user entity
 	now := time.Now()
 	user := &entities.User{
@@ -71,6 +100,23 @@ func (h *UserHandler) HandleCreateUser(ctx context.Context, cmd commands.CreateU
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 	
+	// Save credentials
+	credential := &entities.UserCredential{
+		UserID:            user.ID,
+		PasswordHash:      passwordHash,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	
+	if err := h.credentialRepo.Create(ctx, credential); err != nil {
+		// Don't leave behind an account nobody can log into
+		if delErr := h.userRepo.Delete(ctx, user.ID); delErr != nil {
+			return nil, fmt.Errorf("failed to create credentials: %w (cleanup failed: %v)", err, delErr)
+		}
+		return nil, fmt.Errorf("failed to create credentials: %w", err)
+	}
+	
 	return &commands.CreateUserResult{
 		UserID:    user.ID,
 		CreatedAt: user.CreatedAt,
@@ -149,6 +195,87 @@ func (h *UserHandler) HandleDeleteUser(ctx context.Context, cmd commands.DeleteU
 	}, nil
 }
 
+func (h *UserHandler) HandleAuthenticateUser(ctx context.Context, cmd commands.AuthenticateUserCommand) (*commands.AuthenticateUserResult, error) {
+	user, err := h.userRepo.GetByEmail(ctx, cmd.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	
+	if user == nil {
+		h.passwordHasher.Verify(cmd.Password, h.dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+	
+	credential, err := h.credentialRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user credentials: %w", err)
+	}
+	
+	// Accounts created before passwords were stored have no credentials
+	if credential == nil {
+		h.passwordHasher.Verify(cmd.Password, h.dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+	
+	match, needsRehash, err := h.passwordHasher.Verify(cmd.Password, credential.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	
+	if !match {
+		return nil, ErrInvalidCredentials
+	}
+	
+	// Upgrade the stored hash when hashing parameters have changed.
+	// A failure here must not block the login itself.
+	if needsRehash {
+		if newHash, err := h.passwordHasher.Hash(cmd.Password); err == nil {
+			_ = h.credentialRepo.UpdatePasswordHash(ctx, user.ID, newHash, false)
+		}
+	}
+	
+	return &commands.AuthenticateUserResult{
+		User: user,
+	}, nil
+}
+
+func (h *UserHandler) HandleChangePassword(ctx context.Context, cmd commands.ChangePasswordCommand) (*commands.ChangePasswordResult, error) {
+	credential, err := h.credentialRepo.GetByUserID(ctx, cmd.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user credentials: %w", err)
+	}
+	
+	if credential == nil {
+		return nil, ErrInvalidCredentials
+	}
+	
+	match, _, err := h.passwordHasher.Verify(cmd.CurrentPassword, credential.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	
+	if !match {
+		return nil, ErrInvalidCredentials
+	}
+	
+	if cmd.NewPassword == cmd.CurrentPassword {
+		return nil, fmt.Errorf("new password must differ from the current password")
+	}
+	
+	newHash, err := h.passwordHasher.Hash(cmd.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	
+	if err := h.credentialRepo.UpdatePasswordHash(ctx, cmd.UserID, newHash, true); err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+	
+	return &commands.ChangePasswordResult{
+		ChangedAt: time.Now(),
+	}, nil
+}
+
 // Query Handlers
 
 func (h *UserHandler) HandleGetUserByID(ctx context.Context, query queries.GetUserByIDQuery) (*queries.GetUserResult, error) {
@@ -0,0 +1,31 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// GetCalendarFeedQuery represents a query for the user's active feed
+type GetCalendarFeedQuery struct {
+	UserID entities.UserID `json:"user_id" validate:"required"`
+}
+
+// GetFeedCalendarQuery represents a query for the calendar served by a feed token
+type GetFeedCalendarQuery struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Results
+type GetCalendarFeedResult struct {
+	Feed *entities.CalendarFeed `json:"feed"`
+}
+
+type GetFeedCalendarResult struct {
+	Feed       *entities.CalendarFeed                          `json:"feed"`
+	Events     []*entities.Event                               `json:"events"`
+	Exceptions map[entities.EventID][]*entities.EventException `json:"exceptions"`
+	Goals      []*entities.Goal                                `json:"goals"`      // Goals with a deadline, when included
+	Milestones []*entities.Milestone                           `json:"milestones"` // When included
+	ModifiedAt time.Time                                       `json:"modified_at"`
+}
@@ -0,0 +1,25 @@
+package queries
+
+import (
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// GetCalendarsQuery represents a query for all calendars of a user
+type GetCalendarsQuery struct {
+	UserID entities.UserID `json:"user_id" validate:"required"`
+}
+
+// GetCalendarQuery represents a query for a single calendar of a user
+type GetCalendarQuery struct {
+	CalendarID entities.CalendarID `json:"calendar_id" validate:"required"`
+	UserID     entities.UserID     `json:"user_id" validate:"required"`
+}
+
+// Results
+type GetCalendarsResult struct {
+	Calendars []*entities.Calendar `json:"calendars"`
+}
+
+type GetCalendarResult struct {
+	Calendar *entities.Calendar `json:"calendar"`
+}
@@ -29,10 +29,14 @@ type GetEventsResult struct {
 	Limit      int               `json:"limit"`
 }
 
+// GetEventsByTimeRangeQuery selects timed events within the range and
+// all-day events on the days the range covers in Timezone
 type GetEventsByTimeRangeQuery struct {
-	UserID    entities.UserID `json:"user_id"`
-	StartTime time.Time       `json:"start_time"`
-	EndTime   time.Time       `json:"end_time"`
+	UserID      entities.UserID       `json:"user_id"`
+	StartTime   time.Time             `json:"start_time"`
+	EndTime     time.Time             `json:"end_time"`
+	Timezone    string                `json:"timezone"`               // Of the viewer; UTC when empty
+	CalendarIDs []entities.CalendarID `json:"calendar_ids,omitempty"` // The visible calendars when nil
 }
 
 type GetEventsByTimeRangeResult struct {
@@ -49,8 +53,10 @@ type GetEventsByGoalIDResult struct {
 }
 
 type GetUpcomingEventsQuery struct {
-	UserID entities.UserID `json:"user_id"`
-	Limit  int             `json:"limit"`
+	UserID      entities.UserID       `json:"user_id"`
+	Limit       int                   `json:"limit"`
+	Timezone    string                `json:"timezone"`               // All-day events are upcoming until their last day ends here
+	CalendarIDs []entities.CalendarID `json:"calendar_ids,omitempty"` // The visible calendars when nil
 }
 
 type GetUpcomingEventsResult struct {
@@ -58,8 +64,9 @@ type GetUpcomingEventsResult struct {
 }
 
 type GetTodayEventsQuery struct {
-	UserID   entities.UserID `json:"user_id"`
-	Timezone string          `json:"timezone"`
+	UserID      entities.UserID       `json:"user_id"`
+	Timezone    string                `json:"timezone"`
+	CalendarIDs []entities.CalendarID `json:"calendar_ids,omitempty"` // The visible calendars when nil
 }
 
 type GetTodayEventsResult struct {
@@ -101,6 +108,7 @@ type CheckEventConflictQuery struct {
 	StartTime       time.Time         `json:"start_time"`
 	EndTime         time.Time         `json:"end_time"`
 	ExcludeEventID  *entities.EventID `json:"exclude_event_id,omitempty"`
+	IncludeAllDay   bool              `json:"include_all_day"` // All-day events don't block time by default
 }
 
 type CheckEventConflictResult struct {
@@ -116,6 +124,23 @@ type GetEventsByExternalSourceResult struct {
 	Events []*entities.Event `json:"events"`
 }
 
+// ExportEventsQuery selects the events written to an iCalendar export. All
+// filters are optional; recurring series are included when any of their
+// occurrences falls within the time range.
+type ExportEventsQuery struct {
+	UserID      entities.UserID       `json:"user_id"`
+	StartTime   *time.Time            `json:"start_time,omitempty"`
+	EndTime     *time.Time            `json:"end_time,omitempty"`
+	GoalID      *entities.GoalID      `json:"goal_id,omitempty"`
+	Status      *entities.EventStatus `json:"status,omitempty"`
+	VisibleOnly bool                  `json:"visible_only,omitempty"` // Skips the events of hidden calendars
+}
+
+type ExportEventsResult struct {
+	Events     []*entities.Event                               `json:"events"`
+	Exceptions map[entities.EventID][]*entities.EventException `json:"exceptions"`
+}
+
 type GetEventStatsQuery struct {
 	UserID    entities.UserID `json:"user_id"`
 	StartDate time.Time       `json:"start_date"`
@@ -0,0 +1,18 @@
+package queries
+
+import (
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// GetNotificationsQuery represents a query for the in-app notifications of
+// a user, newest first
+type GetNotificationsQuery struct {
+	UserID     entities.UserID `json:"user_id" validate:"required"`
+	UnreadOnly bool            `json:"unread_only"`
+	Limit      int             `json:"limit" validate:"min=1,max=100"`
+}
+
+// Results
+type GetNotificationsResult struct {
+	Notifications []*entities.Notification `json:"notifications"`
+}
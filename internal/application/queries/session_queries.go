@@ -0,0 +1,15 @@
+package queries
+
+import (
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// GetUserSessionsQuery represents a query to list active sessions of a user
+type GetUserSessionsQuery struct {
+	UserID entities.UserID `json:"user_id" validate:"required"`
+}
+
+// Results
+type GetUserSessionsResult struct {
+	Sessions []*entities.Session `json:"sessions"`
+}
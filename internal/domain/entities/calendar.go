@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"regexp"
+	"time"
+)
+
+type CalendarID string
+
+// DefaultCalendarName is the name of the calendar every user gets first
+const DefaultCalendarName = "Calendar"
+
+// Calendar groups a user's events. Every user has one default calendar that
+// takes events created without a calendar; calendars with a Provider mirror
+// a calendar of a connected integration through a GoogleCalendarSync.
+type Calendar struct {
+	ID               CalendarID          `json:"id"`
+	UserID           UserID              `json:"user_id"`
+	Name             string              `json:"name"`
+	Color            string              `json:"color,omitempty"` // Hex color such as "#7ae7bf" for events without their own
+	Timezone         string              `json:"timezone"`        // Default zone of new events
+	DefaultReminders []Reminder          `json:"default_reminders"`
+	Provider         IntegrationProvider `json:"provider,omitempty"` // Empty for local calendars
+	ReadOnly         bool                `json:"read_only"`          // Events can't be changed locally, e.g. one-way synced calendars
+	Visible          bool                `json:"visible"`            // Shown when no calendars are selected explicitly
+	IsDefault        bool                `json:"is_default"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+// Reminder notifies about an event some minutes before it starts
+type Reminder struct {
+	MinutesBefore int             `json:"minutes_before"`
+	Channel       ReminderChannel `json:"channel"`
+}
+
+type ReminderChannel string
+
+const (
+	ReminderChannelEmail   ReminderChannel = "email"
+	ReminderChannelWebhook ReminderChannel = "webhook" // Posted to the user's webhook URL
+	ReminderChannelInApp   ReminderChannel = "in_app"
+)
+
+const (
+	// MaxReminderLead is how long before an event a reminder may fire
+	MaxReminderLead = 4 * 7 * 24 * time.Hour
+
+	// MaxReminders matches the limit of Google Calendar per event
+	MaxReminders = 5
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// IsValidColor reports whether color is empty or a hex color such as "#7ae7bf"
+func IsValidColor(color string) bool {
+	return color == "" || colorPattern.MatchString(color)
+}
+
+func (c ReminderChannel) IsValid() bool {
+	switch c {
+	case ReminderChannelEmail, ReminderChannelWebhook, ReminderChannelInApp:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the reminder fires at most four weeks before the
+// event over a known channel
+func (r Reminder) IsValid() bool {
+	return r.MinutesBefore >= 0 && r.Lead() <= MaxReminderLead && r.Channel.IsValid()
+}
+
+// ValidReminders reports whether there are at most MaxReminders reminders,
+// all of them valid
+func ValidReminders(reminders []Reminder) bool {
+	if len(reminders) > MaxReminders {
+		return false
+	}
+	for _, reminder := range reminders {
+		if !reminder.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Lead returns how long before the event the reminder fires
+func (r Reminder) Lead() time.Duration {
+	return time.Duration(r.MinutesBefore) * time.Minute
+}
+
+// IsSynced reports whether the calendar mirrors a calendar of an integration
+func (c *Calendar) IsSynced() bool {
+	return c.Provider != ""
+}
+
+// TimeLocation returns the calendar's time zone, UTC when it is unknown
+func (c *Calendar) TimeLocation() *time.Location {
+	if loc, err := time.LoadLocation(c.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+)
+
+type CalendarFeedID string
+
+// CalendarFeed is a secret, revocable URL that serves a user's calendar as
+// iCalendar to subscribing clients. Only the SHA-256 hash of its token is
+// stored; the token itself is shown once when the feed is created.
+type CalendarFeed struct {
+	ID                CalendarFeedID `json:"id"`
+	UserID            UserID         `json:"user_id"`
+	TokenHash         string         `json:"-"`
+	IncludeGoals      bool           `json:"include_goals"`      // Goal deadlines as all-day items
+	IncludeMilestones bool           `json:"include_milestones"` // Milestone target dates as all-day items
+	LastAccessedAt    *time.Time     `json:"last_accessed_at,omitempty"`
+	RevokedAt         *time.Time     `json:"revoked_at,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+func (f *CalendarFeed) IsActive() bool {
+	return f.RevokedAt == nil
+}
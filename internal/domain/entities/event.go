@@ -9,18 +9,27 @@ type EventID string
 type Event struct {
 	ID          EventID   `json:"id"`
 	UserID      UserID    `json:"user_id"`
+	CalendarID  CalendarID `json:"calendar_id"`
 	GoalID      *GoalID   `json:"goal_id,omitempty"` // Optional связь с целью
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	StartTime   time.Time `json:"start_time"`
 	EndTime     time.Time `json:"end_time"`
 	Timezone    string    `json:"timezone"`
+	AllDay      bool      `json:"all_day"` // Date-only: runs from midnight to midnight in Timezone, see Dates
 	Recurrence  *RecurrenceRule `json:"recurrence,omitempty"`
 	Location    string `json:"location"`
 	Attendees   []Attendee `json:"attendees"`
 	Status      EventStatus `json:"status"`
+	Color       string    `json:"color,omitempty"` // Hex color such as "#7ae7bf"; the calendar's color when empty
+	Transparent bool      `json:"transparent"`     // Shown as free rather than busy
+	Reminders   []Reminder `json:"reminders"`       // The calendar's or the user's default reminders when nil, see EffectiveReminders
 	ExternalID  string    `json:"external_id,omitempty"` // For Google Calendar sync
 	ExternalSource string `json:"external_source,omitempty"` // 'google', 'outlook', etc.
+	RecurringEventID  *EventID   `json:"recurring_event_id,omitempty"`  // Set on virtual occurrences of a recurring event
+	OriginalStartTime *time.Time `json:"original_start_time,omitempty"` // Start of the occurrence as generated by the rule
+	PushStatus        *EventPushStatus `json:"push_status,omitempty"` // State of pushing local changes to Google; nil when none were queued
+	PushError         *string          `json:"push_error,omitempty"`  // Last error of pushing to Google
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -33,6 +42,15 @@ const (
 	EventStatusCancelled EventStatus = "cancelled"
 )
 
+// EventPushStatus tracks local changes queued for Google Calendar
+type EventPushStatus string
+
+const (
+	EventPushStatusPending EventPushStatus = "pending"
+	EventPushStatusSynced  EventPushStatus = "synced"
+	EventPushStatusFailed  EventPushStatus = "failed" // Retries gave up
+)
+
 type Location struct {
 	Name      string  `json:"name"`
 	Address   string  `json:"address"`
@@ -56,53 +74,6 @@ const (
 	AttendeeStatusTentative AttendeeStatus = "tentative"
 )
 
-type RecurrenceRule struct {
-	Frequency Frequency  `json:"frequency"`
-	Interval  int        `json:"interval"`       // Every N frequency
-	Until     *time.Time `json:"until,omitempty"`
-	Count     *int       `json:"count,omitempty"`
-	ByDay     []Weekday  `json:"by_day,omitempty"`
-	ByMonth   []Month    `json:"by_month,omitempty"`
-}
-
-type Frequency string
-
-const (
-	FrequencyDaily   Frequency = "DAILY"
-	FrequencyWeekly  Frequency = "WEEKLY"
-	FrequencyMonthly Frequency = "MONTHLY"
-	FrequencyYearly  Frequency = "YEARLY"
-)
-
-type Weekday string
-
-const (
-	WeekdayMonday    Weekday = "MO"
-	WeekdayTuesday   Weekday = "TU"
-	WeekdayWednesday Weekday = "WE"
-	WeekdayThursday  Weekday = "TH"
-	WeekdayFriday    Weekday = "FR"
-	WeekdaySaturday  Weekday = "SA"
-	WeekdaySunday    Weekday = "SU"
-)
-
-type Month int
-
-const (
-	January Month = iota + 1
-	February
-	March
-	April
-	May
-	June
-	July
-	August
-	September
-	October
-	November
-	December
-)
-
 // Validation methods
 func (e *Event) IsValid() bool {
 	return e.Title != "" && 
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/valueobjects"
+)
+
+// DateFormat is the layout of the dates of all-day events
+const DateFormat = "2006-01-02"
+
+// All-day events are stored as the first instants of their first day and
+// the day after their last day in the event's own zone. Their dates are
+// compared as calendar dates, represented as midnight UTC, so an all-day
+// event shows on the same days wherever it is viewed from.
+
+// DateIn returns the calendar date of t in loc
+func DateIn(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// StartOfDay returns the first instant of a calendar date in loc. That is
+// midnight, unless a daylight saving jump skips midnight on that day.
+func StartOfDay(date time.Time, loc *time.Location) time.Time {
+	return valueobjects.LocalTime(date.Year(), date.Month(), date.Day(), 0, 0, 0, loc)
+}
+
+// IsStartOfDay reports whether t is the first instant of its day in loc
+func IsStartOfDay(t time.Time, loc *time.Location) bool {
+	return t.Equal(StartOfDay(DateIn(t, loc), loc))
+}
+
+// ParseDate parses a date in DateFormat
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// AllDayTimes returns the start and end of an all-day event running from
+// the start date up to, but excluding, the end date in loc
+func AllDayTimes(start, end time.Time, loc *time.Location) (time.Time, time.Time) {
+	return StartOfDay(start, loc), StartOfDay(end, loc)
+}
+
+// Dates returns the first day of the event and the day after its last day
+// in the event's time zone. A timed event ending at midnight doesn't cover
+// the day it ends on.
+func (e *Event) Dates() (time.Time, time.Time) {
+	loc := e.TimeLocation()
+	start := DateIn(e.StartTime, loc)
+	end := DateIn(e.EndTime, loc)
+	if !end.After(start) || !e.EndTime.Equal(StartOfDay(end, loc)) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// Days returns the number of days the event covers
+func (e *Event) Days() int {
+	start, end := e.Dates()
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// OverlapsDates reports whether the event covers any day from start up to,
+// but excluding, end
+func (e *Event) OverlapsDates(start, end time.Time) bool {
+	first, after := e.Dates()
+	return first.Before(end) && after.After(start)
+}
+
+// BlocksTime reports whether the event makes the time it spans busy.
+// All-day and transparent events don't.
+func (e *Event) BlocksTime() bool {
+	return !e.AllDay && !e.Transparent
+}
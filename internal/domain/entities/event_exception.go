@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"time"
+)
+
+// RecurrenceScope selects which occurrences of a recurring event a change applies to
+type RecurrenceScope string
+
+const (
+	RecurrenceScopeThis             RecurrenceScope = "this"
+	RecurrenceScopeThisAndFollowing RecurrenceScope = "this_and_following"
+	RecurrenceScopeAll              RecurrenceScope = "all"
+)
+
+// EventException changes a single occurrence of a recurring event. It is
+// keyed by RecurrenceID, the original start of the occurrence. A cancelled
+// exception removes the occurrence (EXDATE); otherwise every non-nil field
+// overrides the value generated from the series.
+type EventException struct {
+	ID           string       `json:"id"`
+	EventID      EventID      `json:"event_id"`
+	RecurrenceID time.Time    `json:"recurrence_id"`
+	Cancelled    bool         `json:"cancelled"`
+	Title        *string      `json:"title,omitempty"`
+	Description  *string      `json:"description,omitempty"`
+	StartTime    *time.Time   `json:"start_time,omitempty"`
+	EndTime      *time.Time   `json:"end_time,omitempty"`
+	Location     *string      `json:"location,omitempty"`
+	Attendees    []Attendee   `json:"attendees,omitempty"`
+	Status       *EventStatus `json:"status,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+func (s RecurrenceScope) IsValid() bool {
+	switch s {
+	case RecurrenceScopeThis, RecurrenceScopeThisAndFollowing, RecurrenceScopeAll:
+		return true
+	}
+	return false
+}
+
+// IsMoved reports whether the exception changes the time of the occurrence
+func (x *EventException) IsMoved() bool {
+	return x.StartTime != nil || x.EndTime != nil
+}
+
+// IsModified reports whether the exception overrides any field of the
+// occurrence
+func (x *EventException) IsModified() bool {
+	return x.Title != nil || x.Description != nil || x.IsMoved() || x.Location != nil ||
+		x.Attendees != nil || x.Status != nil
+}
+
+// Apply returns a copy of the occurrence with the overrides applied
+func (x *EventException) Apply(occurrence *Event) *Event {
+	modified := *occurrence
+
+	if x.Title != nil {
+		modified.Title = *x.Title
+	}
+	if x.Description != nil {
+		modified.Description = *x.Description
+	}
+	if x.StartTime != nil {
+		modified.StartTime = *x.StartTime
+	}
+	if x.EndTime != nil {
+		modified.EndTime = *x.EndTime
+	}
+	if x.Location != nil {
+		modified.Location = *x.Location
+	}
+	if x.Attendees != nil {
+		modified.Attendees = x.Attendees
+	}
+	if x.Status != nil {
+		modified.Status = *x.Status
+	}
+
+	return &modified
+}
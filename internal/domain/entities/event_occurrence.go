@@ -0,0 +1,174 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// occurrenceTimeFormat is the UTC basic format used in occurrence IDs,
+// the same shape Google Calendar uses for instance IDs
+const occurrenceTimeFormat = "20060102T150405Z"
+
+// OccurrenceID builds the stable ID of a single occurrence of a recurring
+// event: "<eventID>_<original start in UTC>"
+func OccurrenceID(eventID EventID, originalStart time.Time) EventID {
+	return EventID(fmt.Sprintf("%s_%s", eventID, originalStart.UTC().Format(occurrenceTimeFormat)))
+}
+
+// ParseOccurrenceID splits an occurrence ID into the recurring event ID and
+// the original start time of the occurrence
+func ParseOccurrenceID(id EventID) (EventID, time.Time, bool) {
+	idx := strings.LastIndex(string(id), "_")
+	if idx <= 0 {
+		return "", time.Time{}, false
+	}
+
+	originalStart, err := time.Parse(occurrenceTimeFormat, string(id)[idx+1:])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	return EventID(string(id)[:idx]), originalStart, true
+}
+
+// IsOccurrence reports whether the event is a virtual occurrence of a recurring event
+func (e *Event) IsOccurrence() bool {
+	return e.RecurringEventID != nil
+}
+
+// Occurrence returns a virtual instance of the recurring event starting at originalStart
+func (e *Event) Occurrence(originalStart time.Time) *Event {
+	occurrence := *e
+	recurringEventID := e.ID
+	start := originalStart.In(e.TimeLocation())
+
+	occurrence.ID = OccurrenceID(e.ID, start)
+	occurrence.StartTime = start
+	occurrence.EndTime = start.Add(e.Duration())
+	if e.AllDay {
+		// Days across a DST change are shorter or longer than others
+		occurrence.EndTime = StartOfDay(DateIn(start, start.Location()).AddDate(0, 0, e.Days()), start.Location())
+	}
+	occurrence.Recurrence = nil
+	occurrence.RecurringEventID = &recurringEventID
+	occurrence.OriginalStartTime = &start
+
+	return &occurrence
+}
+
+// OccurrencesBetween expands the recurring event into the occurrences that
+// start within [start, end]
+func (e *Event) OccurrencesBetween(start, end time.Time) []*Event {
+	if !e.IsRecurring() {
+		return nil
+	}
+
+	var occurrences []*Event
+	for _, t := range e.Recurrence.Between(e.StartTime, start, end, e.TimeLocation()) {
+		occurrences = append(occurrences, e.Occurrence(t))
+	}
+
+	return occurrences
+}
+
+// OccurrencesOnDates expands the recurring event into the occurrences that
+// cover any day from start up to, but excluding, end
+func (e *Event) OccurrencesOnDates(start, end time.Time) []*Event {
+	if !e.IsRecurring() {
+		return nil
+	}
+
+	// Zone offsets are within a day of UTC
+	from := start.AddDate(0, 0, -e.Days()-1)
+	until := end.AddDate(0, 0, 1)
+
+	var occurrences []*Event
+	for _, occurrence := range e.OccurrencesBetween(from, until) {
+		if occurrence.OverlapsDates(start, end) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	return occurrences
+}
+
+// OccurrencesAfter returns up to limit occurrences starting after the given time
+func (e *Event) OccurrencesAfter(after time.Time, limit int) []*Event {
+	if !e.IsRecurring() {
+		return nil
+	}
+
+	var occurrences []*Event
+	for _, t := range e.Recurrence.After(e.StartTime, after, e.TimeLocation(), limit) {
+		occurrences = append(occurrences, e.Occurrence(t))
+	}
+
+	return occurrences
+}
+
+// HasOccurrenceAt reports whether the recurring event has an occurrence starting at t
+func (e *Event) HasOccurrenceAt(t time.Time) bool {
+	if !e.IsRecurring() {
+		return false
+	}
+
+	return e.Recurrence.HasOccurrenceAt(e.StartTime, t, e.TimeLocation())
+}
+
+// TimeLocation returns the event's timezone, falling back to UTC
+func (e *Event) TimeLocation() *time.Location {
+	if e.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// ResolveOccurrences applies exceptions to generated occurrences: cancelled
+// ones are dropped and modified ones replaced by their override. Moved
+// overrides whose original occurrence wasn't generated are appended as well,
+// since they may have been moved into the requested range; callers filter
+// and sort the result.
+func (e *Event) ResolveOccurrences(occurrences []*Event, exceptions []*EventException) []*Event {
+	if len(exceptions) == 0 {
+		return occurrences
+	}
+
+	byRecurrenceID := make(map[int64]*EventException, len(exceptions))
+	for _, exception := range exceptions {
+		byRecurrenceID[exception.RecurrenceID.Unix()] = exception
+	}
+
+	resolved := make([]*Event, 0, len(occurrences))
+	seen := make(map[int64]bool, len(occurrences))
+	for _, occurrence := range occurrences {
+		key := occurrence.OriginalStartTime.Unix()
+		seen[key] = true
+
+		exception, ok := byRecurrenceID[key]
+		if !ok {
+			resolved = append(resolved, occurrence)
+			continue
+		}
+		if !exception.Cancelled {
+			resolved = append(resolved, exception.Apply(occurrence))
+		}
+	}
+
+	for key, exception := range byRecurrenceID {
+		if seen[key] || exception.Cancelled || !exception.IsMoved() {
+			continue
+		}
+		if e.HasOccurrenceAt(exception.RecurrenceID) {
+			resolved = append(resolved, exception.Apply(e.Occurrence(exception.RecurrenceID)))
+		}
+	}
+
+	return resolved
+}
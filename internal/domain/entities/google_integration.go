@@ -1,26 +1,41 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 )
 
 type GoogleIntegrationID string
 
+// IntegrationProvider is the calendar service an integration connects to.
+// Integrations of every provider are stored as GoogleIntegrations; events
+// synced from them carry the provider as their ExternalSource.
+type IntegrationProvider string
+
+const (
+	IntegrationProviderGoogle    IntegrationProvider = "google"
+	IntegrationProviderMicrosoft IntegrationProvider = "microsoft"
+)
+
 type GoogleIntegration struct {
 	ID           GoogleIntegrationID `json:"id"`
 	UserID       UserID              `json:"user_id"`
+	Provider     IntegrationProvider `json:"provider"`
 	GoogleUserID string              `json:"google_user_id"`
 	Email        string              `json:"email"`
 	Name         string              `json:"name"`
-	AccessToken  string              `json:"access_token"`
-	RefreshToken string              `json:"refresh_token"`
+	AccessToken  string              `json:"-"` // Never serialized; stored encrypted
+	RefreshToken string              `json:"-"` // Never serialized; stored encrypted
 	TokenType    string              `json:"token_type"`
 	ExpiresAt    time.Time           `json:"expires_at"`
 	Scopes       []string            `json:"scopes"`
 	CalendarID   string              `json:"calendar_id"` // Primary calendar ID
 	Enabled      bool                `json:"enabled"`
-	CreatedAt    time.Time           `json:"created_at"`
-	UpdatedAt    time.Time           `json:"updated_at"`
+	// The provider rejected the refresh token; the user must connect the
+	// account again
+	NeedsReconsent bool      `json:"needs_reconsent"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type GoogleCalendarSync struct {
@@ -29,16 +44,30 @@ type GoogleCalendarSync struct {
 	GoogleIntegrationID GoogleIntegrationID     `json:"google_integration_id"`
 	CalendarID          string                  `json:"calendar_id"`
 	CalendarName        string                  `json:"calendar_name"`
+	LocalCalendarID     CalendarID              `json:"local_calendar_id"` // Calendar the synced events belong to
 	SyncDirection       CalendarSyncDirection   `json:"sync_direction"`
 	SyncStatus          CalendarSyncStatus      `json:"sync_status"`
 	LastSyncAt          *time.Time              `json:"last_sync_at"`
 	LastSyncError       string                  `json:"last_sync_error"`
 	SyncToken           string                  `json:"sync_token"`
 	Settings            CalendarSyncSettings    `json:"settings"`
+	NextSyncAt          *time.Time              `json:"next_sync_at"`      // When the scheduler runs the sync next; nil when due
+	FailureCount        int                     `json:"failure_count"`     // Consecutive failed runs, drives the retry backoff
+	Channel             *WatchChannel           `json:"channel,omitempty"` // Push notifications of changes in Google; nil when not watched
+	Notified            bool                    `json:"notified"`          // A push notification scheduled the next run
 	CreatedAt           time.Time               `json:"created_at"`
 	UpdatedAt           time.Time               `json:"updated_at"`
 }
 
+// WatchChannel is a Google push notification channel for a synced calendar.
+// Notifications carry the channel token, which is only stored hashed.
+type WatchChannel struct {
+	ID         string    `json:"id"`
+	ResourceID string    `json:"resource_id"`
+	TokenHash  string    `json:"-"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
 type CalendarSyncDirection string
 
 const (
@@ -57,11 +86,23 @@ const (
 )
 
 type CalendarSyncSettings struct {
-	SyncInterval      time.Duration `json:"sync_interval"`       // How often to sync
-	AutoSync          bool          `json:"auto_sync"`           // Enable automatic sync
-	SyncPastEvents    bool          `json:"sync_past_events"`    // Sync events from the past
-	SyncFutureEvents  bool          `json:"sync_future_events"`  // Sync future events
-	ConflictResolution string       `json:"conflict_resolution"` // "google_wins", "local_wins", "manual"
+	SyncInterval       time.Duration      `json:"sync_interval"`       // How often to sync
+	AutoSync           bool               `json:"auto_sync"`           // Enable automatic sync
+	SyncPastEvents     bool               `json:"sync_past_events"`    // Sync events from the past
+	SyncFutureEvents   bool               `json:"sync_future_events"`  // Sync future events
+	ConflictResolution ConflictResolution `json:"conflict_resolution"` // Applies to bidirectional syncs
+}
+
+// String describes the integration without its tokens, so that formatting
+// or logging it can't leak them
+func (gi GoogleIntegration) String() string {
+	return fmt.Sprintf("GoogleIntegration{ID: %s, UserID: %s, Email: %s, AccessToken: [REDACTED], RefreshToken: [REDACTED]}",
+		gi.ID, gi.UserID, gi.Email)
+}
+
+// GoString redacts the tokens from %#v like String
+func (gi GoogleIntegration) GoString() string {
+	return gi.String()
 }
 
 // Validation methods
@@ -90,16 +131,46 @@ func (gcs *GoogleCalendarSync) IsActive() bool {
 	return gcs.SyncStatus == SyncStatusActive
 }
 
+// IsScheduled reports whether the background scheduler runs the sync.
+// Failed syncs stay scheduled and are retried with backoff.
+func (gcs *GoogleCalendarSync) IsScheduled() bool {
+	return (gcs.SyncStatus == SyncStatusActive || gcs.SyncStatus == SyncStatusError) && gcs.Settings.AutoSync
+}
+
 func (gcs *GoogleCalendarSync) NeedsSync() bool {
-	if !gcs.IsActive() {
+	if !gcs.IsScheduled() {
 		return false
 	}
 
+	if gcs.NextSyncAt != nil {
+		return !time.Now().Before(*gcs.NextSyncAt)
+	}
+
 	if gcs.LastSyncAt == nil {
 		return true
 	}
 
-	return time.Now().Sub(*gcs.LastSyncAt) >= gcs.Settings.SyncInterval
+	return time.Now().Sub(*gcs.LastSyncAt) >= gcs.Interval()
+}
+
+// NeedsWatch reports whether changes in Google should be pushed to the
+// webhook: the sync runs automatically and reads from Google
+func (gcs *GoogleCalendarSync) NeedsWatch() bool {
+	return gcs.IsScheduled() && gcs.SyncDirection != SyncDirectionToGoogle
+}
+
+// IsReadOnly reports whether local changes to the synced events would be
+// lost because they are never pushed back
+func (gcs *GoogleCalendarSync) IsReadOnly() bool {
+	return gcs.SyncDirection == SyncDirectionFromGoogle
+}
+
+// Interval returns the sync interval, falling back to the default one
+func (gcs *GoogleCalendarSync) Interval() time.Duration {
+	if gcs.Settings.SyncInterval <= 0 {
+		return DefaultCalendarSyncSettings().SyncInterval
+	}
+	return gcs.Settings.SyncInterval
 }
 
 // Default settings
@@ -109,6 +180,6 @@ func DefaultCalendarSyncSettings() CalendarSyncSettings {
 		AutoSync:           true,
 		SyncPastEvents:     false,
 		SyncFutureEvents:   true,
-		ConflictResolution: "google_wins",
+		ConflictResolution: ConflictResolutionGoogleWins,
 	}
 }
\ No newline at end of file
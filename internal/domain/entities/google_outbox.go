@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"time"
+)
+
+// GoogleOutboxOperation is the change to push to a Google event
+type GoogleOutboxOperation string
+
+const (
+	GoogleOutboxUpdate GoogleOutboxOperation = "update"
+	GoogleOutboxDelete GoogleOutboxOperation = "delete"
+)
+
+type GoogleOutboxStatus string
+
+const (
+	GoogleOutboxPending GoogleOutboxStatus = "pending"
+	GoogleOutboxDone    GoogleOutboxStatus = "done"
+	GoogleOutboxFailed  GoogleOutboxStatus = "failed" // Retries gave up
+)
+
+// GoogleOutboxEntry is a local change of a linked event waiting to be pushed
+// to the Google event of one calendar sync. Entries are queued together with
+// the change, so none is lost when the push can't happen right away.
+type GoogleOutboxEntry struct {
+	ID             string                `json:"id"`
+	CalendarSyncID string                `json:"calendar_sync_id"`
+	UserID         UserID                `json:"user_id"`
+	EventID        EventID               `json:"event_id"`
+	GoogleEventID  string                `json:"google_event_id"`
+	Operation      GoogleOutboxOperation `json:"operation"`
+	Status         GoogleOutboxStatus    `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastError      string                `json:"last_error"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
@@ -0,0 +1,131 @@
+package entities
+
+import (
+	"time"
+)
+
+// ConflictResolution decides which side wins when an event was changed both
+// locally and in Google since the last bidirectional sync
+type ConflictResolution string
+
+const (
+	ConflictResolutionGoogleWins ConflictResolution = "google_wins"
+	ConflictResolutionLocalWins  ConflictResolution = "local_wins"
+	ConflictResolutionManual     ConflictResolution = "manual" // Queue the conflict for the user
+)
+
+// ConflictChoice is how the user resolved a queued conflict
+type ConflictChoice string
+
+const (
+	ConflictChoiceLocal  ConflictChoice = "local"
+	ConflictChoiceRemote ConflictChoice = "remote"
+	ConflictChoiceMerged ConflictChoice = "merged"
+)
+
+// GoogleEventLink ties a local event to its Google event within a calendar
+// sync. The timestamps are the versions of both sides at the last sync, so
+// a side changed since when its current timestamp is later.
+type GoogleEventLink struct {
+	ID              string    `json:"id"`
+	CalendarSyncID  string    `json:"calendar_sync_id"`
+	EventID         EventID   `json:"event_id"`
+	GoogleEventID   string    `json:"google_event_id"`
+	LocalUpdatedAt  time.Time `json:"local_updated_at"`
+	RemoteUpdatedAt time.Time `json:"remote_updated_at"`
+	SyncedAt        time.Time `json:"synced_at"`
+}
+
+// EventVersion is a snapshot of the synced fields of an event on one side.
+// ExcludedDates are the occurrences a remote series excludes; locally they
+// are cancelled exceptions, which are not part of the snapshot.
+type EventVersion struct {
+	Title         string          `json:"title"`
+	Description   string          `json:"description"`
+	Location      string          `json:"location"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       time.Time       `json:"end_time"`
+	Timezone      string          `json:"timezone"`
+	AllDay        bool            `json:"all_day,omitempty"`
+	Recurrence    *RecurrenceRule `json:"recurrence,omitempty"`
+	ExcludedDates []time.Time     `json:"excluded_dates,omitempty"`
+	Attendees     []Attendee      `json:"attendees,omitempty"`
+	Status        EventStatus     `json:"status,omitempty"`
+	Color         string          `json:"color,omitempty"`
+	Transparent   bool            `json:"transparent,omitempty"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// GoogleSyncConflict is an event changed on both sides that waits for the
+// user to pick a version. While it is open, the event is not synced.
+type GoogleSyncConflict struct {
+	ID             string          `json:"id"`
+	CalendarSyncID string          `json:"calendar_sync_id"`
+	UserID         UserID          `json:"user_id"`
+	EventID        EventID         `json:"event_id"`
+	GoogleEventID  string          `json:"google_event_id"`
+	LocalVersion   EventVersion    `json:"local_version"`
+	RemoteVersion  EventVersion    `json:"remote_version"`
+	Resolution     *ConflictChoice `json:"resolution,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ResolvedAt     *time.Time      `json:"resolved_at,omitempty"`
+}
+
+func (r ConflictResolution) IsValid() bool {
+	switch r {
+	case ConflictResolutionGoogleWins, ConflictResolutionLocalWins, ConflictResolutionManual:
+		return true
+	}
+	return false
+}
+
+func (c ConflictChoice) IsValid() bool {
+	switch c {
+	case ConflictChoiceLocal, ConflictChoiceRemote, ConflictChoiceMerged:
+		return true
+	}
+	return false
+}
+
+func (c *GoogleSyncConflict) IsOpen() bool {
+	return c.ResolvedAt == nil
+}
+
+// NewEventVersion captures the synced fields of a local event
+func NewEventVersion(event *Event) EventVersion {
+	return EventVersion{
+		Title:       event.Title,
+		Description: event.Description,
+		Location:    event.Location,
+		StartTime:   event.StartTime,
+		EndTime:     event.EndTime,
+		Timezone:    event.Timezone,
+		AllDay:      event.AllDay,
+		Recurrence:  event.Recurrence,
+		Attendees:   event.Attendees,
+		Status:      event.Status,
+		Color:       event.Color,
+		Transparent: event.Transparent,
+		UpdatedAt:   event.UpdatedAt,
+	}
+}
+
+// ApplyTo overwrites the synced fields of an event with the version
+func (v EventVersion) ApplyTo(event *Event) {
+	event.Title = v.Title
+	event.Description = v.Description
+	event.Location = v.Location
+	event.StartTime = v.StartTime
+	event.EndTime = v.EndTime
+	event.Timezone = v.Timezone
+	event.AllDay = v.AllDay
+	event.Recurrence = v.Recurrence
+	event.Attendees = v.Attendees
+	event.Color = v.Color
+	event.Transparent = v.Transparent
+
+	// Versions stored before statuses were synced have none
+	if v.Status != "" {
+		event.Status = v.Status
+	}
+}
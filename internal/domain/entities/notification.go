@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"time"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationFailed  NotificationStatus = "failed"  // Retries gave up
+	NotificationSkipped NotificationStatus = "skipped" // The user turned notifications off before it was due
+)
+
+// Notification is a reminder of an event due for delivery over one channel.
+// A reminder is queued once per start of the event, so the reminders of
+// each occurrence of a recurring event, and of a moved event, are sent
+// again. In-app notifications are kept for the user to read.
+type Notification struct {
+	ID            string             `json:"id"`
+	UserID        UserID             `json:"user_id"`
+	EventID       EventID            `json:"event_id"` // The recurring event for occurrences
+	EventTitle    string             `json:"event_title"`
+	Location      string             `json:"location,omitempty"`
+	StartsAt      time.Time          `json:"starts_at"` // Start of the event or occurrence reminded of
+	AllDay        bool               `json:"all_day"`
+	Timezone      string             `json:"timezone"` // Zone of the event, for all-day dates
+	Channel       ReminderChannel    `json:"channel"`
+	MinutesBefore int                `json:"minutes_before"`
+	DueAt         time.Time          `json:"due_at"`
+	Status        NotificationStatus `json:"status"`
+	Attempts      int                `json:"attempts"`
+	LastError     string             `json:"last_error,omitempty"`
+	NextAttemptAt time.Time          `json:"next_attempt_at"`
+	SentAt        *time.Time         `json:"sent_at,omitempty"`
+	ReadAt        *time.Time         `json:"read_at,omitempty"` // In-app notifications only
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// EffectiveReminders returns the reminders of the event: its own when set,
+// otherwise the defaults of its calendar, or the user's when the calendar
+// has none. calendar may be nil.
+func (e *Event) EffectiveReminders(calendar *Calendar, settings UserSettings) []Reminder {
+	if e.Reminders != nil {
+		return e.Reminders
+	}
+	if calendar != nil && len(calendar.DefaultReminders) > 0 {
+		return calendar.DefaultReminders
+	}
+	return settings.DefaultReminders
+}
+
+// NewNotification returns the pending notification of a reminder of the
+// event, which may be an occurrence
+func NewNotification(event *Event, reminder Reminder) *Notification {
+	eventID := event.ID
+	if event.RecurringEventID != nil {
+		eventID = *event.RecurringEventID
+	}
+
+	dueAt := event.StartTime.Add(-reminder.Lead())
+	return &Notification{
+		UserID:        event.UserID,
+		EventID:       eventID,
+		EventTitle:    event.Title,
+		Location:      event.Location,
+		StartsAt:      event.StartTime,
+		AllDay:        event.AllDay,
+		Timezone:      event.Timezone,
+		Channel:       reminder.Channel,
+		MinutesBefore: reminder.MinutesBefore,
+		DueAt:         dueAt,
+		Status:        NotificationPending,
+		NextAttemptAt: dueAt,
+	}
+}
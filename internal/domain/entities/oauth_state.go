@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"time"
+)
+
+// OAuthState is a single-use nonce of an OAuth connect flow. It is bound to
+// the user who started the flow and holds the PKCE code verifier for the
+// code exchange. Only the SHA-256 hash of the state is stored.
+type OAuthState struct {
+	StateHash    string     `json:"-"`
+	UserID       UserID     `json:"user_id"`
+	CodeVerifier string     `json:"-"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (s *OAuthState) IsExpired() bool {
+	return !time.Now().Before(s.ExpiresAt)
+}
@@ -0,0 +1,51 @@
+package entities
+
+import "github.com/andranikuz/smart-goal-calendar/internal/domain/valueobjects"
+
+type RecurrenceRule = valueobjects.RecurrenceRule
+
+type Frequency = valueobjects.Frequency
+
+const (
+	FrequencySecondly = valueobjects.FrequencySecondly
+	FrequencyMinutely = valueobjects.FrequencyMinutely
+	FrequencyHourly   = valueobjects.FrequencyHourly
+	FrequencyDaily    = valueobjects.FrequencyDaily
+	FrequencyWeekly   = valueobjects.FrequencyWeekly
+	FrequencyMonthly  = valueobjects.FrequencyMonthly
+	FrequencyYearly   = valueobjects.FrequencyYearly
+)
+
+type Weekday = valueobjects.Weekday
+
+const (
+	WeekdayMonday    = valueobjects.WeekdayMonday
+	WeekdayTuesday   = valueobjects.WeekdayTuesday
+	WeekdayWednesday = valueobjects.WeekdayWednesday
+	WeekdayThursday  = valueobjects.WeekdayThursday
+	WeekdayFriday    = valueobjects.WeekdayFriday
+	WeekdaySaturday  = valueobjects.WeekdaySaturday
+	WeekdaySunday    = valueobjects.WeekdaySunday
+)
+
+// ParseRRULE parses an RFC 5545 RRULE value
+func ParseRRULE(value string) (*RecurrenceRule, error) {
+	return valueobjects.ParseRRULE(value)
+}
+
+type Month = valueobjects.Month
+
+const (
+	January   = valueobjects.January
+	February  = valueobjects.February
+	March     = valueobjects.March
+	April     = valueobjects.April
+	May       = valueobjects.May
+	June      = valueobjects.June
+	July      = valueobjects.July
+	August    = valueobjects.August
+	September = valueobjects.September
+	October   = valueobjects.October
+	November  = valueobjects.November
+	December  = valueobjects.December
+)
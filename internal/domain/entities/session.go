@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"time"
+)
+
+type SessionID string
+
+// Session revocation reasons
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedByUser         = "revoked_by_user"
+	SessionRevokedTokenReuse     = "token_reuse"
+	SessionRevokedPasswordChange = "password_change"
+)
+
+// Session is a single logged-in device. All refresh tokens issued to the
+// device belong to the same session, so revoking it kills the whole family.
+type Session struct {
+	ID            SessionID  `json:"id"`
+	UserID        UserID     `json:"user_id"`
+	UserAgent     string     `json:"user_agent"`
+	IPAddress     string     `json:"ip_address"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// RefreshToken tracks a single issued refresh token by its jti
+type RefreshToken struct {
+	ID         string     `json:"id"`
+	SessionID  SessionID  `json:"session_id"`
+	UserID     UserID     `json:"user_id"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	ReplacedBy *string    `json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Business methods
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return !s.IsRevoked() && now.Before(s.ExpiresAt)
+}
+
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"time"
+)
+
+// SyncTrigger is what started a calendar sync run
+type SyncTrigger string
+
+const (
+	SyncTriggerManual    SyncTrigger = "manual"
+	SyncTriggerScheduled SyncTrigger = "scheduled"
+	SyncTriggerWebhook   SyncTrigger = "webhook" // A push notification of a change
+)
+
+// SyncSide is the side of a calendar sync an event was written to
+type SyncSide string
+
+const (
+	SyncSideLocal  SyncSide = "local"  // From the remote calendar into local events
+	SyncSideRemote SyncSide = "remote" // From local events to the remote calendar
+)
+
+// maxSyncEventErrors bounds the event errors kept per run; Failed still
+// counts all of them
+const maxSyncEventErrors = 100
+
+// SyncRun records one execution of a calendar sync: what it changed on
+// either side and why events failed, so users can tell why an event didn't
+// show up
+type SyncRun struct {
+	ID             string           `json:"id"`
+	CalendarSyncID string           `json:"calendar_sync_id"`
+	UserID         UserID           `json:"user_id"`
+	Trigger        SyncTrigger      `json:"trigger"`
+	StartedAt      time.Time        `json:"started_at"`
+	FinishedAt     time.Time        `json:"finished_at"`
+	Created        int              `json:"created"`
+	Updated        int              `json:"updated"`
+	Deleted        int              `json:"deleted"`
+	Skipped        int              `json:"skipped"` // Unchanged, or waiting for a conflict to be resolved
+	Failed         int              `json:"failed"`
+	Error          string           `json:"error,omitempty"` // Why the run failed, if it did
+	EventErrors    []SyncEventError `json:"event_errors"`
+}
+
+// SyncEventError is an event a sync run failed to create, update or delete
+type SyncEventError struct {
+	EventID       EventID  `json:"event_id,omitempty"`        // Empty for remote events without a local copy
+	RemoteEventID string   `json:"remote_event_id,omitempty"` // Empty for local events not yet pushed
+	Title         string   `json:"title"`
+	Side          SyncSide `json:"side"`
+	Error         string   `json:"error"`
+}
+
+func (r *SyncRun) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// AddEventError counts a failed event and keeps its error
+func (r *SyncRun) AddEventError(eventError SyncEventError) {
+	r.Failed++
+	if len(r.EventErrors) < maxSyncEventErrors {
+		r.EventErrors = append(r.EventErrors, eventError)
+	}
+}
@@ -29,4 +29,6 @@ type UserSettings struct {
 	TimeFormat       string `json:"time_format"`
 	WeekStartDay     int    `json:"week_start_day"`
 	NotificationEnabled bool `json:"notification_enabled"`
+	DefaultReminders []Reminder `json:"default_reminders"` // Reminders of events whose calendar has no defaults
+	WebhookURL       string     `json:"webhook_url,omitempty"` // Receives reminders over the webhook channel
 }
\ No newline at end of file
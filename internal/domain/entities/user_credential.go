@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+)
+
+// UserCredential holds the password hash of a user. It is kept apart from
+// User so the hash never ends up in profile responses.
+type UserCredential struct {
+	UserID            UserID    `json:"-"`
+	PasswordHash      string    `json:"-"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Validation methods
+func (uc *UserCredential) IsValid() bool {
+	return uc.UserID != "" && uc.PasswordHash != ""
+}
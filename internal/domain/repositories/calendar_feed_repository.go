@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type CalendarFeedRepository interface {
+	// Revoke the user's active feed, if any, and create the new one atomically
+	Replace(ctx context.Context, feed *entities.CalendarFeed) error
+
+	// Get active feed by token hash
+	GetActiveByTokenHash(ctx context.Context, tokenHash string) (*entities.CalendarFeed, error)
+
+	// Get the active feed of a user
+	GetActiveByUserID(ctx context.Context, userID entities.UserID) (*entities.CalendarFeed, error)
+
+	// Update which goal data the feed includes
+	UpdateSettings(ctx context.Context, id entities.CalendarFeedID, includeGoals, includeMilestones bool) error
+
+	// Record when a client last fetched the feed
+	UpdateLastAccessed(ctx context.Context, id entities.CalendarFeedID, at time.Time) error
+
+	// Revoke the active feed of a user. Returns false when there was none.
+	RevokeByUserID(ctx context.Context, userID entities.UserID) (bool, error)
+}
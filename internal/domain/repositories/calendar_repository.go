@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type CalendarRepository interface {
+	// Create a new calendar
+	Create(ctx context.Context, calendar *entities.Calendar) error
+
+	// Get calendar by ID
+	GetByID(ctx context.Context, id entities.CalendarID) (*entities.Calendar, error)
+
+	// Get all calendars of a user, the default one first
+	GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Calendar, error)
+
+	// Get the default calendar of a user, creating it on first use
+	GetOrCreateDefault(ctx context.Context, userID entities.UserID) (*entities.Calendar, error)
+
+	// Update calendar
+	Update(ctx context.Context, calendar *entities.Calendar) error
+
+	// Delete a calendar together with its events and sync
+	Delete(ctx context.Context, id entities.CalendarID) error
+}
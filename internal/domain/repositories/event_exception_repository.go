@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type EventExceptionRepository interface {
+	// Create or replace the exception of an occurrence
+	Upsert(ctx context.Context, exception *entities.EventException) error
+
+	// Get exception of a single occurrence
+	GetByRecurrenceID(ctx context.Context, eventID entities.EventID, recurrenceID time.Time) (*entities.EventException, error)
+
+	// Get all exceptions of a recurring event
+	GetByEventID(ctx context.Context, eventID entities.EventID) ([]*entities.EventException, error)
+
+	// Get exceptions of several recurring events at once
+	GetByEventIDs(ctx context.Context, eventIDs []entities.EventID) ([]*entities.EventException, error)
+
+	// Delete exceptions of occurrences starting at or after the given time
+	DeleteFrom(ctx context.Context, eventID entities.EventID, from time.Time) error
+}
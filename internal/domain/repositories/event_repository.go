@@ -16,11 +16,16 @@ type EventRepository interface {
 	// Get all events for a user
 	GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Event, error)
 	
-	// Get events for a user within a time range
-	GetByUserIDAndTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time) ([]*entities.Event, error)
+	// Get events for a user within a time range, limited to the given
+	// calendars unless calendarIDs is nil
+	GetByUserIDAndTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error)
 	
 	// Alias for GetByUserIDAndTimeRange for consistency
-	GetByTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time) ([]*entities.Event, error)
+	GetByTimeRange(ctx context.Context, userID entities.UserID, start, end time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error)
+	
+	// Get events of a user starting within (start, end], e.g. to find the
+	// reminders falling due
+	GetStartingBetween(ctx context.Context, userID entities.UserID, start, end time.Time) ([]*entities.Event, error)
 	
 	// Get events for a specific goal
 	GetByGoalID(ctx context.Context, goalID entities.GoalID) ([]*entities.Event, error)
@@ -31,14 +36,27 @@ type EventRepository interface {
 	// Get events by external source
 	GetByExternalSource(ctx context.Context, userID entities.UserID, source string) ([]*entities.Event, error)
 	
-	// Get upcoming events for a user
-	GetUpcoming(ctx context.Context, userID entities.UserID, limit int) ([]*entities.Event, error)
+	// Get all-day events covering any day from start up to, but excluding,
+	// end; both are dates as returned by entities.DateIn. Limited to the
+	// given calendars unless calendarIDs is nil.
+	GetAllDayBetween(ctx context.Context, userID entities.UserID, start, end time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error)
+	
+	// Get upcoming events for a user; all-day events that haven't ended yet
+	// are included as well. Limited to the given calendars unless
+	// calendarIDs is nil.
+	GetUpcoming(ctx context.Context, userID entities.UserID, limit int, calendarIDs []entities.CalendarID) ([]*entities.Event, error)
+	
+	// Get timed events starting today in the given timezone, limited to the
+	// given calendars unless calendarIDs is nil
+	GetForToday(ctx context.Context, userID entities.UserID, timezone string, calendarIDs []entities.CalendarID) ([]*entities.Event, error)
 	
-	// Get events for today
-	GetForToday(ctx context.Context, userID entities.UserID, timezone string) ([]*entities.Event, error)
+	// Get recurring events, limited to the given calendars unless
+	// calendarIDs is nil
+	GetRecurring(ctx context.Context, userID entities.UserID, calendarIDs []entities.CalendarID) ([]*entities.Event, error)
 	
-	// Get recurring events
-	GetRecurring(ctx context.Context, userID entities.UserID) ([]*entities.Event, error)
+	// Get recurring events whose series starts before the given time,
+	// limited to the given calendars unless calendarIDs is nil
+	GetRecurringStartingBefore(ctx context.Context, userID entities.UserID, before time.Time, calendarIDs []entities.CalendarID) ([]*entities.Event, error)
 	
 	// Get events by status
 	GetByStatus(ctx context.Context, userID entities.UserID, status entities.EventStatus) ([]*entities.Event, error)
@@ -49,11 +67,20 @@ type EventRepository interface {
 	// Delete event
 	Delete(ctx context.Context, id entities.EventID) error
 	
+	// Delete an event that was deleted in the calendar it is synced from;
+	// unlike Delete no push of the deletion is queued
+	DeleteSynced(ctx context.Context, id entities.EventID) error
+	
 	// Bulk create events (for recurring events)
 	BulkCreate(ctx context.Context, events []*entities.Event) error
 	
-	// Check for time conflicts
-	HasConflict(ctx context.Context, userID entities.UserID, start, end time.Time, excludeEventID *entities.EventID) (bool, error)
+	// Create and update the events of an import in one transaction; the
+	// exceptions replace those of the updated events
+	Import(ctx context.Context, created, updated []*entities.Event, exceptions []*entities.EventException) error
+	
+	// Check for time conflicts with events that block time; all-day events
+	// only count when includeAllDay is set, transparent ones never
+	HasConflict(ctx context.Context, userID entities.UserID, start, end time.Time, excludeEventID *entities.EventID, includeAllDay bool) (bool, error)
 	
 	// Get events with pagination
 	GetByUserIDPaginated(ctx context.Context, userID entities.UserID, offset, limit int) ([]*entities.Event, int64, error)
@@ -86,6 +86,9 @@ type MilestoneRepository interface {
 	// Get upcoming milestones for a user
 	GetUpcomingByUserID(ctx context.Context, userID entities.UserID, before time.Time) ([]*entities.Milestone, error)
 	
+	// Get all milestones of a user's goals
+	GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Milestone, error)
+	
 	// Update milestone
 	Update(ctx context.Context, milestone *entities.Milestone) error
 	
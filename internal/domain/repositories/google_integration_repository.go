@@ -14,26 +14,34 @@ type GoogleIntegrationRepository interface {
 	// Get integration by ID
 	GetByID(ctx context.Context, id entities.GoogleIntegrationID) (*entities.GoogleIntegration, error)
 	
-	// Get integration by user ID
-	GetByUserID(ctx context.Context, userID entities.UserID) (*entities.GoogleIntegration, error)
+	// Get integrations by user ID
+	GetByUserID(ctx context.Context, userID entities.UserID) ([]*entities.GoogleIntegration, error)
 	
-	// Get integration by Google user ID
-	GetByGoogleUserID(ctx context.Context, googleUserID string) (*entities.GoogleIntegration, error)
+	// Get integration by the account ID of the user at the provider
+	GetByGoogleUserID(ctx context.Context, provider entities.IntegrationProvider, googleUserID string) (*entities.GoogleIntegration, error)
 	
 	// Update integration
 	Update(ctx context.Context, integration *entities.GoogleIntegration) error
 	
-	// Update tokens
+	// Update tokens; clears NeedsReconsent
 	UpdateTokens(ctx context.Context, id entities.GoogleIntegrationID, accessToken, refreshToken string, expiresAt time.Time) error
 	
-	// Delete integration
+	// Flag an integration whose refresh token the provider rejected
+	MarkNeedsReconsent(ctx context.Context, id entities.GoogleIntegrationID) error
+	
+	// Delete integration with its syncs; their calendars become local calendars
 	Delete(ctx context.Context, id entities.GoogleIntegrationID) error
 	
-	// Get integrations that need token refresh
+	// Get enabled integrations whose token expires before beforeTime and
+	// can be refreshed
 	GetExpiringSoon(ctx context.Context, beforeTime time.Time) ([]*entities.GoogleIntegration, error)
 	
 	// Get all active integrations
 	GetActive(ctx context.Context) ([]*entities.GoogleIntegration, error)
+	
+	// Re-encrypt tokens sealed with a retired key or stored unencrypted with
+	// the active key; returns the number of integrations updated
+	ReencryptTokens(ctx context.Context) (int, error)
 }
 
 type GoogleCalendarSyncRepository interface {
@@ -49,8 +57,8 @@ type GoogleCalendarSyncRepository interface {
 	// Get sync configurations by integration ID
 	GetByIntegrationID(ctx context.Context, integrationID entities.GoogleIntegrationID) ([]*entities.GoogleCalendarSync, error)
 	
-	// Get sync configuration by calendar ID
-	GetByCalendarID(ctx context.Context, userID entities.UserID, calendarID string) (*entities.GoogleCalendarSync, error)
+	// Get sync configuration of an integration by calendar ID
+	GetByCalendarID(ctx context.Context, integrationID entities.GoogleIntegrationID, calendarID string) (*entities.GoogleCalendarSync, error)
 	
 	// Update sync configuration
 	Update(ctx context.Context, sync *entities.GoogleCalendarSync) error
@@ -61,12 +69,38 @@ type GoogleCalendarSyncRepository interface {
 	// Update sync token
 	UpdateSyncToken(ctx context.Context, id string, syncToken string) error
 	
-	// Delete sync configuration
+	// Delete sync configuration; its calendar becomes a local calendar
 	Delete(ctx context.Context, id string) error
 	
 	// Get configurations that need sync
 	GetNeedingSync(ctx context.Context) ([]*entities.GoogleCalendarSync, error)
 	
+	// Claim up to limit due syncs for a worker until the lease expires.
+	// Rows claimed by other workers are skipped.
+	ClaimDue(ctx context.Context, workerID string, limit int, lease time.Duration) ([]*entities.GoogleCalendarSync, error)
+	
+	// Claim a single sync; false when another worker holds it
+	Claim(ctx context.Context, id string, workerID string, lease time.Duration) (bool, error)
+	
+	// Release a claimed sync and schedule its next run; clears Notified
+	Release(ctx context.Context, id string, workerID string, nextSyncAt time.Time, failureCount int) error
+	
+	// Get sync configuration by watch channel ID
+	GetByChannelID(ctx context.Context, channelID string) (*entities.GoogleCalendarSync, error)
+	
+	// Get the syncs of a provider's integrations whose watch channel must be
+	// created, renewed because it expires before expiresBefore, or stopped
+	// because the sync isn't watched
+	GetNeedingChannel(ctx context.Context, provider entities.IntegrationProvider, expiresBefore time.Time) ([]*entities.GoogleCalendarSync, error)
+	
+	// Replace the watch channel if the current one is still oldChannelID;
+	// false when another worker swapped it first. A nil channel clears it.
+	SwapChannel(ctx context.Context, id string, oldChannelID *string, channel *entities.WatchChannel) (bool, error)
+	
+	// Make the scheduler run the sync on its next poll, unless it is backing
+	// off after failures, and flag the sync as notified
+	ScheduleNow(ctx context.Context, id string) error
+	
 	// Get active sync configurations
 	GetActive(ctx context.Context) ([]*entities.GoogleCalendarSync, error)
 }
\ No newline at end of file
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// GoogleOutboxRepository hands out queued pushes. Entries are written by
+// EventRepository.Update and Delete in the transaction of the change.
+type GoogleOutboxRepository interface {
+	// Claim up to limit due entries for a worker until the lease expires.
+	// Only the oldest pending entry of an event is handed out, so the
+	// changes of an event are pushed in order.
+	ClaimDue(ctx context.Context, workerID string, limit int, lease time.Duration) ([]*entities.GoogleOutboxEntry, error)
+
+	// Mark an entry as pushed; the event is synced once nothing is pending
+	Complete(ctx context.Context, id string) error
+
+	// Record a failed attempt and schedule the next one
+	Retry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+
+	// Record a failed attempt and give up; the event shows the error
+	Fail(ctx context.Context, id string, lastError string) error
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type GoogleEventLinkRepository interface {
+	// Create or update the link of an event within a calendar sync
+	Upsert(ctx context.Context, link *entities.GoogleEventLink) error
+
+	// Get the link of a Google event, or nil
+	GetByGoogleEventID(ctx context.Context, calendarSyncID string, googleEventID string) (*entities.GoogleEventLink, error)
+
+	// Get the link of a local event, or nil
+	GetByEventID(ctx context.Context, calendarSyncID string, eventID entities.EventID) (*entities.GoogleEventLink, error)
+
+	// Get all links of a calendar sync
+	GetBySyncID(ctx context.Context, calendarSyncID string) ([]*entities.GoogleEventLink, error)
+}
+
+type GoogleSyncConflictRepository interface {
+	// Create a conflict, or refresh both versions of the open conflict of the event
+	Upsert(ctx context.Context, conflict *entities.GoogleSyncConflict) error
+
+	// Get conflict by ID
+	GetByID(ctx context.Context, id string) (*entities.GoogleSyncConflict, error)
+
+	// Get the open conflict of an event, or nil
+	GetOpenByEventID(ctx context.Context, calendarSyncID string, eventID entities.EventID) (*entities.GoogleSyncConflict, error)
+
+	// Get the open conflicts of a calendar sync, oldest first
+	GetOpenBySyncID(ctx context.Context, calendarSyncID string) ([]*entities.GoogleSyncConflict, error)
+
+	// Mark a conflict as resolved
+	Resolve(ctx context.Context, id string, choice entities.ConflictChoice, resolvedAt time.Time) error
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// NotificationRepository queues reminders for delivery and keeps the in-app
+// notifications of users
+type NotificationRepository interface {
+	// Queue a notification unless the same reminder was queued before;
+	// reports whether it was queued
+	Enqueue(ctx context.Context, notification *entities.Notification) (bool, error)
+
+	// Claim up to limit due notifications for a worker until the lease expires
+	ClaimDue(ctx context.Context, workerID string, limit int, lease time.Duration) ([]*entities.Notification, error)
+
+	// Mark a notification as sent
+	Complete(ctx context.Context, id string) error
+
+	// Mark a notification as skipped without sending it
+	Skip(ctx context.Context, id string, reason string) error
+
+	// Record a failed attempt and schedule the next one
+	Retry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+
+	// Record a failed attempt and give up
+	Fail(ctx context.Context, id string, lastError string) error
+
+	// Get the sent in-app notifications of a user, newest first
+	GetInApp(ctx context.Context, userID entities.UserID, unreadOnly bool, limit int) ([]*entities.Notification, error)
+
+	// Mark an in-app notification of a user as read
+	MarkRead(ctx context.Context, id string, userID entities.UserID) error
+
+	// Mark all in-app notifications of a user as read
+	MarkAllRead(ctx context.Context, userID entities.UserID) error
+}
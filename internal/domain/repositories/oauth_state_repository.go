@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type OAuthStateRepository interface {
+	// Create a new state
+	Create(ctx context.Context, state *entities.OAuthState) error
+
+	// Get state by its hash
+	GetByHash(ctx context.Context, stateHash string) (*entities.OAuthState, error)
+
+	// Mark a state as used. Returns false when it has already been used.
+	MarkUsed(ctx context.Context, stateHash string) (bool, error)
+
+	// Delete states that expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
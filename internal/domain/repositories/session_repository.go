@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type SessionRepository interface {
+	// Create a new session together with its first refresh token
+	Create(ctx context.Context, session *entities.Session, token *entities.RefreshToken) error
+
+	// Get session by ID
+	GetByID(ctx context.Context, id entities.SessionID) (*entities.Session, error)
+
+	// Get active (not revoked, not expired) sessions of a user
+	GetActiveByUserID(ctx context.Context, userID entities.UserID) ([]*entities.Session, error)
+
+	// Get refresh token by its jti
+	GetRefreshToken(ctx context.Context, id string) (*entities.RefreshToken, error)
+
+	// Mark the old refresh token as used and store its replacement atomically.
+	// Returns false when the old token has already been used.
+	RotateRefreshToken(ctx context.Context, oldTokenID string, newToken *entities.RefreshToken, sessionExpiresAt time.Time) (bool, error)
+
+	// Revoke a session and thereby all of its refresh tokens
+	Revoke(ctx context.Context, id entities.SessionID, reason string) error
+
+	// Revoke all sessions of a user except the given one (may be empty)
+	RevokeAllByUserID(ctx context.Context, userID entities.UserID, exceptID entities.SessionID, reason string) error
+}
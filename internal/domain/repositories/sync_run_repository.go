@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type SyncRunRepository interface {
+	// Record a finished sync run
+	Create(ctx context.Context, run *entities.SyncRun) error
+
+	// Get the runs of a calendar sync, newest first, with their total count
+	GetBySyncID(ctx context.Context, calendarSyncID string, offset, limit int) ([]*entities.SyncRun, int64, error)
+
+	// Delete all but the newest keep runs of a calendar sync
+	Prune(ctx context.Context, calendarSyncID string, keep int) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+type UserCredentialRepository interface {
+	// Create credentials for a user
+	Create(ctx context.Context, credential *entities.UserCredential) error
+
+	// Get credentials by user ID
+	GetByUserID(ctx context.Context, userID entities.UserID) (*entities.UserCredential, error)
+
+	// Replace the password hash of a user
+	UpdatePasswordHash(ctx context.Context, userID entities.UserID, passwordHash string, changed bool) error
+}
@@ -23,4 +23,7 @@ type UserRepository interface {
 	
 	// Check if user exists by email
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	
+	// Get users who turned notifications on
+	GetWithNotificationsEnabled(ctx context.Context) ([]*entities.User, error)
 }
\ No newline at end of file
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// ErrRemoteEventNotFound is returned when an event doesn't exist at the provider
+var ErrRemoteEventNotFound = errors.New("remote event not found")
+
+// ErrInvalidGrant is returned by RefreshToken when the provider rejects the
+// refresh token, e.g. because the user revoked access; only connecting the
+// account again helps
+var ErrInvalidGrant = errors.New("refresh token rejected")
+
+// CalendarProvider is a calendar service local events are synced with, such
+// as Google Calendar or Outlook. Calls are made with the access token of an
+// integration of the provider.
+type CalendarProvider interface {
+	// Name is the provider of the integrations the provider serves
+	Name() entities.IntegrationProvider
+
+	// RefreshToken exchanges a refresh token for a new access token. The
+	// returned refresh token is empty unless the provider rotated it.
+	// Rejected refresh tokens fail with ErrInvalidGrant.
+	RefreshToken(ctx context.Context, refreshToken string) (*ProviderTokens, error)
+
+	// ListCalendars lists the calendars of the account
+	ListCalendars(ctx context.Context, accessToken string) ([]*RemoteCalendar, error)
+
+	// ListEventChanges lists the events changed since the listing that
+	// returned deltaToken, including deleted ones. Without a delta token, or
+	// when the provider expired it, all events starting after timeMin are
+	// listed instead.
+	ListEventChanges(ctx context.Context, accessToken, calendarID, deltaToken string, timeMin time.Time) (*RemoteEventChanges, error)
+
+	// CreateEvent creates a local event in the calendar. The cancelled
+	// exceptions of a recurring event are excluded from the remote series.
+	CreateEvent(ctx context.Context, accessToken, calendarID string, event *entities.Event, exceptions []*entities.EventException) (*RemoteEvent, error)
+
+	// UpdateEvent overwrites a remote event with a local one, like CreateEvent
+	UpdateEvent(ctx context.Context, accessToken, calendarID, eventID string, event *entities.Event, exceptions []*entities.EventException) (*RemoteEvent, error)
+
+	// DeleteEvent deletes a remote event; ErrRemoteEventNotFound when it is
+	// already gone
+	DeleteEvent(ctx context.Context, accessToken, calendarID, eventID string) error
+}
+
+// ChangeWatcher is implemented by providers that notify a webhook of changes
+// to a calendar. Calendars of other providers are only polled.
+type ChangeWatcher interface {
+	// WatchEvents opens a channel on which the provider notifies address of
+	// changes to the calendar's events. Notifications carry token so the
+	// receiver can verify them; the provider may expire the channel before ttl.
+	WatchEvents(ctx context.Context, accessToken, calendarID, channelID, address, token string, ttl time.Duration) (*entities.WatchChannel, error)
+
+	// StopWatch stops the notifications of a channel; channels that already
+	// expired are ignored
+	StopWatch(ctx context.Context, accessToken string, channel *entities.WatchChannel) error
+}
+
+// ProviderTokens are the OAuth tokens of an integration
+type ProviderTokens struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// RemoteCalendar is a calendar of a provider account
+type RemoteCalendar struct {
+	ID          string `json:"id"`
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+	AccessRole  string `json:"access_role"`
+}
+
+// RemoteEvent is an event as a provider returns it. All-day events start
+// and end at midnight in TimeZone, the end being exclusive.
+type RemoteEvent struct {
+	ID          string               `json:"id"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Location    string               `json:"location"`
+	StartTime   time.Time            `json:"start_time"`
+	EndTime     time.Time            `json:"end_time"`
+	AllDay      bool                 `json:"all_day"`
+	TimeZone    string               `json:"time_zone,omitempty"`
+	Attendees   []entities.Attendee  `json:"attendees,omitempty"`
+	Status      entities.EventStatus `json:"status,omitempty"`
+	Color       string               `json:"color,omitempty"`
+	Transparent bool                 `json:"transparent,omitempty"`
+	CalendarID  string               `json:"calendar_id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+
+	// Recurrence is set on recurring series, along with the original starts
+	// of the occurrences excluded from it. RecurringEventID is set on
+	// modified instances of a series, which providers return alongside it.
+	Recurrence       *entities.RecurrenceRule `json:"recurrence,omitempty"`
+	ExcludedDates    []time.Time              `json:"excluded_dates,omitempty"`
+	RecurringEventID string                   `json:"recurring_event_id,omitempty"`
+
+	// Cancelled is set on events deleted at the provider, which incremental
+	// listings return. OriginalStartTime identifies a cancelled or modified
+	// instance within its series.
+	Cancelled         bool       `json:"cancelled,omitempty"`
+	OriginalStartTime *time.Time `json:"original_start_time,omitempty"`
+}
+
+// RemoteEventChanges is the result of an incremental listing. A full
+// listing returns every event ending after timeMin and, unless WindowEnd is
+// zero, starting before WindowEnd; events missing from it were deleted.
+type RemoteEventChanges struct {
+	Events     []*RemoteEvent
+	DeltaToken string    // Lists the changes after this listing next time
+	FullSync   bool      // The delta token was missing or expired and all events were listed
+	WindowEnd  time.Time // Of a full listing
+}
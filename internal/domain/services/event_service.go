@@ -63,28 +63,38 @@ func (s *EventService) ValidateEventCreation(event *entities.Event) error {
 		return fmt.Errorf("event description must be 1000 characters or less")
 	}
 	
-	// Validate time range
-	if event.EndTime.Before(event.StartTime) || event.EndTime.Equal(event.StartTime) {
-		return fmt.Errorf("end time must be after start time")
-	}
-	
-	// Validate event duration (max 24 hours)
-	duration := event.EndTime.Sub(event.StartTime)
-	if duration > 24*time.Hour {
-		return fmt.Errorf("event duration cannot exceed 24 hours")
-	}
-	
 	// Validate timezone
 	if event.Timezone == "" {
 		event.Timezone = "UTC" // Default to UTC
 	}
 	
 	// Validate timezone format
-	_, err := time.LoadLocation(event.Timezone)
+	loc, err := time.LoadLocation(event.Timezone)
 	if err != nil {
 		return fmt.Errorf("invalid timezone: %s", event.Timezone)
 	}
 	
+	// Validate time range
+	if event.EndTime.Before(event.StartTime) || event.EndTime.Equal(event.StartTime) {
+		return fmt.Errorf("end time must be after start time")
+	}
+	
+	if event.AllDay {
+		// All-day events cover whole days of their timezone and may span several
+		if !entities.IsStartOfDay(event.StartTime, loc) || !entities.IsStartOfDay(event.EndTime, loc) {
+			return fmt.Errorf("all-day events must start and end at midnight in %s", event.Timezone)
+		}
+		if event.Days() > 366 {
+			return fmt.Errorf("all-day events cannot span more than 366 days")
+		}
+	} else {
+		// Validate event duration (max 24 hours)
+		duration := event.EndTime.Sub(event.StartTime)
+		if duration > 24*time.Hour {
+			return fmt.Errorf("event duration cannot exceed 24 hours, use an all-day event for longer ones")
+		}
+	}
+	
 	// Validate location length
 	if utf8.RuneCountInString(event.Location) > 255 {
 		return fmt.Errorf("event location must be 255 characters or less")
@@ -116,6 +126,11 @@ func (s *EventService) ValidateEventCreation(event *entities.Event) error {
 		}
 	}
 	
+	// Validate reminders
+	if !entities.ValidReminders(event.Reminders) {
+		return fmt.Errorf("events can have at most %d reminders, each with a known channel and at most four weeks before the event", entities.MaxReminders)
+	}
+	
 	return nil
 }
 
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// ErrUndeliverable is wrapped by Notifier errors that retrying won't fix,
+// e.g. a user without an address for the channel or a recipient the
+// server rejected
+var ErrUndeliverable = errors.New("notification undeliverable")
+
+// Notifier delivers notifications over one reminder channel, such as email
+// or a webhook. Errors not wrapping ErrUndeliverable are retried.
+type Notifier interface {
+	// Channel is the reminder channel the notifier delivers
+	Channel() entities.ReminderChannel
+
+	// Notify delivers a notification to the user it belongs to
+	Notify(ctx context.Context, notification *entities.Notification, user *entities.User) error
+}
@@ -0,0 +1,11 @@
+package services
+
+// PasswordHasher hashes and verifies user passwords.
+type PasswordHasher interface {
+	// Hash returns an encoded hash that embeds its own salt and parameters
+	Hash(password string) (string, error)
+
+	// Verify checks password against encodedHash. needsRehash reports that
+	// the hash was produced with parameters other than the current ones.
+	Verify(password, encodedHash string) (match bool, needsRehash bool, err error)
+}
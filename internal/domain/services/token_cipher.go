@@ -0,0 +1,13 @@
+package services
+
+// TokenCipher encrypts secrets stored at rest, such as OAuth tokens.
+type TokenCipher interface {
+	// Encrypt seals plaintext with the active key. associatedData binds the
+	// ciphertext to its owner: decrypting it with other data fails.
+	Encrypt(plaintext, associatedData string) (string, error)
+
+	// Decrypt opens a value sealed by Encrypt. needsReencrypt reports that
+	// it was sealed with a key other than the active one, or stored
+	// unencrypted before encryption was enabled.
+	Decrypt(ciphertext, associatedData string) (plaintext string, needsReencrypt bool, err error)
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -78,6 +80,24 @@ func (s *UserService) ValidateName(name string) error {
 	return nil
 }
 
+// ValidatePassword validates password strength requirements
+func (s *UserService) ValidatePassword(password string) error {
+	if len(password) < 8 {
+		return fmt.Errorf("password is too short (min 8 characters)")
+	}
+	
+	// argon2 accepts any length, but very long inputs are only a DoS vector
+	if len(password) > 128 {
+		return fmt.Errorf("password is too long (max 128 characters)")
+	}
+	
+	if strings.TrimSpace(password) == "" {
+		return fmt.Errorf("password cannot consist of whitespace only")
+	}
+	
+	return nil
+}
+
 // ValidateProfile validates user profile data
 func (s *UserService) ValidateProfile(profile *entities.UserProfile) error {
 	if profile.FirstName != "" && len(profile.FirstName) > 50 {
@@ -127,9 +147,73 @@ func (s *UserService) ValidateSettings(settings *entities.UserSettings) error {
 		return fmt.Errorf("invalid week start day (must be 0-6)")
 	}
 	
+	if !entities.ValidReminders(settings.DefaultReminders) {
+		return fmt.Errorf("at most %d default reminders are allowed, each with a known channel and at most four weeks before the event", entities.MaxReminders)
+	}
+	
+	if settings.WebhookURL != "" {
+		if err := s.ValidateWebhookURL(settings.WebhookURL); err != nil {
+			return fmt.Errorf("invalid webhook URL: %w", err)
+		}
+	}
+	
 	return nil
 }
 
+// ValidateWebhookURL validates the URL reminders are posted to
+func (s *UserService) ValidateWebhookURL(rawURL string) error {
+	if len(rawURL) > 500 {
+		return fmt.Errorf("webhook URL is too long (max 500 characters)")
+	}
+	
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook URL must be an absolute http or https URL")
+	}
+	
+	// Names are checked again when the webhook is called, as they may
+	// resolve differently by then
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("webhook URL must point to a public host")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublicAddress(addr) {
+		return fmt.Errorf("webhook URL must point to a public host")
+	}
+	
+	return nil
+}
+
+// nonPublicPrefixes are the special-purpose ranges (RFC 6890) not covered
+// by the netip.Addr predicates used in IsPublicAddress
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// IsPublicAddress reports whether an address is reachable on the public
+// internet, i.e. not loopback, link-local, private or otherwise reserved.
+// Webhooks are only posted to public addresses, so that they can't reach
+// the internal network of the server.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
 // ValidateTimezone validates timezone string
 func (s *UserService) ValidateTimezone(tz string) error {
 	_, err := time.LoadLocation(tz)
@@ -228,6 +312,9 @@ func (s *UserService) GetDefaultUserSettings() entities.UserSettings {
 		TimeFormat:          "24h",
 		WeekStartDay:        1, // Monday
 		NotificationEnabled: true,
+		DefaultReminders: []entities.Reminder{
+			{MinutesBefore: 10, Channel: entities.ReminderChannelInApp},
+		},
 	}
 }
 
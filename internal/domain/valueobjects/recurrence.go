@@ -2,28 +2,43 @@ package valueobjects
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// RecurrenceRule is an RFC 5545 RRULE
 type RecurrenceRule struct {
-	Frequency Frequency  `json:"frequency"`
-	Interval  int        `json:"interval"`       // Every N frequency
-	Until     *time.Time `json:"until,omitempty"`
-	Count     *int       `json:"count,omitempty"`
-	ByDay     []Weekday  `json:"by_day,omitempty"`
-	ByMonth   []Month    `json:"by_month,omitempty"`
+	Frequency  Frequency  `json:"frequency"`
+	Interval   int        `json:"interval"`       // Every N frequency
+	Until      *time.Time `json:"until,omitempty"`
+	Count      *int       `json:"count,omitempty"`
+	BySecond   []int      `json:"by_second,omitempty"`
+	ByMinute   []int      `json:"by_minute,omitempty"`
+	ByHour     []int      `json:"by_hour,omitempty"`
+	ByDay      []Weekday  `json:"by_day,omitempty"`       // Optionally with ordinal, e.g. "2TU" or "-1FR"
+	ByMonthDay []int      `json:"by_month_day,omitempty"` // 1..31 or -31..-1
+	ByYearDay  []int      `json:"by_year_day,omitempty"`  // 1..366 or -366..-1
+	ByWeekNo   []int      `json:"by_week_no,omitempty"`   // 1..53 or -53..-1
+	ByMonth    []Month    `json:"by_month,omitempty"`
+	BySetPos   []int      `json:"by_set_pos,omitempty"`   // 1..366 or -366..-1
+	WeekStart  Weekday    `json:"week_start,omitempty"`   // WKST, Monday when empty
 }
 
 type Frequency string
 
 const (
-	FrequencyDaily   Frequency = "DAILY"
-	FrequencyWeekly  Frequency = "WEEKLY"
-	FrequencyMonthly Frequency = "MONTHLY"
-	FrequencyYearly  Frequency = "YEARLY"
+	FrequencySecondly Frequency = "SECONDLY"
+	FrequencyMinutely Frequency = "MINUTELY"
+	FrequencyHourly   Frequency = "HOURLY"
+	FrequencyDaily    Frequency = "DAILY"
+	FrequencyWeekly   Frequency = "WEEKLY"
+	FrequencyMonthly  Frequency = "MONTHLY"
+	FrequencyYearly   Frequency = "YEARLY"
 )
 
+// Weekday is an RFC 5545 weekday ("MO".."SU"), optionally prefixed with an
+// ordinal for MONTHLY and YEARLY rules ("1MO", "-1FR")
 type Weekday string
 
 const (
@@ -53,6 +68,47 @@ const (
 	December
 )
 
+var weekdays = []Weekday{WeekdayMonday, WeekdayTuesday, WeekdayWednesday, WeekdayThursday, WeekdayFriday, WeekdaySaturday, WeekdaySunday}
+
+// NthWeekday returns the ordinal weekday, e.g. NthWeekday(-1, WeekdayFriday) is "-1FR"
+func NthWeekday(n int, weekday Weekday) Weekday {
+	if n == 0 {
+		return weekday
+	}
+	return Weekday(fmt.Sprintf("%d%s", n, weekday))
+}
+
+// Ordinal splits an ordinal weekday into its ordinal (0 when absent) and plain weekday
+func (w Weekday) Ordinal() (int, Weekday) {
+	s := string(w)
+	if len(s) <= 2 {
+		return 0, w
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-2])
+	if err != nil {
+		return 0, Weekday(s[len(s)-2:])
+	}
+	return n, Weekday(s[len(s)-2:])
+}
+
+// IsValid checks the weekday and, if present, its ordinal (1..53 or -53..-1)
+func (w Weekday) IsValid() bool {
+	n, day := w.Ordinal()
+	if n < -53 || n > 53 {
+		return false
+	}
+	if n == 0 && len(w) > 2 {
+		return false
+	}
+	for _, wd := range weekdays {
+		if day == wd {
+			return true
+		}
+	}
+	return false
+}
+
 // NewRecurrenceRule creates a new recurrence rule with validation
 func NewRecurrenceRule(frequency Frequency, interval int) *RecurrenceRule {
 	if interval <= 0 {
@@ -91,13 +147,34 @@ func (r *RecurrenceRule) SetByMonth(months ...Month) *RecurrenceRule {
 	return r
 }
 
+// SetByMonthDay sets the days of the month; negative values count from the end
+func (r *RecurrenceRule) SetByMonthDay(days ...int) *RecurrenceRule {
+	r.ByMonthDay = days
+	return r
+}
+
+// SetBySetPos limits each period to the n-th occurrences of the expanded set
+func (r *RecurrenceRule) SetBySetPos(positions ...int) *RecurrenceRule {
+	r.BySetPos = positions
+	return r
+}
+
+// SetWeekStart sets the day a week starts on (WKST)
+func (r *RecurrenceRule) SetWeekStart(weekday Weekday) *RecurrenceRule {
+	r.WeekStart = weekday
+	return r
+}
+
 // IsValid validates the recurrence rule
 func (r *RecurrenceRule) IsValid() bool {
 	if r.Interval <= 0 {
 		return false
 	}
 	
-	validFrequencies := []Frequency{FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly}
+	validFrequencies := []Frequency{
+		FrequencySecondly, FrequencyMinutely, FrequencyHourly,
+		FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly,
+	}
 	validFreq := false
 	for _, f := range validFrequencies {
 		if r.Frequency == f {
@@ -115,32 +192,75 @@ func (r *RecurrenceRule) IsValid() bool {
 		return false
 	}
 	
-	// Validate weekdays for weekly recurrence
-	if r.Frequency == FrequencyWeekly && len(r.ByDay) > 0 {
-		validWeekdays := []Weekday{WeekdayMonday, WeekdayTuesday, WeekdayWednesday, WeekdayThursday, WeekdayFriday, WeekdaySaturday, WeekdaySunday}
-		for _, wd := range r.ByDay {
-			valid := false
-			for _, vwd := range validWeekdays {
-				if wd == vwd {
-					valid = true
-					break
-				}
-			}
-			if !valid {
-				return false
-			}
+	if r.Count != nil && *r.Count <= 0 {
+		return false
+	}
+	
+	// Validate weekdays; ordinals only make sense for monthly and yearly rules
+	for _, wd := range r.ByDay {
+		if !wd.IsValid() {
+			return false
+		}
+		if n, _ := wd.Ordinal(); n != 0 && r.Frequency != FrequencyMonthly && r.Frequency != FrequencyYearly {
+			return false
 		}
 	}
 	
-	// Validate months for yearly recurrence
-	if r.Frequency == FrequencyYearly && len(r.ByMonth) > 0 {
-		for _, m := range r.ByMonth {
-			if m < January || m > December {
-				return false
-			}
+	if r.WeekStart != "" {
+		if n, _ := r.WeekStart.Ordinal(); n != 0 || !r.WeekStart.IsValid() {
+			return false
+		}
+	}
+	
+	// Validate months
+	for _, m := range r.ByMonth {
+		if m < January || m > December {
+			return false
 		}
 	}
 	
+	if !inRange(r.BySecond, 0, 60, false) || !inRange(r.ByMinute, 0, 59, false) || !inRange(r.ByHour, 0, 23, false) {
+		return false
+	}
+	
+	if !inRange(r.ByMonthDay, 1, 31, true) || !inRange(r.ByYearDay, 1, 366, true) ||
+		!inRange(r.ByWeekNo, 1, 53, true) || !inRange(r.BySetPos, 1, 366, true) {
+		return false
+	}
+	
+	// RFC 5545 restrictions on where rule parts may appear
+	if len(r.ByMonthDay) > 0 && r.Frequency == FrequencyWeekly {
+		return false
+	}
+	if len(r.ByYearDay) > 0 && (r.Frequency == FrequencyDaily || r.Frequency == FrequencyWeekly || r.Frequency == FrequencyMonthly) {
+		return false
+	}
+	if len(r.ByWeekNo) > 0 && r.Frequency != FrequencyYearly {
+		return false
+	}
+	
+	// BYSETPOS selects from the set the other BYxxx parts expand to
+	if len(r.BySetPos) > 0 && len(r.BySecond) == 0 && len(r.ByMinute) == 0 && len(r.ByHour) == 0 &&
+		len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByYearDay) == 0 &&
+		len(r.ByWeekNo) == 0 && len(r.ByMonth) == 0 {
+		return false
+	}
+	
+	return true
+}
+
+// inRange checks that all values are within [min, max], or within
+// [-max, -min] as well when negative is allowed
+func inRange(values []int, min, max int, negative bool) bool {
+	for _, v := range values {
+		if v >= min && v <= max {
+			continue
+		}
+		if negative && v <= -min && v >= -max {
+			continue
+		}
+		return false
+	}
 	return true
 }
 
@@ -166,6 +286,10 @@ func (r *RecurrenceRule) ToRRULE() string {
 		parts = append(parts, fmt.Sprintf("COUNT=%d", *r.Count))
 	}
 	
+	parts = appendIntPart(parts, "BYSECOND", r.BySecond)
+	parts = appendIntPart(parts, "BYMINUTE", r.ByMinute)
+	parts = appendIntPart(parts, "BYHOUR", r.ByHour)
+	
 	if len(r.ByDay) > 0 {
 		weekdays := make([]string, len(r.ByDay))
 		for i, wd := range r.ByDay {
@@ -174,6 +298,10 @@ func (r *RecurrenceRule) ToRRULE() string {
 		parts = append(parts, fmt.Sprintf("BYDAY=%s", strings.Join(weekdays, ",")))
 	}
 	
+	parts = appendIntPart(parts, "BYMONTHDAY", r.ByMonthDay)
+	parts = appendIntPart(parts, "BYYEARDAY", r.ByYearDay)
+	parts = appendIntPart(parts, "BYWEEKNO", r.ByWeekNo)
+	
 	if len(r.ByMonth) > 0 {
 		months := make([]string, len(r.ByMonth))
 		for i, m := range r.ByMonth {
@@ -182,29 +310,146 @@ func (r *RecurrenceRule) ToRRULE() string {
 		parts = append(parts, fmt.Sprintf("BYMONTH=%s", strings.Join(months, ",")))
 	}
 	
+	parts = appendIntPart(parts, "BYSETPOS", r.BySetPos)
+	
+	if r.WeekStart != "" && r.WeekStart != WeekdayMonday {
+		parts = append(parts, fmt.Sprintf("WKST=%s", r.WeekStart))
+	}
+	
 	return strings.Join(parts, ";")
 }
 
-// GetNextOccurrence calculates the next occurrence after the given time
-func (r *RecurrenceRule) GetNextOccurrence(after time.Time) time.Time {
-	if !r.IsValid() {
-		return time.Time{}
+func appendIntPart(parts []string, name string, values []int) []string {
+	if len(values) == 0 {
+		return parts
+	}
+	
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = strconv.Itoa(v)
+	}
+	return append(parts, fmt.Sprintf("%s=%s", name, strings.Join(formatted, ",")))
+}
+
+// ParseRRULE parses an RFC 5545 RRULE value, with or without the "RRULE:" prefix
+func ParseRRULE(value string) (*RecurrenceRule, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= 6 && strings.EqualFold(value[:6], "RRULE:") {
+		value = value[6:]
+	}
+	if value == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+	
+	rule := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		
+		name, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Frequency = Frequency(strings.ToUpper(val))
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(val)
+			rule.Until = &until
+		case "COUNT":
+			var count int
+			count, err = strconv.Atoi(val)
+			rule.Count = &count
+		case "BYSECOND":
+			rule.BySecond, err = parseIntList(val)
+		case "BYMINUTE":
+			rule.ByMinute, err = parseIntList(val)
+		case "BYHOUR":
+			rule.ByHour, err = parseIntList(val)
+		case "BYDAY":
+			for _, wd := range strings.Split(val, ",") {
+				rule.ByDay = append(rule.ByDay, Weekday(strings.ToUpper(strings.TrimPrefix(wd, "+"))))
+			}
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val)
+		case "BYYEARDAY":
+			rule.ByYearDay, err = parseIntList(val)
+		case "BYWEEKNO":
+			rule.ByWeekNo, err = parseIntList(val)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, Month(m))
+			}
+		case "BYSETPOS":
+			rule.BySetPos, err = parseIntList(val)
+		case "WKST":
+			rule.WeekStart = Weekday(strings.ToUpper(val))
+		default:
+			// X-names and future rule parts are ignored
+		}
+		
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence rule part %q: %w", part, err)
+		}
 	}
 	
-	next := after
+	if rule.Frequency == "" {
+		return nil, fmt.Errorf("recurrence rule is missing FREQ")
+	}
+	
+	if !rule.IsValid() {
+		return nil, fmt.Errorf("invalid recurrence rule %q", value)
+	}
 	
-	switch r.Frequency {
-	case FrequencyDaily:
-		next = next.AddDate(0, 0, r.Interval)
-	case FrequencyWeekly:
-		next = next.AddDate(0, 0, 7*r.Interval)
-	case FrequencyMonthly:
-		next = next.AddDate(0, r.Interval, 0)
-	case FrequencyYearly:
-		next = next.AddDate(r.Interval, 0, 0)
+	return rule, nil
+}
+
+// parseUntil accepts UTC date-times, floating date-times (treated as UTC) and
+// dates, which include the whole day
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+func parseIntList(value string) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(strings.TrimPrefix(item, "+"))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// GetNextOccurrence calculates the next occurrence of a series that starts
+// at the given time, honouring BYDAY, BYMONTH, COUNT and UNTIL. Returns the
+// zero time when the series has no further occurrences.
+func (r *RecurrenceRule) GetNextOccurrence(after time.Time) time.Time {
+	next := r.After(after, after, after.Location(), 1)
+	if len(next) == 0 {
+		return time.Time{}
 	}
 	
-	return next
+	return next[0]
 }
 
 // Common recurrence patterns
@@ -228,4 +473,16 @@ func MonthlyRecurrence() *RecurrenceRule {
 
 func YearlyRecurrence() *RecurrenceRule {
 	return NewRecurrenceRule(FrequencyYearly, 1)
-}
\ No newline at end of file
+}
+
+// Last given weekday of every month, e.g. "last Friday"
+func LastWeekdayOfMonthRecurrence(weekday Weekday) *RecurrenceRule {
+	return NewRecurrenceRule(FrequencyMonthly, 1).SetByDay(NthWeekday(-1, weekday))
+}
+
+// First working day (Monday to Friday) of every month
+func FirstWorkdayOfMonthRecurrence() *RecurrenceRule {
+	return NewRecurrenceRule(FrequencyMonthly, 1).SetByDay(
+		WeekdayMonday, WeekdayTuesday, WeekdayWednesday, WeekdayThursday, WeekdayFriday,
+	).SetBySetPos(1)
+}
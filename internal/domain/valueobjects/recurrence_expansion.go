@@ -0,0 +1,527 @@
+package valueobjects
+
+import (
+	"sort"
+	"time"
+)
+
+// maxRecurrencePeriods bounds the expansion loop so a rule that never
+// produces an instance (e.g. monthly on the 31st restricted to February)
+// can't spin forever. It counts periods from the first one expanded, which is
+// the period of the from hint unless the rule has a COUNT. One expansion thus
+// covers at most 100000 periods: about 69 days of a MINUTELY rule and 27
+// hours of a SECONDLY one, or that long after DTSTART when COUNT is set.
+// Occurrences beyond are not returned.
+const maxRecurrencePeriods = 100000
+
+// Between returns the start times of all occurrences of a series starting at
+// dtstart that fall within [from, to]. Occurrences are computed on the wall
+// clock of loc, so a 09:00 meeting stays at 09:00 across DST changes.
+// Sub-daily rules are expanded up to maxRecurrencePeriods periods only.
+func (r *RecurrenceRule) Between(dtstart, from, to time.Time, loc *time.Location) []time.Time {
+	var occurrences []time.Time
+	r.iterate(dtstart, from, loc, func(t time.Time) bool {
+		if t.After(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// After returns up to limit occurrence start times strictly after the given
+// time, within maxRecurrencePeriods periods like Between
+func (r *RecurrenceRule) After(dtstart, after time.Time, loc *time.Location, limit int) []time.Time {
+	if limit <= 0 {
+		return nil
+	}
+
+	var occurrences []time.Time
+	r.iterate(dtstart, after, loc, func(t time.Time) bool {
+		if t.After(after) {
+			occurrences = append(occurrences, t)
+		}
+		return len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// HasOccurrenceAt reports whether the series produces an occurrence starting exactly at t
+func (r *RecurrenceRule) HasOccurrenceAt(dtstart, t time.Time, loc *time.Location) bool {
+	for _, occurrence := range r.Between(dtstart, t, t, loc) {
+		if occurrence.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// iterate calls yield for every occurrence in chronological order until yield
+// returns false or the series ends. from is only a hint used to skip periods
+// that can't contain interesting occurrences.
+func (r *RecurrenceRule) iterate(dtstart, from time.Time, loc *time.Location, yield func(time.Time) bool) {
+	if !r.IsValid() {
+		return
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	start := dtstart.In(loc)
+	exp := newExpansion(r, start)
+	count := 0
+	last := start
+
+	emit := func(t time.Time) bool {
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		if r.Count != nil && count >= *r.Count {
+			return false
+		}
+		count++
+		return yield(t)
+	}
+
+	// DTSTART is always the first instance of the series
+	if !emit(start) {
+		return
+	}
+
+	first := r.skipPeriods(start, from)
+	for period := first; period < first+maxRecurrencePeriods; period++ {
+		candidates, ok := exp.period(period)
+		if !ok {
+			return
+		}
+		for _, t := range candidates {
+			// Wall clock times repeated by a DST change are only emitted once
+			if !t.After(last) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+			last = t
+		}
+	}
+}
+
+// skipPeriods returns the first period worth expanding. Periods can only be
+// skipped when COUNT isn't set, since every earlier instance must be counted.
+func (r *RecurrenceRule) skipPeriods(start, from time.Time) int {
+	if r.Count != nil || !from.After(start) {
+		return 0
+	}
+
+	from = from.In(start.Location())
+
+	var periods int
+	switch r.Frequency {
+	case FrequencySecondly:
+		periods = int(from.Sub(start)/time.Second) / r.Interval
+	case FrequencyMinutely:
+		periods = int(from.Sub(start)/time.Minute) / r.Interval
+	case FrequencyHourly:
+		periods = int(from.Sub(start)/time.Hour) / r.Interval
+	case FrequencyDaily:
+		periods = int(from.Sub(start).Hours()/24) / r.Interval
+	case FrequencyWeekly:
+		periods = int(from.Sub(start).Hours()/(24*7)) / r.Interval
+	case FrequencyMonthly:
+		months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+		periods = months / r.Interval
+	case FrequencyYearly:
+		periods = (from.Year() - start.Year()) / r.Interval
+	}
+
+	// Step back one period to stay clear of DST and month-length rounding
+	if periods > 1 {
+		return periods - 1
+	}
+	return 0
+}
+
+// maxRecurrenceYears stops expanding rules whose periods keep coming up empty
+// long after DTSTART.
+const maxRecurrenceYears = 1000
+
+// frequencyRank orders frequencies from the finest to the coarsest
+var frequencyRank = map[Frequency]int{
+	FrequencySecondly: 0,
+	FrequencyMinutely: 1,
+	FrequencyHourly:   2,
+	FrequencyDaily:    3,
+	FrequencyWeekly:   4,
+	FrequencyMonthly:  5,
+	FrequencyYearly:   6,
+}
+
+// expansion holds a rule with the parts RFC 5545 derives from DTSTART filled in
+type expansion struct {
+	rule       *RecurrenceRule
+	start      time.Time
+	weekStart  time.Weekday
+	byMonth    []Month
+	byMonthDay []int
+	byDay      []Weekday
+	byHour     []int
+	byMinute   []int
+	bySecond   []int
+}
+
+func newExpansion(r *RecurrenceRule, start time.Time) *expansion {
+	exp := &expansion{
+		rule:       r,
+		start:      start,
+		weekStart:  time.Monday,
+		byMonth:    r.ByMonth,
+		byMonthDay: r.ByMonthDay,
+		byDay:      r.ByDay,
+		byHour:     r.ByHour,
+		byMinute:   r.ByMinute,
+		bySecond:   r.BySecond,
+	}
+
+	if r.WeekStart != "" {
+		if wd, ok := r.WeekStart.ToTimeWeekday(); ok {
+			exp.weekStart = wd
+		}
+	}
+
+	// Without any day selector the series repeats on DTSTART's day
+	if len(r.ByWeekNo) == 0 && len(r.ByYearDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		switch r.Frequency {
+		case FrequencyYearly:
+			if len(r.ByMonth) == 0 {
+				exp.byMonth = []Month{Month(start.Month())}
+			}
+			exp.byMonthDay = []int{start.Day()}
+		case FrequencyMonthly:
+			exp.byMonthDay = []int{start.Day()}
+		case FrequencyWeekly:
+			exp.byDay = []Weekday{weekdayOf(start.Weekday())}
+		}
+	}
+
+	rank := frequencyRank[r.Frequency]
+	if len(exp.byHour) == 0 && rank > frequencyRank[FrequencyHourly] {
+		exp.byHour = []int{start.Hour()}
+	}
+	if len(exp.byMinute) == 0 && rank > frequencyRank[FrequencyMinutely] {
+		exp.byMinute = []int{start.Minute()}
+	}
+	if len(exp.bySecond) == 0 && rank > frequencyRank[FrequencySecondly] {
+		exp.bySecond = []int{start.Second()}
+	}
+
+	return exp
+}
+
+// period returns the sorted occurrence candidates of the given period. It
+// returns false once the period lies beyond maxRecurrenceYears.
+func (e *expansion) period(period int) ([]time.Time, bool) {
+	r := e.rule
+	loc := e.start.Location()
+	year, month, day := e.start.Date()
+	step := period * r.Interval
+
+	var days []time.Time
+	var base time.Time
+	switch r.Frequency {
+	case FrequencySecondly, FrequencyMinutely, FrequencyHourly:
+		unit := time.Hour
+		if r.Frequency == FrequencyMinutely {
+			unit = time.Minute
+		} else if r.Frequency == FrequencySecondly {
+			unit = time.Second
+		}
+		base = e.start.Add(time.Duration(step) * unit)
+		days = []time.Time{dateOf(base)}
+
+	case FrequencyDaily:
+		days = []time.Time{time.Date(year, month, day+step, 0, 0, 0, 0, time.UTC)}
+
+	case FrequencyWeekly:
+		offset := (int(e.start.Weekday()) - int(e.weekStart) + 7) % 7
+		weekStart := time.Date(year, month, day-offset+7*step, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 7; i++ {
+			days = append(days, weekStart.AddDate(0, 0, i))
+		}
+
+	case FrequencyMonthly:
+		monthStart := time.Date(year, month+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		for d := monthStart; d.Month() == monthStart.Month(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+
+	case FrequencyYearly:
+		yearStart := time.Date(year+step, time.January, 1, 0, 0, 0, 0, time.UTC)
+		for d := yearStart; d.Year() == yearStart.Year(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	}
+
+	if len(days) == 0 || days[0].Year() > year+maxRecurrenceYears {
+		return nil, false
+	}
+
+	var candidates []time.Time
+	for _, d := range days {
+		if !e.matchesDay(d) {
+			continue
+		}
+		for _, t := range e.times(base) {
+			candidates = append(candidates, LocalTime(d.Year(), d.Month(), d.Day(), t[0], t[1], t[2], loc))
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	return e.applySetPos(candidates), true
+}
+
+// times returns the hour, minute and second combinations of a day. For
+// sub-daily frequencies base is the start of the period and fixes the parts
+// at or above the frequency.
+func (e *expansion) times(base time.Time) [][3]int {
+	rank := frequencyRank[e.rule.Frequency]
+
+	hours := e.byHour
+	if rank <= frequencyRank[FrequencyHourly] {
+		hours = filterInts([]int{base.Hour()}, e.byHour)
+	}
+	minutes := e.byMinute
+	if rank <= frequencyRank[FrequencyMinutely] {
+		minutes = filterInts([]int{base.Minute()}, e.byMinute)
+	}
+	seconds := e.bySecond
+	if rank <= frequencyRank[FrequencySecondly] {
+		seconds = filterInts([]int{base.Second()}, e.bySecond)
+	}
+
+	var times [][3]int
+	for _, h := range hours {
+		for _, m := range minutes {
+			for _, s := range seconds {
+				times = append(times, [3]int{h, m, s})
+			}
+		}
+	}
+	return times
+}
+
+func (e *expansion) matchesDay(d time.Time) bool {
+	if len(e.byMonth) > 0 && !containsMonth(e.byMonth, d.Month()) {
+		return false
+	}
+
+	if len(e.rule.ByWeekNo) > 0 {
+		week, weeks := e.weekNumber(d)
+		if !containsInt(e.rule.ByWeekNo, week) && !containsInt(e.rule.ByWeekNo, week-weeks-1) {
+			return false
+		}
+	}
+
+	if len(e.rule.ByYearDay) > 0 {
+		yearDays := daysIn(d.Year(), 0)
+		if !containsInt(e.rule.ByYearDay, d.YearDay()) && !containsInt(e.rule.ByYearDay, d.YearDay()-yearDays-1) {
+			return false
+		}
+	}
+
+	if len(e.byMonthDay) > 0 {
+		monthDays := daysIn(d.Year(), d.Month())
+		if !containsInt(e.byMonthDay, d.Day()) && !containsInt(e.byMonthDay, d.Day()-monthDays-1) {
+			return false
+		}
+	}
+
+	if len(e.byDay) > 0 && !e.matchesByDay(d) {
+		return false
+	}
+
+	return true
+}
+
+// matchesByDay checks plain and ordinal weekdays. Ordinals count within the
+// month for MONTHLY rules and YEARLY rules with BYMONTH, otherwise within the year.
+func (e *expansion) matchesByDay(d time.Time) bool {
+	inMonth := e.rule.Frequency == FrequencyMonthly || (e.rule.Frequency == FrequencyYearly && len(e.rule.ByMonth) > 0)
+
+	for _, wd := range e.byDay {
+		n, day := wd.Ordinal()
+		weekday, ok := day.ToTimeWeekday()
+		if !ok || weekday != d.Weekday() {
+			continue
+		}
+		if n == 0 {
+			return true
+		}
+
+		var index, total int
+		if inMonth {
+			index, total = d.Day(), daysIn(d.Year(), d.Month())
+		} else {
+			index, total = d.YearDay(), daysIn(d.Year(), 0)
+		}
+		if n == (index-1)/7+1 || n == -((total-index)/7+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekNumber returns the week of the year d belongs to and the number of weeks
+// in that year. Week 1 is the first week with at least four days in the year.
+func (e *expansion) weekNumber(d time.Time) (int, int) {
+	ws := e.startOfWeek(d)
+	weekYear := ws.AddDate(0, 0, 3).Year()
+
+	first := e.startOfWeek(time.Date(weekYear, time.January, 4, 0, 0, 0, 0, time.UTC))
+	next := e.startOfWeek(time.Date(weekYear+1, time.January, 4, 0, 0, 0, 0, time.UTC))
+
+	week := int(ws.Sub(first).Hours()/(24*7)) + 1
+	weeks := int(next.Sub(first).Hours() / (24 * 7))
+	return week, weeks
+}
+
+func (e *expansion) startOfWeek(d time.Time) time.Time {
+	offset := (int(d.Weekday()) - int(e.weekStart) + 7) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// applySetPos keeps only the BYSETPOS positions of the period's candidates
+func (e *expansion) applySetPos(candidates []time.Time) []time.Time {
+	if len(e.rule.BySetPos) == 0 || len(candidates) == 0 {
+		return candidates
+	}
+
+	var selected []time.Time
+	for i, t := range candidates {
+		if containsInt(e.rule.BySetPos, i+1) || containsInt(e.rule.BySetPos, i-len(candidates)) {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
+
+// daysIn returns the number of days in the month, or in the year when month is 0
+func daysIn(year int, month time.Month) int {
+	if month == 0 {
+		return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func filterInts(values, allowed []int) []int {
+	if len(allowed) == 0 {
+		return values
+	}
+
+	var filtered []int
+	for _, v := range values {
+		if containsInt(allowed, v) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMonth(months []Month, month time.Month) bool {
+	for _, m := range months {
+		if time.Month(m) == month {
+			return true
+		}
+	}
+	return false
+}
+
+func weekdayOf(weekday time.Weekday) Weekday {
+	return weekdays[(int(weekday)+6)%7]
+}
+
+// ToTimeWeekday converts an RFC 5545 weekday to time.Weekday
+func (w Weekday) ToTimeWeekday() (time.Weekday, bool) {
+	switch w {
+	case WeekdaySunday:
+		return time.Sunday, true
+	case WeekdayMonday:
+		return time.Monday, true
+	case WeekdayTuesday:
+		return time.Tuesday, true
+	case WeekdayWednesday:
+		return time.Wednesday, true
+	case WeekdayThursday:
+		return time.Thursday, true
+	case WeekdayFriday:
+		return time.Friday, true
+	case WeekdaySaturday:
+		return time.Saturday, true
+	}
+	return 0, false
+}
+
+// SplitAt divides the series at the occurrence starting at splitAt. The head
+// rule ends right before it via UNTIL; the tail rule describes the remaining
+// occurrences when used with splitAt as its start.
+func (r *RecurrenceRule) SplitAt(dtstart, splitAt time.Time, loc *time.Location) (head, tail *RecurrenceRule) {
+	head = r.Copy()
+	tail = r.Copy()
+
+	if r.Count != nil {
+		before := len(r.Between(dtstart, dtstart, splitAt.Add(-time.Nanosecond), loc))
+		remaining := *r.Count - before
+		if remaining < 1 {
+			remaining = 1
+		}
+		tail.Count = &remaining
+	}
+
+	until := splitAt.Add(-time.Second).UTC()
+	head.Count = nil
+	head.Until = &until
+
+	return head, tail
+}
+
+// Copy returns a deep copy of the rule
+func (r *RecurrenceRule) Copy() *RecurrenceRule {
+	copied := *r
+
+	if r.Until != nil {
+		until := *r.Until
+		copied.Until = &until
+	}
+	if r.Count != nil {
+		count := *r.Count
+		copied.Count = &count
+	}
+	copied.BySecond = append([]int(nil), r.BySecond...)
+	copied.ByMinute = append([]int(nil), r.ByMinute...)
+	copied.ByHour = append([]int(nil), r.ByHour...)
+	copied.ByDay = append([]Weekday(nil), r.ByDay...)
+	copied.ByMonthDay = append([]int(nil), r.ByMonthDay...)
+	copied.ByYearDay = append([]int(nil), r.ByYearDay...)
+	copied.ByWeekNo = append([]int(nil), r.ByWeekNo...)
+	copied.ByMonth = append([]Month(nil), r.ByMonth...)
+	copied.BySetPos = append([]int(nil), r.BySetPos...)
+
+	return &copied
+}
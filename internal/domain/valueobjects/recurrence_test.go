@@ -0,0 +1,781 @@
+package valueobjects
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const localFormat = "20060102T150405"
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+// localTimes parses wall clock times in loc
+func localTimes(t *testing.T, loc *time.Location, values ...string) []time.Time {
+	t.Helper()
+
+	times := make([]time.Time, len(values))
+	for i, value := range values {
+		parsed, err := time.ParseInLocation(localFormat, value, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		times[i] = parsed
+	}
+	return times
+}
+
+func formatLocal(times []time.Time, loc *time.Location) []string {
+	formatted := make([]string, len(times))
+	for i, t := range times {
+		formatted[i] = t.In(loc).Format(localFormat + " MST")
+	}
+	return formatted
+}
+
+func mustParseRRULE(t *testing.T, value string) *RecurrenceRule {
+	t.Helper()
+
+	rule, err := ParseRRULE(value)
+	if err != nil {
+		t.Fatalf("ParseRRULE(%q): %v", value, err)
+	}
+	return rule
+}
+
+// rfc5545Examples are the examples of RFC 5545 section 3.8.5.3 that start in
+// America/New_York. Unbounded rules list their first occurrences only.
+var rfc5545Examples = []struct {
+	name      string
+	dtstart   string
+	rrule     string
+	unbounded bool
+	want      []string
+}{
+	{
+		name:    "daily for 10 occurrences",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=DAILY;COUNT=10",
+		want: []string{
+			"19970902T090000", "19970903T090000", "19970904T090000", "19970905T090000", "19970906T090000",
+			"19970907T090000", "19970908T090000", "19970909T090000", "19970910T090000", "19970911T090000",
+		},
+	},
+	{
+		name:      "every other day",
+		dtstart:   "19970902T090000",
+		rrule:     "FREQ=DAILY;INTERVAL=2",
+		unbounded: true,
+		want:      []string{"19970902T090000", "19970904T090000", "19970906T090000", "19970908T090000"},
+	},
+	{
+		name:    "every 10 days, 5 occurrences",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=DAILY;INTERVAL=10;COUNT=5",
+		want:    []string{"19970902T090000", "19970912T090000", "19970922T090000", "19971002T090000", "19971012T090000"},
+	},
+	{
+		// Crosses the end of daylight saving time on October 26
+		name:    "weekly for 10 occurrences",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=WEEKLY;COUNT=10",
+		want: []string{
+			"19970902T090000", "19970909T090000", "19970916T090000", "19970923T090000", "19970930T090000",
+			"19971007T090000", "19971014T090000", "19971021T090000", "19971028T090000", "19971104T090000",
+		},
+	},
+	{
+		name:    "weekly on Tuesday and Thursday for five weeks",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=WEEKLY;UNTIL=19971007T000000Z;WKST=SU;BYDAY=TU,TH",
+		want: []string{
+			"19970902T090000", "19970904T090000", "19970909T090000", "19970911T090000", "19970916T090000",
+			"19970918T090000", "19970923T090000", "19970925T090000", "19970930T090000", "19971002T090000",
+		},
+	},
+	{
+		name:    "every other week on Tuesday and Thursday for 8 occurrences",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=WEEKLY;INTERVAL=2;COUNT=8;WKST=SU;BYDAY=TU,TH",
+		want: []string{
+			"19970902T090000", "19970904T090000", "19970916T090000", "19970918T090000",
+			"19970930T090000", "19971002T090000", "19971014T090000", "19971016T090000",
+		},
+	},
+	{
+		name:    "monthly on the first Friday for 10 occurrences",
+		dtstart: "19970905T090000",
+		rrule:   "FREQ=MONTHLY;COUNT=10;BYDAY=1FR",
+		want: []string{
+			"19970905T090000", "19971003T090000", "19971107T090000", "19971205T090000", "19980102T090000",
+			"19980206T090000", "19980306T090000", "19980403T090000", "19980501T090000", "19980605T090000",
+		},
+	},
+	{
+		name:    "every other month on the first and last Sunday for 10 occurrences",
+		dtstart: "19970907T090000",
+		rrule:   "FREQ=MONTHLY;INTERVAL=2;COUNT=10;BYDAY=1SU,-1SU",
+		want: []string{
+			"19970907T090000", "19970928T090000", "19971102T090000", "19971130T090000", "19980104T090000",
+			"19980125T090000", "19980301T090000", "19980329T090000", "19980503T090000", "19980531T090000",
+		},
+	},
+	{
+		name:    "monthly on the second-to-last Monday for 6 months",
+		dtstart: "19970922T090000",
+		rrule:   "FREQ=MONTHLY;COUNT=6;BYDAY=-2MO",
+		want: []string{
+			"19970922T090000", "19971020T090000", "19971117T090000",
+			"19971222T090000", "19980119T090000", "19980216T090000",
+		},
+	},
+	{
+		name:      "monthly on the third-to-last day",
+		dtstart:   "19970928T090000",
+		rrule:     "FREQ=MONTHLY;BYMONTHDAY=-3",
+		unbounded: true,
+		want: []string{
+			"19970928T090000", "19971029T090000", "19971128T090000",
+			"19971229T090000", "19980129T090000", "19980226T090000",
+		},
+	},
+	{
+		name:    "monthly on the 2nd and 15th for 10 occurrences",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=MONTHLY;COUNT=10;BYMONTHDAY=2,15",
+		want: []string{
+			"19970902T090000", "19970915T090000", "19971002T090000", "19971015T090000", "19971102T090000",
+			"19971115T090000", "19971202T090000", "19971215T090000", "19980102T090000", "19980115T090000",
+		},
+	},
+	{
+		name:    "monthly on the first and last day for 10 occurrences",
+		dtstart: "19970930T090000",
+		rrule:   "FREQ=MONTHLY;COUNT=10;BYMONTHDAY=1,-1",
+		want: []string{
+			"19970930T090000", "19971001T090000", "19971031T090000", "19971101T090000", "19971130T090000",
+			"19971201T090000", "19971231T090000", "19980101T090000", "19980131T090000", "19980201T090000",
+		},
+	},
+	{
+		name:    "every 18 months on the 10th to 15th for 10 occurrences",
+		dtstart: "19970910T090000",
+		rrule:   "FREQ=MONTHLY;INTERVAL=18;COUNT=10;BYMONTHDAY=10,11,12,13,14,15",
+		want: []string{
+			"19970910T090000", "19970911T090000", "19970912T090000", "19970913T090000", "19970914T090000",
+			"19970915T090000", "19990310T090000", "19990311T090000", "19990312T090000", "19990313T090000",
+		},
+	},
+	{
+		name:      "every Tuesday, every other month",
+		dtstart:   "19970902T090000",
+		rrule:     "FREQ=MONTHLY;INTERVAL=2;BYDAY=TU",
+		unbounded: true,
+		want: []string{
+			"19970902T090000", "19970909T090000", "19970916T090000", "19970923T090000", "19970930T090000",
+			"19971104T090000", "19971111T090000", "19971118T090000", "19971125T090000",
+			"19980106T090000", "19980113T090000", "19980120T090000", "19980127T090000",
+		},
+	},
+	{
+		name:    "yearly in June and July for 10 occurrences",
+		dtstart: "19970610T090000",
+		rrule:   "FREQ=YEARLY;COUNT=10;BYMONTH=6,7",
+		want: []string{
+			"19970610T090000", "19970710T090000", "19980610T090000", "19980710T090000", "19990610T090000",
+			"19990710T090000", "20000610T090000", "20000710T090000", "20010610T090000", "20010710T090000",
+		},
+	},
+	{
+		name:    "every other year in January, February and March for 10 occurrences",
+		dtstart: "19970310T090000",
+		rrule:   "FREQ=YEARLY;INTERVAL=2;COUNT=10;BYMONTH=1,2,3",
+		want: []string{
+			"19970310T090000", "19990110T090000", "19990210T090000", "19990310T090000", "20010110T090000",
+			"20010210T090000", "20010310T090000", "20030110T090000", "20030210T090000", "20030310T090000",
+		},
+	},
+	{
+		name:    "every third year on the 1st, 100th and 200th day for 10 occurrences",
+		dtstart: "19970101T090000",
+		rrule:   "FREQ=YEARLY;INTERVAL=3;COUNT=10;BYYEARDAY=1,100,200",
+		want: []string{
+			"19970101T090000", "19970410T090000", "19970719T090000", "20000101T090000", "20000409T090000",
+			"20000718T090000", "20030101T090000", "20030410T090000", "20030719T090000", "20060101T090000",
+		},
+	},
+	{
+		name:      "every 20th Monday of the year",
+		dtstart:   "19970519T090000",
+		rrule:     "FREQ=YEARLY;BYDAY=20MO",
+		unbounded: true,
+		want:      []string{"19970519T090000", "19980518T090000", "19990517T090000"},
+	},
+	{
+		name:      "Monday of week number 20",
+		dtstart:   "19970512T090000",
+		rrule:     "FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO",
+		unbounded: true,
+		want:      []string{"19970512T090000", "19980511T090000", "19990517T090000"},
+	},
+	{
+		name:      "every Thursday in March",
+		dtstart:   "19970313T090000",
+		rrule:     "FREQ=YEARLY;BYMONTH=3;BYDAY=TH",
+		unbounded: true,
+		want: []string{
+			"19970313T090000", "19970320T090000", "19970327T090000",
+			"19980305T090000", "19980312T090000", "19980319T090000", "19980326T090000",
+			"19990304T090000", "19990311T090000", "19990318T090000", "19990325T090000",
+		},
+	},
+	{
+		// DTSTART is an instance even though it isn't a Friday the 13th;
+		// the RFC excludes it with an EXDATE
+		name:      "every Friday the 13th",
+		dtstart:   "19970902T090000",
+		rrule:     "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+		unbounded: true,
+		want: []string{
+			"19970902T090000", "19980213T090000", "19980313T090000",
+			"19981113T090000", "19990813T090000", "20001013T090000",
+		},
+	},
+	{
+		name:      "the first Saturday that follows the first Sunday of the month",
+		dtstart:   "19970913T090000",
+		rrule:     "FREQ=MONTHLY;BYDAY=SA;BYMONTHDAY=7,8,9,10,11,12,13",
+		unbounded: true,
+		want: []string{
+			"19970913T090000", "19971011T090000", "19971108T090000", "19971213T090000", "19980110T090000",
+			"19980207T090000", "19980307T090000", "19980411T090000", "19980509T090000", "19980613T090000",
+		},
+	},
+	{
+		name:      "US presidential election day",
+		dtstart:   "19961105T090000",
+		rrule:     "FREQ=YEARLY;INTERVAL=4;BYMONTH=11;BYDAY=TU;BYMONTHDAY=2,3,4,5,6,7,8",
+		unbounded: true,
+		want:      []string{"19961105T090000", "20001107T090000", "20041102T090000"},
+	},
+	{
+		name:    "the third Tuesday, Wednesday or Thursday of the month, 3 times",
+		dtstart: "19970904T090000",
+		rrule:   "FREQ=MONTHLY;COUNT=3;BYDAY=TU,WE,TH;BYSETPOS=3",
+		want:    []string{"19970904T090000", "19971007T090000", "19971106T090000"},
+	},
+	{
+		name:      "the second-to-last weekday of the month",
+		dtstart:   "19970929T090000",
+		rrule:     "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-2",
+		unbounded: true,
+		want: []string{
+			"19970929T090000", "19971030T090000", "19971127T090000", "19971230T090000",
+			"19980129T090000", "19980226T090000", "19980330T090000",
+		},
+	},
+	{
+		name:    "every 15 minutes for 6 occurrences",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=MINUTELY;INTERVAL=15;COUNT=6",
+		want: []string{
+			"19970902T090000", "19970902T091500", "19970902T093000",
+			"19970902T094500", "19970902T100000", "19970902T101500",
+		},
+	},
+	{
+		name:    "every hour and a half for 4 occurrences",
+		dtstart: "19970902T090000",
+		rrule:   "FREQ=MINUTELY;INTERVAL=90;COUNT=4",
+		want:    []string{"19970902T090000", "19970902T103000", "19970902T120000", "19970902T133000"},
+	},
+	{
+		name:    "week starting on Monday",
+		dtstart: "19970805T090000",
+		rrule:   "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+		want:    []string{"19970805T090000", "19970810T090000", "19970819T090000", "19970824T090000"},
+	},
+	{
+		name:    "week starting on Sunday",
+		dtstart: "19970805T090000",
+		rrule:   "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+		want:    []string{"19970805T090000", "19970817T090000", "19970819T090000", "19970831T090000"},
+	},
+	{
+		name:    "invalid dates are skipped",
+		dtstart: "20070115T090000",
+		rrule:   "FREQ=MONTHLY;BYMONTHDAY=15,30;COUNT=5",
+		want:    []string{"20070115T090000", "20070130T090000", "20070215T090000", "20070315T090000", "20070330T090000"},
+	},
+}
+
+func TestRecurrenceRFC5545Examples(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+
+	for _, tt := range rfc5545Examples {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := mustParseRRULE(t, tt.rrule)
+			dtstart := localTimes(t, newYork, tt.dtstart)[0]
+			want := localTimes(t, newYork, tt.want...)
+
+			// One more than expected, to see a bounded rule end
+			got := rule.After(dtstart, dtstart.Add(-time.Second), newYork, len(want)+1)
+			if tt.unbounded && len(got) == len(want)+1 {
+				got = got[:len(want)]
+			}
+			if !reflect.DeepEqual(formatLocal(got, newYork), formatLocal(want, newYork)) {
+				t.Errorf("occurrences =\n%v\nwant\n%v", formatLocal(got, newYork), formatLocal(want, newYork))
+			}
+		})
+	}
+}
+
+func TestRecurrenceRFC5545Counts(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name    string
+		dtstart string
+		rrule   string
+		end     string // Of the window expanded; the end of the series when empty
+		count   int
+		last    string
+	}{
+		{
+			name:    "daily until December 24",
+			dtstart: "19970902T090000",
+			rrule:   "FREQ=DAILY;UNTIL=19971224T000000Z",
+			count:   113,
+			last:    "19971223T090000",
+		},
+		{
+			name:    "every day in January for 3 years",
+			dtstart: "19980101T090000",
+			rrule:   "FREQ=YEARLY;UNTIL=20000131T140000Z;BYMONTH=1;BYDAY=SU,MO,TU,WE,TH,FR,SA",
+			count:   93,
+			last:    "20000131T090000",
+		},
+		{
+			name:    "the same days with a daily rule",
+			dtstart: "19980101T090000",
+			rrule:   "FREQ=DAILY;UNTIL=20000131T140000Z;BYMONTH=1",
+			count:   93,
+			last:    "20000131T090000",
+		},
+		{
+			name:    "every other week on Monday, Wednesday and Friday",
+			dtstart: "19970901T090000",
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;UNTIL=19971224T000000Z;WKST=SU;BYDAY=MO,WE,FR",
+			count:   25,
+			last:    "19971222T090000",
+		},
+		{
+			name:    "every 20 minutes from 9:00 to 16:40, for two days",
+			dtstart: "19970902T090000",
+			rrule:   "FREQ=DAILY;BYHOUR=9,10,11,12,13,14,15,16;BYMINUTE=0,20,40",
+			end:     "19970903T235959",
+			count:   48,
+			last:    "19970903T164000",
+		},
+		{
+			name:    "the same with a minutely rule",
+			dtstart: "19970902T090000",
+			rrule:   "FREQ=MINUTELY;INTERVAL=20;BYHOUR=9,10,11,12,13,14,15,16",
+			end:     "19970903T235959",
+			count:   48,
+			last:    "19970903T164000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := mustParseRRULE(t, tt.rrule)
+			dtstart := localTimes(t, newYork, tt.dtstart)[0]
+			end := dtstart.AddDate(10, 0, 0)
+			if tt.end != "" {
+				end = localTimes(t, newYork, tt.end)[0]
+			}
+
+			got := rule.Between(dtstart, dtstart, end, newYork)
+			if len(got) != tt.count {
+				t.Fatalf("%d occurrences, want %d", len(got), tt.count)
+			}
+			if last := got[len(got)-1].In(newYork).Format(localFormat); last != tt.last {
+				t.Errorf("last occurrence %s, want %s", last, tt.last)
+			}
+		})
+	}
+}
+
+func TestRecurrenceAcrossDST(t *testing.T) {
+	berlin := loadLocation(t, "Europe/Berlin")
+	newYork := loadLocation(t, "America/New_York")
+	edt := time.FixedZone("EDT", -4*60*60)
+	est := time.FixedZone("EST", -5*60*60)
+
+	tests := []struct {
+		name    string
+		rrule   string
+		dtstart time.Time
+		loc     *time.Location
+		want    []time.Time
+	}{
+		{
+			// Clocks go forward on March 31
+			name:    "daily at 09:00 in Berlin",
+			rrule:   "FREQ=DAILY;COUNT=3",
+			dtstart: time.Date(2024, 3, 30, 9, 0, 0, 0, berlin),
+			loc:     berlin,
+			want: []time.Time{
+				time.Date(2024, 3, 30, 8, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 7, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 1, 7, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 02:30 doesn't exist on March 10 and is read with the offset
+			// before the gap
+			name:    "daily at a time skipped by the change",
+			rrule:   "FREQ=DAILY;COUNT=3",
+			dtstart: time.Date(2024, 3, 9, 2, 30, 0, 0, est),
+			loc:     newYork,
+			want: []time.Time{
+				time.Date(2024, 3, 9, 2, 30, 0, 0, est),
+				time.Date(2024, 3, 10, 2, 30, 0, 0, est),
+				time.Date(2024, 3, 11, 2, 30, 0, 0, edt),
+			},
+		},
+		{
+			// 01:00 happens twice on November 3 and is only emitted once
+			name:    "hourly through a repeated hour",
+			rrule:   "FREQ=HOURLY;COUNT=3",
+			dtstart: time.Date(2024, 11, 3, 0, 0, 0, 0, edt),
+			loc:     newYork,
+			want: []time.Time{
+				time.Date(2024, 11, 3, 0, 0, 0, 0, edt),
+				time.Date(2024, 11, 3, 1, 0, 0, 0, edt),
+				time.Date(2024, 11, 3, 2, 0, 0, 0, est),
+			},
+		},
+		{
+			name:    "weekly at 09:00 in New York",
+			rrule:   "FREQ=WEEKLY;BYDAY=FR;COUNT=2",
+			dtstart: time.Date(2024, 11, 1, 9, 0, 0, 0, edt),
+			loc:     newYork,
+			want: []time.Time{
+				time.Date(2024, 11, 1, 9, 0, 0, 0, edt),
+				time.Date(2024, 11, 8, 9, 0, 0, 0, est),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mustParseRRULE(t, tt.rrule).After(tt.dtstart, tt.dtstart.Add(-time.Second), tt.loc, 10)
+			if len(got) != len(tt.want) {
+				t.Fatalf("occurrences = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %v, want %v", i, got[i].UTC(), tt.want[i].UTC())
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	dtstart := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC) // A Monday
+
+	tests := []struct {
+		name     string
+		rrule    string
+		from, to time.Time
+		count    int
+		first    time.Time
+	}{
+		{
+			name:  "both ends included",
+			rrule: "FREQ=DAILY",
+			from:  time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC),
+			to:    time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC),
+			count: 3,
+			first: time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "window before the series",
+			rrule: "FREQ=DAILY",
+			from:  time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "window after the count",
+			rrule: "FREQ=WEEKLY;COUNT=4",
+			from:  time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "window after the until",
+			rrule: "FREQ=DAILY;UNTIL=20240110T090000Z",
+			from:  time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+			count: 1,
+			first: time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "years after the start",
+			rrule: "FREQ=WEEKLY;BYDAY=MO,FR",
+			from:  time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2034, 1, 31, 0, 0, 0, 0, time.UTC),
+			count: 9,
+			first: time.Date(2034, 1, 2, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			// Further than maxRecurrencePeriods minutes from the start
+			name:  "minutely months after the start",
+			rrule: "FREQ=MINUTELY;BYMINUTE=0,30",
+			from:  time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2024, 6, 1, 23, 59, 59, 0, time.UTC),
+			count: 48,
+			first: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "monthly on a day that never comes",
+			rrule: "FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=30",
+			from:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mustParseRRULE(t, tt.rrule).Between(dtstart, tt.from, tt.to, time.UTC)
+			if len(got) != tt.count {
+				t.Fatalf("%d occurrences, want %d: %v", len(got), tt.count, got)
+			}
+			if tt.count > 0 && !got[0].Equal(tt.first) {
+				t.Errorf("first occurrence %v, want %v", got[0], tt.first)
+			}
+		})
+	}
+}
+
+func TestRecurrenceAfter(t *testing.T) {
+	dtstart := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	daily := mustParseRRULE(t, "FREQ=DAILY;COUNT=5")
+
+	tests := []struct {
+		name  string
+		after time.Time
+		limit int
+		want  []time.Time
+	}{
+		{
+			name:  "no limit",
+			after: dtstart,
+		},
+		{
+			name:  "strictly after",
+			after: dtstart,
+			limit: 2,
+			want:  []time.Time{dtstart.AddDate(0, 0, 1), dtstart.AddDate(0, 0, 2)},
+		},
+		{
+			name:  "up to the count",
+			after: dtstart.AddDate(0, 0, 2).Add(time.Minute),
+			limit: 10,
+			want:  []time.Time{dtstart.AddDate(0, 0, 3), dtstart.AddDate(0, 0, 4)},
+		},
+		{
+			name:  "after the series",
+			after: dtstart.AddDate(0, 0, 4),
+			limit: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := daily.After(dtstart, tt.after, time.UTC, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("After(%v, %d) = %v, want %v", tt.after, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceHasOccurrenceAt(t *testing.T) {
+	berlin := loadLocation(t, "Europe/Berlin")
+	dtstart := time.Date(2024, 3, 1, 9, 0, 0, 0, berlin) // A Friday
+	weekly := mustParseRRULE(t, "FREQ=WEEKLY;BYDAY=FR;COUNT=6")
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"the start", dtstart, true},
+		{"after the DST change", time.Date(2024, 4, 5, 9, 0, 0, 0, berlin), true},
+		{"at the old offset after the DST change", time.Date(2024, 4, 5, 8, 0, 0, 0, time.UTC), false},
+		{"a minute off", time.Date(2024, 3, 8, 9, 1, 0, 0, berlin), false},
+		{"another day", time.Date(2024, 3, 7, 9, 0, 0, 0, berlin), false},
+		{"before the start", time.Date(2024, 2, 23, 9, 0, 0, 0, berlin), false},
+		{"after the count", time.Date(2024, 4, 12, 9, 0, 0, 0, berlin), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weekly.HasOccurrenceAt(dtstart, tt.at, berlin); got != tt.want {
+				t.Errorf("HasOccurrenceAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRRULERoundTrip(t *testing.T) {
+	for _, tt := range rfc5545Examples {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := mustParseRRULE(t, tt.rrule)
+			formatted := rule.ToRRULE()
+			if formatted == "" {
+				t.Fatalf("ToRRULE of %q is empty", tt.rrule)
+			}
+
+			// Monday is the default week start and not written out
+			want := *rule
+			if want.WeekStart == WeekdayMonday {
+				want.WeekStart = ""
+			}
+			if again := mustParseRRULE(t, formatted); !reflect.DeepEqual(*again, want) {
+				t.Errorf("%q read back from %q as %+v, want %+v", tt.rrule, formatted, *again, want)
+			}
+		})
+	}
+}
+
+func TestToRRULE(t *testing.T) {
+	tests := []struct {
+		rrule string
+		want  string
+	}{
+		{"RRULE:freq=weekly;interval=1;byday=tu,th;wkst=su", "FREQ=WEEKLY;BYDAY=TU,TH;WKST=SU"},
+		{"FREQ=WEEKLY;WKST=MO", "FREQ=WEEKLY"},
+		{"FREQ=MONTHLY;BYDAY=+1FR,-1SU", "FREQ=MONTHLY;BYDAY=1FR,-1SU"},
+		{"FREQ=MONTHLY;BYMONTHDAY=+15,-1;X-EXAMPLE=1", "FREQ=MONTHLY;BYMONTHDAY=15,-1"},
+		{"FREQ=YEARLY;BYMONTH=1;BYDAY=SU;UNTIL=20000131T140000Z", "FREQ=YEARLY;UNTIL=20000131T140000Z;BYDAY=SU;BYMONTH=1"},
+		{"FREQ=YEARLY;BYDAY=MO;BYWEEKNO=20,-1", "FREQ=YEARLY;BYDAY=MO;BYWEEKNO=20,-1"},
+		{"FREQ=YEARLY;BYSETPOS=-1;BYYEARDAY=1,-1", "FREQ=YEARLY;BYYEARDAY=1,-1;BYSETPOS=-1"},
+		{"FREQ=DAILY;BYSECOND=0,30;BYMINUTE=15;BYHOUR=9", "FREQ=DAILY;BYSECOND=0,30;BYMINUTE=15;BYHOUR=9"},
+		// Dates include the whole day, floating times are read as UTC
+		{"FREQ=DAILY;UNTIL=19971224", "FREQ=DAILY;UNTIL=19971224T235959Z"},
+		{"FREQ=DAILY;UNTIL=19971224T090000", "FREQ=DAILY;UNTIL=19971224T090000Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rrule, func(t *testing.T) {
+			if got := mustParseRRULE(t, tt.rrule).ToRRULE(); got != tt.want {
+				t.Errorf("ToRRULE() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	invalid := &RecurrenceRule{Frequency: FrequencyDaily}
+	if got := invalid.ToRRULE(); got != "" {
+		t.Errorf("ToRRULE() of a rule without interval = %q, want empty", got)
+	}
+}
+
+func TestParseRRULERejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		rrule string
+	}{
+		{"empty", ""},
+		{"only the prefix", "RRULE:"},
+		{"no FREQ", "INTERVAL=2;COUNT=3"},
+		{"unknown FREQ", "FREQ=FORTNIGHTLY"},
+		{"part without a value", "FREQ=DAILY;COUNT"},
+		{"COUNT together with UNTIL", "FREQ=DAILY;COUNT=10;UNTIL=19971224T000000Z"},
+		{"zero COUNT", "FREQ=DAILY;COUNT=0"},
+		{"negative COUNT", "FREQ=DAILY;COUNT=-1"},
+		{"COUNT not a number", "FREQ=DAILY;COUNT=ten"},
+		{"zero INTERVAL", "FREQ=DAILY;INTERVAL=0"},
+		{"negative INTERVAL", "FREQ=WEEKLY;INTERVAL=-2"},
+		{"malformed UNTIL", "FREQ=DAILY;UNTIL=1997-12-24"},
+		{"BYSECOND above 60", "FREQ=MINUTELY;BYSECOND=61"},
+		{"BYMINUTE above 59", "FREQ=HOURLY;BYMINUTE=60"},
+		{"BYHOUR above 23", "FREQ=DAILY;BYHOUR=24"},
+		{"negative BYHOUR", "FREQ=DAILY;BYHOUR=-1"},
+		{"unknown weekday", "FREQ=WEEKLY;BYDAY=MO,XX"},
+		{"zero ordinal", "FREQ=MONTHLY;BYDAY=0MO"},
+		{"ordinal beyond 53", "FREQ=YEARLY;BYDAY=54MO"},
+		{"ordinal in a DAILY rule", "FREQ=DAILY;BYDAY=1MO"},
+		{"ordinal in a WEEKLY rule", "FREQ=WEEKLY;BYDAY=-1FR"},
+		{"zero BYMONTHDAY", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"BYMONTHDAY beyond 31", "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{"BYMONTHDAY beyond -31", "FREQ=MONTHLY;BYMONTHDAY=-32"},
+		{"BYMONTHDAY in a WEEKLY rule", "FREQ=WEEKLY;BYMONTHDAY=15"},
+		{"zero BYYEARDAY", "FREQ=YEARLY;BYYEARDAY=0"},
+		{"BYYEARDAY beyond 366", "FREQ=YEARLY;BYYEARDAY=367"},
+		{"BYYEARDAY in a DAILY rule", "FREQ=DAILY;BYYEARDAY=100"},
+		{"BYYEARDAY in a WEEKLY rule", "FREQ=WEEKLY;BYYEARDAY=100"},
+		{"BYYEARDAY in a MONTHLY rule", "FREQ=MONTHLY;BYYEARDAY=100"},
+		{"zero BYWEEKNO", "FREQ=YEARLY;BYWEEKNO=0"},
+		{"BYWEEKNO beyond 53", "FREQ=YEARLY;BYWEEKNO=54"},
+		{"BYWEEKNO in a MONTHLY rule", "FREQ=MONTHLY;BYWEEKNO=20"},
+		{"BYWEEKNO in a WEEKLY rule", "FREQ=WEEKLY;BYWEEKNO=20"},
+		{"zero BYMONTH", "FREQ=YEARLY;BYMONTH=0"},
+		{"BYMONTH beyond 12", "FREQ=YEARLY;BYMONTH=13"},
+		{"zero BYSETPOS", "FREQ=MONTHLY;BYDAY=MO;BYSETPOS=0"},
+		{"BYSETPOS beyond 366", "FREQ=MONTHLY;BYDAY=MO;BYSETPOS=367"},
+		{"BYSETPOS without another BYxxx part", "FREQ=MONTHLY;BYSETPOS=1"},
+		{"WKST with an ordinal", "FREQ=WEEKLY;WKST=1MO"},
+		{"unknown WKST", "FREQ=WEEKLY;WKST=XX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rule, err := ParseRRULE(tt.rrule); err == nil {
+				t.Errorf("ParseRRULE(%q) = %+v, want an error", tt.rrule, rule)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleIsValid(t *testing.T) {
+	until := time.Date(1997, 12, 24, 0, 0, 0, 0, time.UTC)
+	count := 10
+
+	tests := []struct {
+		name string
+		rule *RecurrenceRule
+		want bool
+	}{
+		{"daily", DailyRecurrence(), true},
+		{"last Friday of the month", LastWeekdayOfMonthRecurrence(WeekdayFriday), true},
+		{"first workday of the month", FirstWorkdayOfMonthRecurrence(), true},
+		{"COUNT replaced by UNTIL", DailyRecurrence().SetCount(count).SetUntil(until), true},
+		{"COUNT together with UNTIL", &RecurrenceRule{Frequency: FrequencyDaily, Interval: 1, Until: &until, Count: &count}, false},
+		{"no interval", &RecurrenceRule{Frequency: FrequencyDaily}, false},
+		{"no frequency", &RecurrenceRule{Interval: 1}, false},
+		{"ordinal weekday in a weekly rule", WeeklyRecurrence().SetByDay(NthWeekday(2, WeekdayTuesday)), false},
+		{"month day in a weekly rule", WeeklyRecurrence().SetByMonthDay(1), false},
+		{"set position alone", MonthlyRecurrence().SetBySetPos(-1), false},
+		{"unknown month", YearlyRecurrence().SetByMonth(Month(13)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.IsValid(); got != tt.want {
+				t.Errorf("IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -50,6 +50,21 @@ func (tz Timezone) ConvertTime(t time.Time) (time.Time, error) {
 	return t.In(loc), nil
 }
 
+// LocalTime returns the instant of a wall clock time in loc. Times skipped
+// by a daylight saving jump are read with the offset before the jump, as
+// RFC 5545 asks, so the midnight a zone skips becomes the first instant of
+// that day instead of the last hour of the previous one.
+func LocalTime(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, 0, loc)
+	wall := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	if ty, tm, td := t.Date(); time.Date(ty, tm, td, t.Hour(), t.Minute(), t.Second(), 0, time.UTC).Equal(wall) {
+		return t
+	}
+
+	_, offset := t.Add(-24 * time.Hour).Zone()
+	return wall.Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
 func (tz Timezone) String() string {
 	return tz.Name
 }
\ No newline at end of file
@@ -0,0 +1,165 @@
+package caldav
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+const (
+	// credentialTTL is how long a successful credential check is reused.
+	// Clients send Basic auth with every request, and checking the password
+	// hash each time would make every request slow. A changed password keeps
+	// working for a client that used it for at most this long.
+	credentialTTL = 2 * time.Minute
+
+	// maxFailures failed logins within failureWindow block further attempts
+	// from the same IP or for the same username until the window ends
+	maxFailures   = 10
+	failureWindow = 15 * time.Minute
+
+	// sweepInterval is how often expired entries are dropped
+	sweepInterval = time.Minute
+)
+
+// authenticator limits failed logins per client IP and per username, and
+// caches successful credential checks. Credentials are only kept as keyed
+// hashes.
+type authenticator struct {
+	mu        sync.Mutex
+	key       []byte
+	verified  map[string]verifiedCredentials
+	failures  map[string]*failureCount
+	lastSweep time.Time
+}
+
+type verifiedCredentials struct {
+	user    *entities.User
+	expires time.Time
+}
+
+type failureCount struct {
+	count int
+	until time.Time
+}
+
+func newAuthenticator() *authenticator {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("caldav: failed to generate credential cache key: " + err.Error())
+	}
+
+	return &authenticator{
+		key:      key,
+		verified: make(map[string]verifiedCredentials),
+		failures: make(map[string]*failureCount),
+	}
+}
+
+// blocked returns how long the client has to wait before trying again, zero
+// when it may try now
+func (a *authenticator) blocked(r *http.Request, email string) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	a.sweep(now)
+
+	var wait time.Duration
+	for _, key := range limitKeys(r, email) {
+		failures, ok := a.failures[key]
+		if ok && failures.count >= maxFailures && failures.until.Sub(now) > wait {
+			wait = failures.until.Sub(now)
+		}
+	}
+	return wait
+}
+
+// cached returns the user of credentials checked successfully before, or nil
+func (a *authenticator) cached(email, password string) *entities.User {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	verified, ok := a.verified[a.credentialsKey(email, password)]
+	if !ok || !time.Now().Before(verified.expires) {
+		return nil
+	}
+	return verified.user
+}
+
+// succeeded caches the credentials of a successful login
+func (a *authenticator) succeeded(email, password string, user *entities.User) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.verified[a.credentialsKey(email, password)] = verifiedCredentials{
+		user:    user,
+		expires: time.Now().Add(credentialTTL),
+	}
+}
+
+// failed counts a failed login against the client IP and the username
+func (a *authenticator) failed(r *http.Request, email string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range limitKeys(r, email) {
+		failures, ok := a.failures[key]
+		if !ok || !now.Before(failures.until) {
+			failures = &failureCount{until: now.Add(failureWindow)}
+			a.failures[key] = failures
+		}
+		failures.count++
+	}
+}
+
+// sweep drops expired entries; the caller holds the lock
+func (a *authenticator) sweep(now time.Time) {
+	if now.Sub(a.lastSweep) < sweepInterval {
+		return
+	}
+	a.lastSweep = now
+
+	for key, verified := range a.verified {
+		if !now.Before(verified.expires) {
+			delete(a.verified, key)
+		}
+	}
+	for key, failures := range a.failures {
+		if !now.Before(failures.until) {
+			delete(a.failures, key)
+		}
+	}
+}
+
+func (a *authenticator) credentialsKey(email, password string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(email))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// limitKeys are the keys failed logins are counted under
+func limitKeys(r *http.Request, email string) []string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return []string{"ip:" + ip, "user:" + strings.ToLower(email)}
+}
+
+// retryAfter formats a wait as the value of a Retry-After header
+func retryAfter(wait time.Duration) string {
+	return strconv.Itoa(int((wait + time.Second - 1) / time.Second))
+}
@@ -0,0 +1,63 @@
+package caldav
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestLoginFailuresAreLimited(t *testing.T) {
+	baseURL, _ := newTestServer(t)
+	path := "/caldav/principals/user-ada/"
+
+	resp, _ := davRequest(t, baseURL, "", "", "PROPFIND", path, "", "Depth", "0")
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+		t.Fatalf("without credentials = %d, want 401 with a challenge", resp.StatusCode)
+	}
+
+	for i := 0; i < maxFailures; i++ {
+		resp, _ := davRequest(t, baseURL, adaEmail, "wrong password", "PROPFIND", path, "", "Depth", "0")
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("failed login %d = %d, want 401", i+1, resp.StatusCode)
+		}
+	}
+
+	// Blocked for the username and the client IP, even with the right password
+	tests := []struct {
+		name            string
+		email, password string
+	}{
+		{name: "wrong password", email: adaEmail, password: "wrong password"},
+		{name: "right password", email: adaEmail, password: adaPassword},
+		{name: "other user from the same IP", email: bob.Email, password: adaPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := davRequest(t, baseURL, tt.email, tt.password, "PROPFIND", path, "", "Depth", "0")
+			if resp.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("status = %d, want 429", resp.StatusCode)
+			}
+			seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+			if err != nil || seconds <= 0 || seconds > int(failureWindow.Seconds()) {
+				t.Errorf("Retry-After = %q, want up to %v in seconds", resp.Header.Get("Retry-After"), failureWindow)
+			}
+		})
+	}
+}
+
+func TestLoginSucceedsBelowTheLimit(t *testing.T) {
+	baseURL, _ := newTestServer(t)
+	path := "/caldav/principals/user-ada/"
+
+	for i := 0; i < maxFailures-1; i++ {
+		davRequest(t, baseURL, adaEmail, "wrong password", "PROPFIND", path, "", "Depth", "0")
+	}
+
+	// Twice, the second time from the credential cache
+	for i := 0; i < 2; i++ {
+		resp, _ := asAda(t, baseURL, "PROPFIND", path, "", "Depth", "0")
+		if resp.StatusCode != http.StatusMultiStatus {
+			t.Fatalf("login %d = %d, want 207", i+1, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/ical"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// maxObjectSize bounds the size of a calendar object sent with PUT
+const maxObjectSize = 1 << 20
+
+// calendarObject is an event series together with its exceptions
+type calendarObject struct {
+	uid        string
+	event      *entities.Event
+	exceptions []*entities.EventException
+}
+
+func newCalendarObject(event *entities.Event, exceptions []*entities.EventException) *calendarObject {
+	return &calendarObject{
+		uid:        ical.EventUID(event),
+		event:      event,
+		exceptions: exceptions,
+	}
+}
+
+// modifiedAt is the latest change to the event or one of its exceptions
+func (o *calendarObject) modifiedAt() time.Time {
+	latest := o.event.UpdatedAt
+	for _, exception := range o.exceptions {
+		if exception.UpdatedAt.After(latest) {
+			latest = exception.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// etag is derived from the modification time at the precision it is stored with
+func (o *calendarObject) etag() string {
+	return fmt.Sprintf(`"%x"`, o.modifiedAt().UnixMicro())
+}
+
+func (o *calendarObject) encode() ([]byte, error) {
+	var body bytes.Buffer
+	err := ical.Encode(&body, &ical.Calendar{
+		Events:     []*entities.Event{o.event},
+		Exceptions: map[entities.EventID][]*entities.EventException{o.event.ID: o.exceptions},
+		Timestamp:  o.modifiedAt(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	return body.Bytes(), nil
+}
+
+// listObjects returns the user's events overlapping the optional time range
+func (s *Server) listObjects(ctx context.Context, user *entities.User, start, end *time.Time) ([]*calendarObject, error) {
+	result, err := s.eventHandler.HandleExportEvents(ctx, queries.ExportEventsQuery{
+		UserID:    user.ID,
+		StartTime: start,
+		EndTime:   end,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]*calendarObject, len(result.Events))
+	for i, event := range result.Events {
+		objects[i] = newCalendarObject(event, result.Exceptions[event.ID])
+	}
+	return objects, nil
+}
+
+// findObject returns the user's event with the given UID, or nil
+func (s *Server) findObject(ctx context.Context, user *entities.User, uid string) (*calendarObject, error) {
+	// A candidate only matches when it really has the UID: Google events are
+	// found by their ExternalID but have a UID of their own
+	matches := func(event *entities.Event) bool {
+		return event != nil && event.UserID == user.ID && ical.EventUID(event) == uid
+	}
+
+	var event *entities.Event
+	if id, ok := ical.EventIDFromUID(uid); ok {
+		byID, err := s.eventRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event: %w", err)
+		}
+		if matches(byID) {
+			event = byID
+		}
+	}
+	if event == nil {
+		byExternalID, err := s.eventRepo.GetByExternalID(ctx, user.ID, uid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event by external ID: %w", err)
+		}
+		if !matches(byExternalID) {
+			return nil, nil
+		}
+		event = byExternalID
+	}
+
+	var exceptions []*entities.EventException
+	if event.IsRecurring() {
+		var err error
+		exceptions, err = s.exceptionRepo.GetByEventID(ctx, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event exceptions: %w", err)
+		}
+	}
+
+	return newCalendarObject(event, exceptions), nil
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, user *entities.User, res resource) {
+	if res.kind != kindObject {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	obj, err := s.findObject(r.Context(), user, res.uid)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if obj == nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	body, err := obj.encode()
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", obj.etag())
+	w.Header().Set("Last-Modified", obj.modifiedAt().UTC().Format(http.TimeFormat))
+	if etagMatches(r.Header.Get("If-None-Match"), obj) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// put creates or replaces the event series of an object. The resource name
+// has to be the UID of the event it contains.
+func (s *Server) put(w http.ResponseWriter, r *http.Request, user *entities.User, res resource) {
+	if res.kind != kindObject {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	existing, err := s.findObject(r.Context(), user, res.uid)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !preconditionsMet(r, existing) {
+		http.Error(w, "Precondition Failed", http.StatusPreconditionFailed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxObjectSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, xml.Name{Space: nsCalDAV, Local: "max-resource-size"})
+		return
+	}
+
+	imported, err := ical.Decode(bytes.NewReader(body))
+	if err != nil || len(imported) == 0 || imported[0].Err != nil {
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-data"})
+		return
+	}
+	if len(imported) > 1 || imported[0].UID == "" {
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-object-resource"})
+		return
+	}
+	if imported[0].UID != res.uid {
+		http.Error(w, "Resource name must match the UID of the event", http.StatusBadRequest)
+		return
+	}
+
+	obj, err := s.saveObject(r.Context(), user, existing, imported[0])
+	if err != nil {
+		var validationErr *validationError
+		if errors.As(err, &validationErr) {
+			http.Error(w, validationErr.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", obj.etag())
+	if existing == nil {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, user *entities.User, res resource) {
+	if res.kind != kindObject {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	obj, err := s.findObject(r.Context(), user, res.uid)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if obj == nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if !preconditionsMet(r, obj) {
+		http.Error(w, "Precondition Failed", http.StatusPreconditionFailed)
+		return
+	}
+
+	readOnly, err := s.readOnly(r.Context(), obj.event)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if readOnly {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := s.eventRepo.Delete(r.Context(), obj.event.ID); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validationError is an event a client sent that can't be stored
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string {
+	return e.err.Error()
+}
+
+// saveObject creates the event of an imported object, or replaces the
+// existing event and its exceptions. Events created by clients get their UID
+// as ExternalID so it is kept across round trips.
+func (s *Server) saveObject(ctx context.Context, user *entities.User, existing *calendarObject, imported *ical.ImportedEvent) (*calendarObject, error) {
+	now := time.Now().Truncate(time.Microsecond)
+
+	var event entities.Event
+	if existing == nil {
+		// The collection stands for all calendars; new events go to the
+		// default one
+		calendar, err := s.calendarRepo.GetOrCreateDefault(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default calendar: %w", err)
+		}
+		if calendar == nil {
+			return nil, fmt.Errorf("user %s not found", user.ID)
+		}
+
+		event = *imported.Event
+		event.ID = entities.EventID(uuid.New().String())
+		event.UserID = user.ID
+		event.CalendarID = calendar.ID
+		event.ExternalID = imported.UID
+		event.ExternalSource = ical.ImportSource
+		event.CreatedAt = now
+	} else {
+		readOnly, err := s.readOnly(ctx, existing.event)
+		if err != nil {
+			return nil, err
+		}
+		if readOnly {
+			return nil, &validationError{err: errors.New("calendar is read-only")}
+		}
+
+		event = *existing.event
+		event.StartTime = imported.Event.StartTime
+		event.EndTime = imported.Event.EndTime
+		event.Timezone = imported.Event.Timezone
+		event.AllDay = imported.Event.AllDay
+		event.Recurrence = imported.Event.Recurrence
+		event.Location = imported.Event.Location
+		event.Attendees = imported.Event.Attendees
+		event.Status = imported.Event.Status
+	}
+	event.Title = s.eventService.SanitizeEventTitle(imported.Event.Title)
+	event.Description = s.eventService.SanitizeEventDescription(imported.Event.Description)
+	event.UpdatedAt = now
+
+	if err := s.eventService.ValidateEventCreation(&event); err != nil {
+		return nil, &validationError{err: err}
+	}
+
+	exceptions := make([]*entities.EventException, len(imported.Exceptions))
+	for i, exception := range imported.Exceptions {
+		prepared := *exception
+		prepared.ID = uuid.New().String()
+		prepared.EventID = event.ID
+		prepared.CreatedAt = now
+		prepared.UpdatedAt = now
+		exceptions[i] = &prepared
+	}
+
+	if existing == nil {
+		if err := s.eventRepo.Create(ctx, &event); err != nil {
+			return nil, fmt.Errorf("failed to create event: %w", err)
+		}
+	} else {
+		if err := s.eventRepo.Update(ctx, &event); err != nil {
+			return nil, fmt.Errorf("failed to update event: %w", err)
+		}
+		if err := s.exceptionRepo.DeleteFrom(ctx, event.ID, time.Time{}); err != nil {
+			return nil, fmt.Errorf("failed to delete event exceptions: %w", err)
+		}
+	}
+
+	for _, exception := range exceptions {
+		if err := s.exceptionRepo.Upsert(ctx, exception); err != nil {
+			return nil, fmt.Errorf("failed to save event exception: %w", err)
+		}
+	}
+
+	return newCalendarObject(&event, exceptions), nil
+}
+
+// preconditionsMet evaluates If-Match and If-None-Match against the current
+// state of a resource, nil when it doesn't exist
+func preconditionsMet(r *http.Request, obj *calendarObject) bool {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if obj == nil || !etagMatches(ifMatch, obj) {
+			return false
+		}
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if obj != nil && etagMatches(ifNoneMatch, obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// readOnly reports whether the event belongs to a read-only calendar
+func (s *Server) readOnly(ctx context.Context, event *entities.Event) (bool, error) {
+	calendar, err := s.calendarRepo.GetByID(ctx, event.CalendarID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get calendar: %w", err)
+	}
+	return calendar != nil && calendar.ReadOnly, nil
+}
+
+// etagMatches reports whether a list of entity tags matches the object
+func etagMatches(header string, obj *calendarObject) bool {
+	if obj == nil {
+		return false
+	}
+	etag := obj.etag()
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// displayName is the name of the calendar shown by clients
+const displayName = "Smart Goal Calendar"
+
+func davName(local string) xml.Name {
+	return xml.Name{Space: nsDAV, Local: local}
+}
+
+func calDAVName(local string) xml.Name {
+	return xml.Name{Space: nsCalDAV, Local: local}
+}
+
+// calendarData is only returned when requested by name
+var calendarData = calDAVName("calendar-data")
+
+// propFilter selects the properties of a PROPFIND or REPORT request
+type propFilter struct {
+	names    []xml.Name // Requested properties; all when nil
+	nameOnly bool       // DAV:propname
+}
+
+func parsePropFilter(req *element) propFilter {
+	switch {
+	case req == nil || req.child(nsDAV, "allprop") != nil:
+		return propFilter{}
+	case req.child(nsDAV, "propname") != nil:
+		return propFilter{nameOnly: true}
+	}
+	return propFilter{names: req.child(nsDAV, "prop").propNames()}
+}
+
+// propertySet holds the live properties of a resource in a stable order
+type propertySet struct {
+	props []prop
+}
+
+func (ps *propertySet) add(name xml.Name, inner string) {
+	ps.props = append(ps.props, prop{Name: name, Inner: inner})
+}
+
+func (ps *propertySet) get(name xml.Name) (prop, bool) {
+	for _, p := range ps.props {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return prop{}, false
+}
+
+// response builds the multistatus entry of a resource for the filter
+func (ps *propertySet) response(href string, filter propFilter) *response {
+	resp := &response{Href: href}
+
+	if filter.names == nil {
+		found := propstat{Status: http.StatusOK}
+		for _, p := range ps.props {
+			if p.Name == calendarData {
+				continue
+			}
+			if filter.nameOnly {
+				p.Inner = ""
+			}
+			found.Props = append(found.Props, p)
+		}
+		resp.Propstats = append(resp.Propstats, found)
+		return resp
+	}
+
+	found := propstat{Status: http.StatusOK}
+	missing := propstat{Status: http.StatusNotFound}
+	for _, name := range filter.names {
+		if p, ok := ps.get(name); ok {
+			found.Props = append(found.Props, p)
+		} else {
+			missing.Props = append(missing.Props, prop{Name: name})
+		}
+	}
+	for _, stat := range []propstat{found, missing} {
+		if len(stat.Props) > 0 {
+			resp.Propstats = append(resp.Propstats, stat)
+		}
+	}
+	return resp
+}
+
+func (s *Server) propfind(w http.ResponseWriter, r *http.Request, user *entities.User, res resource) {
+	req, err := parseXML(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req != nil && !req.is(nsDAV, "propfind") {
+		http.Error(w, "Expected a DAV:propfind body", http.StatusBadRequest)
+		return
+	}
+	filter := parsePropFilter(req)
+
+	// Depth infinity is treated as 1; the hierarchy is shallow anyway
+	children := r.Header.Get("Depth") != "0"
+
+	var responses []*response
+	switch res.kind {
+	case kindRoot:
+		responses = append(responses, rootProperties(user).response(res.href(user), filter))
+		if children {
+			responses = append(responses,
+				principalProperties(user).response(principalHref(user), filter),
+				homeProperties(user).response(homeHref(user), filter),
+			)
+		}
+
+	case kindPrincipal:
+		responses = append(responses, principalProperties(user).response(res.href(user), filter))
+
+	case kindHome:
+		responses = append(responses, homeProperties(user).response(res.href(user), filter))
+		if children {
+			objects, err := s.listObjects(r.Context(), user, nil, nil)
+			if err != nil {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			responses = append(responses, calendarProperties(user, objects).response(calendarHref(user), filter))
+		}
+
+	case kindCalendar:
+		objects, err := s.listObjects(r.Context(), user, nil, nil)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, calendarProperties(user, objects).response(res.href(user), filter))
+		if children {
+			objectResponses, err := objectsResponses(user, objects, filter)
+			if err != nil {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			responses = append(responses, objectResponses...)
+		}
+
+	case kindObject:
+		obj, err := s.findObject(r.Context(), user, res.uid)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if obj == nil {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		objectResponses, err := objectsResponses(user, []*calendarObject{obj}, filter)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, objectResponses...)
+	}
+
+	writeMultistatus(w, responses)
+}
+
+// proppatch rejects all changes; the properties of the calendar are fixed
+func (s *Server) proppatch(w http.ResponseWriter, r *http.Request, user *entities.User, res resource) {
+	req, err := parseXML(r.Body)
+	if err != nil || !req.is(nsDAV, "propertyupdate") {
+		http.Error(w, "Expected a DAV:propertyupdate body", http.StatusBadRequest)
+		return
+	}
+
+	denied := propstat{Status: http.StatusForbidden}
+	for _, update := range req.Children {
+		for _, name := range update.child(nsDAV, "prop").propNames() {
+			denied.Props = append(denied.Props, prop{Name: name})
+		}
+	}
+
+	writeMultistatus(w, []*response{{
+		Href:      res.href(user),
+		Propstats: []propstat{denied},
+	}})
+}
+
+func objectsResponses(user *entities.User, objects []*calendarObject, filter propFilter) ([]*response, error) {
+	responses := make([]*response, len(objects))
+	for i, obj := range objects {
+		props, err := objectProperties(obj)
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = props.response(objectHref(user, obj.uid), filter)
+	}
+	return responses, nil
+}
+
+func rootProperties(user *entities.User) *propertySet {
+	props := &propertySet{}
+	props.add(davName("resourcetype"), "<d:collection/>")
+	props.add(davName("current-user-principal"), hrefXML(principalHref(user)))
+	props.add(davName("principal-collection-set"), hrefXML(Prefix+"/principals/"))
+	return props
+}
+
+func principalProperties(user *entities.User) *propertySet {
+	props := &propertySet{}
+	props.add(davName("resourcetype"), "<d:collection/><d:principal/>")
+	props.add(davName("displayname"), escapeXML(user.Name))
+	props.add(davName("current-user-principal"), hrefXML(principalHref(user)))
+	props.add(davName("principal-URL"), hrefXML(principalHref(user)))
+	props.add(calDAVName("calendar-home-set"), hrefXML(homeHref(user)))
+	props.add(calDAVName("calendar-user-address-set"), hrefXML("mailto:"+user.Email))
+	return props
+}
+
+func homeProperties(user *entities.User) *propertySet {
+	props := &propertySet{}
+	props.add(davName("resourcetype"), "<d:collection/>")
+	props.add(davName("current-user-principal"), hrefXML(principalHref(user)))
+	props.add(davName("owner"), hrefXML(principalHref(user)))
+	return props
+}
+
+func calendarProperties(user *entities.User, objects []*calendarObject) *propertySet {
+	tag := collectionTag(objects)
+
+	props := &propertySet{}
+	props.add(davName("resourcetype"), "<d:collection/><c:calendar/>")
+	props.add(davName("displayname"), escapeXML(displayName))
+	props.add(davName("current-user-principal"), hrefXML(principalHref(user)))
+	props.add(davName("owner"), hrefXML(principalHref(user)))
+	props.add(davName("current-user-privilege-set"),
+		"<d:privilege><d:read/></d:privilege>"+
+			"<d:privilege><d:write/></d:privilege>"+
+			"<d:privilege><d:write-content/></d:privilege>"+
+			"<d:privilege><d:bind/></d:privilege>"+
+			"<d:privilege><d:unbind/></d:privilege>"+
+			"<d:privilege><d:read-current-user-privilege-set/></d:privilege>")
+	props.add(davName("supported-report-set"),
+		"<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>"+
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>")
+	props.add(davName("getetag"), escapeXML(tag))
+	props.add(calDAVName("supported-calendar-component-set"), `<c:comp name="VEVENT"/>`)
+	props.add(calDAVName("supported-calendar-data"), `<c:calendar-data content-type="text/calendar" version="2.0"/>`)
+	props.add(calDAVName("max-resource-size"), fmt.Sprint(maxObjectSize))
+	props.add(xml.Name{Space: nsCalendarServer, Local: "getctag"}, escapeXML(tag))
+	return props
+}
+
+func objectProperties(obj *calendarObject) (*propertySet, error) {
+	body, err := obj.encode()
+	if err != nil {
+		return nil, err
+	}
+
+	props := &propertySet{}
+	props.add(davName("resourcetype"), "")
+	props.add(davName("getetag"), escapeXML(obj.etag()))
+	props.add(davName("getcontenttype"), "text/calendar; charset=utf-8; component=vevent")
+	props.add(davName("getcontentlength"), fmt.Sprint(len(body)))
+	props.add(davName("getlastmodified"), obj.modifiedAt().UTC().Format(http.TimeFormat))
+	props.add(calendarData, escapeXML(string(body)))
+	return props, nil
+}
+
+// collectionTag changes whenever an object of the calendar is created,
+// modified or deleted
+func collectionTag(objects []*calendarObject) string {
+	var latest time.Time
+	for _, obj := range objects {
+		if modified := obj.modifiedAt(); modified.After(latest) {
+			latest = modified
+		}
+	}
+	return fmt.Sprintf(`"%x-%d"`, latest.UnixMicro(), len(objects))
+}
@@ -0,0 +1,161 @@
+package caldav
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+)
+
+// timeRangeFormat is the UTC date-time format of CALDAV:time-range
+const timeRangeFormat = "20060102T150405Z"
+
+var errUnsupportedFilter = errors.New("unsupported filter")
+
+// calendarFilter is the part of a CALDAV:filter the server evaluates
+type calendarFilter struct {
+	matchNone  bool // A component other than VEVENT was requested
+	start, end *time.Time
+}
+
+func (s *Server) report(w http.ResponseWriter, r *http.Request, user *entities.User, res resource) {
+	req, err := parseXML(r.Body)
+	if err != nil || req == nil {
+		http.Error(w, "Expected a REPORT body", http.StatusBadRequest)
+		return
+	}
+
+	filter := parsePropFilter(req)
+
+	switch {
+	case req.is(nsCalDAV, "calendar-query"):
+		s.calendarQuery(w, r, user, res, req, filter)
+	case req.is(nsCalDAV, "calendar-multiget"):
+		s.calendarMultiget(w, r, user, res, req, filter)
+	default:
+		writeError(w, http.StatusForbidden, davName("supported-report"))
+	}
+}
+
+func (s *Server) calendarQuery(w http.ResponseWriter, r *http.Request, user *entities.User, res resource, req *element, filter propFilter) {
+	if res.kind != kindCalendar {
+		writeError(w, http.StatusForbidden, davName("supported-report"))
+		return
+	}
+
+	query, err := parseCalendarFilter(req.child(nsCalDAV, "filter"))
+	if err != nil {
+		writeError(w, http.StatusForbidden, calDAVName("supported-filter"))
+		return
+	}
+
+	var objects []*calendarObject
+	if !query.matchNone {
+		objects, err = s.listObjects(r.Context(), user, query.start, query.end)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	responses, err := objectsResponses(user, objects, filter)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeMultistatus(w, responses)
+}
+
+func (s *Server) calendarMultiget(w http.ResponseWriter, r *http.Request, user *entities.User, res resource, req *element, filter propFilter) {
+	if res.kind != kindCalendar && res.kind != kindObject {
+		writeError(w, http.StatusForbidden, davName("supported-report"))
+		return
+	}
+
+	// Clients ask for many objects at once, so they are all loaded together
+	objects, err := s.listObjects(r.Context(), user, nil, nil)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	byUID := make(map[string]*calendarObject, len(objects))
+	for _, obj := range objects {
+		byUID[obj.uid] = obj
+	}
+
+	var responses []*response
+	for _, el := range req.childrenNamed(nsDAV, "href") {
+		href := strings.TrimSpace(el.Text)
+		target, ok := resolveHref(user, href)
+		obj := byUID[target.uid]
+		if !ok || target.kind != kindObject || obj == nil {
+			responses = append(responses, &response{Href: href, Status: http.StatusNotFound})
+			continue
+		}
+
+		props, err := objectProperties(obj)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, props.response(href, filter))
+	}
+
+	writeMultistatus(w, responses)
+}
+
+// parseCalendarFilter supports component filters on VCALENDAR and VEVENT
+// with an optional time range. Property and parameter filters are rejected.
+func parseCalendarFilter(filter *element) (calendarFilter, error) {
+	var result calendarFilter
+	if filter == nil {
+		return result, nil
+	}
+
+	calendar := filter.child(nsCalDAV, "comp-filter")
+	if calendar == nil {
+		return result, nil
+	}
+	if calendar.attr("name") != "VCALENDAR" {
+		return calendarFilter{matchNone: true}, nil
+	}
+	if calendar.child(nsCalDAV, "prop-filter") != nil {
+		return result, errUnsupportedFilter
+	}
+
+	component := calendar.child(nsCalDAV, "comp-filter")
+	if component == nil {
+		return result, nil
+	}
+	if component.attr("name") != "VEVENT" || component.child(nsCalDAV, "is-not-defined") != nil {
+		return calendarFilter{matchNone: true}, nil
+	}
+	if component.child(nsCalDAV, "prop-filter") != nil || component.child(nsCalDAV, "comp-filter") != nil {
+		return result, errUnsupportedFilter
+	}
+
+	if timeRange := component.child(nsCalDAV, "time-range"); timeRange != nil {
+		var err error
+		if result.start, err = parseTimeRangeBound(timeRange.attr("start")); err != nil {
+			return result, err
+		}
+		if result.end, err = parseTimeRangeBound(timeRange.attr("end")); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func parseTimeRangeBound(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(timeRangeFormat, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
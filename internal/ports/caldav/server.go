@@ -0,0 +1,254 @@
+// Package caldav serves the events of a user as a single CalDAV (RFC 4791)
+// calendar so that native calendar clients can read and write them.
+//
+// Resources, relative to Prefix:
+//
+//	/                                    service root
+//	/principals/{user_id}/               principal of the authenticated user
+//	/calendars/{user_id}/                calendar home
+//	/calendars/{user_id}/events/         the calendar
+//	/calendars/{user_id}/events/{uid}.ics  one event series per resource
+//
+// Clients authenticate with HTTP Basic using their email and password.
+// Failed logins are limited per client IP and username, and successful ones
+// are cached briefly.
+package caldav
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+const (
+	// Prefix is the path the server is mounted at
+	Prefix = "/caldav"
+
+	// calendarName is the path segment of the only calendar of a user
+	calendarName = "events"
+
+	// objectSuffix is the file extension of calendar object resources
+	objectSuffix = ".ics"
+
+	realm = "Smart Goal Calendar"
+)
+
+const allowedMethods = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, PROPPATCH, REPORT"
+
+type Server struct {
+	userHandler   *appHandlers.UserHandler
+	eventHandler  *appHandlers.EventHandler
+	eventRepo     repositories.EventRepository
+	exceptionRepo repositories.EventExceptionRepository
+	calendarRepo  repositories.CalendarRepository
+	eventService  *services.EventService
+	auth          *authenticator
+}
+
+func NewServer(
+	userHandler *appHandlers.UserHandler,
+	eventHandler *appHandlers.EventHandler,
+	eventRepo repositories.EventRepository,
+	exceptionRepo repositories.EventExceptionRepository,
+	calendarRepo repositories.CalendarRepository,
+	eventService *services.EventService,
+) *Server {
+	return &Server{
+		userHandler:   userHandler,
+		eventHandler:  eventHandler,
+		eventRepo:     eventRepo,
+		exceptionRepo: exceptionRepo,
+		calendarRepo:  calendarRepo,
+		eventService:  eventService,
+		auth:          newAuthenticator(),
+	}
+}
+
+// Register mounts the server and the /.well-known/caldav redirect (RFC 6764)
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.Handle(Prefix+"/", s)
+	mux.Handle("/.well-known/caldav", http.RedirectHandler(Prefix+"/", http.StatusMovedPermanently))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("DAV", "1, 3, calendar-access")
+		w.Header().Set("Allow", allowedMethods)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	user, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	res, ok := resolve(user, r.URL.EscapedPath())
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "PROPFIND":
+		s.propfind(w, r, user, res)
+	case "PROPPATCH":
+		s.proppatch(w, r, user, res)
+	case "REPORT":
+		s.report(w, r, user, res)
+	case http.MethodGet, http.MethodHead:
+		s.get(w, r, user, res)
+	case http.MethodPut:
+		s.put(w, r, user, res)
+	case http.MethodDelete:
+		s.delete(w, r, user, res)
+	default:
+		w.Header().Set("Allow", allowedMethods)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*entities.User, bool) {
+	email, password, ok := r.BasicAuth()
+	if ok {
+		if wait := s.auth.blocked(r, email); wait > 0 {
+			w.Header().Set("Retry-After", retryAfter(wait))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return nil, false
+		}
+		if user := s.auth.cached(email, password); user != nil {
+			return user, true
+		}
+
+		result, err := s.userHandler.HandleAuthenticateUser(r.Context(), commands.AuthenticateUserCommand{
+			Email:    email,
+			Password: password,
+		})
+		if err == nil {
+			s.auth.succeeded(email, password, result.User)
+			return result.User, true
+		}
+		if !errors.Is(err, appHandlers.ErrInvalidCredentials) {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return nil, false
+		}
+		s.auth.failed(r, email)
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return nil, false
+}
+
+type resourceKind int
+
+const (
+	kindRoot resourceKind = iota
+	kindPrincipal
+	kindHome
+	kindCalendar
+	kindObject
+)
+
+// resource is a path resolved for the authenticated user. UID is set for
+// calendar objects.
+type resource struct {
+	kind resourceKind
+	uid  string
+}
+
+// resolve maps an escaped request path to a resource. Paths of other users
+// don't resolve.
+func resolve(user *entities.User, escapedPath string) (resource, bool) {
+	rel, ok := strings.CutPrefix(escapedPath, Prefix)
+	if !ok {
+		return resource{}, false
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return resource{}, false
+		}
+		segments = append(segments, unescaped)
+	}
+
+	if len(segments) == 0 {
+		return resource{kind: kindRoot}, true
+	}
+	if len(segments) < 2 || segments[1] != string(user.ID) {
+		return resource{}, false
+	}
+
+	switch {
+	case segments[0] == "principals" && len(segments) == 2:
+		return resource{kind: kindPrincipal}, true
+	case segments[0] != "calendars":
+		return resource{}, false
+	case len(segments) == 2:
+		return resource{kind: kindHome}, true
+	case segments[2] != calendarName:
+		return resource{}, false
+	case len(segments) == 3:
+		return resource{kind: kindCalendar}, true
+	case len(segments) == 4:
+		uid, ok := strings.CutSuffix(segments[3], objectSuffix)
+		if !ok || uid == "" {
+			return resource{}, false
+		}
+		return resource{kind: kindObject, uid: uid}, true
+	}
+
+	return resource{}, false
+}
+
+// resolveHref resolves an href of a multiget report, which may be a path or
+// an absolute URL
+func resolveHref(user *entities.User, href string) (resource, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return resource{}, false
+	}
+	return resolve(user, u.EscapedPath())
+}
+
+func principalHref(user *entities.User) string {
+	return Prefix + "/principals/" + url.PathEscape(string(user.ID)) + "/"
+}
+
+func homeHref(user *entities.User) string {
+	return Prefix + "/calendars/" + url.PathEscape(string(user.ID)) + "/"
+}
+
+func calendarHref(user *entities.User) string {
+	return homeHref(user) + calendarName + "/"
+}
+
+func objectHref(user *entities.User, uid string) string {
+	return calendarHref(user) + url.PathEscape(uid) + objectSuffix
+}
+
+func (res resource) href(user *entities.User) string {
+	switch res.kind {
+	case kindPrincipal:
+		return principalHref(user)
+	case kindHome:
+		return homeHref(user)
+	case kindCalendar:
+		return calendarHref(user)
+	case kindObject:
+		return objectHref(user, res.uid)
+	}
+	return Prefix + "/"
+}
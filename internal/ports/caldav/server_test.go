@@ -0,0 +1,570 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+const (
+	adaEmail    = "ada@example.com"
+	adaPassword = "correct horse battery staple"
+)
+
+var (
+	ada = &entities.User{ID: "user-ada", Email: adaEmail, Name: "Ada Lovelace"}
+	bob = &entities.User{ID: "user-bob", Email: "bob@example.com", Name: "Bob"}
+)
+
+// fakeUsers finds users by email
+type fakeUsers struct {
+	repositories.UserRepository
+	users []*entities.User
+}
+
+func (f *fakeUsers) GetByEmail(_ context.Context, email string) (*entities.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+// fakeCredentials holds the same password for every user
+type fakeCredentials struct {
+	repositories.UserCredentialRepository
+	passwordHash string
+}
+
+func (f *fakeCredentials) GetByUserID(_ context.Context, userID entities.UserID) (*entities.UserCredential, error) {
+	return &entities.UserCredential{UserID: userID, PasswordHash: f.passwordHash}, nil
+}
+
+// plainHasher stores passwords with a prefix; hashing isn't under test
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error) {
+	return "plain:" + password, nil
+}
+
+func (plainHasher) Verify(password, encodedHash string) (bool, bool, error) {
+	return encodedHash == "plain:"+password, false, nil
+}
+
+// memoryEvents keeps the events of all users
+type memoryEvents struct {
+	repositories.EventRepository
+	events map[entities.EventID]*entities.Event
+}
+
+func (m *memoryEvents) Create(_ context.Context, event *entities.Event) error {
+	stored := *event
+	m.events[event.ID] = &stored
+	return nil
+}
+
+func (m *memoryEvents) Update(_ context.Context, event *entities.Event) error {
+	stored := *event
+	m.events[event.ID] = &stored
+	return nil
+}
+
+func (m *memoryEvents) Delete(_ context.Context, id entities.EventID) error {
+	delete(m.events, id)
+	return nil
+}
+
+func (m *memoryEvents) GetByID(_ context.Context, id entities.EventID) (*entities.Event, error) {
+	stored, ok := m.events[id]
+	if !ok {
+		return nil, nil
+	}
+	event := *stored
+	return &event, nil
+}
+
+func (m *memoryEvents) GetByExternalID(_ context.Context, userID entities.UserID, externalID string) (*entities.Event, error) {
+	for _, stored := range m.events {
+		if stored.UserID == userID && stored.ExternalID == externalID {
+			event := *stored
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryEvents) GetByUserID(_ context.Context, userID entities.UserID) ([]*entities.Event, error) {
+	var events []*entities.Event
+	for _, stored := range m.events {
+		if stored.UserID == userID {
+			event := *stored
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
+
+// memoryExceptions keeps exceptions of recurring events
+type memoryExceptions struct {
+	repositories.EventExceptionRepository
+	exceptions []*entities.EventException
+}
+
+func (m *memoryExceptions) GetByEventID(_ context.Context, eventID entities.EventID) ([]*entities.EventException, error) {
+	return m.GetByEventIDs(context.Background(), []entities.EventID{eventID})
+}
+
+func (m *memoryExceptions) GetByEventIDs(_ context.Context, eventIDs []entities.EventID) ([]*entities.EventException, error) {
+	var exceptions []*entities.EventException
+	for _, exception := range m.exceptions {
+		if slices.Contains(eventIDs, exception.EventID) {
+			exceptions = append(exceptions, exception)
+		}
+	}
+	return exceptions, nil
+}
+
+func (m *memoryExceptions) Upsert(_ context.Context, exception *entities.EventException) error {
+	m.exceptions = append(m.exceptions, exception)
+	return nil
+}
+
+func (m *memoryExceptions) DeleteFrom(_ context.Context, eventID entities.EventID, from time.Time) error {
+	m.exceptions = slices.DeleteFunc(m.exceptions, func(exception *entities.EventException) bool {
+		return exception.EventID == eventID && !exception.RecurrenceID.Before(from)
+	})
+	return nil
+}
+
+// fakeCalendars gives every user a writable default calendar
+type fakeCalendars struct {
+	repositories.CalendarRepository
+}
+
+func (f *fakeCalendars) GetOrCreateDefault(_ context.Context, userID entities.UserID) (*entities.Calendar, error) {
+	return &entities.Calendar{ID: entities.CalendarID("calendar-" + userID), UserID: userID, Visible: true}, nil
+}
+
+func (f *fakeCalendars) GetByID(_ context.Context, id entities.CalendarID) (*entities.Calendar, error) {
+	userID, _ := strings.CutPrefix(string(id), "calendar-")
+	return f.GetOrCreateDefault(context.Background(), entities.UserID(userID))
+}
+
+// newTestServer serves a CalDAV server for Ada and Bob, who share
+// adaPassword, and returns its URL and event store
+func newTestServer(t *testing.T) (string, *memoryEvents) {
+	t.Helper()
+
+	events := &memoryEvents{events: make(map[entities.EventID]*entities.Event)}
+	exceptions := &memoryExceptions{}
+	calendars := &fakeCalendars{}
+	eventService := services.NewEventService()
+
+	hash, _ := plainHasher{}.Hash(adaPassword)
+	server := NewServer(
+		appHandlers.NewUserHandler(&fakeUsers{users: []*entities.User{ada, bob}}, &fakeCredentials{passwordHash: hash}, plainHasher{}),
+		appHandlers.NewEventHandler(events, exceptions, calendars, nil, eventService),
+		events,
+		exceptions,
+		calendars,
+		eventService,
+	)
+
+	mux := http.NewServeMux()
+	server.Register(mux)
+	httpServer := httptest.NewServer(mux)
+	t.Cleanup(httpServer.Close)
+	return httpServer.URL, events
+}
+
+// davRequest sends a request as the given user, without credentials when
+// email is empty; headers alternate names and values
+func davRequest(t *testing.T, baseURL, email, password, method, path, body string, headers ...string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, baseURL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "" {
+		req.SetBasicAuth(email, password)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(data)
+}
+
+// asAda sends a request with Ada's credentials
+func asAda(t *testing.T, baseURL, method, path, body string, headers ...string) (*http.Response, string) {
+	t.Helper()
+	return davRequest(t, baseURL, adaEmail, adaPassword, method, path, body, headers...)
+}
+
+type multistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+}
+
+type davResponse struct {
+	Href      string `xml:"DAV: href"`
+	Status    string `xml:"DAV: status"`
+	Propstats []struct {
+		Prop struct {
+			Props []davProp `xml:",any"`
+		} `xml:"DAV: prop"`
+		Status string `xml:"DAV: status"`
+	} `xml:"DAV: propstat"`
+}
+
+// davProp is a property with its hrefs, the names of its child elements
+// and its text
+type davProp struct {
+	XMLName  xml.Name
+	Hrefs    []string `xml:"DAV: href"`
+	Children []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+	Text string `xml:",chardata"`
+}
+
+// prop returns a property found for the resource, or nil
+func (r davResponse) prop(space, local string) *davProp {
+	for _, propstat := range r.Propstats {
+		if !strings.Contains(propstat.Status, " 200 ") {
+			continue
+		}
+		for i, p := range propstat.Prop.Props {
+			if p.XMLName.Space == space && p.XMLName.Local == local {
+				return &propstat.Prop.Props[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (p *davProp) has(space, local string) bool {
+	for _, child := range p.Children {
+		if child.XMLName.Space == space && child.XMLName.Local == local {
+			return true
+		}
+	}
+	return false
+}
+
+func parseMultistatus(t *testing.T, resp *http.Response, body string) map[string]davResponse {
+	t.Helper()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want 207; body %s", resp.StatusCode, body)
+	}
+	var ms multistatus
+	if err := xml.Unmarshal([]byte(body), &ms); err != nil {
+		t.Fatalf("invalid multistatus: %v\n%s", err, body)
+	}
+
+	byHref := make(map[string]davResponse, len(ms.Responses))
+	for _, r := range ms.Responses {
+		byHref[r.Href] = r
+	}
+	return byHref
+}
+
+func TestDiscovery(t *testing.T) {
+	baseURL, _ := newTestServer(t)
+	propfind := func(props string) string {
+		return `<?xml version="1.0" encoding="utf-8"?>` +
+			`<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop>` + props + `</d:prop></d:propfind>`
+	}
+
+	resp, _ := davRequest(t, baseURL, "", "", http.MethodGet, "/.well-known/caldav", "")
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != Prefix+"/" {
+		t.Errorf("well-known = %d to %q, want 301 to %s/", resp.StatusCode, resp.Header.Get("Location"), Prefix)
+	}
+
+	// Principal
+	resp, body := asAda(t, baseURL, "PROPFIND", Prefix+"/", propfind(`<d:current-user-principal/>`), "Depth", "0")
+	root := parseMultistatus(t, resp, body)[Prefix+"/"]
+	principal := root.prop(nsDAV, "current-user-principal")
+	if principal == nil || !slices.Equal(principal.Hrefs, []string{"/caldav/principals/user-ada/"}) {
+		t.Fatalf("current-user-principal = %+v, want /caldav/principals/user-ada/", principal)
+	}
+
+	// Calendar home
+	resp, body = asAda(t, baseURL, "PROPFIND", principal.Hrefs[0],
+		propfind(`<c:calendar-home-set/><c:calendar-user-address-set/><d:displayname/>`), "Depth", "0")
+	principalResp := parseMultistatus(t, resp, body)[principal.Hrefs[0]]
+	home := principalResp.prop(nsCalDAV, "calendar-home-set")
+	if home == nil || !slices.Equal(home.Hrefs, []string{"/caldav/calendars/user-ada/"}) {
+		t.Fatalf("calendar-home-set = %+v, want /caldav/calendars/user-ada/", home)
+	}
+	if address := principalResp.prop(nsCalDAV, "calendar-user-address-set"); address == nil || !slices.Equal(address.Hrefs, []string{"mailto:" + adaEmail}) {
+		t.Errorf("calendar-user-address-set = %+v, want mailto:%s", address, adaEmail)
+	}
+	if name := principalResp.prop(nsDAV, "displayname"); name == nil || name.Text != ada.Name {
+		t.Errorf("displayname = %+v, want %q", name, ada.Name)
+	}
+
+	// Calendar
+	resp, body = asAda(t, baseURL, "PROPFIND", home.Hrefs[0],
+		propfind(`<d:resourcetype/><c:supported-calendar-component-set/><d:sync-token/>`), "Depth", "1")
+	responses := parseMultistatus(t, resp, body)
+	calendarResp, ok := responses["/caldav/calendars/user-ada/events/"]
+	if !ok {
+		t.Fatalf("calendar not listed in the home: %s", body)
+	}
+	resourceType := calendarResp.prop(nsDAV, "resourcetype")
+	if resourceType == nil || !resourceType.has(nsDAV, "collection") || !resourceType.has(nsCalDAV, "calendar") {
+		t.Errorf("calendar resourcetype = %+v, want a calendar collection", resourceType)
+	}
+	components := calendarResp.prop(nsCalDAV, "supported-calendar-component-set")
+	if components == nil || !components.has(nsCalDAV, "comp") {
+		t.Errorf("supported-calendar-component-set = %+v, want VEVENT", components)
+	}
+	// Unknown properties are reported as not found
+	if len(calendarResp.Propstats) != 2 || !strings.Contains(calendarResp.Propstats[1].Status, " 404 ") {
+		t.Errorf("propstats = %+v, want the sync token not found", calendarResp.Propstats)
+	}
+}
+
+func TestOtherUsersPaths(t *testing.T) {
+	baseURL, events := newTestServer(t)
+
+	// Bob's event is not reachable under any of Ada's paths either
+	resp, _ := davRequest(t, baseURL, bob.Email, adaPassword, http.MethodPut,
+		"/caldav/calendars/user-bob/events/bob-1.ics", eventICS("bob-1", "Bob's dentist", "20240402T090000Z", "20240402T100000Z"))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT as Bob = %d, want 201", resp.StatusCode)
+	}
+	if len(events.events) != 1 {
+		t.Fatalf("%d events stored, want 1", len(events.events))
+	}
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"PROPFIND", "/caldav/principals/user-bob/"},
+		{"PROPFIND", "/caldav/calendars/user-bob/"},
+		{"PROPFIND", "/caldav/calendars/user-bob/events/"},
+		{http.MethodGet, "/caldav/calendars/user-bob/events/bob-1.ics"},
+		{http.MethodDelete, "/caldav/calendars/user-bob/events/bob-1.ics"},
+		{http.MethodGet, "/caldav/calendars/user-ada/events/bob-1.ics"},
+		{http.MethodGet, "/caldav/calendars/user-ada/other/bob-1.ics"},
+		{http.MethodGet, "/caldav/calendars/user-ada/events/bob-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			resp, _ := asAda(t, baseURL, tt.method, tt.path, "", "Depth", "0")
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("status = %d, want 404", resp.StatusCode)
+			}
+		})
+	}
+
+	if len(events.events) != 1 {
+		t.Errorf("%d events stored, want Bob's event kept", len(events.events))
+	}
+}
+
+func TestObjectPreconditions(t *testing.T) {
+	baseURL, events := newTestServer(t)
+	path := "/caldav/calendars/user-ada/events/dentist@example.com.ics"
+	original := eventICS("dentist@example.com", "Dentist", "20240402T090000Z", "20240402T100000Z")
+	moved := eventICS("dentist@example.com", "Dentist (moved)", "20240402T110000Z", "20240402T120000Z")
+
+	// Create only when absent
+	resp, _ := asAda(t, baseURL, http.MethodPut, path, original, "If-None-Match", "*", "Content-Type", "text/calendar")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT = %d, want 201", resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("PUT returned no ETag")
+	}
+	resp, _ = asAda(t, baseURL, http.MethodPut, path, original, "If-None-Match", "*")
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("second PUT with If-None-Match = %d, want 412", resp.StatusCode)
+	}
+
+	resp, body := asAda(t, baseURL, http.MethodGet, path, "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != etag {
+		t.Fatalf("GET = %d with ETag %q, want 200 with %q", resp.StatusCode, resp.Header.Get("ETag"), etag)
+	}
+	if !strings.Contains(body, "UID:dentist@example.com") || !strings.Contains(body, "SUMMARY:Dentist") {
+		t.Errorf("GET body lacks the UID or summary:\n%s", body)
+	}
+	resp, _ = asAda(t, baseURL, http.MethodGet, path, "", "If-None-Match", etag)
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET with If-None-Match = %d, want 304", resp.StatusCode)
+	}
+
+	// Replace only the version the client has
+	resp, _ = asAda(t, baseURL, http.MethodPut, path, moved, "If-Match", `"stale"`)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with a stale If-Match = %d, want 412", resp.StatusCode)
+	}
+	resp, _ = asAda(t, baseURL, http.MethodPut, path, moved, "If-Match", etag)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT with If-Match = %d, want 204", resp.StatusCode)
+	}
+	newETag := resp.Header.Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Errorf("ETag after the update = %q, want one other than %q", newETag, etag)
+	}
+	if len(events.events) != 1 {
+		t.Fatalf("%d events stored, want 1", len(events.events))
+	}
+	for _, event := range events.events {
+		if event.Title != "Dentist (moved)" || !event.StartTime.Equal(time.Date(2024, 4, 2, 11, 0, 0, 0, time.UTC)) {
+			t.Errorf("stored event = %q at %v, want the moved dentist", event.Title, event.StartTime)
+		}
+	}
+
+	// The resource name has to be the UID
+	resp, _ = asAda(t, baseURL, http.MethodPut, "/caldav/calendars/user-ada/events/other.ics", original)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("PUT under another name = %d, want 400", resp.StatusCode)
+	}
+
+	// Delete only the version the client has
+	resp, _ = asAda(t, baseURL, http.MethodDelete, path, "", "If-Match", etag)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with a stale If-Match = %d, want 412", resp.StatusCode)
+	}
+	resp, _ = asAda(t, baseURL, http.MethodDelete, path, "", "If-Match", newETag)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE = %d, want 204", resp.StatusCode)
+	}
+	resp, _ = asAda(t, baseURL, http.MethodGet, path, "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET after DELETE = %d, want 404", resp.StatusCode)
+	}
+	resp, _ = asAda(t, baseURL, http.MethodDelete, path, "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("second DELETE = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestReports(t *testing.T) {
+	baseURL, _ := newTestServer(t)
+	calendarPath := "/caldav/calendars/user-ada/events/"
+	for uid, ics := range map[string]string{
+		"dentist": eventICS("dentist", "Dentist", "20240402T090000Z", "20240402T100000Z"),
+		"gym":     eventICS("gym", "Gym", "20240405T180000Z", "20240405T190000Z"),
+	} {
+		resp, _ := asAda(t, baseURL, http.MethodPut, calendarPath+uid+".ics", ics)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("PUT %s = %d, want 201", uid, resp.StatusCode)
+		}
+	}
+
+	t.Run("calendar-multiget", func(t *testing.T) {
+		body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <d:href>` + calendarPath + `gym.ics</d:href>
+  <d:href>` + calendarPath + `missing.ics</d:href>
+  <d:href>/caldav/calendars/user-bob/events/gym.ics</d:href>
+</c:calendar-multiget>`
+		resp, respBody := asAda(t, baseURL, "REPORT", calendarPath, body, "Depth", "1")
+		responses := parseMultistatus(t, resp, respBody)
+		if len(responses) != 3 {
+			t.Fatalf("%d responses, want 3: %s", len(responses), respBody)
+		}
+
+		gym := responses[calendarPath+"gym.ics"]
+		if data := gym.prop(nsCalDAV, "calendar-data"); data == nil || !strings.Contains(data.Text, "SUMMARY:Gym") {
+			t.Errorf("gym calendar-data = %+v, want the event", data)
+		}
+		if etag := gym.prop(nsDAV, "getetag"); etag == nil || etag.Text == "" {
+			t.Errorf("gym getetag = %+v, want an ETag", etag)
+		}
+		for _, href := range []string{calendarPath + "missing.ics", "/caldav/calendars/user-bob/events/gym.ics"} {
+			if status := responses[href].Status; !strings.Contains(status, " 404 ") {
+				t.Errorf("%s status = %q, want 404", href, status)
+			}
+		}
+	})
+
+	t.Run("time-range", func(t *testing.T) {
+		body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/></d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="20240401T000000Z" end="20240403T000000Z"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+		resp, respBody := asAda(t, baseURL, "REPORT", calendarPath, body, "Depth", "1")
+		responses := parseMultistatus(t, resp, respBody)
+		if _, ok := responses[calendarPath+"dentist.ics"]; !ok || len(responses) != 1 {
+			t.Errorf("responses = %v, want only the dentist", slices.Collect(maps.Keys(responses)))
+		}
+	})
+
+	t.Run("unsupported filter", func(t *testing.T) {
+		body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/></d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:prop-filter name="SUMMARY"><c:text-match>Gym</c:text-match></c:prop-filter>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+		resp, respBody := asAda(t, baseURL, "REPORT", calendarPath, body, "Depth", "1")
+		if resp.StatusCode != http.StatusForbidden || !strings.Contains(respBody, "supported-filter") {
+			t.Errorf("status = %d, body %s; want 403 supported-filter", resp.StatusCode, respBody)
+		}
+	})
+}
+
+// eventICS is a calendar object with a single UTC event
+func eventICS(uid, summary, start, end string) string {
+	return strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//Client//EN",
+		"BEGIN:VEVENT",
+		"UID:" + uid,
+		"DTSTAMP:20240401T000000Z",
+		"DTSTART:" + start,
+		"DTEND:" + end,
+		"SUMMARY:" + summary,
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+}
@@ -0,0 +1,226 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// XML namespaces used by CalDAV clients
+const (
+	nsDAV            = "DAV:"
+	nsCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	nsCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// prefixes are declared on the root of every response
+var prefixes = map[string]string{
+	nsDAV:            "d",
+	nsCalDAV:         "c",
+	nsCalendarServer: "cs",
+}
+
+// element is a node of a request body
+type element struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Children []*element
+	Text     string
+}
+
+// parseXML reads a request body into an element tree. An empty body yields nil.
+func parseXML(r io.Reader) (*element, error) {
+	decoder := xml.NewDecoder(r)
+
+	var root *element
+	var stack []*element
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &element{Name: t.Name, Attrs: t.Attr}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("invalid XML: multiple root elements")
+				}
+				root = el
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, el)
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+
+	return root, nil
+}
+
+func (e *element) is(space, local string) bool {
+	return e != nil && e.Name.Space == space && e.Name.Local == local
+}
+
+// child returns the first child with the given name, or nil
+func (e *element) child(space, local string) *element {
+	if e == nil {
+		return nil
+	}
+	for _, c := range e.Children {
+		if c.is(space, local) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (e *element) childrenNamed(space, local string) []*element {
+	if e == nil {
+		return nil
+	}
+	var result []*element
+	for _, c := range e.Children {
+		if c.is(space, local) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (e *element) attr(local string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// propNames returns the names of the properties inside a DAV:prop element
+func (e *element) propNames() []xml.Name {
+	if e == nil {
+		return nil
+	}
+	names := make([]xml.Name, len(e.Children))
+	for i, c := range e.Children {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// prop is a property value; Inner is raw XML
+type prop struct {
+	Name  xml.Name
+	Inner string
+}
+
+type propstat struct {
+	Status int
+	Props  []prop
+}
+
+// response is one resource of a multistatus. Status is set instead of
+// Propstats when the resource itself could not be returned.
+type response struct {
+	Href      string
+	Status    int
+	Propstats []propstat
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []*response) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<d:multistatus" + namespaceDeclarations() + ">")
+
+	for _, resp := range responses {
+		b.WriteString("<d:response>")
+		writeElement(&b, xml.Name{Space: nsDAV, Local: "href"}, escapeXML(resp.Href))
+		if resp.Status != 0 {
+			writeElement(&b, xml.Name{Space: nsDAV, Local: "status"}, statusLine(resp.Status))
+		}
+		for _, ps := range resp.Propstats {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, p := range ps.Props {
+				writeElement(&b, p.Name, p.Inner)
+			}
+			b.WriteString("</d:prop>")
+			writeElement(&b, xml.Name{Space: nsDAV, Local: "status"}, statusLine(ps.Status))
+			b.WriteString("</d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+
+	b.WriteString("</d:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write(b.Bytes())
+}
+
+// writeError writes a DAV:error body naming the failed precondition
+func writeError(w http.ResponseWriter, status int, condition xml.Name) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<d:error" + namespaceDeclarations() + ">")
+	writeElement(&b, condition, "")
+	b.WriteString("</d:error>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(b.Bytes())
+}
+
+func writeElement(b *bytes.Buffer, name xml.Name, inner string) {
+	prefix, known := prefixes[name.Space]
+	qualified := name.Local
+	if known {
+		qualified = prefix + ":" + name.Local
+	}
+
+	b.WriteString("<" + qualified)
+	if !known && name.Space != "" {
+		b.WriteString(` xmlns="` + escapeXML(name.Space) + `"`)
+	}
+	if inner == "" {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">" + inner + "</" + qualified + ">")
+}
+
+func namespaceDeclarations() string {
+	var b strings.Builder
+	for _, space := range []string{nsDAV, nsCalDAV, nsCalendarServer} {
+		fmt.Fprintf(&b, ` xmlns:%s="%s"`, prefixes[space], space)
+	}
+	return b.String()
+}
+
+func statusLine(status int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", status, http.StatusText(status))
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// hrefXML is the inner XML of properties holding a single href
+func hrefXML(href string) string {
+	return "<d:href>" + escapeXML(href) + "</d:href>"
+}
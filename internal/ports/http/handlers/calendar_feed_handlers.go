@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/ical"
+	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
+)
+
+// feedPath is where feeds are served, relative to the host
+const feedPath = "/api/v1/feeds/"
+
+type CalendarFeedHTTPHandler struct {
+	feedHandler *appHandlers.CalendarFeedHandler
+}
+
+func NewCalendarFeedHTTPHandler(feedHandler *appHandlers.CalendarFeedHandler) *CalendarFeedHTTPHandler {
+	return &CalendarFeedHTTPHandler{
+		feedHandler: feedHandler,
+	}
+}
+
+// Request/Response models
+
+type CalendarFeedSettingsRequest struct {
+	IncludeGoals      *bool `json:"include_goals,omitempty"`
+	IncludeMilestones *bool `json:"include_milestones,omitempty"`
+}
+
+type CalendarFeedResponse struct {
+	ID                entities.CalendarFeedID `json:"id"`
+	URL               string                  `json:"url,omitempty"` // Only returned when the token is issued
+	IncludeGoals      bool                    `json:"include_goals"`
+	IncludeMilestones bool                    `json:"include_milestones"`
+	LastAccessedAt    *time.Time              `json:"last_accessed_at,omitempty"`
+	CreatedAt         time.Time               `json:"created_at"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+}
+
+// GetFeed returns the current user's active feed. The URL itself can't be
+// shown again; rotating the feed issues a new one.
+func (h *CalendarFeedHTTPHandler) GetFeed(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	result, err := h.feedHandler.HandleGetCalendarFeed(c.Request.Context(), queries.GetCalendarFeedQuery{UserID: userID})
+	if err != nil {
+		h.handleFeedError(c, err, "feed_retrieval_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapFeedToResponse(result.Feed, ""))
+}
+
+// RotateFeed issues a new feed URL, revoking the previous one
+func (h *CalendarFeedHTTPHandler) RotateFeed(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	var req CalendarFeedSettingsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	cmd := commands.RotateCalendarFeedCommand{
+		UserID:            userID,
+		IncludeGoals:      req.IncludeGoals,
+		IncludeMilestones: req.IncludeMilestones,
+	}
+
+	result, err := h.feedHandler.HandleRotateCalendarFeed(c.Request.Context(), cmd)
+	if err != nil {
+		h.handleFeedError(c, err, "feed_rotation_failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.mapFeedToResponse(result.Feed, feedURL(c, result.Token)))
+}
+
+// UpdateFeed changes whether goal deadlines and milestones are included
+func (h *CalendarFeedHTTPHandler) UpdateFeed(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	var req CalendarFeedSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	cmd := commands.UpdateCalendarFeedCommand{
+		UserID:            userID,
+		IncludeGoals:      req.IncludeGoals,
+		IncludeMilestones: req.IncludeMilestones,
+	}
+
+	result, err := h.feedHandler.HandleUpdateCalendarFeed(c.Request.Context(), cmd)
+	if err != nil {
+		h.handleFeedError(c, err, "feed_update_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapFeedToResponse(result.Feed, ""))
+}
+
+// RevokeFeed disables the current user's feed URL
+func (h *CalendarFeedHTTPHandler) RevokeFeed(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	result, err := h.feedHandler.HandleRevokeCalendarFeed(c.Request.Context(), commands.RevokeCalendarFeedCommand{UserID: userID})
+	if err != nil {
+		h.handleFeedError(c, err, "feed_revocation_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Calendar feed revoked successfully",
+		"revoked_at": result.RevokedAt,
+	})
+}
+
+// ServeFeed serves the calendar of a feed token. It is authenticated by the
+// token alone and supports conditional requests via ETag/If-None-Match.
+func (h *CalendarFeedHTTPHandler) ServeFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	result, err := h.feedHandler.HandleGetFeedCalendar(c.Request.Context(), queries.GetFeedCalendarQuery{Token: token})
+	if err != nil {
+		if errors.Is(err, appHandlers.ErrCalendarFeedNotFound) {
+			c.String(http.StatusNotFound, "Calendar feed not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "Failed to load calendar feed")
+		return
+	}
+
+	cal := &ical.Calendar{
+		Name:       "Smart Goal Calendar",
+		Events:     result.Events,
+		Exceptions: result.Exceptions,
+		Timestamp:  result.ModifiedAt,
+	}
+	for _, goal := range result.Goals {
+		cal.AllDay = append(cal.AllDay, ical.AllDayItem{
+			UID:         fmt.Sprintf("goal-%s@smart-goal-calendar", goal.ID),
+			Title:       "Goal deadline: " + goal.Title,
+			Description: goal.Description,
+			Date:        *goal.Deadline,
+			UpdatedAt:   goal.UpdatedAt,
+		})
+	}
+	for _, milestone := range result.Milestones {
+		cal.AllDay = append(cal.AllDay, ical.AllDayItem{
+			UID:         fmt.Sprintf("milestone-%s@smart-goal-calendar", milestone.ID),
+			Title:       "Milestone: " + milestone.Title,
+			Description: milestone.Description,
+			Date:        milestone.TargetDate,
+			UpdatedAt:   milestone.CreatedAt,
+		})
+	}
+
+	var body bytes.Buffer
+	if err := ical.Encode(&body, cal); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to encode calendar feed")
+		return
+	}
+
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=300")
+
+	if ifNoneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body.Bytes())
+}
+
+func (h *CalendarFeedHTTPHandler) handleFeedError(c *gin.Context, err error, code string) {
+	if errors.Is(err, appHandlers.ErrCalendarFeedNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "feed_not_found",
+			"message": "Calendar feed not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   code,
+		"message": err.Error(),
+	})
+}
+
+func (h *CalendarFeedHTTPHandler) mapFeedToResponse(feed *entities.CalendarFeed, url string) CalendarFeedResponse {
+	return CalendarFeedResponse{
+		ID:                feed.ID,
+		URL:               url,
+		IncludeGoals:      feed.IncludeGoals,
+		IncludeMilestones: feed.IncludeMilestones,
+		LastAccessedAt:    feed.LastAccessedAt,
+		CreatedAt:         feed.CreatedAt,
+		UpdatedAt:         feed.UpdatedAt,
+	}
+}
+
+// feedURL builds the absolute feed URL from the request's host
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s%s.ics", scheme, c.Request.Host, feedPath, token)
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches the ETag
+func ifNoneMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarHTTPHandler struct {
+	calendarHandler *appHandlers.CalendarHandler
+}
+
+func NewCalendarHTTPHandler(calendarHandler *appHandlers.CalendarHandler) *CalendarHTTPHandler {
+	return &CalendarHTTPHandler{
+		calendarHandler: calendarHandler,
+	}
+}
+
+// Request/Response models
+
+type CreateCalendarRequest struct {
+	Name             string              `json:"name" binding:"required,max=255"`
+	Color            string              `json:"color"`
+	Timezone         string              `json:"timezone"` // The default calendar's timezone when empty
+	DefaultReminders []entities.Reminder `json:"default_reminders"`
+	Visible          *bool               `json:"visible,omitempty"`
+}
+
+type UpdateCalendarRequest struct {
+	Name             *string              `json:"name,omitempty" binding:"omitempty,max=255"`
+	Color            *string              `json:"color,omitempty"`
+	Timezone         *string              `json:"timezone,omitempty"`
+	DefaultReminders *[]entities.Reminder `json:"default_reminders,omitempty"`
+	Visible          *bool                `json:"visible,omitempty"`
+}
+
+type CalendarResponse struct {
+	ID               entities.CalendarID          `json:"id"`
+	Name             string                       `json:"name"`
+	Color            string                       `json:"color,omitempty"`
+	Timezone         string                       `json:"timezone"`
+	DefaultReminders []entities.Reminder          `json:"default_reminders"`
+	Provider         entities.IntegrationProvider `json:"provider,omitempty"` // Set for synced calendars
+	ReadOnly         bool                         `json:"read_only"`
+	Visible          bool                         `json:"visible"`
+	IsDefault        bool                         `json:"is_default"`
+	CreatedAt        time.Time                    `json:"created_at"`
+	UpdatedAt        time.Time                    `json:"updated_at"`
+}
+
+// GetCalendars lists the current user's calendars, the default one first
+func (h *CalendarHTTPHandler) GetCalendars(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	result, err := h.calendarHandler.HandleGetCalendars(c.Request.Context(), queries.GetCalendarsQuery{UserID: userID})
+	if err != nil {
+		h.handleCalendarError(c, err, "calendars_retrieval_failed")
+		return
+	}
+
+	calendars := make([]CalendarResponse, len(result.Calendars))
+	for i, calendar := range result.Calendars {
+		calendars[i] = h.mapCalendarToResponse(calendar)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"calendars": calendars,
+	})
+}
+
+func (h *CalendarHTTPHandler) GetCalendar(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	query := queries.GetCalendarQuery{
+		CalendarID: entities.CalendarID(c.Param("id")),
+		UserID:     userID,
+	}
+
+	result, err := h.calendarHandler.HandleGetCalendar(c.Request.Context(), query)
+	if err != nil {
+		h.handleCalendarError(c, err, "calendar_retrieval_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapCalendarToResponse(result.Calendar))
+}
+
+// CreateCalendar creates a local calendar. Synced calendars are created
+// along with their sync.
+func (h *CalendarHTTPHandler) CreateCalendar(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	var req CreateCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	cmd := commands.CreateCalendarCommand{
+		UserID:           userID,
+		Name:             req.Name,
+		Color:            req.Color,
+		Timezone:         req.Timezone,
+		DefaultReminders: req.DefaultReminders,
+		Visible:          req.Visible,
+	}
+
+	result, err := h.calendarHandler.HandleCreateCalendar(c.Request.Context(), cmd)
+	if err != nil {
+		h.handleCalendarError(c, err, "calendar_creation_failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.mapCalendarToResponse(result.Calendar))
+}
+
+// UpdateCalendar changes the given settings of a calendar
+func (h *CalendarHTTPHandler) UpdateCalendar(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	var req UpdateCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	cmd := commands.UpdateCalendarCommand{
+		CalendarID:       entities.CalendarID(c.Param("id")),
+		UserID:           userID,
+		Name:             req.Name,
+		Color:            req.Color,
+		Timezone:         req.Timezone,
+		DefaultReminders: req.DefaultReminders,
+		Visible:          req.Visible,
+	}
+
+	result, err := h.calendarHandler.HandleUpdateCalendar(c.Request.Context(), cmd)
+	if err != nil {
+		h.handleCalendarError(c, err, "calendar_update_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapCalendarToResponse(result.Calendar))
+}
+
+// DeleteCalendar deletes a local calendar with all its events. Synced
+// calendars become local once DELETE /google/calendar-syncs/:id stops
+// their sync.
+func (h *CalendarHTTPHandler) DeleteCalendar(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	cmd := commands.DeleteCalendarCommand{
+		CalendarID: entities.CalendarID(c.Param("id")),
+		UserID:     userID,
+	}
+
+	if _, err := h.calendarHandler.HandleDeleteCalendar(c.Request.Context(), cmd); err != nil {
+		h.handleCalendarError(c, err, "calendar_deletion_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Calendar deleted successfully",
+	})
+}
+
+func (h *CalendarHTTPHandler) handleCalendarError(c *gin.Context, err error, code string) {
+	switch {
+	case errors.Is(err, appHandlers.ErrCalendarNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "calendar_not_found",
+			"message": "Calendar not found",
+		})
+	case errors.Is(err, appHandlers.ErrDefaultCalendar):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "default_calendar",
+			"message": err.Error(),
+		})
+	case errors.Is(err, appHandlers.ErrSyncedCalendar):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "synced_calendar",
+			"message": "Stop the sync of the calendar first with DELETE /api/v1/google/calendar-syncs/:id; it then stays as a local calendar",
+		})
+	case errors.Is(err, appHandlers.ErrInvalidCalendar):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_calendar",
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   code,
+			"message": err.Error(),
+		})
+	}
+}
+
+func (h *CalendarHTTPHandler) mapCalendarToResponse(calendar *entities.Calendar) CalendarResponse {
+	reminders := calendar.DefaultReminders
+	if reminders == nil {
+		reminders = []entities.Reminder{}
+	}
+
+	return CalendarResponse{
+		ID:               calendar.ID,
+		Name:             calendar.Name,
+		Color:            calendar.Color,
+		Timezone:         calendar.Timezone,
+		DefaultReminders: reminders,
+		Provider:         calendar.Provider,
+		ReadOnly:         calendar.ReadOnly,
+		Visible:          calendar.Visible,
+		IsDefault:        calendar.IsDefault,
+		CreatedAt:        calendar.CreatedAt,
+		UpdatedAt:        calendar.UpdatedAt,
+	}
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/ical"
 	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
 	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
 	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
@@ -26,33 +31,44 @@ func NewEventHTTPHandler(eventHandler *appHandlers.EventHandler) *EventHTTPHandl
 // Request/Response models
 
 type CreateEventRequest struct {
+	CalendarID     string                  `json:"calendar_id,omitempty"` // The default calendar when empty
 	GoalID         string                  `json:"goal_id,omitempty"`
 	Title          string                  `json:"title" binding:"required,min=2,max=255"`
 	Description    string                  `json:"description" binding:"max=1000"`
-	StartTime      time.Time               `json:"start_time" binding:"required"`
-	EndTime        time.Time               `json:"end_time" binding:"required"`
-	Timezone       string                  `json:"timezone"`
+	StartTime      time.Time               `json:"start_time"`
+	EndTime        time.Time               `json:"end_time"`
+	Timezone       string                  `json:"timezone"` // The calendar's timezone when empty
+	AllDay         bool                    `json:"all_day"`
+	StartDate      string                  `json:"start_date,omitempty"` // YYYY-MM-DD, for all-day events instead of the times
+	EndDate        string                  `json:"end_date,omitempty"`   // Exclusive; the day after start_date when empty
 	Recurrence     *entities.RecurrenceRule `json:"recurrence,omitempty"`
 	Location       string                  `json:"location" binding:"max=255"`
 	Attendees      []entities.Attendee     `json:"attendees"`
+	Reminders      []entities.Reminder     `json:"reminders,omitempty"` // The calendar's or user's defaults when omitted
 	Status         entities.EventStatus    `json:"status"`
 	ExternalID     string                  `json:"external_id" binding:"max=255"`
 	ExternalSource string                  `json:"external_source" binding:"max=50"`
 }
 
 type UpdateEventRequest struct {
-	GoalID         *string                  `json:"goal_id,omitempty"`
-	Title          *string                  `json:"title,omitempty" binding:"omitempty,min=2,max=255"`
-	Description    *string                  `json:"description,omitempty" binding:"omitempty,max=1000"`
-	StartTime      *time.Time               `json:"start_time,omitempty"`
-	EndTime        *time.Time               `json:"end_time,omitempty"`
-	Timezone       *string                  `json:"timezone,omitempty"`
-	Recurrence     *entities.RecurrenceRule `json:"recurrence,omitempty"`
-	Location       *string                  `json:"location,omitempty" binding:"omitempty,max=255"`
-	Attendees      *[]entities.Attendee     `json:"attendees,omitempty"`
-	Status         *entities.EventStatus    `json:"status,omitempty"`
-	ExternalID     *string                  `json:"external_id,omitempty" binding:"omitempty,max=255"`
-	ExternalSource *string                  `json:"external_source,omitempty" binding:"omitempty,max=50"`
+	CalendarID          *string                  `json:"calendar_id,omitempty"` // Moves the event to another calendar
+	GoalID              *string                  `json:"goal_id,omitempty"`
+	Title               *string                  `json:"title,omitempty" binding:"omitempty,min=2,max=255"`
+	Description         *string                  `json:"description,omitempty" binding:"omitempty,max=1000"`
+	StartTime           *time.Time               `json:"start_time,omitempty"`
+	EndTime             *time.Time               `json:"end_time,omitempty"`
+	Timezone            *string                  `json:"timezone,omitempty"`
+	AllDay              *bool                    `json:"all_day,omitempty"`
+	StartDate           *string                  `json:"start_date,omitempty"` // Makes the event all-day
+	EndDate             *string                  `json:"end_date,omitempty"`   // Exclusive
+	Recurrence          *entities.RecurrenceRule `json:"recurrence,omitempty"`
+	Location            *string                  `json:"location,omitempty" binding:"omitempty,max=255"`
+	Attendees           *[]entities.Attendee     `json:"attendees,omitempty"`
+	Reminders           *[]entities.Reminder     `json:"reminders,omitempty"`
+	UseDefaultReminders bool                     `json:"use_default_reminders,omitempty"` // Resets the reminders to the defaults
+	Status              *entities.EventStatus    `json:"status,omitempty"`
+	ExternalID          *string                  `json:"external_id,omitempty" binding:"omitempty,max=255"`
+	ExternalSource      *string                  `json:"external_source,omitempty" binding:"omitempty,max=50"`
 }
 
 type MoveEventRequest struct {
@@ -76,18 +92,29 @@ type LinkEventToGoalRequest struct {
 type EventResponse struct {
 	ID             entities.EventID         `json:"id"`
 	UserID         entities.UserID          `json:"user_id"`
+	CalendarID     entities.CalendarID      `json:"calendar_id"`
 	GoalID         *entities.GoalID         `json:"goal_id"`
 	Title          string                   `json:"title"`
 	Description    string                   `json:"description"`
 	StartTime      time.Time                `json:"start_time"`
 	EndTime        time.Time                `json:"end_time"`
 	Timezone       string                   `json:"timezone"`
+	AllDay         bool                     `json:"all_day"`
+	StartDate      string                   `json:"start_date,omitempty"` // First day of all-day events
+	EndDate        string                   `json:"end_date,omitempty"`   // Day after the last day of all-day events
 	Recurrence     *entities.RecurrenceRule `json:"recurrence"`
 	Location       string                   `json:"location"`
 	Attendees      []entities.Attendee      `json:"attendees"`
+	Reminders      []entities.Reminder      `json:"reminders"` // null when the defaults apply
 	Status         entities.EventStatus     `json:"status"`
+	Color          string                   `json:"color,omitempty"`
+	Transparent    bool                     `json:"transparent"`
 	ExternalID     string                   `json:"external_id"`
 	ExternalSource string                   `json:"external_source"`
+	RecurringEventID  *entities.EventID     `json:"recurring_event_id,omitempty"`
+	OriginalStartTime *time.Time            `json:"original_start_time,omitempty"`
+	PushStatus        *entities.EventPushStatus `json:"push_status,omitempty"`
+	PushError         *string               `json:"push_error,omitempty"`
 	CreatedAt      time.Time                `json:"created_at"`
 	UpdatedAt      time.Time                `json:"updated_at"`
 }
@@ -120,9 +147,33 @@ func (h *EventHTTPHandler) CreateEvent(c *gin.Context) {
 		goalID = &gID
 	}
 	
-	// Set default timezone if not provided
-	if req.Timezone == "" {
-		req.Timezone = "UTC"
+	var calendarID *entities.CalendarID
+	if req.CalendarID != "" {
+		cID := entities.CalendarID(req.CalendarID)
+		calendarID = &cID
+	}
+	
+	// All-day events are given by their dates or by midnight times
+	var startDate, endDate *time.Time
+	if req.StartDate != "" {
+		var err error
+		if startDate, err = parseEventDate(req.StartDate); err == nil && req.EndDate != "" {
+			endDate, err = parseEventDate(req.EndDate)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_date",
+				"message": err.Error(),
+			})
+			return
+		}
+		req.AllDay = true
+	} else if req.StartTime.IsZero() || req.EndTime.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "start_time and end_time, or start_date for all-day events, are required",
+		})
+		return
 	}
 	
 	// Set default status if not provided
@@ -133,15 +184,20 @@ func (h *EventHTTPHandler) CreateEvent(c *gin.Context) {
 	// Create command
 	cmd := commands.CreateEventCommand{
 		UserID:         userID,
+		CalendarID:     calendarID,
 		GoalID:         goalID,
 		Title:          req.Title,
 		Description:    req.Description,
 		StartTime:      req.StartTime,
 		EndTime:        req.EndTime,
 		Timezone:       req.Timezone,
+		AllDay:         req.AllDay,
+		StartDate:      startDate,
+		EndDate:        endDate,
 		Recurrence:     req.Recurrence,
 		Location:       req.Location,
 		Attendees:      req.Attendees,
+		Reminders:      req.Reminders,
 		Status:         req.Status,
 		ExternalID:     req.ExternalID,
 		ExternalSource: req.ExternalSource,
@@ -294,13 +350,26 @@ func (h *EventHTTPHandler) GetEventsByTimeRange(c *gin.Context) {
 		return
 	}
 	
+	// All-day events are matched by the days the range covers in the viewer's timezone
+	timezone := c.DefaultQuery("timezone", "UTC")
+	if _, err := time.LoadLocation(timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_timezone",
+			"message": "timezone must be an IANA time zone name",
+		})
+		return
+	}
+	
 	// Create query
 	query := queries.GetEventsByTimeRangeQuery{
 		UserID:    userID,
 		StartTime: startTime,
 		EndTime:   endTime,
+		Timezone:  timezone,
 	}
 	
+	query.CalendarIDs = calendarIDsParam(c)
+	
 	// Execute query
 	result, err := h.eventHandler.HandleGetEventsByTimeRange(c.Request.Context(), query)
 	if err != nil {
@@ -322,6 +391,204 @@ func (h *EventHTTPHandler) GetEventsByTimeRange(c *gin.Context) {
 	})
 }
 
+// ExportEvents returns the user's events as an iCalendar file. Optional
+// filters: start_time, end_time (RFC3339), goal_id and status.
+func (h *EventHTTPHandler) ExportEvents(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+	
+	query := queries.ExportEventsQuery{
+		UserID: userID,
+	}
+	
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		startTime, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_start_time",
+				"message": "start_time must be in RFC3339 format",
+			})
+			return
+		}
+		query.StartTime = &startTime
+	}
+	
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		endTime, err := time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_end_time",
+				"message": "end_time must be in RFC3339 format",
+			})
+			return
+		}
+		query.EndTime = &endTime
+	}
+	
+	if query.StartTime != nil && query.EndTime != nil && !query.StartTime.Before(*query.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_time_range",
+			"message": "start_time must be before end_time",
+		})
+		return
+	}
+	
+	if goalIDStr := c.Query("goal_id"); goalIDStr != "" {
+		goalID := entities.GoalID(goalIDStr)
+		query.GoalID = &goalID
+	}
+	
+	if statusStr := c.Query("status"); statusStr != "" {
+		status := entities.EventStatus(statusStr)
+		switch status {
+		case entities.EventStatusTentative, entities.EventStatusConfirmed, entities.EventStatusCancelled:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_status",
+				"message": "status must be one of tentative, confirmed, cancelled",
+			})
+			return
+		}
+		query.Status = &status
+	}
+	
+	result, err := h.eventHandler.HandleExportEvents(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "events_export_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	var body bytes.Buffer
+	err = ical.Encode(&body, &ical.Calendar{
+		Name:       "Smart Goal Calendar",
+		Events:     result.Events,
+		Exceptions: result.Exceptions,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "events_export_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	c.Header("Content-Disposition", `attachment; filename="calendar.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body.Bytes())
+}
+
+// maxImportSize bounds the size of an uploaded iCalendar file
+const maxImportSize = 10 << 20
+
+// ImportEvents imports an iCalendar file sent either as the "file" field of
+// a multipart form or as the raw request body. New events go to the
+// calendar given by ?calendar_id, the default calendar otherwise. With
+// ?dry_run=true it only reports what would be created, updated and skipped.
+func (h *EventHTTPHandler) ImportEvents(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+	
+	dryRun := false
+	if dryRunStr := c.Query("dry_run"); dryRunStr != "" {
+		parsed, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_dry_run",
+				"message": "dry_run must be a boolean",
+			})
+			return
+		}
+		dryRun = parsed
+	}
+	
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "file is required",
+			})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": err.Error(),
+			})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	
+	imported, err := ical.Decode(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_calendar",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	cmd := commands.ImportEventsCommand{
+		UserID:         userID,
+		ExternalSource: ical.ImportSource,
+		Events:         make([]*commands.ImportedEvent, len(imported)),
+		DryRun:         dryRun,
+	}
+	if calendarID := c.Query("calendar_id"); calendarID != "" {
+		cID := entities.CalendarID(calendarID)
+		cmd.CalendarID = &cID
+	}
+	for i, event := range imported {
+		cmd.Events[i] = &commands.ImportedEvent{
+			UID:        event.UID,
+			Event:      event.Event,
+			Exceptions: event.Exceptions,
+			Err:        event.Err,
+		}
+		if id, ok := ical.EventIDFromUID(event.UID); ok {
+			cmd.Events[i].EventID = id
+		}
+	}
+	
+	result, err := h.eventHandler.HandleImportEvents(c.Request.Context(), cmd)
+	if errors.Is(err, appHandlers.ErrCalendarNotFound) || errors.Is(err, appHandlers.ErrCalendarReadOnly) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_calendar",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "events_import_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *EventHTTPHandler) UpdateEvent(c *gin.Context) {
 	userID, exists := middleware.GetCurrentUserID(c)
 	if !exists {
@@ -357,22 +624,53 @@ func (h *EventHTTPHandler) UpdateEvent(c *gin.Context) {
 		goalID = &gID
 	}
 	
+	var calendarID *entities.CalendarID
+	if req.CalendarID != nil && *req.CalendarID != "" {
+		cID := entities.CalendarID(*req.CalendarID)
+		calendarID = &cID
+	}
+	
+	var startDate, endDate *time.Time
+	if req.StartDate != nil || req.EndDate != nil {
+		var err error
+		if req.StartDate != nil {
+			startDate, err = parseEventDate(*req.StartDate)
+		}
+		if err == nil && req.EndDate != nil {
+			endDate, err = parseEventDate(*req.EndDate)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_date",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+	
 	// Create command
 	cmd := commands.UpdateEventCommand{
-		EventID:        eventID,
-		UserID:         userID,
-		GoalID:         goalID,
-		Title:          req.Title,
-		Description:    req.Description,
-		StartTime:      req.StartTime,
-		EndTime:        req.EndTime,
-		Timezone:       req.Timezone,
-		Recurrence:     req.Recurrence,
-		Location:       req.Location,
-		Attendees:      req.Attendees,
-		Status:         req.Status,
-		ExternalID:     req.ExternalID,
-		ExternalSource: req.ExternalSource,
+		EventID:             eventID,
+		UserID:              userID,
+		CalendarID:          calendarID,
+		GoalID:              goalID,
+		Title:               req.Title,
+		Description:         req.Description,
+		StartTime:           req.StartTime,
+		EndTime:             req.EndTime,
+		Timezone:            req.Timezone,
+		AllDay:              req.AllDay,
+		StartDate:           startDate,
+		EndDate:             endDate,
+		Recurrence:          req.Recurrence,
+		Location:            req.Location,
+		Attendees:           req.Attendees,
+		Reminders:           req.Reminders,
+		UseDefaultReminders: req.UseDefaultReminders,
+		Status:              req.Status,
+		ExternalID:          req.ExternalID,
+		ExternalSource:      req.ExternalSource,
+		Scope:               entities.RecurrenceScope(c.Query("scope")),
 	}
 	
 	// Execute command
@@ -385,10 +683,17 @@ func (h *EventHTTPHandler) UpdateEvent(c *gin.Context) {
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message":    "Event updated successfully",
 		"updated_at": result.UpdatedAt,
-	})
+	}
+	
+	// Splitting a series creates a new event for the following occurrences
+	if result.EventID != "" {
+		response["event_id"] = result.EventID
+	}
+	
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *EventHTTPHandler) DeleteEvent(c *gin.Context) {
@@ -414,6 +719,7 @@ func (h *EventHTTPHandler) DeleteEvent(c *gin.Context) {
 	cmd := commands.DeleteEventCommand{
 		EventID: eventID,
 		UserID:  userID,
+		Scope:   entities.RecurrenceScope(c.Query("scope")),
 	}
 	
 	// Execute command
@@ -466,6 +772,7 @@ func (h *EventHTTPHandler) MoveEvent(c *gin.Context) {
 		UserID:    userID,
 		StartTime: req.StartTime,
 		EndTime:   req.EndTime,
+		Scope:     entities.RecurrenceScope(c.Query("scope")),
 	}
 	
 	// Execute command
@@ -698,10 +1005,22 @@ func (h *EventHTTPHandler) GetUpcomingEvents(c *gin.Context) {
 		limit = 50
 	}
 	
+	// All-day events stay upcoming until their last day is over in this timezone
+	timezone := c.DefaultQuery("timezone", "UTC")
+	if _, err := time.LoadLocation(timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_timezone",
+			"message": "timezone must be an IANA time zone name",
+		})
+		return
+	}
+	
 	// Create query
 	query := queries.GetUpcomingEventsQuery{
-		UserID: userID,
-		Limit:  limit,
+		UserID:      userID,
+		Limit:       limit,
+		Timezone:    timezone,
+		CalendarIDs: calendarIDsParam(c),
 	}
 	
 	// Execute query
@@ -740,8 +1059,9 @@ func (h *EventHTTPHandler) GetTodayEvents(c *gin.Context) {
 	
 	// Create query
 	query := queries.GetTodayEventsQuery{
-		UserID:   userID,
-		Timezone: timezone,
+		UserID:      userID,
+		Timezone:    timezone,
+		CalendarIDs: calendarIDsParam(c),
 	}
 	
 	// Execute query
@@ -834,6 +1154,7 @@ func (h *EventHTTPHandler) CheckEventConflict(c *gin.Context) {
 	startTimeStr := c.Query("start_time")
 	endTimeStr := c.Query("end_time")
 	excludeEventIDStr := c.Query("exclude_event_id")
+	includeAllDay := c.Query("include_all_day") == "true"
 	
 	if startTimeStr == "" || endTimeStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -873,6 +1194,7 @@ func (h *EventHTTPHandler) CheckEventConflict(c *gin.Context) {
 		StartTime:       startTime,
 		EndTime:         endTime,
 		ExcludeEventID:  excludeEventID,
+		IncludeAllDay:   includeAllDay,
 	}
 	
 	// Execute query
@@ -892,23 +1214,66 @@ func (h *EventHTTPHandler) CheckEventConflict(c *gin.Context) {
 
 // Helper methods
 
+// parseEventDate parses a date of an all-day event
+func parseEventDate(value string) (*time.Time, error) {
+	date, err := entities.ParseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// calendarIDsParam returns the comma-separated calendars to show, nil for
+// the visible ones when the calendar_ids parameter is not given
+func calendarIDsParam(c *gin.Context) []entities.CalendarID {
+	value, ok := c.GetQuery("calendar_ids")
+	if !ok {
+		return nil
+	}
+
+	calendarIDs := []entities.CalendarID{}
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			calendarIDs = append(calendarIDs, entities.CalendarID(id))
+		}
+	}
+	return calendarIDs
+}
+
 func (h *EventHTTPHandler) mapEventToResponse(event *entities.Event) EventResponse {
-	return EventResponse{
+	response := EventResponse{
 		ID:             event.ID,
 		UserID:         event.UserID,
+		CalendarID:     event.CalendarID,
 		GoalID:         event.GoalID,
 		Title:          event.Title,
 		Description:    event.Description,
 		StartTime:      event.StartTime,
 		EndTime:        event.EndTime,
 		Timezone:       event.Timezone,
+		AllDay:         event.AllDay,
 		Recurrence:     event.Recurrence,
 		Location:       event.Location,
 		Attendees:      event.Attendees,
+		Reminders:      event.Reminders,
 		Status:         event.Status,
+		Color:          event.Color,
+		Transparent:    event.Transparent,
 		ExternalID:     event.ExternalID,
 		ExternalSource: event.ExternalSource,
+		RecurringEventID:  event.RecurringEventID,
+		OriginalStartTime: event.OriginalStartTime,
+		PushStatus:        event.PushStatus,
+		PushError:         event.PushError,
 		CreatedAt:      event.CreatedAt,
 		UpdatedAt:      event.UpdatedAt,
 	}
+	
+	if event.AllDay {
+		startDate, endDate := event.Dates()
+		response.StartDate = startDate.Format(entities.DateFormat)
+		response.EndDate = endDate.Format(entities.DateFormat)
+	}
+	
+	return response
 }
\ No newline at end of file
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	zlog "github.com/rs/zerolog/log"
 
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/google"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
@@ -15,22 +18,31 @@ import (
 
 type GoogleAuthHandler struct {
 	oauth2Service               *google.OAuth2Service
+	authFlow                    *google.AuthFlow
 	calendarService             *google.CalendarService
+	syncer                      *calendarsync.Syncer
 	googleIntegrationRepo       repositories.GoogleIntegrationRepository
 	googleCalendarSyncRepo      repositories.GoogleCalendarSyncRepository
+	calendarRepo                repositories.CalendarRepository
 }
 
 func NewGoogleAuthHandler(
 	oauth2Service *google.OAuth2Service,
+	authFlow *google.AuthFlow,
 	calendarService *google.CalendarService,
+	syncer *calendarsync.Syncer,
 	googleIntegrationRepo repositories.GoogleIntegrationRepository,
 	googleCalendarSyncRepo repositories.GoogleCalendarSyncRepository,
+	calendarRepo repositories.CalendarRepository,
 ) *GoogleAuthHandler {
 	return &GoogleAuthHandler{
 		oauth2Service:               oauth2Service,
+		authFlow:                    authFlow,
 		calendarService:             calendarService,
+		syncer:                      syncer,
 		googleIntegrationRepo:       googleIntegrationRepo,
 		googleCalendarSyncRepo:      googleCalendarSyncRepo,
+		calendarRepo:                calendarRepo,
 	}
 }
 
@@ -46,10 +58,12 @@ func (h *GoogleAuthHandler) GetAuthURL(c *gin.Context) {
 		return
 	}
 
-	// Generate a unique state parameter for CSRF protection
-	state := uuid.New().String() + ":" + string(userID)
-	
-	authURL := h.oauth2Service.GetAuthURL(state)
+	// The state is a single-use nonce bound to the user, see google.AuthFlow
+	authURL, state, err := h.authFlow.Begin(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start Google authorization"})
+		return
+	}
 
 	c.JSON(http.StatusOK, AuthURLResponse{
 		AuthURL: authURL,
@@ -64,11 +78,17 @@ type OAuthCallbackRequest struct {
 
 type IntegrationResponse struct {
 	ID           string    `json:"id"`
+	Provider     string    `json:"provider"`
+	GoogleUserID string    `json:"google_user_id"`
 	Email        string    `json:"email"`
 	Name         string    `json:"name"`
 	Enabled      bool      `json:"enabled"`
 	CalendarID   string    `json:"calendar_id"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	// The provider rejected the stored refresh token; the account must be
+	// connected again before it syncs
+	NeedsReconsent bool `json:"needs_reconsent"`
 }
 
 func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
@@ -84,16 +104,41 @@ func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
 		return
 	}
 
-	// Validate state parameter (basic CSRF protection)
-	if req.State == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state parameter"})
-		return
-	}
-
-	// Exchange authorization code for tokens
-	tokens, err := h.oauth2Service.ExchangeCode(c.Request.Context(), req.Code)
+	// Consume the state and exchange the authorization code for tokens
+	tokens, err := h.authFlow.Complete(c.Request.Context(), userID, req.State, req.Code)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to exchange authorization code"})
+		switch {
+		case errors.Is(err, google.ErrAuthStateNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_state",
+				"message": "Invalid state parameter",
+			})
+		case errors.Is(err, google.ErrAuthStateExpired):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "state_expired",
+				"message": "Authorization took too long, please start again",
+			})
+		case errors.Is(err, google.ErrAuthStateUsed):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "state_reused",
+				"message": "State has already been used",
+			})
+		case errors.Is(err, google.ErrAuthStateForeign):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "state_user_mismatch",
+				"message": "State was issued to another user",
+			})
+		case errors.Is(err, google.ErrCodeExchange):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "code_exchange_failed",
+				"message": "Failed to exchange authorization code",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "oauth_state_failed",
+				"message": "Failed to verify the authorization state",
+			})
+		}
 		return
 	}
 
@@ -105,7 +150,7 @@ func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
 	}
 
 	// Get primary calendar
-	calendars, err := h.calendarService.GetCalendars(c.Request.Context(), tokens.AccessToken)
+	calendars, err := h.calendarService.ListCalendars(c.Request.Context(), tokens.AccessToken)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get calendars"})
 		return
@@ -120,7 +165,14 @@ func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
 	}
 
 	// Check if integration already exists
-	existingIntegration, err := h.googleIntegrationRepo.GetByGoogleUserID(c.Request.Context(), userInfo.UserID)
+	existingIntegration, err := h.googleIntegrationRepo.GetByGoogleUserID(c.Request.Context(), entities.IntegrationProviderGoogle, userInfo.UserID)
+	if err == nil && existingIntegration != nil && existingIntegration.UserID != userID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "google_account_linked",
+			"message": "Google account is connected to another user",
+		})
+		return
+	}
 	if err == nil && existingIntegration != nil {
 		// Update existing integration
 		existingIntegration.AccessToken = tokens.AccessToken
@@ -131,6 +183,7 @@ func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
 		existingIntegration.Name = userInfo.Name
 		existingIntegration.CalendarID = primaryCalendarID
 		existingIntegration.Enabled = true
+		existingIntegration.NeedsReconsent = false
 		existingIntegration.UpdatedAt = time.Now()
 
 		if err := h.googleIntegrationRepo.Update(c.Request.Context(), existingIntegration); err != nil {
@@ -148,6 +201,7 @@ func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
 	integration := &entities.GoogleIntegration{
 		ID:           entities.GoogleIntegrationID(uuid.New().String()),
 		UserID:       userID,
+		Provider:     entities.IntegrationProviderGoogle,
 		GoogleUserID: userInfo.UserID,
 		Email:        userInfo.Email,
 		Name:         userInfo.Name,
@@ -182,9 +236,14 @@ func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
 			UpdatedAt:           now,
 		}
 
-		if err := h.googleCalendarSyncRepo.Create(c.Request.Context(), sync); err != nil {
-			// Log error but don't fail the integration creation
-			// TODO: Add proper logging
+		// The integration stays connected without it; calendars can still be
+		// added to the sync later
+		calendar, err := createSyncCalendar(c.Request.Context(), h.calendarRepo, integration, sync)
+		if err != nil {
+			zlog.Error().Err(err).Str("integration_id", string(integration.ID)).Msg("Failed to create calendar for initial Google sync")
+		} else if err := h.googleCalendarSyncRepo.Create(c.Request.Context(), sync); err != nil {
+			zlog.Error().Err(err).Str("integration_id", string(integration.ID)).Msg("Failed to create initial Google calendar sync")
+			_ = h.calendarRepo.Delete(c.Request.Context(), calendar.ID)
 		}
 	}
 
@@ -192,43 +251,41 @@ func (h *GoogleAuthHandler) HandleCallback(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
-func (h *GoogleAuthHandler) GetIntegration(c *gin.Context) {
+// GetIntegrations returns the Google accounts the user has connected
+func (h *GoogleAuthHandler) GetIntegrations(c *gin.Context) {
 	userID, exists := middleware.GetCurrentUserID(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	integration, err := h.googleIntegrationRepo.GetByUserID(c.Request.Context(), userID)
+	integrations, err := h.googleIntegrationRepo.GetByUserID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integration"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integrations"})
 		return
 	}
 
-	if integration == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Google integration not found"})
-		return
+	response := make([]IntegrationResponse, 0, len(integrations))
+	for _, integration := range integrations {
+		response = append(response, h.toIntegrationResponse(integration))
 	}
 
-	response := h.toIntegrationResponse(integration)
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{"integrations": response})
 }
 
-func (h *GoogleAuthHandler) DisconnectIntegration(c *gin.Context) {
-	userID, exists := middleware.GetCurrentUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+func (h *GoogleAuthHandler) GetIntegration(c *gin.Context) {
+	integration, ok := h.ownedIntegration(c, c.Param("id"))
+	if !ok {
 		return
 	}
 
-	integration, err := h.googleIntegrationRepo.GetByUserID(c.Request.Context(), userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integration"})
-		return
-	}
+	response := h.toIntegrationResponse(integration)
+	c.JSON(http.StatusOK, response)
+}
 
-	if integration == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Google integration not found"})
+func (h *GoogleAuthHandler) DisconnectIntegration(c *gin.Context) {
+	integration, ok := h.ownedIntegration(c, c.Param("id"))
+	if !ok {
 		return
 	}
 
@@ -249,58 +306,92 @@ func (h *GoogleAuthHandler) DisconnectIntegration(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Google integration disconnected successfully"})
 }
 
+// GetCalendars lists the calendars of the account given by the
+// integration_id query parameter, which may be omitted when the user has
+// connected a single account
 func (h *GoogleAuthHandler) GetCalendars(c *gin.Context) {
-	userID, exists := middleware.GetCurrentUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	integration, ok := h.ownedIntegration(c, c.Query("integration_id"))
+	if !ok {
 		return
 	}
 
-	integration, err := h.googleIntegrationRepo.GetByUserID(c.Request.Context(), userID)
+	// The provider's token is refreshed when it is about to expire
+	calendars, err := h.syncer.ListCalendars(c.Request.Context(), integration.ID)
+	if errors.Is(err, calendarsync.ErrReconsentRequired) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Provider access was revoked. Please connect the account again"})
+		return
+	}
+	if errors.Is(err, calendarsync.ErrTokenRefreshFailed) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to refresh provider token"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integration"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get calendars from provider"})
 		return
 	}
 
-	if integration == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Google integration not found"})
-		return
+	c.JSON(http.StatusOK, gin.H{"integration_id": integration.ID, "calendars": calendars})
+}
+
+// ownedIntegration loads an integration of the current user and writes the
+// error response when it can't. Without an ID, the user's only integration is
+// used.
+func (h *GoogleAuthHandler) ownedIntegration(c *gin.Context, integrationID string) (*entities.GoogleIntegration, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	return userIntegration(c, h.googleIntegrationRepo, userID, integrationID)
+}
+
+func (h *GoogleAuthHandler) toIntegrationResponse(integration *entities.GoogleIntegration) IntegrationResponse {
+	return IntegrationResponse{
+		ID:           string(integration.ID),
+		Provider:     string(integration.Provider),
+		GoogleUserID: integration.GoogleUserID,
+		Email:        integration.Email,
+		Name:         integration.Name,
+		Enabled:      integration.Enabled,
+		CalendarID:   integration.CalendarID,
+		CreatedAt:    integration.CreatedAt,
+
+		NeedsReconsent: integration.NeedsReconsent,
 	}
+}
 
-	// Check if token needs refresh
-	if integration.IsTokenExpiringSoon() {
-		newTokens, err := h.oauth2Service.RefreshToken(c.Request.Context(), integration.RefreshToken)
+// userIntegration loads an integration of a user and writes the error
+// response when it can't. Without an ID, the user's only integration is
+// used; users with several must pick one.
+func userIntegration(c *gin.Context, repo repositories.GoogleIntegrationRepository, userID entities.UserID, integrationID string) (*entities.GoogleIntegration, bool) {
+	if integrationID == "" {
+		integrations, err := repo.GetByUserID(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to refresh Google token"})
-			return
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integration"})
+			return nil, false
 		}
-
-		// Update tokens in database
-		if err := h.googleIntegrationRepo.UpdateTokens(c.Request.Context(), integration.ID, 
-			newTokens.AccessToken, newTokens.RefreshToken, newTokens.Expiry); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tokens"})
-			return
+		switch len(integrations) {
+		case 0:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Google integration not found. Please connect Google account first"})
+			return nil, false
+		case 1:
+			return integrations[0], true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Several Google accounts are connected, integration ID is required"})
+			return nil, false
 		}
-
-		integration.AccessToken = newTokens.AccessToken
 	}
 
-	calendars, err := h.calendarService.GetCalendars(c.Request.Context(), integration.AccessToken)
+	integration, err := repo.GetByID(c.Request.Context(), entities.GoogleIntegrationID(integrationID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get calendars from Google"})
-		return
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integration"})
+		return nil, false
+	}
+	if integration == nil || integration.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Google integration not found"})
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, gin.H{"calendars": calendars})
+	return integration, true
 }
-
-func (h *GoogleAuthHandler) toIntegrationResponse(integration *entities.GoogleIntegration) IntegrationResponse {
-	return IntegrationResponse{
-		ID:         string(integration.ID),
-		Email:      integration.Email,
-		Name:       integration.Name,
-		Enabled:    integration.Enabled,
-		CalendarID: integration.CalendarID,
-		CreatedAt:  integration.CreatedAt,
-	}
-}
\ No newline at end of file
@@ -2,61 +2,102 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
-	"github.com/andranikuz/smart-goal-calendar/internal/adapters/google"
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
 	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
 )
 
+// syncNowLease bounds how long a manual sync holds its claim on a calendar
+const syncNowLease = 5 * time.Minute
+
 type GoogleCalendarSyncHandler struct {
-	oauth2Service          *google.OAuth2Service
-	calendarService        *google.CalendarService
+	syncer                 *calendarsync.Syncer
 	googleIntegrationRepo  repositories.GoogleIntegrationRepository
 	googleCalendarSyncRepo repositories.GoogleCalendarSyncRepository
-	eventRepo              repositories.EventRepository
+	googleSyncConflictRepo repositories.GoogleSyncConflictRepository
+	syncRunRepo            repositories.SyncRunRepository
+	calendarRepo           repositories.CalendarRepository
 }
 
 func NewGoogleCalendarSyncHandler(
-	oauth2Service *google.OAuth2Service,
-	calendarService *google.CalendarService,
+	syncer *calendarsync.Syncer,
 	googleIntegrationRepo repositories.GoogleIntegrationRepository,
 	googleCalendarSyncRepo repositories.GoogleCalendarSyncRepository,
-	eventRepo repositories.EventRepository,
+	googleSyncConflictRepo repositories.GoogleSyncConflictRepository,
+	syncRunRepo repositories.SyncRunRepository,
+	calendarRepo repositories.CalendarRepository,
 ) *GoogleCalendarSyncHandler {
 	return &GoogleCalendarSyncHandler{
-		oauth2Service:          oauth2Service,
-		calendarService:        calendarService,
+		syncer:                 syncer,
 		googleIntegrationRepo:  googleIntegrationRepo,
 		googleCalendarSyncRepo: googleCalendarSyncRepo,
-		eventRepo:              eventRepo,
+		googleSyncConflictRepo: googleSyncConflictRepo,
+		syncRunRepo:            syncRunRepo,
+		calendarRepo:           calendarRepo,
 	}
 }
 
+// CalendarSyncConfigRequest configures a calendar sync. GoogleIntegrationID
+// picks the Google account of the calendar on creation; it may be omitted when
+// the user has connected a single account and can't be changed later.
 type CalendarSyncConfigRequest struct {
-	CalendarID    string                         `json:"calendar_id" binding:"required"`
-	CalendarName  string                         `json:"calendar_name" binding:"required"`
-	SyncDirection entities.CalendarSyncDirection `json:"sync_direction" binding:"required"`
-	SyncStatus    entities.CalendarSyncStatus    `json:"sync_status"`
-	Settings      entities.CalendarSyncSettings  `json:"settings"`
+	GoogleIntegrationID string                         `json:"google_integration_id"`
+	CalendarID          string                         `json:"calendar_id" binding:"required"`
+	CalendarName        string                         `json:"calendar_name" binding:"required"`
+	SyncDirection       entities.CalendarSyncDirection `json:"sync_direction" binding:"required"`
+	SyncStatus          entities.CalendarSyncStatus    `json:"sync_status"`
+	Settings            entities.CalendarSyncSettings  `json:"settings"`
+}
+
+// ResolveConflictRequest picks the version of a conflicting event to keep.
+// Merged is the version to keep when Resolution is "merged".
+type ResolveConflictRequest struct {
+	Resolution entities.ConflictChoice `json:"resolution" binding:"required"`
+	Merged     *MergedEventVersion     `json:"merged"`
+}
+
+type MergedEventVersion struct {
+	Title       string                   `json:"title" binding:"required"`
+	Description string                   `json:"description"`
+	Location    string                   `json:"location"`
+	StartTime   time.Time                `json:"start_time" binding:"required"`
+	EndTime     time.Time                `json:"end_time" binding:"required"`
+	Timezone    string                   `json:"timezone"`
+	Recurrence  *entities.RecurrenceRule `json:"recurrence,omitempty"`
+}
+
+// SyncRunResponse is a sync run along with its duration
+type SyncRunResponse struct {
+	*entities.SyncRun
+	DurationMs int64 `json:"duration_ms"`
+}
+
+func newSyncRunResponse(run *entities.SyncRun) SyncRunResponse {
+	return SyncRunResponse{SyncRun: run, DurationMs: run.Duration().Milliseconds()}
 }
 
 type CalendarSyncConfigResponse struct {
-	ID            string                         `json:"id"`
-	CalendarID    string                         `json:"calendar_id"`
-	CalendarName  string                         `json:"calendar_name"`
-	SyncDirection entities.CalendarSyncDirection `json:"sync_direction"`
-	SyncStatus    entities.CalendarSyncStatus    `json:"sync_status"`
-	LastSyncAt    *time.Time                     `json:"last_sync_at"`
-	LastSyncError string                         `json:"last_sync_error"`
-	Settings      entities.CalendarSyncSettings  `json:"settings"`
-	CreatedAt     time.Time                      `json:"created_at"`
-	UpdatedAt     time.Time                      `json:"updated_at"`
+	ID                  string                         `json:"id"`
+	GoogleIntegrationID entities.GoogleIntegrationID   `json:"google_integration_id"`
+	CalendarID          string                         `json:"calendar_id"`
+	CalendarName        string                         `json:"calendar_name"`
+	LocalCalendarID     entities.CalendarID            `json:"local_calendar_id"`
+	SyncDirection       entities.CalendarSyncDirection `json:"sync_direction"`
+	SyncStatus          entities.CalendarSyncStatus    `json:"sync_status"`
+	LastSyncAt          *time.Time                     `json:"last_sync_at"`
+	LastSyncError       string                         `json:"last_sync_error"`
+	Settings            entities.CalendarSyncSettings  `json:"settings"`
+	CreatedAt           time.Time                      `json:"created_at"`
+	UpdatedAt           time.Time                      `json:"updated_at"`
 }
 
 // CreateCalendarSync creates a new calendar sync configuration
@@ -67,25 +108,20 @@ func (h *GoogleCalendarSyncHandler) CreateCalendarSync(c *gin.Context) {
 		return
 	}
 
-	// Check if user has Google integration
-	integration, err := h.googleIntegrationRepo.GetByUserID(c.Request.Context(), userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integration"})
-		return
-	}
-	if integration == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Google integration not found. Please connect Google account first"})
-		return
-	}
-
 	var req CalendarSyncConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// The Google account the calendar belongs to
+	integration, ok := userIntegration(c, h.googleIntegrationRepo, userID, req.GoogleIntegrationID)
+	if !ok {
+		return
+	}
+
 	// Check if sync already exists for this calendar
-	existingSync, err := h.googleCalendarSyncRepo.GetByCalendarID(c.Request.Context(), userID, req.CalendarID)
+	existingSync, err := h.googleCalendarSyncRepo.GetByCalendarID(c.Request.Context(), integration.ID, req.CalendarID)
 	if err == nil && existingSync != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Sync configuration already exists for this calendar"})
 		return
@@ -95,6 +131,9 @@ func (h *GoogleCalendarSyncHandler) CreateCalendarSync(c *gin.Context) {
 	if req.Settings.SyncInterval == 0 {
 		req.Settings = entities.DefaultCalendarSyncSettings()
 	}
+	if !validConflictResolution(c, &req.Settings) {
+		return
+	}
 
 	now := time.Now()
 	sync := &entities.GoogleCalendarSync{
@@ -110,7 +149,15 @@ func (h *GoogleCalendarSyncHandler) CreateCalendarSync(c *gin.Context) {
 		UpdatedAt:           now,
 	}
 
+	// Synced events live in a calendar of their own
+	calendar, err := createSyncCalendar(c.Request.Context(), h.calendarRepo, integration, sync)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar sync configuration"})
+		return
+	}
+
 	if err := h.googleCalendarSyncRepo.Create(c.Request.Context(), sync); err != nil {
+		_ = h.calendarRepo.Delete(c.Request.Context(), calendar.ID)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar sync configuration"})
 		return
 	}
@@ -173,6 +220,9 @@ func (h *GoogleCalendarSyncHandler) UpdateCalendarSync(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !validConflictResolution(c, &req.Settings) {
+		return
+	}
 
 	// Update fields
 	sync.CalendarName = req.CalendarName
@@ -180,6 +230,12 @@ func (h *GoogleCalendarSyncHandler) UpdateCalendarSync(c *gin.Context) {
 	if req.SyncStatus != "" {
 		sync.SyncStatus = req.SyncStatus
 	}
+
+	// The sync token keeps the window of the listing it came from, so a new
+	// window needs a full listing
+	if req.Settings.SyncPastEvents != sync.Settings.SyncPastEvents {
+		sync.SyncToken = ""
+	}
 	sync.Settings = req.Settings
 	sync.UpdatedAt = time.Now()
 
@@ -188,6 +244,12 @@ func (h *GoogleCalendarSyncHandler) UpdateCalendarSync(c *gin.Context) {
 		return
 	}
 
+	// Only calendars synced both ways, or to Google, accept local changes
+	if err := h.updateSyncCalendar(c.Request.Context(), sync); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calendar sync configuration"})
+		return
+	}
+
 	response := h.toSyncConfigResponse(sync)
 	c.JSON(http.StatusOK, response)
 }
@@ -224,6 +286,10 @@ func (h *GoogleCalendarSyncHandler) DeleteCalendarSync(c *gin.Context) {
 		return
 	}
 
+	// Best effort: an orphaned channel only lives until it expires, and its
+	// notifications are rejected meanwhile
+	_ = h.syncer.StopChannel(c.Request.Context(), sync)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Calendar sync configuration deleted successfully"})
 }
 
@@ -254,195 +320,258 @@ func (h *GoogleCalendarSyncHandler) SyncNow(c *gin.Context) {
 		return
 	}
 
-	// Get Google integration
-	integration, err := h.googleIntegrationRepo.GetByID(c.Request.Context(), sync.GoogleIntegrationID)
-	if err != nil || integration == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Google integration"})
+	// Claim the calendar so that the background scheduler, or another
+	// manual sync, doesn't sync it at the same time
+	workerID := "api-" + uuid.New().String()
+	claimed, err := h.googleCalendarSyncRepo.Claim(c.Request.Context(), syncID, workerID, syncNowLease)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sync"})
 		return
 	}
-
-	// Check if token needs refresh
-	if integration.IsTokenExpiringSoon() {
-		newTokens, err := h.oauth2Service.RefreshToken(c.Request.Context(), integration.RefreshToken)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to refresh Google token"})
-			return
-		}
-
-		// Update tokens in database
-		if err := h.googleIntegrationRepo.UpdateTokens(c.Request.Context(), integration.ID,
-			newTokens.AccessToken, newTokens.RefreshToken, newTokens.Expiry); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tokens"})
-			return
-		}
-
-		integration.AccessToken = newTokens.AccessToken
-	}
-
-	// Perform sync based on sync direction
-	var syncError error
-	syncedCount := 0
-
-	switch sync.SyncDirection {
-	case entities.SyncDirectionFromGoogle:
-		syncedCount, syncError = h.syncFromGoogle(c.Request.Context(), sync, integration)
-	case entities.SyncDirectionToGoogle:
-		syncedCount, syncError = h.syncToGoogle(c.Request.Context(), sync, integration)
-	case entities.SyncDirectionBidirectional:
-		// First sync from Google, then to Google
-		fromCount, fromErr := h.syncFromGoogle(c.Request.Context(), sync, integration)
-		if fromErr == nil {
-			toCount, toErr := h.syncToGoogle(c.Request.Context(), sync, integration)
-			syncedCount = fromCount + toCount
-			syncError = toErr
-		} else {
-			syncError = fromErr
-			syncedCount = fromCount
-		}
-	}
-
-	// Update sync status
-	now := time.Now()
-	var status entities.CalendarSyncStatus
-	var errorMsg string
-
-	if syncError != nil {
-		status = entities.SyncStatusError
-		errorMsg = syncError.Error()
-	} else {
-		status = entities.SyncStatusActive
-		errorMsg = ""
+	if !claimed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Sync already in progress"})
+		return
 	}
 
-	if err := h.googleCalendarSyncRepo.UpdateSyncStatus(c.Request.Context(), syncID, status, &now, errorMsg); err != nil {
+	result, err := h.syncer.Run(c.Request.Context(), sync, workerID, entities.SyncTriggerManual)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sync status"})
 		return
 	}
 
-	if syncError != nil {
+	if errors.Is(result.Err, calendarsync.ErrReconsentRequired) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Provider access was revoked. Please connect the account again",
+			"run":   newSyncRunResponse(result.Run),
+		})
+		return
+	}
+	if result.Err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":        "Sync completed with errors",
-			"error_detail": syncError.Error(),
-			"synced_count": syncedCount,
+			"error_detail": result.Err.Error(),
+			"synced_count": result.SyncedCount,
+			"run":          newSyncRunResponse(result.Run),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Sync completed successfully",
-		"synced_count": syncedCount,
-		"synced_at":    now,
+		"synced_count": result.SyncedCount,
+		"synced_at":    result.SyncedAt,
+		"run":          newSyncRunResponse(result.Run),
 	})
 }
 
-// syncFromGoogle syncs events from Google Calendar to local database
-func (h *GoogleCalendarSyncHandler) syncFromGoogle(ctx context.Context, sync *entities.GoogleCalendarSync, integration *entities.GoogleIntegration) (int, error) {
-	// Get events from Google Calendar
-	now := time.Now()
-	timeMin := now.AddDate(0, -1, 0) // 1 month ago
-	timeMax := now.AddDate(0, 3, 0)  // 3 months ahead
+// GetSyncRuns pages through the runs of a calendar sync, newest first
+func (h *GoogleCalendarSyncHandler) GetSyncRuns(c *gin.Context) {
+	sync, ok := h.ownedSync(c)
+	if !ok {
+		return
+	}
 
-	if !sync.Settings.SyncPastEvents {
-		timeMin = now
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
 	}
 
-	googleEvents, err := h.calendarService.GetEvents(ctx, integration.AccessToken, sync.CalendarID, timeMin, timeMax)
+	runs, totalCount, err := h.syncRunRepo.GetBySyncID(c.Request.Context(), sync.ID, offset, limit)
 	if err != nil {
-		return 0, err
-	}
-
-	syncedCount := 0
-	for _, googleEvent := range googleEvents {
-		// Check if event already exists
-		existingEvent, err := h.eventRepo.GetByExternalID(ctx, sync.UserID, googleEvent.ID)
-		if err == nil && existingEvent != nil {
-			// Update existing event
-			existingEvent.Title = googleEvent.Summary
-			existingEvent.Description = googleEvent.Description
-			existingEvent.Location = googleEvent.Location
-			existingEvent.StartTime = googleEvent.StartTime
-			existingEvent.EndTime = googleEvent.EndTime
-			existingEvent.UpdatedAt = time.Now()
-
-			if err := h.eventRepo.Update(ctx, existingEvent); err == nil {
-				syncedCount++
-			}
-		} else {
-			// Create new event
-			event := &entities.Event{
-				ID:             entities.EventID(uuid.New().String()),
-				UserID:         sync.UserID,
-				Title:          googleEvent.Summary,
-				Description:    googleEvent.Description,
-				Location:       googleEvent.Location,
-				StartTime:      googleEvent.StartTime,
-				EndTime:        googleEvent.EndTime,
-				Timezone:       "UTC", // TODO: Get from Google event
-				Status:         entities.EventStatusConfirmed,
-				ExternalID:     googleEvent.ID,
-				ExternalSource: "google",
-				CreatedAt:      time.Now(),
-				UpdatedAt:      time.Now(),
-			}
-
-			if err := h.eventRepo.Create(ctx, event); err == nil {
-				syncedCount++
-			}
-		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sync runs"})
+		return
 	}
 
-	return syncedCount, nil
-}
+	response := make([]SyncRunResponse, 0, len(runs))
+	for _, run := range runs {
+		response = append(response, newSyncRunResponse(run))
+	}
 
-// syncToGoogle syncs events from local database to Google Calendar
-func (h *GoogleCalendarSyncHandler) syncToGoogle(ctx context.Context, sync *entities.GoogleCalendarSync, integration *entities.GoogleIntegration) (int, error) {
-	// Get local events that need to be synced
-	now := time.Now()
-	timeMin := now.AddDate(0, -1, 0) // 1 month ago
-	timeMax := now.AddDate(0, 3, 0)  // 3 months ahead
+	c.JSON(http.StatusOK, gin.H{
+		"runs":        response,
+		"total_count": totalCount,
+		"offset":      offset,
+		"limit":       limit,
+	})
+}
 
-	if !sync.Settings.SyncPastEvents {
-		timeMin = now
+// GetConflicts returns the open conflicts of a calendar sync
+func (h *GoogleCalendarSyncHandler) GetConflicts(c *gin.Context) {
+	sync, ok := h.ownedSync(c)
+	if !ok {
+		return
 	}
 
-	localEvents, err := h.eventRepo.GetByTimeRange(ctx, sync.UserID, timeMin, timeMax)
+	conflicts, err := h.googleSyncConflictRepo.GetOpenBySyncID(c.Request.Context(), sync.ID)
 	if err != nil {
-		return 0, err
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sync conflicts"})
+		return
+	}
+	if conflicts == nil {
+		conflicts = []*entities.GoogleSyncConflict{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+}
+
+// ResolveConflict keeps the local, remote or a merged version of a conflicting event
+func (h *GoogleCalendarSyncHandler) ResolveConflict(c *gin.Context) {
+	sync, ok := h.ownedSync(c)
+	if !ok {
+		return
+	}
+
+	conflict, err := h.googleSyncConflictRepo.GetByID(c.Request.Context(), c.Param("conflict_id"))
+	if err != nil || conflict == nil || conflict.CalendarSyncID != sync.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sync conflict not found"})
+		return
+	}
+
+	var req ResolveConflictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Resolution.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Resolution must be one of local, remote or merged"})
+		return
 	}
 
-	syncedCount := 0
-	for _, event := range localEvents {
-		// Skip events already synced to Google
-		if event.ExternalID != "" && event.ExternalSource == "google" {
-			continue
+	var merged *entities.EventVersion
+	if req.Resolution == entities.ConflictChoiceMerged {
+		if req.Merged == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Merged version is required"})
+			return
+		}
+		if !req.Merged.StartTime.Before(req.Merged.EndTime) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "End time must be after start time"})
+			return
+		}
+		timezone := req.Merged.Timezone
+		if timezone == "" {
+			timezone = conflict.LocalVersion.Timezone
 		}
+		merged = &entities.EventVersion{
+			Title:       req.Merged.Title,
+			Description: req.Merged.Description,
+			Location:    req.Merged.Location,
+			StartTime:   req.Merged.StartTime,
+			EndTime:     req.Merged.EndTime,
+			Timezone:    timezone,
+			Recurrence:  req.Merged.Recurrence,
+		}
+	}
 
-		// Create event in Google Calendar
-		googleEvent, err := h.calendarService.CreateEvent(ctx, integration.AccessToken, sync.CalendarID, event)
-		if err == nil {
-			// Update local event with Google ID
-			event.ExternalID = googleEvent.ID
-			event.ExternalSource = "google"
-			if err := h.eventRepo.Update(ctx, event); err == nil {
-				syncedCount++
-			}
+	if err := h.syncer.ResolveConflict(c.Request.Context(), sync, conflict, req.Resolution, merged); err != nil {
+		if errors.Is(err, calendarsync.ErrConflictResolved) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Sync conflict already resolved"})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve sync conflict", "error_detail": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sync conflict resolved successfully"})
+}
+
+// ownedSync loads the calendar sync of the :id parameter and checks that
+// it belongs to the current user
+func (h *GoogleCalendarSyncHandler) ownedSync(c *gin.Context) (*entities.GoogleCalendarSync, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
 	}
 
-	return syncedCount, nil
+	sync, err := h.googleCalendarSyncRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil || sync == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar sync configuration not found"})
+		return nil, false
+	}
+	if sync.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this sync configuration"})
+		return nil, false
+	}
+
+	return sync, true
+}
+
+// validConflictResolution defaults an empty conflict resolution and
+// rejects unknown ones
+// updateSyncCalendar keeps the local calendar of a sync read-only while the
+// sync only pulls from Google
+func (h *GoogleCalendarSyncHandler) updateSyncCalendar(ctx context.Context, sync *entities.GoogleCalendarSync) error {
+	calendar, err := h.calendarRepo.GetByID(ctx, sync.LocalCalendarID)
+	if err != nil || calendar == nil || calendar.ReadOnly == sync.IsReadOnly() {
+		return err
+	}
+	calendar.ReadOnly = sync.IsReadOnly()
+	return h.calendarRepo.Update(ctx, calendar)
+}
+
+// createSyncCalendar creates the local calendar mirroring a synced calendar
+// and points the sync at it. The calendar starts out in the zone of the
+// user's default calendar.
+func createSyncCalendar(ctx context.Context, calendarRepo repositories.CalendarRepository, integration *entities.GoogleIntegration, sync *entities.GoogleCalendarSync) (*entities.Calendar, error) {
+	timezone := "UTC"
+	defaultCalendar, err := calendarRepo.GetOrCreateDefault(ctx, sync.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if defaultCalendar != nil {
+		timezone = defaultCalendar.Timezone
+	}
+
+	calendar := &entities.Calendar{
+		ID:        entities.CalendarID(uuid.New().String()),
+		UserID:    sync.UserID,
+		Name:      sync.CalendarName,
+		Timezone:  timezone,
+		Provider:  integration.Provider,
+		ReadOnly:  sync.IsReadOnly(),
+		Visible:   true,
+		CreatedAt: sync.CreatedAt,
+		UpdatedAt: sync.UpdatedAt,
+	}
+	if err := calendarRepo.Create(ctx, calendar); err != nil {
+		return nil, err
+	}
+
+	sync.LocalCalendarID = calendar.ID
+	return calendar, nil
+}
+
+func validConflictResolution(c *gin.Context, settings *entities.CalendarSyncSettings) bool {
+	if settings.ConflictResolution == "" {
+		settings.ConflictResolution = entities.ConflictResolutionGoogleWins
+	}
+	if !settings.ConflictResolution.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Conflict resolution must be one of google_wins, local_wins or manual"})
+		return false
+	}
+	return true
 }
 
 func (h *GoogleCalendarSyncHandler) toSyncConfigResponse(sync *entities.GoogleCalendarSync) CalendarSyncConfigResponse {
 	return CalendarSyncConfigResponse{
-		ID:            sync.ID,
-		CalendarID:    sync.CalendarID,
-		CalendarName:  sync.CalendarName,
-		SyncDirection: sync.SyncDirection,
-		SyncStatus:    sync.SyncStatus,
-		LastSyncAt:    sync.LastSyncAt,
-		LastSyncError: sync.LastSyncError,
-		Settings:      sync.Settings,
-		CreatedAt:     sync.CreatedAt,
-		UpdatedAt:     sync.UpdatedAt,
+		ID:                  sync.ID,
+		GoogleIntegrationID: sync.GoogleIntegrationID,
+		CalendarID:          sync.CalendarID,
+		CalendarName:        sync.CalendarName,
+		LocalCalendarID:     sync.LocalCalendarID,
+		SyncDirection:       sync.SyncDirection,
+		SyncStatus:          sync.SyncStatus,
+		LastSyncAt:          sync.LastSyncAt,
+		LastSyncError:       sync.LastSyncError,
+		Settings:            sync.Settings,
+		CreatedAt:           sync.CreatedAt,
+		UpdatedAt:           sync.UpdatedAt,
 	}
 }
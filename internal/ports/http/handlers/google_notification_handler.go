@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+// Headers of Google Calendar push notifications
+const (
+	googleChannelIDHeader     = "X-Goog-Channel-ID"
+	googleChannelTokenHeader  = "X-Goog-Channel-Token"
+	googleResourceIDHeader    = "X-Goog-Resource-ID"
+	googleResourceStateHeader = "X-Goog-Resource-State"
+)
+
+// GoogleNotificationHandler receives the push notifications of Google watch
+// channels. The endpoint is public; notifications are authenticated by the
+// channel token.
+type GoogleNotificationHandler struct {
+	googleCalendarSyncRepo repositories.GoogleCalendarSyncRepository
+}
+
+func NewGoogleNotificationHandler(googleCalendarSyncRepo repositories.GoogleCalendarSyncRepository) *GoogleNotificationHandler {
+	return &GoogleNotificationHandler{
+		googleCalendarSyncRepo: googleCalendarSyncRepo,
+	}
+}
+
+// HandleNotification schedules an incremental sync of the calendar that
+// changed. Google retries notifications answered with a server error.
+func (h *GoogleNotificationHandler) HandleNotification(c *gin.Context) {
+	channelID := c.GetHeader(googleChannelIDHeader)
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID is required"})
+		return
+	}
+
+	// Google confirms every new channel with a "sync" message, which may
+	// arrive before the channel is stored
+	if c.GetHeader(googleResourceStateHeader) == "sync" {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	sync, err := h.googleCalendarSyncRepo.GetByChannelID(c.Request.Context(), channelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get calendar sync"})
+		return
+	}
+	if sync == nil || sync.Channel == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown channel"})
+		return
+	}
+
+	if !calendarsync.VerifyChannelToken(sync.Channel, c.GetHeader(googleChannelTokenHeader)) ||
+		c.GetHeader(googleResourceIDHeader) != sync.Channel.ResourceID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid channel token"})
+		return
+	}
+
+	if err := h.googleCalendarSyncRepo.ScheduleNow(c.Request.Context(), sync.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule calendar sync"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+const (
+	testChannelID  = "01234567-89ab-cdef-0123-456789abcdef"
+	testResourceID = "ret08u3rv24htgh289g"
+	testToken      = "c2VjcmV0LWNoYW5uZWwtdG9rZW4"
+)
+
+// fakeNotifiedSyncs stands in for the sync repository, which only has to
+// look up channels and schedule syncs here
+type fakeNotifiedSyncs struct {
+	repositories.GoogleCalendarSyncRepository
+
+	syncs     map[string]*entities.GoogleCalendarSync // By channel ID
+	scheduled []string
+}
+
+func (f *fakeNotifiedSyncs) GetByChannelID(_ context.Context, channelID string) (*entities.GoogleCalendarSync, error) {
+	return f.syncs[channelID], nil
+}
+
+func (f *fakeNotifiedSyncs) ScheduleNow(_ context.Context, id string) error {
+	f.scheduled = append(f.scheduled, id)
+	return nil
+}
+
+func TestHandleNotification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sum := sha256.Sum256([]byte(testToken))
+	watched := &entities.GoogleCalendarSync{
+		ID: "sync-1",
+		Channel: &entities.WatchChannel{
+			ID:         testChannelID,
+			ResourceID: testResourceID,
+			TokenHash:  hex.EncodeToString(sum[:]),
+			ExpiresAt:  time.Now().Add(24 * time.Hour),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		status    int
+		scheduled bool
+	}{
+		{
+			name: "change",
+			headers: map[string]string{
+				googleChannelIDHeader:     testChannelID,
+				googleChannelTokenHeader:  testToken,
+				googleResourceIDHeader:    testResourceID,
+				googleResourceStateHeader: "exists",
+			},
+			status:    http.StatusOK,
+			scheduled: true,
+		},
+		{
+			// Confirms a channel that may not be stored yet
+			name: "sync message",
+			headers: map[string]string{
+				googleChannelIDHeader:     "not-stored-yet",
+				googleResourceIDHeader:    testResourceID,
+				googleResourceStateHeader: "sync",
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "unknown channel",
+			headers: map[string]string{
+				googleChannelIDHeader:     "unknown",
+				googleChannelTokenHeader:  testToken,
+				googleResourceIDHeader:    testResourceID,
+				googleResourceStateHeader: "exists",
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "wrong token",
+			headers: map[string]string{
+				googleChannelIDHeader:     testChannelID,
+				googleChannelTokenHeader:  "guessed",
+				googleResourceIDHeader:    testResourceID,
+				googleResourceStateHeader: "exists",
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "missing token",
+			headers: map[string]string{
+				googleChannelIDHeader:     testChannelID,
+				googleResourceIDHeader:    testResourceID,
+				googleResourceStateHeader: "exists",
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "wrong resource",
+			headers: map[string]string{
+				googleChannelIDHeader:     testChannelID,
+				googleChannelTokenHeader:  testToken,
+				googleResourceIDHeader:    "another-resource",
+				googleResourceStateHeader: "exists",
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "missing channel",
+			headers: map[string]string{
+				googleChannelTokenHeader:  testToken,
+				googleResourceIDHeader:    testResourceID,
+				googleResourceStateHeader: "exists",
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeNotifiedSyncs{
+				syncs: map[string]*entities.GoogleCalendarSync{testChannelID: watched},
+			}
+			router := gin.New()
+			router.POST("/google/notifications", NewGoogleNotificationHandler(repo).HandleNotification)
+
+			req := httptest.NewRequest(http.MethodPost, "/google/notifications", nil)
+			req.Header.Set("X-Goog-Message-Number", "1")
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if scheduled := len(repo.scheduled) > 0; scheduled != tt.scheduled {
+				t.Errorf("scheduled = %v, want %v", repo.scheduled, tt.scheduled)
+			}
+			if tt.scheduled && repo.scheduled[0] != watched.ID {
+				t.Errorf("scheduled %q, want %q", repo.scheduled[0], watched.ID)
+			}
+		})
+	}
+}
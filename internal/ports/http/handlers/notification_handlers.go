@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/application/commands"
+	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/application/queries"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHTTPHandler struct {
+	notificationHandler *appHandlers.NotificationHandler
+}
+
+func NewNotificationHTTPHandler(notificationHandler *appHandlers.NotificationHandler) *NotificationHTTPHandler {
+	return &NotificationHTTPHandler{
+		notificationHandler: notificationHandler,
+	}
+}
+
+// Request/Response models
+
+type NotificationResponse struct {
+	ID            string                   `json:"id"`
+	EventID       entities.EventID         `json:"event_id"`
+	EventTitle    string                   `json:"event_title"`
+	Location      string                   `json:"location,omitempty"`
+	StartsAt      time.Time                `json:"starts_at"`
+	AllDay        bool                     `json:"all_day"`
+	Timezone      string                   `json:"timezone"`
+	MinutesBefore int                      `json:"minutes_before"`
+	SentAt        *time.Time               `json:"sent_at"`
+	ReadAt        *time.Time               `json:"read_at"`
+	Read          bool                     `json:"read"`
+	Channel       entities.ReminderChannel `json:"channel"`
+}
+
+// GetNotifications lists the current user's in-app notifications, newest
+// first. With unread=true only those not read yet are listed.
+func (h *NotificationHTTPHandler) GetNotifications(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	query := queries.GetNotificationsQuery{
+		UserID:     userID,
+		UnreadOnly: unreadOnly,
+		Limit:      limit,
+	}
+
+	result, err := h.notificationHandler.HandleGetNotifications(c.Request.Context(), query)
+	if err != nil {
+		h.handleNotificationError(c, err, "notifications_retrieval_failed")
+		return
+	}
+
+	notifications := make([]NotificationResponse, len(result.Notifications))
+	for i, notification := range result.Notifications {
+		notifications[i] = h.mapNotificationToResponse(notification)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"limit":         limit,
+	})
+}
+
+func (h *NotificationHTTPHandler) MarkNotificationRead(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	cmd := commands.MarkNotificationReadCommand{
+		NotificationID: c.Param("id"),
+		UserID:         userID,
+	}
+
+	if _, err := h.notificationHandler.HandleMarkNotificationRead(c.Request.Context(), cmd); err != nil {
+		h.handleNotificationError(c, err, "notification_update_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification marked as read",
+	})
+}
+
+func (h *NotificationHTTPHandler) MarkAllNotificationsRead(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	cmd := commands.MarkAllNotificationsReadCommand{
+		UserID: userID,
+	}
+
+	if _, err := h.notificationHandler.HandleMarkAllNotificationsRead(c.Request.Context(), cmd); err != nil {
+		h.handleNotificationError(c, err, "notification_update_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All notifications marked as read",
+	})
+}
+
+func (h *NotificationHTTPHandler) handleNotificationError(c *gin.Context, err error, code string) {
+	switch {
+	case errors.Is(err, appHandlers.ErrNotificationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "notification_not_found",
+			"message": "Notification not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   code,
+			"message": err.Error(),
+		})
+	}
+}
+
+func (h *NotificationHTTPHandler) mapNotificationToResponse(notification *entities.Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:            notification.ID,
+		EventID:       notification.EventID,
+		EventTitle:    notification.EventTitle,
+		Location:      notification.Location,
+		StartsAt:      notification.StartsAt,
+		AllDay:        notification.AllDay,
+		Timezone:      notification.Timezone,
+		MinutesBefore: notification.MinutesBefore,
+		SentAt:        notification.SentAt,
+		ReadAt:        notification.ReadAt,
+		Read:          notification.ReadAt != nil,
+		Channel:       notification.Channel,
+	}
+}
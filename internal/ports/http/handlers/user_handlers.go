@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/auth"
@@ -14,20 +16,23 @@ import (
 )
 
 type UserHTTPHandler struct {
-	userHandler *appHandlers.UserHandler
-	userService *services.UserService
-	jwtService  *auth.JWTService
+	userHandler    *appHandlers.UserHandler
+	sessionHandler *appHandlers.SessionHandler
+	userService    *services.UserService
+	jwtService     *auth.JWTService
 }
 
 func NewUserHTTPHandler(
 	userHandler *appHandlers.UserHandler,
+	sessionHandler *appHandlers.SessionHandler,
 	userService *services.UserService,
 	jwtService *auth.JWTService,
 ) *UserHTTPHandler {
 	return &UserHTTPHandler{
-		userHandler: userHandler,
-		userService: userService,
-		jwtService:  jwtService,
+		userHandler:    userHandler,
+		sessionHandler: sessionHandler,
+		userService:    userService,
+		jwtService:     jwtService,
 	}
 }
 
@@ -46,6 +51,12 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// ChangePasswordRequest represents password change request
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
 // UpdateProfileRequest represents profile update request
 type UpdateProfileRequest struct {
 	Name     *string                 `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
@@ -53,6 +64,22 @@ type UpdateProfileRequest struct {
 	Settings *entities.UserSettings  `json:"settings,omitempty"`
 }
 
+// RefreshRequest represents token refresh and logout request
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SessionResponse represents a session in API responses
+type SessionResponse struct {
+	ID         entities.SessionID `json:"id"`
+	UserAgent  string             `json:"user_agent"`
+	IPAddress  string             `json:"ip_address"`
+	CreatedAt  time.Time          `json:"created_at"`
+	LastUsedAt time.Time          `json:"last_used_at"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	Current    bool               `json:"current"`
+}
+
 // UserResponse represents user data in API responses
 type UserResponse struct {
 	ID       entities.UserID       `json:"id"`
@@ -77,6 +104,14 @@ func (h *UserHTTPHandler) Register(c *gin.Context) {
 	req.Email = h.userService.SanitizeEmail(req.Email)
 	req.Name = h.userService.SanitizeName(req.Name)
 	
+	if err := h.userService.ValidatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_password",
+			"message": err.Error(),
+		})
+		return
+	}
+	
 	// Set default profile and settings if not provided
 	profile := entities.UserProfile{}
 	if req.Profile != nil {
@@ -96,6 +131,7 @@ func (h *UserHTTPHandler) Register(c *gin.Context) {
 	cmd := commands.CreateUserCommand{
 		Email:    req.Email,
 		Name:     req.Name,
+		Password: req.Password,
 		Profile:  profile,
 		Settings: settings,
 	}
@@ -121,8 +157,8 @@ func (h *UserHTTPHandler) Register(c *gin.Context) {
 		return
 	}
 	
-	// Generate JWT tokens
-	tokenPair, err := h.jwtService.GenerateTokenPair(userResult.User)
+	// Start session and generate JWT tokens
+	tokenPair, err := h.startSession(c, userResult.User)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "token_generation_failed",
@@ -152,22 +188,30 @@ func (h *UserHTTPHandler) Login(c *gin.Context) {
 	// Sanitize email
 	req.Email = h.userService.SanitizeEmail(req.Email)
 	
-	// Get user by email
-	userQuery := queries.GetUserByEmailQuery{Email: req.Email}
-	userResult, err := h.userHandler.HandleGetUserByEmail(c.Request.Context(), userQuery)
-	if err != nil || userResult.User == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "invalid_credentials",
-			"message": "Invalid email or password",
+	// Verify credentials
+	cmd := commands.AuthenticateUserCommand{
+		Email:    req.Email,
+		Password: req.Password,
+	}
+	
+	userResult, err := h.userHandler.HandleAuthenticateUser(c.Request.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, appHandlers.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_credentials",
+				"message": "Invalid email or password",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "authentication_failed",
+			"message": "Failed to authenticate user",
 		})
 		return
 	}
 	
-	// TODO: Implement password verification
-	// For now, skip password verification (will be implemented with password hashing)
-	
-	// Generate JWT tokens
-	tokenPair, err := h.jwtService.GenerateTokenPair(userResult.User)
+	// Start session and generate JWT tokens
+	tokenPair, err := h.startSession(c, userResult.User)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "token_generation_failed",
@@ -241,6 +285,17 @@ func (h *UserHTTPHandler) UpdateProfile(c *gin.Context) {
 		req.Name = &sanitized
 	}
 	
+	// Validate settings if provided
+	if req.Settings != nil {
+		if err := h.userService.ValidateSettings(req.Settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_settings",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+	
 	// Create update command
 	cmd := commands.UpdateUserCommand{
 		UserID:   userID,
@@ -265,6 +320,77 @@ func (h *UserHTTPHandler) UpdateProfile(c *gin.Context) {
 	})
 }
 
+// ChangePassword changes current user password
+func (h *UserHTTPHandler) ChangePassword(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+	
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	if err := h.userService.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_password",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	cmd := commands.ChangePasswordCommand{
+		UserID:          userID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	}
+	
+	result, err := h.userHandler.HandleChangePassword(c.Request.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, appHandlers.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_credentials",
+				"message": "Current password is incorrect",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "password_change_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	// Sign out every other device; the current one stays logged in
+	revokeCmd := commands.RevokeOtherSessionsCommand{
+		UserID:           userID,
+		CurrentSessionID: h.currentSessionID(c),
+		Reason:           entities.SessionRevokedPasswordChange,
+	}
+	
+	if _, err := h.sessionHandler.HandleRevokeOtherSessions(c.Request.Context(), revokeCmd); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "session_revocation_failed",
+			"message": "Password changed but other sessions could not be revoked",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Password changed successfully",
+		"changed_at": result.ChangedAt,
+	})
+}
+
 // DeleteAccount deletes current user account
 func (h *UserHTTPHandler) DeleteAccount(c *gin.Context) {
 	userID, exists := middleware.GetCurrentUserID(c)
@@ -304,12 +430,8 @@ func (h *UserHTTPHandler) DeleteAccount(c *gin.Context) {
 	})
 }
 
-// RefreshToken refreshes JWT tokens using refresh token
+// RefreshToken rotates the refresh token and issues a new token pair
 func (h *UserHTTPHandler) RefreshToken(c *gin.Context) {
-	type RefreshRequest struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
-	}
-	
 	var req RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -329,6 +451,40 @@ func (h *UserHTTPHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 	
+	// Rotate refresh token within its session
+	cmd := commands.RotateRefreshTokenCommand{
+		UserID:         claims.UserID,
+		SessionID:      claims.SessionID,
+		RefreshTokenID: claims.ID,
+	}
+	
+	result, err := h.sessionHandler.HandleRotateRefreshToken(c.Request.Context(), cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, appHandlers.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "refresh_token_reused",
+				"message": "Refresh token has already been used, session revoked",
+			})
+		case errors.Is(err, appHandlers.ErrSessionRevoked):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "session_revoked",
+				"message": "Session has been revoked",
+			})
+		case errors.Is(err, appHandlers.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_refresh_token",
+				"message": "Invalid refresh token",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "token_refresh_failed",
+				"message": "Failed to refresh tokens",
+			})
+		}
+		return
+	}
+	
 	// Get user
 	userQuery := queries.GetUserByIDQuery{UserID: claims.UserID}
 	userResult, err := h.userHandler.HandleGetUserByID(c.Request.Context(), userQuery)
@@ -341,7 +497,7 @@ func (h *UserHTTPHandler) RefreshToken(c *gin.Context) {
 	}
 	
 	// Generate new token pair
-	tokenPair, err := h.jwtService.RefreshTokenPair(req.RefreshToken, userResult.User)
+	tokenPair, err := h.jwtService.GenerateTokenPair(userResult.User, result.SessionID, result.RefreshTokenID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "token_refresh_failed",
@@ -356,6 +512,188 @@ func (h *UserHTTPHandler) RefreshToken(c *gin.Context) {
 	})
 }
 
+// Logout revokes the session the refresh token belongs to
+func (h *UserHTTPHandler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	claims, err := h.jwtService.ValidateRefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_refresh_token",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	cmd := commands.RevokeSessionCommand{
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		Reason:    entities.SessionRevokedLogout,
+	}
+	
+	if _, err := h.sessionHandler.HandleRevokeSession(c.Request.Context(), cmd); err != nil {
+		if errors.Is(err, appHandlers.ErrSessionNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_refresh_token",
+				"message": "Invalid refresh token",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "logout_failed",
+			"message": "Failed to revoke session",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// GetSessions lists active sessions of current user
+func (h *UserHTTPHandler) GetSessions(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+	
+	query := queries.GetUserSessionsQuery{UserID: userID}
+	result, err := h.sessionHandler.HandleGetUserSessions(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "sessions_retrieval_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	currentSessionID := h.currentSessionID(c)
+	sessions := make([]SessionResponse, 0, len(result.Sessions))
+	for _, session := range result.Sessions {
+		sessions = append(sessions, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession revokes a single session of current user
+func (h *UserHTTPHandler) RevokeSession(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+	
+	cmd := commands.RevokeSessionCommand{
+		UserID:    userID,
+		SessionID: entities.SessionID(c.Param("id")),
+		Reason:    entities.SessionRevokedByUser,
+	}
+	
+	result, err := h.sessionHandler.HandleRevokeSession(c.Request.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, appHandlers.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "session_not_found",
+				"message": "Session not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "session_revocation_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Session revoked successfully",
+		"revoked_at": result.RevokedAt,
+	})
+}
+
+// RevokeOtherSessions revokes all sessions of current user except the current one
+func (h *UserHTTPHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+	
+	cmd := commands.RevokeOtherSessionsCommand{
+		UserID:           userID,
+		CurrentSessionID: h.currentSessionID(c),
+		Reason:           entities.SessionRevokedByUser,
+	}
+	
+	result, err := h.sessionHandler.HandleRevokeOtherSessions(c.Request.Context(), cmd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "session_revocation_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Other sessions revoked successfully",
+		"revoked_at": result.RevokedAt,
+	})
+}
+
+// startSession opens a new session for the user and issues its first token pair
+func (h *UserHTTPHandler) startSession(c *gin.Context, user *entities.User) (*auth.TokenPair, error) {
+	cmd := commands.StartSessionCommand{
+		UserID:    user.ID,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+	
+	result, err := h.sessionHandler.HandleStartSession(c.Request.Context(), cmd)
+	if err != nil {
+		return nil, err
+	}
+	
+	return h.jwtService.GenerateTokenPair(user, result.SessionID, result.RefreshTokenID)
+}
+
+// currentSessionID returns the session of the access token used for the request
+func (h *UserHTTPHandler) currentSessionID(c *gin.Context) entities.SessionID {
+	claims, ok := middleware.GetTokenClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.SessionID
+}
+
 // mapUserToResponse converts User entity to API response format
 func (h *UserHTTPHandler) mapUserToResponse(user *entities.User) UserResponse {
 	return UserResponse{
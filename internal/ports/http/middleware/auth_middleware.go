@@ -5,17 +5,20 @@ import (
 	"strings"
 
 	"github.com/andranikuz/smart-goal-calendar/internal/adapters/auth"
+	appHandlers "github.com/andranikuz/smart-goal-calendar/internal/application/handlers"
 	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
 	"github.com/gin-gonic/gin"
 )
 
 type AuthMiddleware struct {
-	jwtService *auth.JWTService
+	jwtService     *auth.JWTService
+	sessionHandler *appHandlers.SessionHandler
 }
 
-func NewAuthMiddleware(jwtService *auth.JWTService) *AuthMiddleware {
+func NewAuthMiddleware(jwtService *auth.JWTService, sessionHandler *appHandlers.SessionHandler) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtService: jwtService,
+		jwtService:     jwtService,
+		sessionHandler: sessionHandler,
 	}
 }
 
@@ -65,6 +68,26 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// Check that the session hasn't been revoked (logout, password change, ...)
+		active, err := m.isSessionActive(c, claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "session_check_failed",
+				"message": "Failed to verify session",
+			})
+			c.Abort()
+			return
+		}
+
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "session_revoked",
+				"message": "Session has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// Store user information in context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
@@ -96,7 +119,12 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
-		if !m.jwtService.IsTokenExpired(claims) {
+		if m.jwtService.IsTokenExpired(claims) {
+			c.Next()
+			return
+		}
+
+		if active, err := m.isSessionActive(c, claims); err == nil && active {
 			c.Set("user_id", claims.UserID)
 			c.Set("user_email", claims.Email)
 			c.Set("user_name", claims.Name)
@@ -107,6 +135,15 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	}
 }
 
+// isSessionActive checks the session referenced by the token claims
+func (m *AuthMiddleware) isSessionActive(c *gin.Context, claims *auth.JWTClaims) (bool, error) {
+	if claims.SessionID == "" {
+		return false, nil
+	}
+
+	return m.sessionHandler.IsSessionActive(c.Request.Context(), claims.UserID, claims.SessionID)
+}
+
 // GetCurrentUserID extracts current user ID from gin context
 func GetCurrentUserID(c *gin.Context) (entities.UserID, bool) {
 	userID, exists := c.Get("user_id")
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
+)
+
+// SetupCalendarFeedRoutes sets up the iCalendar feed routes
+func SetupCalendarFeedRoutes(
+	router *gin.RouterGroup,
+	feedHandler *handlers.CalendarFeedHTTPHandler,
+	authMiddleware *middleware.AuthMiddleware,
+) {
+	// Public feed, authenticated by its secret token
+	router.GET("/feeds/:token", feedHandler.ServeFeed) // Serve calendar (/feeds/{token}.ics)
+
+	// Feed management for the current user
+	feed := router.Group("/users/me/feed")
+	feed.Use(authMiddleware.RequireAuth())
+	{
+		feed.GET("", feedHandler.GetFeed)       // Get feed settings
+		feed.POST("", feedHandler.RotateFeed)   // Create or rotate feed URL
+		feed.PATCH("", feedHandler.UpdateFeed)  // Update included goal data
+		feed.DELETE("", feedHandler.RevokeFeed) // Revoke feed URL
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCalendarRoutes sets up the routes managing the user's calendars
+func SetupCalendarRoutes(
+	router *gin.RouterGroup,
+	calendarHandler *handlers.CalendarHTTPHandler,
+	authMiddleware *middleware.AuthMiddleware,
+) {
+	calendars := router.Group("/calendars")
+	calendars.Use(authMiddleware.RequireAuth())
+	{
+		calendars.GET("", calendarHandler.GetCalendars)          // List calendars
+		calendars.POST("", calendarHandler.CreateCalendar)       // Create a local calendar
+		calendars.GET("/:id", calendarHandler.GetCalendar)       // Get calendar
+		calendars.PUT("/:id", calendarHandler.UpdateCalendar)    // Update calendar
+		calendars.DELETE("/:id", calendarHandler.DeleteCalendar) // Delete calendar with its events
+	}
+}
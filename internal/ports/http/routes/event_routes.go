@@ -17,18 +17,20 @@ func SetupEventRoutes(
 
 	// Event CRUD operations
 	events.POST("", eventHandler.CreateEvent)                    // Create event
+	events.POST("/import", eventHandler.ImportEvents)            // Import an iCalendar file (?dry_run=true)
 	events.GET("", eventHandler.GetEvents)                       // Get user's events (paginated)
 	events.GET("/search", eventHandler.SearchEvents)             // Search events
 	events.GET("/upcoming", eventHandler.GetUpcomingEvents)      // Get upcoming events
 	events.GET("/today", eventHandler.GetTodayEvents)           // Get today's events
 	events.GET("/time-range", eventHandler.GetEventsByTimeRange) // Get events by time range
 	events.GET("/conflict-check", eventHandler.CheckEventConflict) // Check for conflicts
+	events.GET("/export.ics", eventHandler.ExportEvents)         // Export events as iCalendar (?start_time=&end_time=&goal_id=&status=)
 	events.GET("/:id", eventHandler.GetEvent)                   // Get specific event
-	events.PUT("/:id", eventHandler.UpdateEvent)                // Update event
-	events.DELETE("/:id", eventHandler.DeleteEvent)             // Delete event
+	events.PUT("/:id", eventHandler.UpdateEvent)                // Update event (?scope=this|this_and_following|all)
+	events.DELETE("/:id", eventHandler.DeleteEvent)             // Delete event (?scope=this|this_and_following|all)
 
 	// Event actions
-	events.POST("/:id/move", eventHandler.MoveEvent)            // Move event to new time
+	events.POST("/:id/move", eventHandler.MoveEvent)            // Move event to new time (?scope=...)
 	events.POST("/:id/duplicate", eventHandler.DuplicateEvent) // Duplicate event
 	events.POST("/:id/status", eventHandler.ChangeEventStatus) // Change event status
 	events.POST("/:id/link-goal", eventHandler.LinkEventToGoal) // Link event to goal
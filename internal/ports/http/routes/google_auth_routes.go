@@ -18,10 +18,11 @@ func SetupGoogleAuthRoutes(
 	googleGroup.GET("/auth-url", googleAuthHandler.GetAuthURL)
 	googleGroup.POST("/callback", googleAuthHandler.HandleCallback)
 	
-	// Integration management
-	googleGroup.GET("/integration", googleAuthHandler.GetIntegration)
-	googleGroup.DELETE("/integration", googleAuthHandler.DisconnectIntegration)
+	// Integration management; a user can connect several Google accounts
+	googleGroup.GET("/integrations", googleAuthHandler.GetIntegrations)
+	googleGroup.GET("/integrations/:id", googleAuthHandler.GetIntegration)
+	googleGroup.DELETE("/integrations/:id", googleAuthHandler.DisconnectIntegration)
 	
-	// Calendar management
+	// Calendar management (?integration_id=...)
 	googleGroup.GET("/calendars", googleAuthHandler.GetCalendars)
 }
\ No newline at end of file
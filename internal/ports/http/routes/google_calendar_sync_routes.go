@@ -22,4 +22,18 @@ func SetupGoogleCalendarSyncRoutes(
 	
 	// Sync action endpoints
 	syncGroup.POST("/:id/sync", googleCalendarSyncHandler.SyncNow)
-}
\ No newline at end of file
+	syncGroup.GET("/:id/runs", googleCalendarSyncHandler.GetSyncRuns)
+
+	// Conflicts queued by the manual conflict resolution
+	syncGroup.GET("/:id/conflicts", googleCalendarSyncHandler.GetConflicts)
+	syncGroup.POST("/:id/conflicts/:conflict_id/resolve", googleCalendarSyncHandler.ResolveConflict)
+}
+
+// SetupGoogleNotificationRoutes sets up the public webhook of Google watch
+// channels, authenticated by the channel token
+func SetupGoogleNotificationRoutes(
+	router *gin.RouterGroup,
+	googleNotificationHandler *handlers.GoogleNotificationHandler,
+) {
+	router.POST("/google/notifications", googleNotificationHandler.HandleNotification)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/handlers"
+	"github.com/andranikuz/smart-goal-calendar/internal/ports/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupNotificationRoutes sets up the routes of the user's in-app
+// notifications
+func SetupNotificationRoutes(
+	router *gin.RouterGroup,
+	notificationHandler *handlers.NotificationHTTPHandler,
+	authMiddleware *middleware.AuthMiddleware,
+) {
+	notifications := router.Group("/notifications")
+	notifications.Use(authMiddleware.RequireAuth())
+	{
+		notifications.GET("", notificationHandler.GetNotifications)                   // List notifications
+		notifications.POST("/read-all", notificationHandler.MarkAllNotificationsRead) // Mark all as read
+		notifications.POST("/:id/read", notificationHandler.MarkNotificationRead)     // Mark as read
+	}
+}
@@ -18,6 +18,7 @@ func SetupUserRoutes(
 		auth.POST("/register", userHandler.Register)
 		auth.POST("/login", userHandler.Login)
 		auth.POST("/refresh", userHandler.RefreshToken)
+		auth.POST("/logout", userHandler.Logout)
 	}
 	
 	// Protected routes (authentication required)
@@ -28,5 +29,11 @@ func SetupUserRoutes(
 		users.GET("/me", userHandler.GetProfile)
 		users.PUT("/me", userHandler.UpdateProfile)
 		users.DELETE("/me", userHandler.DeleteAccount)
+		users.PUT("/me/password", userHandler.ChangePassword)
+		
+		// Sessions (logged-in devices)
+		users.GET("/me/sessions", userHandler.GetSessions)
+		users.DELETE("/me/sessions", userHandler.RevokeOtherSessions)
+		users.DELETE("/me/sessions/:id", userHandler.RevokeSession)
 	}
 }
\ No newline at end of file
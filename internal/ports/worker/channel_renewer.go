@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+// ChannelRenewerConfig tunes how a ChannelRenewer keeps watch channels open
+type ChannelRenewerConfig struct {
+	PollInterval time.Duration
+	WebhookURL   string        // Where Google sends the notifications
+	TTL          time.Duration // Requested channel lifetime; Google may shorten it
+	RenewBefore  time.Duration // How long before expiry a channel is replaced
+}
+
+// channelRenewTimeout bounds a single renewal
+const channelRenewTimeout = time.Minute
+
+// ChannelRenewer opens Google watch channels for the syncs that read from
+// Google calendars, renews them before they expire and stops the ones of
+// syncs that no longer need them. Renewals by several replicas are safe, see
+// calendarsync.Syncer.RenewChannel.
+type ChannelRenewer struct {
+	syncRepo repositories.GoogleCalendarSyncRepository
+	syncer   *calendarsync.Syncer
+	config   ChannelRenewerConfig
+	loop     *loop
+}
+
+func NewChannelRenewer(
+	syncRepo repositories.GoogleCalendarSyncRepository,
+	syncer *calendarsync.Syncer,
+	config ChannelRenewerConfig,
+) *ChannelRenewer {
+	r := &ChannelRenewer{
+		syncRepo: syncRepo,
+		syncer:   syncer,
+		config:   config,
+	}
+	// A single job renews all channels of a poll, so polls don't overlap
+	r.loop = newLoop(config.PollInterval, 1, 1, r.claim)
+	return r
+}
+
+// Start renews channels in the background until Shutdown is called
+func (r *ChannelRenewer) Start() {
+	zlog.Info().
+		Str("webhook_url", r.config.WebhookURL).
+		Dur("poll_interval", r.config.PollInterval).
+		Msg("Starting Google watch channel renewer")
+
+	r.loop.start()
+}
+
+// Shutdown stops renewing channels, see SyncScheduler.Shutdown
+func (r *ChannelRenewer) Shutdown(ctx context.Context) error {
+	return r.loop.shutdown(ctx)
+}
+
+func (r *ChannelRenewer) claim(ctx context.Context, limit int) ([]job, error) {
+	syncs, err := r.syncRepo.GetNeedingChannel(ctx, entities.IntegrationProviderGoogle, time.Now().Add(r.config.RenewBefore))
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Error().Err(err).Msg("Failed to get calendar syncs needing a watch channel")
+		}
+		return nil, err
+	}
+	if len(syncs) == 0 {
+		return nil, nil
+	}
+
+	return []job{func(ctx context.Context) { r.renewAll(ctx, syncs) }}, nil
+}
+
+func (r *ChannelRenewer) renewAll(ctx context.Context, syncs []*entities.GoogleCalendarSync) {
+	for _, sync := range syncs {
+		if ctx.Err() != nil {
+			return
+		}
+		r.renew(ctx, sync)
+	}
+}
+
+func (r *ChannelRenewer) renew(ctx context.Context, sync *entities.GoogleCalendarSync) {
+	ctx, cancel := context.WithTimeout(ctx, channelRenewTimeout)
+	defer cancel()
+
+	if err := r.syncer.RenewChannel(ctx, sync, r.config.WebhookURL, r.config.TTL); err != nil {
+		zlog.Warn().Err(err).Str("sync_id", sync.ID).Msg("Failed to renew Google watch channel")
+		return
+	}
+
+	zlog.Debug().Str("sync_id", sync.ID).Bool("watched", sync.Channel != nil).Msg("Google watch channel renewed")
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// job is a claimed unit of work
+type job func(ctx context.Context)
+
+// claimFunc claims up to limit jobs. Claims are leases held by the worker.
+type claimFunc func(ctx context.Context, limit int) ([]job, error)
+
+// loop polls for jobs and runs them with bounded concurrency. Jobs are only
+// claimed for free slots, so no claim waits for a slot while its lease runs
+// out.
+type loop struct {
+	pollInterval time.Duration
+	batchSize    int
+	claim        claimFunc
+
+	// pollCtx stops claiming new jobs, runCtx cancels the running ones
+	pollCtx    context.Context
+	stopPoll   context.CancelFunc
+	runCtx     context.Context
+	cancelRuns context.CancelFunc
+
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newLoop(pollInterval time.Duration, batchSize, concurrency int, claim claimFunc) *loop {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if batchSize < 1 {
+		batchSize = concurrency
+	}
+
+	pollCtx, stopPoll := context.WithCancel(context.Background())
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+
+	return &loop{
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		claim:        claim,
+		pollCtx:      pollCtx,
+		stopPoll:     stopPoll,
+		runCtx:       runCtx,
+		cancelRuns:   cancelRuns,
+		slots:        make(chan struct{}, concurrency),
+	}
+}
+
+func (l *loop) start() {
+	l.wg.Add(1)
+	go l.poll()
+}
+
+// shutdown stops claiming jobs and waits for the running ones to finish.
+// When ctx is done first, the running jobs are cancelled and ctx's error is
+// returned; their claims expire with the lease.
+func (l *loop) shutdown(ctx context.Context) error {
+	l.stopPoll()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		l.cancelRuns()
+		return nil
+	case <-ctx.Done():
+		l.cancelRuns()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (l *loop) poll() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		l.claimFree()
+
+		select {
+		case <-l.pollCtx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimFree claims as many jobs as there are free slots and runs them
+func (l *loop) claimFree() {
+	free := cap(l.slots) - len(l.slots)
+	if free == 0 {
+		return
+	}
+
+	jobs, err := l.claim(l.pollCtx, min(free, l.batchSize))
+	if err != nil {
+		return
+	}
+
+	for _, run := range jobs {
+		// Only this goroutine takes slots, so one is free for every claimed job
+		l.slots <- struct{}{}
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			defer func() { <-l.slots }()
+			run(l.runCtx)
+		}()
+	}
+}
+
+// newWorkerID identifies this process in the locks it holds
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+}
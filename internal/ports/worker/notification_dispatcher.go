@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/notify"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+// NotificationDispatcherConfig tunes how a NotificationDispatcher delivers
+// queued notifications
+type NotificationDispatcherConfig struct {
+	PollInterval  time.Duration
+	BatchSize     int           // Most notifications claimed per poll
+	Concurrency   int           // Most deliveries running at once
+	LeaseDuration time.Duration // How long a claimed notification stays locked; bounds a single delivery
+	MaxAttempts   int           // Attempts before a notification gives up
+}
+
+// NotificationDispatcher delivers the notifications queued by the
+// ReminderScheduler. Failed deliveries are retried with backoff until
+// MaxAttempts; undeliverable ones, e.g. to a user without a webhook URL,
+// give up right away.
+type NotificationDispatcher struct {
+	notificationRepo repositories.NotificationRepository
+	sender           *notify.Sender
+	config           NotificationDispatcherConfig
+	workerID         string
+	loop             *loop
+}
+
+func NewNotificationDispatcher(
+	notificationRepo repositories.NotificationRepository,
+	sender *notify.Sender,
+	config NotificationDispatcherConfig,
+) *NotificationDispatcher {
+	d := &NotificationDispatcher{
+		notificationRepo: notificationRepo,
+		sender:           sender,
+		config:           config,
+		workerID:         newWorkerID(),
+	}
+	d.loop = newLoop(config.PollInterval, config.BatchSize, config.Concurrency, d.claim)
+	return d
+}
+
+// Start polls for due notifications in the background until Shutdown is
+// called
+func (d *NotificationDispatcher) Start() {
+	zlog.Info().
+		Str("worker_id", d.workerID).
+		Dur("poll_interval", d.config.PollInterval).
+		Msg("Starting notification dispatcher")
+
+	d.loop.start()
+}
+
+// Shutdown stops claiming notifications and waits for the running
+// deliveries, see SyncScheduler.Shutdown
+func (d *NotificationDispatcher) Shutdown(ctx context.Context) error {
+	return d.loop.shutdown(ctx)
+}
+
+func (d *NotificationDispatcher) claim(ctx context.Context, limit int) ([]job, error) {
+	notifications, err := d.notificationRepo.ClaimDue(ctx, d.workerID, limit, d.config.LeaseDuration)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Error().Err(err).Msg("Failed to claim notifications")
+		}
+		return nil, err
+	}
+
+	jobs := make([]job, len(notifications))
+	for i, notification := range notifications {
+		jobs[i] = func(ctx context.Context) { d.deliver(ctx, notification) }
+	}
+	return jobs, nil
+}
+
+func (d *NotificationDispatcher) deliver(ctx context.Context, notification *entities.Notification) {
+	sendCtx, cancel := context.WithTimeout(ctx, d.config.LeaseDuration)
+	defer cancel()
+
+	sendErr := d.sender.Send(sendCtx, notification)
+
+	// The outcome is stored even when the delivery was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	attempts := notification.Attempts + 1
+	switch {
+	case sendErr == nil:
+		err = d.notificationRepo.Complete(ctx, notification.ID)
+	case errors.Is(sendErr, notify.ErrNotificationsDisabled):
+		err = d.notificationRepo.Skip(ctx, notification.ID, sendErr.Error())
+	case errors.Is(sendErr, services.ErrUndeliverable) || attempts >= d.config.MaxAttempts:
+		zlog.Warn().Err(sendErr).Str("notification_id", notification.ID).Msg("Giving up notification")
+		err = d.notificationRepo.Fail(ctx, notification.ID, sendErr.Error())
+	default:
+		err = d.notificationRepo.Retry(ctx, notification.ID, sendErr.Error(), time.Now().Add(notify.RetryDelay(attempts)))
+	}
+
+	if err != nil {
+		zlog.Error().Err(err).Str("notification_id", notification.ID).Msg("Failed to record notification delivery")
+	}
+}
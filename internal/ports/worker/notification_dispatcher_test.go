@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/notify"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/services"
+)
+
+type fakeNotifiedUsers struct {
+	repositories.UserRepository
+	users map[entities.UserID]*entities.User
+}
+
+func (f *fakeNotifiedUsers) GetByID(_ context.Context, id entities.UserID) (*entities.User, error) {
+	return f.users[id], nil
+}
+
+// fakeNotifier fails every delivery with err, nil to deliver
+type fakeNotifier struct {
+	channel entities.ReminderChannel
+	err     error
+}
+
+func (f *fakeNotifier) Channel() entities.ReminderChannel {
+	return f.channel
+}
+
+func (f *fakeNotifier) Notify(context.Context, *entities.Notification, *entities.User) error {
+	return f.err
+}
+
+// fakeDeliveryRecords records the outcome the dispatcher stores
+type fakeDeliveryRecords struct {
+	repositories.NotificationRepository
+	outcome       entities.NotificationStatus
+	lastError     string
+	nextAttemptAt time.Time
+}
+
+func (f *fakeDeliveryRecords) Complete(context.Context, string) error {
+	f.outcome = entities.NotificationSent
+	return nil
+}
+
+func (f *fakeDeliveryRecords) Skip(_ context.Context, _ string, reason string) error {
+	f.outcome, f.lastError = entities.NotificationSkipped, reason
+	return nil
+}
+
+func (f *fakeDeliveryRecords) Retry(_ context.Context, _ string, lastError string, nextAttemptAt time.Time) error {
+	f.outcome, f.lastError, f.nextAttemptAt = entities.NotificationPending, lastError, nextAttemptAt
+	return nil
+}
+
+func (f *fakeDeliveryRecords) Fail(_ context.Context, _ string, lastError string) error {
+	f.outcome, f.lastError = entities.NotificationFailed, lastError
+	return nil
+}
+
+func TestNotificationDispatcherDeliver(t *testing.T) {
+	const maxAttempts = 3
+	temporary := errors.New("connection refused")
+	undeliverable := fmt.Errorf("%w: mailbox unknown", services.ErrUndeliverable)
+
+	tests := []struct {
+		name         string
+		channel      entities.ReminderChannel
+		enabled      bool
+		notifyErr    error
+		attempts     int // Failed attempts before this one
+		outcome      entities.NotificationStatus
+		errorMessage string
+	}{
+		{
+			name:    "delivered",
+			channel: entities.ReminderChannelEmail,
+			enabled: true,
+			outcome: entities.NotificationSent,
+		},
+		{
+			name:         "temporary failure",
+			channel:      entities.ReminderChannelEmail,
+			enabled:      true,
+			notifyErr:    temporary,
+			outcome:      entities.NotificationPending,
+			errorMessage: temporary.Error(),
+		},
+		{
+			name:         "temporary failure of the last attempt",
+			channel:      entities.ReminderChannelEmail,
+			enabled:      true,
+			notifyErr:    temporary,
+			attempts:     maxAttempts - 1,
+			outcome:      entities.NotificationFailed,
+			errorMessage: temporary.Error(),
+		},
+		{
+			name:         "undeliverable",
+			channel:      entities.ReminderChannelEmail,
+			enabled:      true,
+			notifyErr:    undeliverable,
+			outcome:      entities.NotificationFailed,
+			errorMessage: undeliverable.Error(),
+		},
+		{
+			// No notifier is configured for webhooks
+			name:    "channel not configured",
+			channel: entities.ReminderChannelWebhook,
+			enabled: true,
+			outcome: entities.NotificationFailed,
+		},
+		{
+			name:         "notifications disabled",
+			channel:      entities.ReminderChannelEmail,
+			outcome:      entities.NotificationSkipped,
+			errorMessage: notify.ErrNotificationsDisabled.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeNotifiedUsers{users: map[entities.UserID]*entities.User{
+				"user-1": {ID: "user-1", Settings: entities.UserSettings{NotificationEnabled: tt.enabled}},
+			}}
+			sender := notify.NewSender(users, []services.Notifier{
+				&fakeNotifier{channel: entities.ReminderChannelEmail, err: tt.notifyErr},
+			})
+			records := &fakeDeliveryRecords{}
+			dispatcher := NewNotificationDispatcher(records, sender, NotificationDispatcherConfig{
+				LeaseDuration: time.Minute,
+				MaxAttempts:   maxAttempts,
+			})
+
+			before := time.Now()
+			dispatcher.deliver(context.Background(), &entities.Notification{
+				ID:       "notification-1",
+				UserID:   "user-1",
+				Channel:  tt.channel,
+				Status:   entities.NotificationPending,
+				Attempts: tt.attempts,
+			})
+
+			if records.outcome != tt.outcome {
+				t.Errorf("outcome = %q, want %q", records.outcome, tt.outcome)
+			}
+			if tt.errorMessage != "" && records.lastError != tt.errorMessage {
+				t.Errorf("last error = %q, want %q", records.lastError, tt.errorMessage)
+			}
+			if tt.outcome == entities.NotificationPending && !records.nextAttemptAt.After(before) {
+				t.Errorf("next attempt at %v, want after %v", records.nextAttemptAt, before)
+			}
+		})
+	}
+}
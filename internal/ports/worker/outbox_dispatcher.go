@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+// OutboxDispatcherConfig tunes how an OutboxDispatcher pushes queued changes
+type OutboxDispatcherConfig struct {
+	PollInterval  time.Duration
+	BatchSize     int           // Most entries claimed per poll
+	Concurrency   int           // Most pushes running at once
+	LeaseDuration time.Duration // How long a claimed entry stays locked; bounds a single push
+	MaxAttempts   int           // Attempts before an entry gives up
+}
+
+// OutboxDispatcher pushes local changes of synced events to their provider.
+// Failed pushes are retried with backoff until MaxAttempts, after which the
+// event shows the push as failed.
+type OutboxDispatcher struct {
+	outboxRepo repositories.GoogleOutboxRepository
+	syncer     *calendarsync.Syncer
+	config     OutboxDispatcherConfig
+	workerID   string
+	loop       *loop
+}
+
+func NewOutboxDispatcher(
+	outboxRepo repositories.GoogleOutboxRepository,
+	syncer *calendarsync.Syncer,
+	config OutboxDispatcherConfig,
+) *OutboxDispatcher {
+	d := &OutboxDispatcher{
+		outboxRepo: outboxRepo,
+		syncer:     syncer,
+		config:     config,
+		workerID:   newWorkerID(),
+	}
+	d.loop = newLoop(config.PollInterval, config.BatchSize, config.Concurrency, d.claim)
+	return d
+}
+
+// Start polls for queued changes in the background until Shutdown is called
+func (d *OutboxDispatcher) Start() {
+	zlog.Info().
+		Str("worker_id", d.workerID).
+		Dur("poll_interval", d.config.PollInterval).
+		Msg("Starting Google outbox dispatcher")
+
+	d.loop.start()
+}
+
+// Shutdown stops claiming changes and waits for the running pushes, see
+// SyncScheduler.Shutdown
+func (d *OutboxDispatcher) Shutdown(ctx context.Context) error {
+	return d.loop.shutdown(ctx)
+}
+
+func (d *OutboxDispatcher) claim(ctx context.Context, limit int) ([]job, error) {
+	entries, err := d.outboxRepo.ClaimDue(ctx, d.workerID, limit, d.config.LeaseDuration)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Error().Err(err).Msg("Failed to claim outbox entries")
+		}
+		return nil, err
+	}
+
+	jobs := make([]job, len(entries))
+	for i, entry := range entries {
+		jobs[i] = func(ctx context.Context) { d.push(ctx, entry) }
+	}
+	return jobs, nil
+}
+
+func (d *OutboxDispatcher) push(ctx context.Context, entry *entities.GoogleOutboxEntry) {
+	pushCtx, cancel := context.WithTimeout(ctx, d.config.LeaseDuration)
+	defer cancel()
+
+	pushErr := d.syncer.Push(pushCtx, entry)
+
+	// The outcome is stored even when the push was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	attempts := entry.Attempts + 1
+	switch {
+	case pushErr == nil:
+		err = d.outboxRepo.Complete(ctx, entry.ID)
+	case attempts >= d.config.MaxAttempts:
+		zlog.Warn().Err(pushErr).Str("outbox_id", entry.ID).Msg("Giving up Google push")
+		err = d.outboxRepo.Fail(ctx, entry.ID, pushErr.Error())
+	default:
+		err = d.outboxRepo.Retry(ctx, entry.ID, pushErr.Error(), time.Now().Add(calendarsync.RetryDelay(attempts)))
+	}
+
+	if err != nil {
+		zlog.Error().Err(err).Str("outbox_id", entry.ID).Msg("Failed to record Google push")
+	}
+}
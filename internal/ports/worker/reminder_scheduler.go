@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/notify"
+)
+
+// ReminderSchedulerConfig tunes how a ReminderScheduler finds due reminders
+type ReminderSchedulerConfig struct {
+	PollInterval time.Duration
+	Lookback     time.Duration // How late a reminder is still sent, e.g. after downtime
+}
+
+// ReminderScheduler queues the reminders falling due as notifications for
+// the NotificationDispatcher. Every poll looks back over Lookback; reminders
+// seen before are not queued again.
+type ReminderScheduler struct {
+	scheduler *notify.Scheduler
+	config    ReminderSchedulerConfig
+	loop      *loop
+}
+
+func NewReminderScheduler(scheduler *notify.Scheduler, config ReminderSchedulerConfig) *ReminderScheduler {
+	s := &ReminderScheduler{
+		scheduler: scheduler,
+		config:    config,
+	}
+	// A single job queues the reminders of all users, so polls don't overlap
+	s.loop = newLoop(config.PollInterval, 1, 1, s.claim)
+	return s
+}
+
+// Start queues due reminders in the background until Shutdown is called
+func (s *ReminderScheduler) Start() {
+	zlog.Info().
+		Dur("poll_interval", s.config.PollInterval).
+		Dur("lookback", s.config.Lookback).
+		Msg("Starting reminder scheduler")
+
+	s.loop.start()
+}
+
+// Shutdown stops queueing reminders, see SyncScheduler.Shutdown
+func (s *ReminderScheduler) Shutdown(ctx context.Context) error {
+	return s.loop.shutdown(ctx)
+}
+
+func (s *ReminderScheduler) claim(ctx context.Context, limit int) ([]job, error) {
+	return []job{s.queueDue}, nil
+}
+
+func (s *ReminderScheduler) queueDue(ctx context.Context) {
+	now := time.Now()
+	queued, err := s.scheduler.QueueDue(ctx, now.Add(-s.config.Lookback), now)
+	if err != nil && ctx.Err() == nil {
+		zlog.Error().Err(err).Msg("Failed to queue reminders")
+	}
+	if queued > 0 {
+		zlog.Debug().Int("queued", queued).Msg("Reminders queued")
+	}
+}
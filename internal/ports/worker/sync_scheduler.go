@@ -0,0 +1,111 @@
+// Package worker runs background jobs next to the HTTP server.
+package worker
+
+import (
+	"context"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+// SyncSchedulerConfig tunes how a SyncScheduler picks up due syncs
+type SyncSchedulerConfig struct {
+	PollInterval  time.Duration
+	BatchSize     int           // Most syncs claimed per poll
+	Concurrency   int           // Most syncs running at once
+	LeaseDuration time.Duration // How long a claimed sync stays locked; bounds a single run
+}
+
+// SyncScheduler periodically runs the calendar syncs that are due.
+// Replicas can each run one: syncs are claimed with row locks, so every due
+// sync is picked up by one scheduler only.
+type SyncScheduler struct {
+	syncRepo repositories.GoogleCalendarSyncRepository
+	syncer   *calendarsync.Syncer
+	config   SyncSchedulerConfig
+	workerID string
+	loop     *loop
+}
+
+func NewSyncScheduler(
+	syncRepo repositories.GoogleCalendarSyncRepository,
+	syncer *calendarsync.Syncer,
+	config SyncSchedulerConfig,
+) *SyncScheduler {
+	s := &SyncScheduler{
+		syncRepo: syncRepo,
+		syncer:   syncer,
+		config:   config,
+		workerID: newWorkerID(),
+	}
+	s.loop = newLoop(config.PollInterval, config.BatchSize, config.Concurrency, s.claim)
+	return s
+}
+
+// Start polls for due syncs in the background until Shutdown is called
+func (s *SyncScheduler) Start() {
+	zlog.Info().
+		Str("worker_id", s.workerID).
+		Dur("poll_interval", s.config.PollInterval).
+		Int("concurrency", s.config.Concurrency).
+		Msg("Starting calendar sync scheduler")
+
+	s.loop.start()
+}
+
+// Shutdown stops claiming syncs and waits for the running ones to finish.
+// When ctx is done first, the running syncs are cancelled and ctx's error is
+// returned; their claims expire with the lease.
+func (s *SyncScheduler) Shutdown(ctx context.Context) error {
+	return s.loop.shutdown(ctx)
+}
+
+func (s *SyncScheduler) claim(ctx context.Context, limit int) ([]job, error) {
+	syncs, err := s.syncRepo.ClaimDue(ctx, s.workerID, limit, s.config.LeaseDuration)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Error().Err(err).Msg("Failed to claim due calendar syncs")
+		}
+		return nil, err
+	}
+
+	jobs := make([]job, len(syncs))
+	for i, sync := range syncs {
+		jobs[i] = func(ctx context.Context) { s.run(ctx, sync) }
+	}
+	return jobs, nil
+}
+
+func (s *SyncScheduler) run(ctx context.Context, sync *entities.GoogleCalendarSync) {
+	// A run must not outlive its claim, or another replica could pick it up
+	ctx, cancel := context.WithTimeout(ctx, s.config.LeaseDuration)
+	defer cancel()
+
+	trigger := entities.SyncTriggerScheduled
+	if sync.Notified {
+		trigger = entities.SyncTriggerWebhook
+	}
+
+	result, err := s.syncer.Run(ctx, sync, s.workerID, trigger)
+	if err != nil {
+		zlog.Error().Err(err).Str("sync_id", sync.ID).Msg("Failed to record calendar sync")
+		return
+	}
+	if result.Err != nil {
+		zlog.Warn().Err(result.Err).
+			Str("sync_id", sync.ID).
+			Int("failure_count", sync.FailureCount+1).
+			Msg("Calendar sync failed")
+		return
+	}
+
+	zlog.Debug().
+		Str("sync_id", sync.ID).
+		Int("synced_count", result.SyncedCount).
+		Int("skipped_count", result.Run.Skipped).
+		Msg("Calendar sync completed")
+}
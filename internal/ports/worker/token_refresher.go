@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/andranikuz/smart-goal-calendar/internal/adapters/calendarsync"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/entities"
+	"github.com/andranikuz/smart-goal-calendar/internal/domain/repositories"
+)
+
+// TokenRefresherConfig tunes how a TokenRefresher keeps integration tokens
+// valid
+type TokenRefresherConfig struct {
+	PollInterval  time.Duration
+	RefreshBefore time.Duration // How long before expiry a token is refreshed
+}
+
+// TokenRefresher refreshes the access tokens of integrations before they
+// expire, so syncs and API requests rarely wait for a refresh. Refreshes go
+// through the shared calendarsync.TokenSource.
+type TokenRefresher struct {
+	integrationRepo repositories.GoogleIntegrationRepository
+	tokens          *calendarsync.TokenSource
+	config          TokenRefresherConfig
+	loop            *loop
+}
+
+func NewTokenRefresher(
+	integrationRepo repositories.GoogleIntegrationRepository,
+	tokens *calendarsync.TokenSource,
+	config TokenRefresherConfig,
+) *TokenRefresher {
+	r := &TokenRefresher{
+		integrationRepo: integrationRepo,
+		tokens:          tokens,
+		config:          config,
+	}
+	// A single job refreshes all tokens of a poll, so polls don't overlap
+	r.loop = newLoop(config.PollInterval, 1, 1, r.claim)
+	return r
+}
+
+// Start refreshes tokens in the background until Shutdown is called
+func (r *TokenRefresher) Start() {
+	zlog.Info().
+		Dur("poll_interval", r.config.PollInterval).
+		Dur("refresh_before", r.config.RefreshBefore).
+		Msg("Starting integration token refresher")
+
+	r.loop.start()
+}
+
+// Shutdown stops refreshing tokens, see SyncScheduler.Shutdown
+func (r *TokenRefresher) Shutdown(ctx context.Context) error {
+	return r.loop.shutdown(ctx)
+}
+
+func (r *TokenRefresher) claim(ctx context.Context, limit int) ([]job, error) {
+	expiresBefore := time.Now().Add(r.config.RefreshBefore)
+	integrations, err := r.integrationRepo.GetExpiringSoon(ctx, expiresBefore)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Error().Err(err).Msg("Failed to get integrations with expiring tokens")
+		}
+		return nil, err
+	}
+	if len(integrations) == 0 {
+		return nil, nil
+	}
+
+	return []job{func(ctx context.Context) { r.refreshAll(ctx, integrations, expiresBefore) }}, nil
+}
+
+func (r *TokenRefresher) refreshAll(ctx context.Context, integrations []*entities.GoogleIntegration, expiresBefore time.Time) {
+	for _, integration := range integrations {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := r.tokens.Refresh(ctx, integration.ID, expiresBefore)
+		switch {
+		case errors.Is(err, calendarsync.ErrReconsentRequired):
+			zlog.Warn().Err(err).Str("integration_id", string(integration.ID)).Msg("Integration needs re-consent")
+		case err != nil:
+			zlog.Warn().Err(err).Str("integration_id", string(integration.ID)).Msg("Failed to refresh integration token")
+		default:
+			zlog.Debug().Str("integration_id", string(integration.ID)).Msg("Integration token refreshed")
+		}
+	}
+}
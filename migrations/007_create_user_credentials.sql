@@ -0,0 +1,16 @@
+-- Migration 007: Create user credentials table
+-- This stores password hashes separately from the users profile data
+
+-- User credentials table
+CREATE TABLE user_credentials (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    password_changed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Update trigger
+CREATE TRIGGER update_user_credentials_updated_at 
+    BEFORE UPDATE ON user_credentials 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,31 @@
+-- Migration 009: Create event exceptions table
+-- Per-occurrence exceptions of recurring events, keyed by RECURRENCE-ID
+-- (the original start of the occurrence). A cancelled row is an EXDATE,
+-- any other row overrides the fields that are not NULL.
+
+-- Event exceptions table
+CREATE TABLE event_exceptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    recurrence_id TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancelled BOOLEAN NOT NULL DEFAULT FALSE,
+    title VARCHAR(255),
+    description TEXT,
+    start_time TIMESTAMP WITH TIME ZONE,
+    end_time TIMESTAMP WITH TIME ZONE,
+    location TEXT,
+    attendees JSONB,
+    status event_status,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    
+    UNIQUE(event_id, recurrence_id)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_event_exceptions_event_id ON event_exceptions(event_id);
+
+-- Update trigger
+CREATE TRIGGER update_event_exceptions_updated_at 
+    BEFORE UPDATE ON event_exceptions 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
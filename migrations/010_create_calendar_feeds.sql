@@ -0,0 +1,23 @@
+-- Migration 010: Create calendar feeds table
+-- A calendar feed is a secret URL serving a user's calendar as iCalendar.
+-- Only the SHA-256 hash of the token is stored.
+
+CREATE TABLE calendar_feeds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    include_goals BOOLEAN NOT NULL DEFAULT FALSE,
+    include_milestones BOOLEAN NOT NULL DEFAULT FALSE,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- A user has at most one active feed
+CREATE UNIQUE INDEX idx_calendar_feeds_active_user ON calendar_feeds(user_id) WHERE revoked_at IS NULL;
+
+-- Update trigger
+CREATE TRIGGER update_calendar_feeds_updated_at 
+    BEFORE UPDATE ON calendar_feeds 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,19 @@
+-- Migration 011: Add scheduling columns to Google calendar syncs
+-- Syncs are run by background workers. A worker claims due rows by setting
+-- locked_by/locked_until, so replicas never run the same sync concurrently;
+-- an expired lease lets another worker take over after a crash.
+
+ALTER TABLE google_calendar_syncs
+    ADD COLUMN next_sync_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN failure_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN locked_by VARCHAR(255),
+    ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
+
+-- Continue the schedule of syncs that already ran (sync_interval is stored in nanoseconds)
+UPDATE google_calendar_syncs
+SET next_sync_at = last_sync_at + make_interval(
+    secs => COALESCE(NULLIF(settings->>'sync_interval', '')::BIGINT, 900000000000) / 1000000000.0
+)
+WHERE last_sync_at IS NOT NULL;
+
+CREATE INDEX idx_google_calendar_syncs_next_sync_at ON google_calendar_syncs(next_sync_at);
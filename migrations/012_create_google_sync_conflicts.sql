@@ -0,0 +1,40 @@
+-- Migration 012: Create Google event links and sync conflicts
+-- Links remember the version of both sides of a synced event at the last
+-- sync, so a bidirectional sync can tell which side changed. Events changed
+-- on both sides under the "manual" policy are queued as conflicts.
+
+-- Google event links table
+CREATE TABLE google_event_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    calendar_sync_id UUID NOT NULL REFERENCES google_calendar_syncs(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    google_event_id VARCHAR(1024) NOT NULL,
+    local_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    remote_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    UNIQUE(calendar_sync_id, google_event_id),
+    UNIQUE(calendar_sync_id, event_id)
+);
+
+-- Google sync conflicts table
+CREATE TABLE google_sync_conflicts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    calendar_sync_id UUID NOT NULL REFERENCES google_calendar_syncs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    google_event_id VARCHAR(1024) NOT NULL,
+    local_version JSONB NOT NULL,
+    remote_version JSONB NOT NULL,
+    resolution VARCHAR(20) CHECK (resolution IN ('local', 'remote', 'merged')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Indexes for performance
+CREATE INDEX idx_google_event_links_event_id ON google_event_links(event_id);
+CREATE INDEX idx_google_sync_conflicts_calendar_sync_id ON google_sync_conflicts(calendar_sync_id);
+
+-- An event has at most one open conflict
+CREATE UNIQUE INDEX idx_google_sync_conflicts_open ON google_sync_conflicts(calendar_sync_id, event_id)
+    WHERE resolved_at IS NULL;
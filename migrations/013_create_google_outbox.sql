@@ -0,0 +1,46 @@
+-- Migration 013: Create Google outbox
+-- Local edits and deletes of events linked to Google are queued in the
+-- same transaction as the change and pushed by a dispatcher. The push
+-- status of an event is kept on the event itself.
+
+ALTER TABLE events
+    ADD COLUMN push_status VARCHAR(20) CHECK (push_status IN ('pending', 'synced', 'failed')),
+    ADD COLUMN push_error TEXT;
+
+-- Recording the push status is not a change of the event: only the columns
+-- below touch updated_at, which sync change detection compares
+DROP TRIGGER update_events_updated_at ON events;
+CREATE TRIGGER update_events_updated_at
+    BEFORE UPDATE OF user_id, goal_id, title, description, start_time, end_time,
+        timezone, recurrence, location, attendees, status, external_id,
+        external_source, created_at, updated_at
+    ON events
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Google outbox table. event_id has no foreign key: delete entries outlive
+-- their event.
+CREATE TABLE google_outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    calendar_sync_id UUID NOT NULL REFERENCES google_calendar_syncs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    google_event_id VARCHAR(1024) NOT NULL,
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('update', 'delete')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_by VARCHAR(255),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Indexes for performance
+CREATE INDEX idx_google_outbox_pending ON google_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_google_outbox_event_id ON google_outbox(event_id);
+
+-- Update trigger
+CREATE TRIGGER update_google_outbox_updated_at
+    BEFORE UPDATE ON google_outbox
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,13 @@
+-- Migration 014: Add Google watch channels to calendar syncs
+-- A watch channel makes Google notify the webhook when a synced calendar
+-- changes, which schedules the sync right away. Only a hash of the channel
+-- token is stored, like calendar feed tokens.
+
+ALTER TABLE google_calendar_syncs
+    ADD COLUMN channel_id VARCHAR(64),
+    ADD COLUMN channel_resource_id VARCHAR(255),
+    ADD COLUMN channel_token_hash VARCHAR(64),
+    ADD COLUMN channel_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX idx_google_calendar_syncs_channel_id ON google_calendar_syncs(channel_id);
+CREATE INDEX idx_google_calendar_syncs_channel_expires_at ON google_calendar_syncs(channel_expires_at);
@@ -0,0 +1,15 @@
+-- Migration 015: Create OAuth states table
+-- An OAuth state is a single-use nonce of the Google connect flow, bound to
+-- the user who started it. It also holds the PKCE code verifier. Only the
+-- SHA-256 hash of the state is stored.
+
+CREATE TABLE oauth_states (
+    state_hash VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_oauth_states_expires_at ON oauth_states(expires_at);
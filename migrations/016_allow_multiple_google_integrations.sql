@@ -0,0 +1,13 @@
+-- Migration 016: Allow multiple Google integrations per user
+-- A user can connect several Google accounts, e.g. a work and a personal
+-- one. A Google account still belongs to one user only. Calendar syncs are
+-- unique per integration, since two accounts can share a calendar.
+
+ALTER TABLE google_integrations
+    DROP CONSTRAINT IF EXISTS google_integrations_user_id_key;
+
+ALTER TABLE google_calendar_syncs
+    DROP CONSTRAINT IF EXISTS google_calendar_syncs_user_id_calendar_id_key;
+
+ALTER TABLE google_calendar_syncs
+    ADD CONSTRAINT google_calendar_syncs_integration_calendar_key UNIQUE (google_integration_id, calendar_id);
@@ -0,0 +1,17 @@
+-- Migration 017: Add provider to integrations
+-- Integrations can connect calendar services other than Google, e.g.
+-- Outlook through Microsoft Graph. Existing integrations are Google ones.
+-- Account IDs are only unique within a provider. Sync tokens hold Graph
+-- delta links, which can exceed the former limit.
+
+ALTER TABLE google_integrations
+    ADD COLUMN provider VARCHAR(20) NOT NULL DEFAULT 'google';
+
+ALTER TABLE google_integrations
+    DROP CONSTRAINT IF EXISTS google_integrations_google_user_id_key;
+
+ALTER TABLE google_integrations
+    ADD CONSTRAINT google_integrations_provider_google_user_id_key UNIQUE (provider, google_user_id);
+
+ALTER TABLE google_calendar_syncs
+    ALTER COLUMN sync_token TYPE TEXT;
@@ -0,0 +1,10 @@
+-- Migration 018: Add all-day, color and transparency to events
+-- Synced calendars carry these per event; they are kept so that pushing an
+-- event back doesn't turn an all-day event into a timed one or reset its
+-- color. All-day events run from midnight to midnight in their time zone.
+-- Transparent events show as free rather than busy.
+
+ALTER TABLE events
+    ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN color VARCHAR(7) NOT NULL DEFAULT '',
+    ADD COLUMN transparent BOOLEAN NOT NULL DEFAULT FALSE;
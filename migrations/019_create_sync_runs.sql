@@ -0,0 +1,26 @@
+-- Migration 019: Create sync runs
+-- Every calendar sync execution is recorded with what started it, what it
+-- changed and the errors of the events it failed to sync. Syncs scheduled by
+-- a push notification are flagged so their run is recorded as such.
+
+CREATE TABLE sync_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    calendar_sync_id UUID NOT NULL REFERENCES google_calendar_syncs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('manual', 'scheduled', 'webhook')),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    updated_count INTEGER NOT NULL DEFAULT 0,
+    deleted_count INTEGER NOT NULL DEFAULT 0,
+    skipped_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    event_errors JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX idx_sync_runs_calendar_sync_id ON sync_runs(calendar_sync_id, started_at DESC);
+
+ALTER TABLE google_calendar_syncs
+    ADD COLUMN notified BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+-- Migration 020: Flag integrations needing re-consent
+-- Integrations whose refresh token the provider rejected with invalid_grant,
+-- e.g. because the user revoked access, are not refreshed again until the
+-- user connects the account anew.
+
+ALTER TABLE google_integrations
+    ADD COLUMN needs_reconsent BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,80 @@
+-- Migration 021: Create calendars
+-- Events belong to one of the user's calendars. Every user has a default
+-- calendar taking events created without one; each synced calendar of an
+-- integration is mirrored by a calendar of its own, so that sync knows
+-- which remote calendar an event came from. Calendars synced one way from
+-- the provider are read-only.
+
+CREATE TABLE calendars (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '',
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    default_reminders JSONB NOT NULL DEFAULT '[]',
+    provider VARCHAR(20), -- NULL for local calendars
+    read_only BOOLEAN NOT NULL DEFAULT FALSE,
+    visible BOOLEAN NOT NULL DEFAULT TRUE,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_calendars_user_id ON calendars(user_id);
+
+-- A user has exactly one default calendar
+CREATE UNIQUE INDEX idx_calendars_default_user ON calendars(user_id) WHERE is_default;
+
+-- Update trigger
+CREATE TRIGGER update_calendars_updated_at
+    BEFORE UPDATE ON calendars
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO calendars (user_id, name, timezone, is_default)
+SELECT id, 'Calendar', COALESCE(NULLIF(profile->>'timezone', ''), 'UTC'), TRUE
+FROM users;
+
+-- Synced calendars reuse the ID of their sync
+ALTER TABLE google_calendar_syncs
+    ADD COLUMN local_calendar_id UUID REFERENCES calendars(id) ON DELETE CASCADE;
+
+INSERT INTO calendars (id, user_id, name, provider, read_only)
+SELECT s.id, s.user_id, s.calendar_name, i.provider, s.sync_direction = 'from_google'
+FROM google_calendar_syncs s
+JOIN google_integrations i ON i.id = s.google_integration_id;
+
+UPDATE google_calendar_syncs SET local_calendar_id = id;
+
+ALTER TABLE google_calendar_syncs
+    ALTER COLUMN local_calendar_id SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_google_calendar_syncs_local_calendar_id ON google_calendar_syncs(local_calendar_id);
+
+-- Synced events move to the calendar of the sync that first linked them,
+-- all others to the default calendar. The backfill keeps updated_at, which
+-- sync compares to tell local changes.
+ALTER TABLE events
+    ADD COLUMN calendar_id UUID REFERENCES calendars(id) ON DELETE CASCADE;
+
+ALTER TABLE events DISABLE TRIGGER update_events_updated_at;
+
+UPDATE events e
+SET calendar_id = l.calendar_sync_id
+FROM (
+    SELECT DISTINCT ON (event_id) event_id, calendar_sync_id
+    FROM google_event_links
+    ORDER BY event_id, synced_at
+) l
+WHERE l.event_id = e.id;
+
+UPDATE events e
+SET calendar_id = c.id
+FROM calendars c
+WHERE e.calendar_id IS NULL AND c.user_id = e.user_id AND c.is_default;
+
+ALTER TABLE events ENABLE TRIGGER update_events_updated_at;
+
+ALTER TABLE events
+    ALTER COLUMN calendar_id SET NOT NULL;
+
+CREATE INDEX idx_events_calendar_id_start_time ON events(calendar_id, start_time);
@@ -0,0 +1,51 @@
+-- Migration 022: Create notifications
+-- Events may set their own reminders; NULL takes the defaults of their
+-- calendar, or of the user. Reminders that fall due are queued as
+-- notifications, one per start of the event, channel and lead time, and
+-- delivered by a dispatcher with retries. In-app notifications are kept
+-- for the user to read.
+
+ALTER TABLE events
+    ADD COLUMN reminders JSONB;
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    event_title VARCHAR(255) NOT NULL,
+    location TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    all_day BOOLEAN NOT NULL DEFAULT FALSE,
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('email', 'webhook', 'in_app')),
+    minutes_before INTEGER NOT NULL,
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed', 'skipped')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_by VARCHAR(255),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- A reminder is queued once, however often the scheduler sees it due
+CREATE UNIQUE INDEX idx_notifications_reminder ON notifications(event_id, starts_at, channel, minutes_before);
+
+-- Indexes for performance
+CREATE INDEX idx_notifications_pending ON notifications(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_notifications_in_app ON notifications(user_id, due_at DESC) WHERE channel = 'in_app' AND status = 'sent';
+
+-- Update trigger
+CREATE TRIGGER update_notifications_updated_at
+    BEFORE UPDATE ON notifications
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing users get the default of new ones: an in-app reminder ten
+-- minutes before each event
+UPDATE users
+SET settings = COALESCE(settings, '{}') || '{"default_reminders": [{"minutes_before": 10, "channel": "in_app"}]}'
+WHERE settings IS NULL OR NOT settings ? 'default_reminders';
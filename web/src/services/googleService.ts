@@ -10,19 +10,18 @@ export class GoogleService {
     return await apiService.post('/google/callback', { code, state });
   }
 
+  async getIntegrations(): Promise<{ integrations: GoogleIntegration[] }> {
+    return await apiService.get('/google/integrations');
+  }
+
+  // The UI manages a single Google account: the first one connected
   async getIntegration(): Promise<GoogleIntegration | null> {
-    try {
-      return await apiService.get('/google/integration');
-    } catch (error: any) {
-      if (error.response?.status === 404) {
-        return null;
-      }
-      throw error;
-    }
+    const { integrations } = await this.getIntegrations();
+    return integrations?.[0] ?? null;
   }
 
-  async disconnect(): Promise<void> {
-    await apiService.delete('/google/integration');
+  async disconnect(integrationId: string): Promise<void> {
+    await apiService.delete(`/google/integrations/${integrationId}`);
   }
 
   async getCalendars(): Promise<{ calendars: GoogleCalendar[] }> {
@@ -61,9 +61,13 @@ export const getIntegration = createAsyncThunk(
 
 export const disconnect = createAsyncThunk(
   'google/disconnect',
-  async (_, { rejectWithValue }) => {
+  async (_, { getState, rejectWithValue }) => {
     try {
-      await googleService.disconnect();
+      const { google } = getState() as { google: GoogleState };
+      const integration = google.integration ?? (await googleService.getIntegration());
+      if (integration) {
+        await googleService.disconnect(integration.id);
+      }
       return null;
     } catch (error: any) {
       return rejectWithValue(error.response?.data?.error || 'Failed to disconnect');